@@ -160,6 +160,7 @@ type impl struct {
 	maxTries                 int
 	clk                      clock.Clock
 	log                      blog.Logger
+	queryLog                 QueryLog
 
 	queryTime         *prometheus.HistogramVec
 	totalLookupTime   *prometheus.HistogramVec
@@ -174,7 +175,8 @@ type exchanger interface {
 }
 
 // New constructs a new DNS resolver object that utilizes the
-// provided list of DNS servers for resolution.
+// provided list of DNS servers for resolution. If queryLog is non-nil every
+// query sent to a resolver is recorded to it.
 func New(
 	readTimeout time.Duration,
 	servers ServerProvider,
@@ -182,6 +184,7 @@ func New(
 	clk clock.Clock,
 	maxTries int,
 	log blog.Logger,
+	queryLog QueryLog,
 ) Client {
 	dnsClient := new(dns.Client)
 
@@ -232,6 +235,7 @@ func New(
 		timeoutCounter:           timeoutCounter,
 		idMismatchCounter:        idMismatchCounter,
 		log:                      log,
+		queryLog:                 queryLog,
 	}
 }
 
@@ -244,8 +248,9 @@ func NewTest(
 	stats prometheus.Registerer,
 	clk clock.Clock,
 	maxTries int,
-	log blog.Logger) Client {
-	resolver := New(readTimeout, servers, stats, clk, maxTries, log)
+	log blog.Logger,
+	queryLog QueryLog) Client {
+	resolver := New(readTimeout, servers, stats, clk, maxTries, log, queryLog)
 	resolver.(*impl).allowRestrictedAddresses = true
 	return resolver
 }
@@ -314,14 +319,18 @@ func (dnsClient *impl) exchangeOne(ctx context.Context, hostname string, qtype u
 			return
 		}
 
-		go func() {
-			rsp, rtt, err := client.Exchange(m, chosenServer)
+		go func(server string) {
+			sent := dnsClient.clk.Now()
+			rsp, rtt, err := client.Exchange(m, server)
+			if dnsClient.queryLog != nil {
+				dnsClient.queryLog.LogQuery(newQueryLogEntry(ctx, sent, dnsClient.clk.Since(sent), server, m, rsp, err))
+			}
 			result := "failed"
 			if rsp != nil {
 				result = dns.RcodeToString[rsp.Rcode]
 			}
 			if err != nil {
-				logDNSError(dnsClient.log, chosenServer, hostname, m, rsp, err)
+				logDNSError(dnsClient.log, server, hostname, m, rsp, err)
 				if err == dns.ErrId {
 					dnsClient.idMismatchCounter.With(prometheus.Labels{
 						"qtype":    qtypeStr,
@@ -335,7 +344,7 @@ func (dnsClient *impl) exchangeOne(ctx context.Context, hostname string, qtype u
 				"resolver": chosenServerIP,
 			}).Observe(rtt.Seconds())
 			ch <- dnsResp{m: rsp, err: err}
-		}()
+		}(chosenServer)
 		select {
 		case <-ctx.Done():
 			if ctx.Err() == context.DeadlineExceeded {
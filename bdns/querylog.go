@@ -0,0 +1,231 @@
+package bdns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+
+	blog "github.com/letsencrypt/boulder/log"
+)
+
+// authzIDCtxKey is the context.Context key under which the ID of the
+// authorization a DNS query is made on behalf of is stored.
+type authzIDCtxKey struct{}
+
+// WithAuthzID returns a copy of ctx carrying the given authorization ID, which
+// is included in the query log entry of every DNS query made with it.
+func WithAuthzID(ctx context.Context, authzID string) context.Context {
+	return context.WithValue(ctx, authzIDCtxKey{}, authzID)
+}
+
+// authzIDFromContext returns the authorization ID stored in ctx by
+// WithAuthzID, or the empty string if there is none.
+func authzIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(authzIDCtxKey{}).(string)
+	return id
+}
+
+// QueryLogEntry describes a single DNS query sent to a resolver. Retried
+// queries produce one entry per attempt.
+type QueryLogEntry struct {
+	Time     time.Time `json:"time"`
+	AuthzID  string    `json:"authzID,omitempty"`
+	Name     string    `json:"name"`
+	Type     string    `json:"type"`
+	Resolver string    `json:"resolver"`
+	// Rcode is empty if no response was received.
+	Rcode   string `json:"rcode,omitempty"`
+	Answers int    `json:"answers"`
+	// LatencyMS is the time taken by the exchange in milliseconds.
+	LatencyMS float64 `json:"latencyMS"`
+	// AD and CD are the Authenticated Data and Checking Disabled bits of the
+	// response.
+	AD    bool   `json:"ad"`
+	CD    bool   `json:"cd"`
+	Error string `json:"error,omitempty"`
+}
+
+// newQueryLogEntry builds a QueryLogEntry for the given query, sent to
+// resolver at the given time, from its response and error (either or both of
+// which may be nil).
+func newQueryLogEntry(ctx context.Context, sent time.Time, latency time.Duration, resolver string, msg, resp *dns.Msg, err error) QueryLogEntry {
+	entry := QueryLogEntry{
+		Time:      sent.UTC(),
+		AuthzID:   authzIDFromContext(ctx),
+		Resolver:  resolver,
+		LatencyMS: float64(latency.Microseconds()) / 1000,
+	}
+	if len(msg.Question) > 0 {
+		entry.Name = msg.Question[0].Name
+		entry.Type = dns.TypeToString[msg.Question[0].Qtype]
+	}
+	if resp != nil {
+		entry.Rcode = dns.RcodeToString[resp.Rcode]
+		entry.Answers = len(resp.Answer)
+		entry.AD = resp.AuthenticatedData
+		entry.CD = resp.CheckingDisabled
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	return entry
+}
+
+// QueryLog records DNS queries made by a Client. Implementations must be safe
+// for concurrent use.
+type QueryLog interface {
+	LogQuery(QueryLogEntry)
+}
+
+// sinkQueryLog writes query log entries as JSON lines to a blog.Logger.
+type sinkQueryLog struct {
+	log blog.Logger
+}
+
+// NewSinkQueryLog returns a QueryLog which writes each entry to the provided
+// logger at the info level.
+func NewSinkQueryLog(logger blog.Logger) QueryLog {
+	return &sinkQueryLog{log: logger}
+}
+
+func (sql *sinkQueryLog) LogQuery(entry QueryLogEntry) {
+	entryJSON, err := json.Marshal(entry)
+	if err != nil {
+		sql.log.Warningf("Could not marshal DNS query log entry: %s", err)
+		return
+	}
+	sql.log.Infof("DNS query JSON=%s", entryJSON)
+}
+
+// FileQueryLog writes query log entries as JSON lines to a local file. Once the
+// file reaches its maximum size it is renamed with a ".1" suffix, any older
+// files are shifted up by one (".1" to ".2" and so on), the oldest beyond
+// maxBackups is discarded, and a new file is started.
+type FileQueryLog struct {
+	sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+	closed     bool
+	log        blog.Logger
+}
+
+var _ QueryLog = &FileQueryLog{}
+
+// NewFileQueryLog opens, or creates, the query log file at path. Errors writing
+// to or rotating the file are reported to logger.
+func NewFileQueryLog(path string, maxSize int64, maxBackups int, logger blog.Logger) (*FileQueryLog, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("DNS query log max size must be positive, got %d", maxSize)
+	}
+	if maxBackups < 1 {
+		return nil, fmt.Errorf("DNS query log must keep at least one backup, got %d", maxBackups)
+	}
+	fql := &FileQueryLog{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+		log:        logger,
+	}
+	err := fql.open()
+	if err != nil {
+		return nil, err
+	}
+	return fql, nil
+}
+
+func (fql *FileQueryLog) open() error {
+	f, err := os.OpenFile(fql.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+	if err != nil {
+		return fmt.Errorf("opening DNS query log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("opening DNS query log: %w", err)
+	}
+	fql.file = f
+	fql.size = info.Size()
+	return nil
+}
+
+// rotate closes the current file, shifts it and its backups along by one, and
+// opens a new file. It must be called with the lock held.
+func (fql *FileQueryLog) rotate() error {
+	err := fql.file.Close()
+	if err != nil {
+		return err
+	}
+	fql.file = nil
+	for i := fql.maxBackups - 1; i >= 1; i-- {
+		err = os.Rename(fmt.Sprintf("%s.%d", fql.path, i), fmt.Sprintf("%s.%d", fql.path, i+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	err = os.Rename(fql.path, fql.path+".1")
+	if err != nil {
+		return err
+	}
+	return fql.open()
+}
+
+// LogQuery appends the entry to the query log file, rotating the file first if
+// the entry would take it beyond its maximum size.
+func (fql *FileQueryLog) LogQuery(entry QueryLogEntry) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		fql.log.Warningf("Could not marshal DNS query log entry: %s", err)
+		return
+	}
+	line = append(line, '\n')
+
+	fql.Lock()
+	defer fql.Unlock()
+	if fql.closed {
+		return
+	}
+	if fql.file == nil {
+		// A previous rotation failed part way through; try to recover.
+		err = fql.open()
+		if err != nil {
+			fql.log.Errf("DNS query log: %s", err)
+			return
+		}
+	}
+	if fql.size > 0 && fql.size+int64(len(line)) > fql.maxSize {
+		err = fql.rotate()
+		if err != nil {
+			fql.log.Errf("Rotating DNS query log: %s", err)
+			if fql.file == nil {
+				return
+			}
+		}
+	}
+	n, err := fql.file.Write(line)
+	fql.size += int64(n)
+	if err != nil {
+		fql.log.Errf("Writing DNS query log: %s", err)
+	}
+}
+
+// Close closes the query log file.
+func (fql *FileQueryLog) Close() error {
+	fql.Lock()
+	defer fql.Unlock()
+	fql.closed = true
+	if fql.file == nil {
+		return nil
+	}
+	err := fql.file.Close()
+	fql.file = nil
+	return err
+}
@@ -0,0 +1,134 @@
+package bdns
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/test"
+)
+
+type recordingQueryLog struct {
+	sync.Mutex
+	entries []QueryLogEntry
+}
+
+func (rql *recordingQueryLog) LogQuery(entry QueryLogEntry) {
+	rql.Lock()
+	defer rql.Unlock()
+	rql.entries = append(rql.entries, entry)
+}
+
+func TestQueryLog(t *testing.T) {
+	staticProvider, err := NewStaticProvider([]string{dnsLoopbackAddr})
+	test.AssertNotError(t, err, "Got error creating StaticProvider")
+
+	queryLog := &recordingQueryLog{}
+	obj := NewTest(time.Second*10, staticProvider, metrics.NoopRegisterer, clock.NewFake(), 1, blog.UseMock(), queryLog)
+
+	ctx := WithAuthzID(context.Background(), "1234")
+	_, err = obj.LookupTXT(ctx, "split-txt.letsencrypt.org")
+	test.AssertNotError(t, err, "No message")
+	_, err = obj.LookupTXT(context.Background(), "servfail.com")
+	test.AssertError(t, err, "LookupTXT didn't return an error")
+
+	test.AssertEquals(t, len(queryLog.entries), 2)
+	entry := queryLog.entries[0]
+	test.AssertEquals(t, entry.AuthzID, "1234")
+	test.AssertEquals(t, entry.Name, "split-txt.letsencrypt.org.")
+	test.AssertEquals(t, entry.Type, "TXT")
+	test.AssertEquals(t, entry.Resolver, dnsLoopbackAddr)
+	test.AssertEquals(t, entry.Rcode, "NOERROR")
+	test.AssertEquals(t, entry.Answers, 1)
+	test.AssertEquals(t, entry.Error, "")
+
+	entry = queryLog.entries[1]
+	test.AssertEquals(t, entry.AuthzID, "")
+	test.AssertEquals(t, entry.Name, "servfail.com.")
+	test.AssertEquals(t, entry.Rcode, "SERVFAIL")
+	test.AssertEquals(t, entry.Answers, 0)
+}
+
+func TestQueryLogRetries(t *testing.T) {
+	staticProvider, err := NewStaticProvider([]string{dnsLoopbackAddr})
+	test.AssertNotError(t, err, "Got error creating StaticProvider")
+
+	queryLog := &recordingQueryLog{}
+	obj := NewTest(time.Second*10, staticProvider, metrics.NoopRegisterer, clock.NewFake(), 3, blog.UseMock(), queryLog)
+	isTempErr := &net.OpError{Op: "read", Err: tempError(true)}
+	obj.(*impl).dnsClient = &testExchanger{errs: []error{isTempErr, isTempErr, nil}}
+
+	_, err = obj.LookupTXT(context.Background(), "example.com")
+	test.AssertNotError(t, err, "LookupTXT failed")
+
+	// Every attempt is logged, including those which failed.
+	test.AssertEquals(t, len(queryLog.entries), 3)
+	test.AssertContains(t, queryLog.entries[0].Error, "read")
+	test.AssertEquals(t, queryLog.entries[0].Rcode, "NOERROR")
+	test.AssertEquals(t, queryLog.entries[2].Error, "")
+}
+
+func TestSinkQueryLog(t *testing.T) {
+	log := blog.NewMock()
+	sink := NewSinkQueryLog(log)
+	sink.LogQuery(QueryLogEntry{Name: "example.com.", Type: "CAA", Rcode: "NOERROR"})
+
+	lines := log.GetAllMatching("DNS query JSON=")
+	test.AssertEquals(t, len(lines), 1)
+	test.AssertContains(t, lines[0], `"name":"example.com."`)
+	test.AssertContains(t, lines[0], `"type":"CAA"`)
+}
+
+func TestFileQueryLogRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dns.log")
+	entry := QueryLogEntry{Name: "example.com.", Type: "A", Rcode: "NOERROR"}
+	line, err := json.Marshal(entry)
+	test.AssertNotError(t, err, "marshaling entry")
+	lineLen := int64(len(line) + 1)
+
+	// Room for two entries per file, keeping two rotated files.
+	fql, err := NewFileQueryLog(path, 2*lineLen, 2, blog.NewMock())
+	test.AssertNotError(t, err, "creating file query log")
+	for i := 0; i < 7; i++ {
+		fql.LogQuery(entry)
+	}
+	test.AssertNotError(t, fql.Close(), "closing file query log")
+	// Entries after Close are dropped rather than reopening the file.
+	fql.LogQuery(entry)
+
+	countLines := func(name string) int {
+		t.Helper()
+		contents, err := os.ReadFile(name)
+		test.AssertNotError(t, err, "reading query log")
+		return strings.Count(string(contents), "\n")
+	}
+	test.AssertEquals(t, countLines(path), 1)
+	test.AssertEquals(t, countLines(path+".1"), 2)
+	test.AssertEquals(t, countLines(path+".2"), 2)
+	_, err = os.Stat(path + ".3")
+	test.Assert(t, os.IsNotExist(err), "kept more backups than configured")
+
+	var decoded QueryLogEntry
+	contents, err := os.ReadFile(path)
+	test.AssertNotError(t, err, "reading query log")
+	test.AssertNotError(t, json.Unmarshal(contents, &decoded), "decoding query log entry")
+	test.AssertEquals(t, decoded.Name, "example.com.")
+}
+
+func TestNewFileQueryLogLimits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dns.log")
+	_, err := NewFileQueryLog(path, 0, 1, blog.NewMock())
+	test.AssertError(t, err, "accepted zero max size")
+	_, err = NewFileQueryLog(path, 1024, 0, blog.NewMock())
+	test.AssertError(t, err, "accepted zero backups")
+}
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	mrand "math/rand"
 	"strings"
 	"time"
 
@@ -38,12 +39,15 @@ const (
 	certType    = certificateType("certificate")
 )
 
-// Two maps of keys to Issuers. Lookup by PublicKeyAlgorithm is useful for
+// Maps of keys to Issuers. Lookup by PublicKeyAlgorithm is useful for
 // determining which issuer to use to sign a given (pre)cert, based on its
-// PublicKeyAlgorithm. Lookup by NameID is useful for looking up the appropriate
-// issuer based on the issuer of a given (pre)certificate.
+// PublicKeyAlgorithm: byAlg holds the default issuer for each algorithm, and
+// allByAlg every issuer for it, in configuration order. Lookup by NameID is
+// useful for looking up the appropriate issuer based on the issuer of a given
+// (pre)certificate.
 type issuerMaps struct {
 	byAlg    map[x509.PublicKeyAlgorithm]*issuance.Issuer
+	allByAlg map[x509.PublicKeyAlgorithm][]*issuance.Issuer
 	byNameID map[issuance.IssuerNameID]*issuance.Issuer
 }
 
@@ -73,6 +77,7 @@ type certificateAuthorityImpl struct {
 	orphanCount        *prometheus.CounterVec
 	adoptedOrphanCount *prometheus.CounterVec
 	signErrorCount     *prometheus.CounterVec
+	precertMismatches  prometheus.Counter
 }
 
 // makeIssuerMaps processes a list of issuers into a set of maps, mapping
@@ -81,9 +86,11 @@ type certificateAuthorityImpl struct {
 // the input list "wins".
 func makeIssuerMaps(issuers []*issuance.Issuer) issuerMaps {
 	issuersByAlg := make(map[x509.PublicKeyAlgorithm]*issuance.Issuer, 2)
+	allIssuersByAlg := make(map[x509.PublicKeyAlgorithm][]*issuance.Issuer, 2)
 	issuersByNameID := make(map[issuance.IssuerNameID]*issuance.Issuer, len(issuers))
 	for _, issuer := range issuers {
 		for _, alg := range issuer.Algs() {
+			allIssuersByAlg[alg] = append(allIssuersByAlg[alg], issuer)
 			// TODO(#5259): Enforce that there is only one issuer for each algorithm,
 			// instead of taking the first issuer for each algorithm type.
 			if issuersByAlg[alg] == nil {
@@ -92,7 +99,72 @@ func makeIssuerMaps(issuers []*issuance.Issuer) issuerMaps {
 		}
 		issuersByNameID[issuer.Cert.NameID()] = issuer
 	}
-	return issuerMaps{issuersByAlg, issuersByNameID}
+	return issuerMaps{issuersByAlg, allIssuersByAlg, issuersByNameID}
+}
+
+// selectIssuer returns the issuer which should sign a certificate containing
+// the given names for a key of the given algorithm. Issuers whose signers are
+// unavailable, for instance because their HSM has stopped responding, are
+// skipped. An issuer whose name constraints have permitted subtrees, i.e. one
+// scoped to particular domains, is preferred in configuration order if it
+// permits all of the names. Failing that, an issuer is picked at random, in
+// proportion to their weights, from those with a positive weight which permit
+// the names. If no issuer has a positive weight the default issuer for the
+// algorithm is used, or else any other issuer for the algorithm whose
+// constraints permit the names. If no issuer may include all of the names,
+// the default issuer's berrors.RejectedIdentifier error is returned.
+func (im issuerMaps) selectIssuer(alg x509.PublicKeyAlgorithm, names []string) (*issuance.Issuer, error) {
+	def, ok := im.byAlg[alg]
+	if !ok {
+		return nil, berrors.InternalServerError("no issuer found for public key algorithm %s", alg)
+	}
+	var available []*issuance.Issuer
+	for _, issuer := range im.allByAlg[alg] {
+		if issuer.Available() {
+			available = append(available, issuer)
+		}
+	}
+	if len(available) == 0 {
+		return nil, berrors.InternalServerError("no issuer available for public key algorithm %s", alg)
+	}
+
+	var weighted []*issuance.Issuer
+	var totalWeight int
+	for _, issuer := range available {
+		nc := issuer.NameConstraints()
+		if nc.Permits(names) != nil {
+			continue
+		}
+		if len(nc.PermittedDNSDomains) != 0 || len(nc.PermittedIPRanges) != 0 {
+			return issuer, nil
+		}
+		if issuer.Weight() > 0 {
+			weighted = append(weighted, issuer)
+			totalWeight += issuer.Weight()
+		}
+	}
+	if totalWeight > 0 {
+		n := mrand.Intn(totalWeight)
+		for _, issuer := range weighted {
+			n -= issuer.Weight()
+			if n < 0 {
+				return issuer, nil
+			}
+		}
+	}
+	defErr := def.NameConstraints().Permits(names)
+	if defErr == nil && def.Available() {
+		return def, nil
+	}
+	for _, issuer := range available {
+		if issuer.NameConstraints().Permits(names) == nil {
+			return issuer, nil
+		}
+	}
+	if defErr == nil {
+		defErr = berrors.InternalServerError("no available issuer may include all of these names")
+	}
+	return nil, defErr
 }
 
 // NewCertificateAuthorityImpl creates a CA instance that can sign certificates
@@ -153,6 +225,13 @@ func NewCertificateAuthorityImpl(
 		[]string{"type"})
 	stats.MustRegister(adoptedOrphanCount)
 
+	precertMismatches := prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "precert_final_mismatches",
+			Help: "Number of final certificates refused because their tbsCertificate did not match that of their precertificate",
+		})
+	stats.MustRegister(precertMismatches)
+
 	ca = &certificateAuthorityImpl{
 		sa:                 sa,
 		pa:                 pa,
@@ -169,6 +248,7 @@ func NewCertificateAuthorityImpl(
 		orphanCount:        orphanCount,
 		adoptedOrphanCount: adoptedOrphanCount,
 		signErrorCount:     signErrorCount,
+		precertMismatches:  precertMismatches,
 		clk:                clk,
 		ecdsaAllowList:     ecdsaAllowList,
 	}
@@ -220,6 +300,7 @@ func (ca *certificateAuthorityImpl) IssuePrecertificate(ctx context.Context, iss
 		return nil, err
 	}
 	issuerNameID := issuer.Cert.NameID()
+	crlShard, _ := issuer.CRLShard(serialBigInt)
 
 	req := &sapb.AddCertificateRequest{
 		Der:          precertDER,
@@ -227,6 +308,7 @@ func (ca *certificateAuthorityImpl) IssuePrecertificate(ctx context.Context, iss
 		Ocsp:         ocspResp,
 		Issued:       nowNanos,
 		IssuerNameID: int64(issuerNameID),
+		CrlShard:     crlShard,
 	}
 
 	_, err = ca.sa.AddPrecertificate(ctx, req)
@@ -244,6 +326,7 @@ func (ca *certificateAuthorityImpl) IssuePrecertificate(ctx context.Context, iss
 				OCSPResp: ocspResp,
 				Precert:  true,
 				IssuerID: int64(issuerNameID),
+				CRLShard: crlShard,
 			})
 		}
 		return nil, err
@@ -320,7 +403,24 @@ func (ca *certificateAuthorityImpl) IssueCertificateForPrecertificate(ctx contex
 	ca.log.AuditInfof("Signing cert: serial=[%s] regID=[%d] names=[%s] precert=[%s]",
 		serialHex, req.RegistrationID, names, hex.EncodeToString(precert.Raw))
 
-	certDER, err := issuer.Issue(issuanceReq)
+	prepared, err := issuer.Prepare(issuanceReq)
+	if err != nil {
+		ca.log.AuditErrf("Signing cert failed: serial=[%s] regID=[%d] names=[%s] err=[%v]",
+			serialHex, req.RegistrationID, names, err)
+		return nil, berrors.InternalServerError("failed to sign precertificate: %s", err)
+	}
+
+	// Refuse to sign a final certificate which differs from the precertificate
+	// that was submitted to CT logs in anything but the CT extensions.
+	err = issuance.CheckPrecertCorrespondence(precert.RawTBSCertificate, prepared.RawTBSCertificate())
+	if err != nil {
+		ca.precertMismatches.Inc()
+		ca.log.AuditErrf("Final cert does not match precert: serial=[%s] regID=[%d] names=[%s] err=[%v]",
+			serialHex, req.RegistrationID, names, err)
+		return nil, berrors.InternalServerError("final certificate does not match precertificate: %s", err)
+	}
+
+	certDER, err := issuer.IssuePrepared(prepared)
 	if err != nil {
 		ca.noteSignError(err)
 		ca.log.AuditErrf("Signing cert failed: serial=[%s] regID=[%d] names=[%s] err=[%v]",
@@ -389,6 +489,8 @@ func (ca *certificateAuthorityImpl) issuePrecertificateInner(ctx context.Context
 		return nil, nil, nil, err
 	}
 
+	names := csrlib.NamesFromCSR(csr)
+
 	var issuer *issuance.Issuer
 	var ok bool
 	if issueReq.IssuerNameID == 0 {
@@ -399,15 +501,21 @@ func (ca *certificateAuthorityImpl) issuePrecertificateInner(ctx context.Context
 		if alg == x509.ECDSA && !features.Enabled(features.ECDSAForAll) && ca.ecdsaAllowList != nil && !ca.ecdsaAllowList.permitted(issueReq.RegistrationID) {
 			alg = x509.RSA
 		}
-		issuer, ok = ca.issuers.byAlg[alg]
-		if !ok {
-			return nil, nil, nil, berrors.InternalServerError("no issuer found for public key algorithm %s", csr.PublicKeyAlgorithm)
+		issuer, err = ca.issuers.selectIssuer(alg, names.SANs)
+		if err != nil {
+			ca.log.AuditErr(err.Error())
+			return nil, nil, nil, err
 		}
 	} else {
 		issuer, ok = ca.issuers.byNameID[issuance.IssuerNameID(issueReq.IssuerNameID)]
 		if !ok {
 			return nil, nil, nil, berrors.InternalServerError("no issuer found for IssuerNameID %d", issueReq.IssuerNameID)
 		}
+		// Retiring issuers are kept only to sign OCSP responses, CRLs, and the
+		// final certificates for precertificates they have already issued.
+		if issuer.State() != issuance.StateActive {
+			return nil, nil, nil, berrors.InternalServerError("issuer for IssuerNameID %d is %s, and cannot issue new certificates", issueReq.IssuerNameID, issuer.State())
+		}
 	}
 
 	if issuer.Cert.NotAfter.Before(validity.NotAfter) {
@@ -437,7 +545,6 @@ func (ca *certificateAuthorityImpl) issuePrecertificateInner(ctx context.Context
 	ca.log.AuditInfof("Signing precert: serial=[%s] regID=[%d] names=[%s] csr=[%s]",
 		serialHex, issueReq.RegistrationID, strings.Join(csr.DNSNames, ", "), hex.EncodeToString(csr.Raw))
 
-	names := csrlib.NamesFromCSR(csr)
 	req := &issuance.IssuanceRequest{
 		PublicKey:         csr.PublicKey,
 		Serial:            serialBigInt.Bytes(),
@@ -502,6 +609,7 @@ type orphanedCert struct {
 	RegID    int64
 	Precert  bool
 	IssuerID int64
+	CRLShard int64
 }
 
 func (ca *certificateAuthorityImpl) queueOrphan(o *orphanedCert) {
@@ -559,6 +667,7 @@ func (ca *certificateAuthorityImpl) integrateOrphan() error {
 			Ocsp:         orphan.OCSPResp,
 			Issued:       issued.UnixNano(),
 			IssuerNameID: orphan.IssuerID,
+			CrlShard:     orphan.CRLShard,
 		})
 		if err != nil && !errors.Is(err, berrors.Duplicate) {
 			return fmt.Errorf("failed to store orphaned precertificate: %s", err)
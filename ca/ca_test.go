@@ -239,7 +239,8 @@ func setup(t *testing.T) *testCtx {
 
 	ocsp, err := NewOCSPImpl(
 		boulderIssuers,
-		time.Hour,
+		96*time.Hour,
+		0,
 		0,
 		time.Second,
 		blog.NewMock(),
@@ -253,6 +254,7 @@ func setup(t *testing.T) *testCtx {
 	crl, err := NewCRLImpl(
 		boulderIssuers,
 		time.Hour,
+		10*time.Minute,
 		"http://c.boulder.test",
 		100,
 		blog.NewMock(),
@@ -794,6 +796,146 @@ func TestIssueCertificateForPrecertificate(t *testing.T) {
 	test.Assert(t, len(sctList) == 1, fmt.Sprintf("Wrong number of SCTs, wanted: 1, got: %d", len(sctList)))
 }
 
+// crlShardSA records the CRL shard sent with each precertificate.
+type crlShardSA struct {
+	mockSA
+	crlShard int64
+}
+
+func (sa *crlShardSA) AddPrecertificate(ctx context.Context, req *sapb.AddCertificateRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+	sa.crlShard = req.CrlShard
+	return &emptypb.Empty{}, nil
+}
+
+func TestIssueCRLDistributionPoints(t *testing.T) {
+	testCtx := setup(t)
+	err := features.Set(map[string]bool{"StoreCRLShards": true})
+	test.AssertNotError(t, err, "setting features")
+	defer features.Reset()
+	profile, err := issuance.NewProfile(
+		issuance.ProfileConfig{
+			AllowCTPoison:                true,
+			AllowSCTList:                 true,
+			AllowCommonName:              true,
+			IncludeCRLDistributionPoints: true,
+			CRLDPBase:                    "http://c.boulder.test",
+			CRLShards:                    10,
+			Policies: []issuance.PolicyInformation{
+				{OID: "2.23.140.1.2.1"},
+			},
+			MaxValidityPeriod:   config.Duration{Duration: time.Hour * 8760},
+			MaxValidityBackdate: config.Duration{Duration: time.Hour},
+		},
+		issuance.IssuerConfig{
+			UseForRSALeaves: true,
+			IssuerURL:       "http://not-example.com/issuer-url",
+			OCSPURL:         "http://not-example.com/ocsp",
+		},
+	)
+	test.AssertNotError(t, err, "creating profile")
+	issuer := &issuance.Issuer{Cert: caCert, Signer: caKey, Profile: profile, Linter: caLinter, Clk: testCtx.fc}
+
+	sa := &crlShardSA{}
+	ca, err := NewCertificateAuthorityImpl(
+		sa,
+		testCtx.pa,
+		testCtx.ocsp,
+		[]*issuance.Issuer{issuer},
+		nil,
+		testCtx.certExpiry,
+		testCtx.certBackdate,
+		testCtx.serialPrefix,
+		testCtx.maxNames,
+		testCtx.keyPolicy,
+		nil,
+		testCtx.logger,
+		testCtx.stats,
+		testCtx.signatureCount,
+		testCtx.signErrorCount,
+		testCtx.fc)
+	test.AssertNotError(t, err, "Failed to create CA")
+
+	precert, err := ca.IssuePrecertificate(ctx, &capb.IssueCertificateRequest{Csr: CNandSANCSR, RegistrationID: arbitraryRegID})
+	test.AssertNotError(t, err, "Failed to issue precert")
+	parsedPrecert, err := x509.ParseCertificate(precert.DER)
+	test.AssertNotError(t, err, "Failed to parse precert")
+
+	// The precertificate names the CRL shard which the SA was told about, at
+	// the same URL as that shard's Issuing Distribution Point.
+	shard := new(big.Int).Mod(parsedPrecert.SerialNumber, big.NewInt(10)).Int64()
+	test.AssertEquals(t, sa.crlShard, shard)
+	test.AssertDeepEquals(t, parsedPrecert.CRLDistributionPoints, []string{
+		fmt.Sprintf("http://c.boulder.test/%d/%d.crl", caCert.NameID(), shard),
+	})
+
+	// The final certificate is assigned the same shard.
+	sctBytes, err := makeSCTs()
+	test.AssertNotError(t, err, "Failed to make SCTs")
+	cert, err := ca.IssueCertificateForPrecertificate(ctx, &capb.IssueCertificateForPrecertificateRequest{
+		DER:            precert.DER,
+		SCTs:           sctBytes,
+		RegistrationID: arbitraryRegID,
+	})
+	test.AssertNotError(t, err, "Failed to issue cert from precert")
+	parsedCert, err := x509.ParseCertificate(cert.Der)
+	test.AssertNotError(t, err, "Failed to parse cert")
+	test.AssertDeepEquals(t, parsedCert.CRLDistributionPoints, parsedPrecert.CRLDistributionPoints)
+}
+
+func TestIssueCertificateForPrecertificateMismatch(t *testing.T) {
+	testCtx := setup(t)
+	ca, err := NewCertificateAuthorityImpl(
+		&mockSA{},
+		testCtx.pa,
+		testCtx.ocsp,
+		testCtx.boulderIssuers,
+		nil,
+		testCtx.certExpiry,
+		testCtx.certBackdate,
+		testCtx.serialPrefix,
+		testCtx.maxNames,
+		testCtx.keyPolicy,
+		nil,
+		testCtx.logger,
+		testCtx.stats,
+		testCtx.signatureCount,
+		testCtx.signErrorCount,
+		testCtx.fc)
+	test.AssertNotError(t, err, "Failed to create CA")
+
+	issueReq := capb.IssueCertificateRequest{Csr: CNandSANCSR, RegistrationID: arbitraryRegID, OrderID: 0}
+	precert, err := ca.IssuePrecertificate(ctx, &issueReq)
+	test.AssertNotError(t, err, "Failed to issue precert")
+	parsedPrecert, err := x509.ParseCertificate(precert.DER)
+	test.AssertNotError(t, err, "Failed to parse precert")
+
+	// Re-sign the precertificate with an extension which the issuance profile
+	// would not reproduce in the final certificate.
+	issuer := ca.issuers.byNameID[issuance.GetIssuerNameID(parsedPrecert)]
+	test.AssertNotNil(t, issuer, "Couldn't find precert issuer")
+	template := *parsedPrecert
+	template.ExtraExtensions = []pkix.Extension{
+		*findExtension(parsedPrecert.Extensions, OIDExtensionCTPoison),
+		{Id: asn1.ObjectIdentifier{1, 2, 3, 4}, Value: []byte{0x05, 0x00}},
+	}
+	badPrecertDER, err := x509.CreateCertificate(rand.Reader, &template, issuer.Cert.Certificate, parsedPrecert.PublicKey, issuer.Signer)
+	test.AssertNotError(t, err, "Failed to re-sign precert")
+
+	sctBytes, err := makeSCTs()
+	test.AssertNotError(t, err, "Failed to make SCTs")
+	_, err = ca.IssueCertificateForPrecertificate(ctx, &capb.IssueCertificateForPrecertificateRequest{
+		DER:            badPrecertDER,
+		SCTs:           sctBytes,
+		RegistrationID: arbitraryRegID,
+		OrderID:        0,
+	})
+	test.AssertErrorIs(t, err, berrors.InternalServer)
+	test.AssertContains(t, err.Error(), "final certificate does not match precertificate")
+	test.AssertMetricWithLabelsEquals(t, ca.precertMismatches, prometheus.Labels{}, 1)
+	test.AssertMetricWithLabelsEquals(t, ca.signatureCount, prometheus.Labels{"purpose": "cert"}, 0)
+	test.AssertEquals(t, len(testCtx.logger.GetAllMatching(`Final cert does not match precert: serial=`)), 1)
+}
+
 // deserializeSCTList deserializes a list of SCTs.
 // Forked from github.com/cloudflare/cfssl/helpers
 func deserializeSCTList(serializedSCTList []byte) ([]ct.SignedCertificateTimestamp, error) {
@@ -1132,3 +1274,197 @@ func TestOrphanQueue(t *testing.T) {
 		t.Fatalf("Unexpected error, wanted %q, got %q", goque.ErrEmpty, err)
 	}
 }
+
+func TestSelectIssuerNameConstraints(t *testing.T) {
+	testCtx := setup(t)
+	defaultIssuer := testCtx.boulderIssuers[1]
+
+	// A copy of the RSA issuer whose certificate carries name constraints.
+	// Selection only consults the certificate's fields, so it need not be
+	// re-signed.
+	constrainedCert := *caCert.Certificate
+	constrainedCert.PermittedDNSDomains = []string{"example.com"}
+	constrainedCert.ExcludedDNSDomains = []string{"bad.example.com"}
+	constrainedIssuer := &issuance.Issuer{
+		Cert:    &issuance.Certificate{Certificate: &constrainedCert},
+		Signer:  caKey,
+		Profile: defaultIssuer.Profile,
+		Linter:  caLinter,
+		Clk:     testCtx.fc,
+	}
+
+	// The default issuer is listed first, but names within the constrained
+	// issuer's permitted subtrees are issued by it.
+	maps := makeIssuerMaps([]*issuance.Issuer{defaultIssuer, constrainedIssuer})
+	test.AssertEquals(t, maps.byAlg[x509.RSA], defaultIssuer)
+	test.AssertEquals(t, len(maps.allByAlg[x509.RSA]), 2)
+
+	issuer, err := maps.selectIssuer(x509.RSA, []string{"example.com", "www.example.com"})
+	test.AssertNotError(t, err, "selecting issuer for constrained names")
+	test.AssertEquals(t, issuer, constrainedIssuer)
+
+	issuer, err = maps.selectIssuer(x509.RSA, []string{"www.example.com", "example.net"})
+	test.AssertNotError(t, err, "selecting issuer for unconstrained names")
+	test.AssertEquals(t, issuer, defaultIssuer)
+
+	// With only constrained issuers, names outside their constraints are
+	// rejected.
+	maps = makeIssuerMaps([]*issuance.Issuer{constrainedIssuer})
+	test.AssertEquals(t, maps.byAlg[x509.RSA], constrainedIssuer)
+	_, err = maps.selectIssuer(x509.RSA, []string{"bad.example.com"})
+	test.AssertErrorIs(t, err, berrors.RejectedIdentifier)
+
+	// The test CA excludes .mil, so no issuer may include such names.
+	maps = makeIssuerMaps([]*issuance.Issuer{defaultIssuer})
+	_, err = maps.selectIssuer(x509.RSA, []string{"example.mil"})
+	test.AssertErrorIs(t, err, berrors.RejectedIdentifier)
+
+	_, err = maps.selectIssuer(x509.Ed25519, []string{"example.com"})
+	test.AssertErrorIs(t, err, berrors.InternalServer)
+}
+
+func TestSelectIssuerLifecycle(t *testing.T) {
+	testCtx := setup(t)
+	profile := func(state issuance.IssuerState, weight int) *issuance.Profile {
+		res, err := issuance.NewProfile(
+			issuance.ProfileConfig{
+				MaxValidityPeriod:   config.Duration{Duration: time.Hour * 8760},
+				MaxValidityBackdate: config.Duration{Duration: time.Hour},
+			},
+			issuance.IssuerConfig{
+				UseForRSALeaves: true,
+				State:           state,
+				Weight:          weight,
+				IssuerURL:       "http://not-example.com/issuer-url",
+				OCSPURL:         "http://not-example.com/ocsp",
+			},
+		)
+		test.AssertNotError(t, err, "creating profile")
+		return res
+	}
+	newIssuer := func(cert *issuance.Certificate, p *issuance.Profile) *issuance.Issuer {
+		return &issuance.Issuer{Cert: cert, Signer: caKey, Profile: p, Linter: caLinter, Clk: testCtx.fc}
+	}
+
+	// A retiring issuer is not selected for issuance, even if listed first,
+	// but still signs for the certificates it has already issued.
+	retiring := newIssuer(caCert2, profile(issuance.StateRetiring, 0))
+	active := newIssuer(caCert, profile("", 0))
+	maps := makeIssuerMaps([]*issuance.Issuer{retiring, active})
+	test.AssertEquals(t, len(maps.allByAlg[x509.RSA]), 1)
+	test.AssertEquals(t, maps.byNameID[caCert2.NameID()], retiring)
+	issuer, err := maps.selectIssuer(x509.RSA, []string{"example.com"})
+	test.AssertNotError(t, err, "selecting issuer")
+	test.AssertEquals(t, issuer, active)
+
+	// With only retiring issuers, nothing can be issued.
+	maps = makeIssuerMaps([]*issuance.Issuer{retiring})
+	_, err = maps.selectIssuer(x509.RSA, []string{"example.com"})
+	test.AssertErrorIs(t, err, berrors.InternalServer)
+
+	// Active issuers with positive weights are selected in proportion to
+	// them, and an unweighted issuer is not selected at all.
+	heavy := newIssuer(caCert, profile(issuance.StateActive, 3))
+	light := newIssuer(caCert2, profile(issuance.StateActive, 1))
+	unweighted := newIssuer(caCert, profile(issuance.StateActive, 0))
+	maps = makeIssuerMaps([]*issuance.Issuer{unweighted, heavy, light})
+	counts := make(map[*issuance.Issuer]int)
+	for i := 0; i < 1000; i++ {
+		issuer, err := maps.selectIssuer(x509.RSA, []string{"example.com"})
+		test.AssertNotError(t, err, "selecting issuer")
+		counts[issuer]++
+	}
+	test.AssertEquals(t, counts[unweighted], 0)
+	test.Assert(t, counts[light] > 0, "lightly weighted issuer never selected")
+	test.Assert(t, counts[heavy] > counts[light], "heavily weighted issuer selected less often")
+}
+
+func TestIssuePrecertificateRetiringIssuerNameID(t *testing.T) {
+	ca, _ := issueCertificateSubTestSetup(t)
+	profile, err := issuance.NewProfile(
+		issuance.ProfileConfig{
+			AllowMustStaple:     true,
+			AllowCTPoison:       true,
+			AllowSCTList:        true,
+			MaxValidityPeriod:   config.Duration{Duration: time.Hour * 8760},
+			MaxValidityBackdate: config.Duration{Duration: time.Hour},
+		},
+		issuance.IssuerConfig{
+			UseForRSALeaves: true,
+			State:           issuance.StateRetiring,
+			IssuerURL:       "http://not-example.com/issuer-url",
+			OCSPURL:         "http://not-example.com/ocsp",
+		},
+	)
+	test.AssertNotError(t, err, "creating profile")
+	retiring := &issuance.Issuer{Cert: caCert, Signer: caKey, Profile: profile, Linter: caLinter, Clk: ca.clk}
+	ca.issuers = makeIssuerMaps([]*issuance.Issuer{ca.issuers.byNameID[caCert2.NameID()], retiring})
+
+	// Naming a retiring issuer does not get around its refusal to issue new
+	// certificates.
+	_, err = ca.IssuePrecertificate(ctx, &capb.IssueCertificateRequest{
+		Csr:            CNandSANCSR,
+		RegistrationID: arbitraryRegID,
+		IssuerNameID:   int64(caCert.NameID()),
+	})
+	test.AssertError(t, err, "issued a precertificate from a retiring issuer")
+	test.AssertContains(t, err.Error(), "retiring")
+}
+
+func TestSelectIssuerByKeyType(t *testing.T) {
+	testCtx := setup(t)
+	profile, err := issuance.NewProfile(
+		issuance.ProfileConfig{
+			AllowEd25519Leaves:  true,
+			MaxValidityPeriod:   config.Duration{Duration: time.Hour * 8760},
+			MaxValidityBackdate: config.Duration{Duration: time.Hour},
+		},
+		issuance.IssuerConfig{
+			UseForEd25519Leaves: true,
+			IssuerURL:           "http://not-example.com/issuer-url",
+			OCSPURL:             "http://not-example.com/ocsp",
+		},
+	)
+	test.AssertNotError(t, err, "creating profile")
+	modern := &issuance.Issuer{Cert: caCert2, Signer: caKey, Profile: profile, Linter: caLinter2, Clk: testCtx.fc}
+	maps := makeIssuerMaps(append([]*issuance.Issuer{modern}, testCtx.boulderIssuers...))
+
+	issuer, err := maps.selectIssuer(x509.Ed25519, []string{"example.com"})
+	test.AssertNotError(t, err, "selecting issuer")
+	test.AssertEquals(t, issuer, modern)
+	issuer, err = maps.selectIssuer(x509.RSA, []string{"example.com"})
+	test.AssertNotError(t, err, "selecting issuer")
+	test.AssertEquals(t, issuer, testCtx.boulderIssuers[1])
+}
+
+// unavailableSigner is a crypto.Signer whose HSM has stopped responding.
+type unavailableSigner struct {
+	crypto.Signer
+}
+
+func (unavailableSigner) Available() bool { return false }
+
+func TestSelectIssuerFailover(t *testing.T) {
+	testCtx := setup(t)
+	rsaIssuer := testCtx.boulderIssuers[1]
+	failing := &issuance.Issuer{
+		Cert:    rsaIssuer.Cert,
+		Signer:  unavailableSigner{caKey},
+		Profile: rsaIssuer.Profile,
+		Linter:  rsaIssuer.Linter,
+		Clk:     testCtx.fc,
+	}
+	backup := &issuance.Issuer{Cert: caCert2, Signer: caKey, Profile: rsaIssuer.Profile, Linter: caLinter2, Clk: testCtx.fc}
+
+	// The default issuer is skipped while its signer is unavailable.
+	maps := makeIssuerMaps([]*issuance.Issuer{failing, backup})
+	test.AssertEquals(t, maps.byAlg[x509.RSA], failing)
+	issuer, err := maps.selectIssuer(x509.RSA, []string{"example.com"})
+	test.AssertNotError(t, err, "selecting issuer")
+	test.AssertEquals(t, issuer, backup)
+
+	// With no available issuer, nothing can be issued.
+	maps = makeIssuerMaps([]*issuance.Issuer{failing})
+	_, err = maps.selectIssuer(x509.RSA, []string{"example.com"})
+	test.AssertErrorIs(t, err, berrors.InternalServer)
+}
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"strings"
 	"time"
 
@@ -22,19 +23,21 @@ import (
 
 type crlImpl struct {
 	capb.UnimplementedCRLGeneratorServer
-	issuers   map[issuance.IssuerNameID]*issuance.Issuer
-	lifetime  time.Duration
-	idpBase   string
-	maxLogLen int
-	log       blog.Logger
+	issuers       map[issuance.IssuerNameID]*issuance.Issuer
+	lifetime      time.Duration
+	deltaLifetime time.Duration
+	idpBase       string
+	maxLogLen     int
+	log           blog.Logger
 }
 
 // NewCRLImpt returns a new object which fulfils the ca.proto CRLGenerator
 // interface. It uses the list of issuers to determine what issuers it can
 // issue CRLs from. lifetime sets the validity period (inclusive) of the
-// resulting CRLs. idpBase is the base URL from which IssuingDistributionPoint
-// URIs will constructed; it must use the http:// scheme.
-func NewCRLImpl(issuers []*issuance.Issuer, lifetime time.Duration, idpBase string, maxLogLen int, logger blog.Logger) (*crlImpl, error) {
+// resulting CRLs, and deltaLifetime does the same for Delta CRLs. idpBase is
+// the base URL from which IssuingDistributionPoint (and FreshestCRL) URIs will
+// constructed; it must use the http:// scheme.
+func NewCRLImpl(issuers []*issuance.Issuer, lifetime time.Duration, deltaLifetime time.Duration, idpBase string, maxLogLen int, logger blog.Logger) (*crlImpl, error) {
 	issuersByNameID := make(map[issuance.IssuerNameID]*issuance.Issuer, len(issuers))
 	for _, issuer := range issuers {
 		issuersByNameID[issuer.Cert.NameID()] = issuer
@@ -49,6 +52,14 @@ func NewCRLImpl(issuers []*issuance.Issuer, lifetime time.Duration, idpBase stri
 		return nil, fmt.Errorf("crl lifetime must be positive, got %q", lifetime)
 	}
 
+	if deltaLifetime == 0 {
+		deltaLifetime = lifetime
+	} else if deltaLifetime > lifetime {
+		return nil, fmt.Errorf("delta crl lifetime cannot be more than crl lifetime %q, got %q", lifetime, deltaLifetime)
+	} else if deltaLifetime < 0 {
+		return nil, fmt.Errorf("delta crl lifetime must be positive, got %q", deltaLifetime)
+	}
+
 	if !strings.HasPrefix(idpBase, "http://") {
 		return nil, fmt.Errorf("issuingDistributionPoint base URI must use http:// scheme, got %q", idpBase)
 	}
@@ -57,11 +68,12 @@ func NewCRLImpl(issuers []*issuance.Issuer, lifetime time.Duration, idpBase stri
 	}
 
 	return &crlImpl{
-		issuers:   issuersByNameID,
-		lifetime:  lifetime,
-		idpBase:   idpBase,
-		maxLogLen: maxLogLen,
-		log:       logger,
+		issuers:       issuersByNameID,
+		lifetime:      lifetime,
+		deltaLifetime: deltaLifetime,
+		idpBase:       idpBase,
+		maxLogLen:     maxLogLen,
+		log:           logger,
 	}, nil
 }
 
@@ -69,6 +81,8 @@ func (ci *crlImpl) GenerateCRL(stream capb.CRLGenerator_GenerateCRLServer) error
 	var issuer *issuance.Issuer
 	var template *crl_x509.RevocationList
 	var shard int64
+	var baseNumber *big.Int
+	var includeFreshest bool
 	rcs := make([]crl_x509.RevokedCertificate, 0)
 
 	for {
@@ -97,7 +111,20 @@ func (ci *crlImpl) GenerateCRL(stream capb.CRLGenerator_GenerateCRLServer) error
 				return fmt.Errorf("got unrecognized IssuerNameID: %d", payload.Metadata.IssuerNameID)
 			}
 
+			// Certificates name the shard they were assigned at issuance in their
+			// CRL distribution points, so if the crl-updater divides them into a
+			// different number of shards, some will never appear on the CRL their
+			// distribution point names.
+			numShards := issuer.CRLShards()
+			if numShards != 0 && payload.Metadata.NumShards != int64(numShards) {
+				return fmt.Errorf("crl-updater uses %d shards, but issuer %q assigns certificates to %d", payload.Metadata.NumShards, issuer.Name(), numShards)
+			}
+
 			shard = payload.Metadata.ShardIdx
+			if payload.Metadata.BaseThisUpdate != 0 {
+				baseNumber = bcrl.Number(time.Unix(0, payload.Metadata.BaseThisUpdate))
+			}
+			includeFreshest = payload.Metadata.IncludeFreshestCRL
 
 		case *capb.GenerateCRLRequest_Entry:
 			rc, err := ci.entryToRevokedCertificate(payload.Entry)
@@ -123,12 +150,33 @@ func (ci *crlImpl) GenerateCRL(stream capb.CRLGenerator_GenerateCRLServer) error
 	}
 	template.ExtraExtensions = append(template.ExtraExtensions, *idp)
 
+	// Delta CRLs carry the number of the complete CRL they update, and complete
+	// CRLs may point at their Delta CRL. A Delta CRL must never do the latter.
+	if baseNumber != nil {
+		delta, err := makeDeltaExt(baseNumber)
+		if err != nil {
+			return fmt.Errorf("creating Delta CRL Indicator extension: %w", err)
+		}
+		template.ExtraExtensions = append(template.ExtraExtensions, *delta)
+	} else if includeFreshest {
+		freshest, err := makeFreshestExt(ci.idpBase, issuer.Cert.NameID(), shard)
+		if err != nil {
+			return fmt.Errorf("creating Freshest CRL extension: %w", err)
+		}
+		template.ExtraExtensions = append(template.ExtraExtensions, *freshest)
+	}
+
 	// Compute a unique ID for this issuer-number-shard combo, to tie together all
-	// the audit log lines related to its issuance.
-	logID := blog.LogLineChecksum(fmt.Sprintf("%d", issuer.Cert.NameID()) + template.Number.String() + fmt.Sprintf("%d", shard))
+	// the audit log lines related to its issuance. Delta CRLs share the number
+	// space with complete CRLs, but are distinguished by their base number.
+	base := ""
+	if baseNumber != nil {
+		base = baseNumber.String()
+	}
+	logID := blog.LogLineChecksum(fmt.Sprintf("%d", issuer.Cert.NameID()) + template.Number.String() + fmt.Sprintf("%d", shard) + base)
 	ci.log.AuditInfof(
-		"Signing CRL: logID=[%s] issuer=[%s] number=[%s] shard=[%d] thisUpdate=[%s] nextUpdate=[%s] numEntries=[%d]",
-		logID, issuer.Cert.Subject.CommonName, template.Number.String(), shard, template.ThisUpdate, template.NextUpdate, len(rcs),
+		"Signing CRL: logID=[%s] issuer=[%s] number=[%s] shard=[%d] thisUpdate=[%s] nextUpdate=[%s] baseNumber=[%s] numEntries=[%d]",
+		logID, issuer.Cert.Subject.CommonName, template.Number.String(), shard, template.ThisUpdate, template.NextUpdate, base, len(rcs),
 	)
 
 	if len(rcs) > 0 {
@@ -203,10 +251,18 @@ func (ci *crlImpl) metadataToTemplate(meta *capb.CRLMetadata) (*crl_x509.Revocat
 	thisUpdate := time.Unix(0, meta.ThisUpdate)
 	number := bcrl.Number(thisUpdate)
 
+	lifetime := ci.lifetime
+	if meta.BaseThisUpdate != 0 {
+		if meta.BaseThisUpdate >= meta.ThisUpdate {
+			return nil, errors.New("delta crl base must precede its thisUpdate")
+		}
+		lifetime = ci.deltaLifetime
+	}
+
 	return &crl_x509.RevocationList{
 		Number:     number,
 		ThisUpdate: thisUpdate,
-		NextUpdate: thisUpdate.Add(-time.Second).Add(ci.lifetime),
+		NextUpdate: thisUpdate.Add(-time.Second).Add(lifetime),
 	}, nil
 }
 
@@ -263,7 +319,7 @@ func makeIDPExt(base string, issuer issuance.IssuerNameID, shardIdx int64) (*pki
 				{ // GeneralName
 					Class: 2, // context-specific
 					Tag:   6, // uniformResourceIdentifier, IA5String
-					Bytes: []byte(fmt.Sprintf("%s/%d/%d.crl", base, issuer, shardIdx)),
+					Bytes: []byte(issuance.ShardedCRLURL(base, issuer, shardIdx)),
 				},
 			},
 		},
@@ -281,3 +337,56 @@ func makeIDPExt(base string, issuer issuance.IssuerNameID, shardIdx int64) (*pki
 		Critical: true,
 	}, nil
 }
+
+// makeDeltaExt returns a critical DeltaCRLIndicator extension, as defined in
+// RFC 5280 Section 5.2.4, containing the CRLNumber of the complete CRL which the
+// Delta CRL updates.
+func makeDeltaExt(baseNumber *big.Int) (*pkix.Extension, error) {
+	valBytes, err := asn1.Marshal(baseNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pkix.Extension{
+		Id:       crl_x509.OIDExtensionDeltaCRLIndicator,
+		Value:    valBytes,
+		Critical: true,
+	}, nil
+}
+
+// distributionPoint represents the ASN.1 DistributionPoint SEQUENCE as defined
+// in RFC 5280 Section 4.2.1.13. We only use one of the fields, so the others
+// are omitted.
+type distributionPoint struct {
+	DistributionPoint distributionPointName `asn1:"optional,tag:0"`
+}
+
+// makeFreshestExt returns a non-critical FreshestCRL extension, as defined in
+// RFC 5280 Section 5.2.6, containing a single URI built from the base url, the
+// issuer's NameID, and the shard number, at which the shard's Delta CRL can be
+// found.
+func makeFreshestExt(base string, issuer issuance.IssuerNameID, shardIdx int64) (*pkix.Extension, error) {
+	val := []distributionPoint{
+		{
+			DistributionPoint: distributionPointName{
+				[]asn1.RawValue{ // GeneralNames
+					{ // GeneralName
+						Class: 2, // context-specific
+						Tag:   6, // uniformResourceIdentifier, IA5String
+						Bytes: []byte(issuance.ShardedDeltaCRLURL(base, issuer, shardIdx)),
+					},
+				},
+			},
+		},
+	}
+
+	valBytes, err := asn1.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pkix.Extension{
+		Id:    crl_x509.OIDExtensionFreshestCRL,
+		Value: valBytes,
+	}, nil
+}
@@ -1,15 +1,26 @@
 package ca
 
 import (
+	"bytes"
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
 	"io"
+	"math/big"
 	"testing"
 	"time"
 
 	"google.golang.org/grpc"
 
 	capb "github.com/letsencrypt/boulder/ca/proto"
+	"github.com/letsencrypt/boulder/config"
 	corepb "github.com/letsencrypt/boulder/core/proto"
+	bcrl "github.com/letsencrypt/boulder/crl"
+	"github.com/letsencrypt/boulder/crl/crl_x509"
+	"github.com/letsencrypt/boulder/features"
+	"github.com/letsencrypt/boulder/issuance"
+	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/test"
 )
 
@@ -257,3 +268,164 @@ func TestGenerateCRL(t *testing.T) {
 	err = testCtx.boulderIssuers[0].Cert.CheckCRLSignature(crl)
 	test.AssertNotError(t, err, "CRL signature should validate")
 }
+
+func TestGenerateDeltaCRL(t *testing.T) {
+	testCtx := setup(t)
+	crli := testCtx.crl
+	nameID := testCtx.boulderIssuers[0].Cert.NameID()
+	now := time.Now()
+
+	generate := func(meta *capb.CRLMetadata) ([]byte, error) {
+		errs := make(chan error, 1)
+		// Buffered, so that the entry can be sent even if the metadata is
+		// rejected before it is read.
+		ins := make(chan *capb.GenerateCRLRequest, 2)
+		outs := make(chan *capb.GenerateCRLResponse)
+		go func() {
+			errs <- crli.GenerateCRL(mockGenerateCRLBidiStream{input: ins, output: outs})
+			close(outs)
+		}()
+		crlBytes := make([]byte, 0)
+		done := make(chan struct{})
+		go func() {
+			for resp := range outs {
+				crlBytes = append(crlBytes, resp.Chunk...)
+			}
+			close(done)
+		}()
+		ins <- &capb.GenerateCRLRequest{
+			Payload: &capb.GenerateCRLRequest_Metadata{Metadata: meta},
+		}
+		ins <- &capb.GenerateCRLRequest{
+			Payload: &capb.GenerateCRLRequest_Entry{
+				Entry: &corepb.CRLEntry{
+					Serial:    "111111111111111111111111111111111111",
+					Reason:    1, // keyCompromise
+					RevokedAt: now.UnixNano(),
+				},
+			},
+		}
+		close(ins)
+		err := <-errs
+		<-done
+		return crlBytes, err
+	}
+
+	findExt := func(crl *crl_x509.RevocationList, oid asn1.ObjectIdentifier) *pkix.Extension {
+		for _, ext := range crl.Extensions {
+			if ext.Id.Equal(oid) {
+				return &ext
+			}
+		}
+		return nil
+	}
+	freshestOID := asn1.ObjectIdentifier{2, 5, 29, 46}
+
+	// Test that a complete CRL can point at its Delta CRL.
+	crlBytes, err := generate(&capb.CRLMetadata{
+		IssuerNameID:       int64(nameID),
+		ThisUpdate:         now.UnixNano(),
+		ShardIdx:           2,
+		IncludeFreshestCRL: true,
+	})
+	test.AssertNotError(t, err, "generating complete CRL with Freshest CRL should work")
+	crl, err := crl_x509.ParseRevocationList(crlBytes)
+	test.AssertNotError(t, err, "parsing complete CRL")
+	test.Assert(t, !crl.IsDelta(), "complete CRL should not have Delta CRL Indicator")
+	freshest := findExt(crl, freshestOID)
+	test.Assert(t, freshest != nil, "complete CRL should have Freshest CRL")
+	test.Assert(t, !freshest.Critical, "Freshest CRL should not be critical")
+	test.Assert(t, bytes.Contains(freshest.Value, []byte(fmt.Sprintf("http://c.boulder.test/%d/2-delta.crl", nameID))), "Freshest CRL should contain delta URL")
+	test.AssertEquals(t, crl.NextUpdate, crl.ThisUpdate.Add(time.Hour-time.Second))
+
+	// Test that a Delta CRL references its base, has the delta lifetime, and
+	// ignores any request for a Freshest CRL.
+	baseThisUpdate := now.Add(-time.Hour)
+	crlBytes, err = generate(&capb.CRLMetadata{
+		IssuerNameID:       int64(nameID),
+		ThisUpdate:         now.UnixNano(),
+		ShardIdx:           2,
+		BaseThisUpdate:     baseThisUpdate.UnixNano(),
+		IncludeFreshestCRL: true,
+	})
+	test.AssertNotError(t, err, "generating Delta CRL should work")
+	crl, err = crl_x509.ParseRevocationList(crlBytes)
+	test.AssertNotError(t, err, "parsing Delta CRL")
+	test.AssertEquals(t, len(crl.RevokedCertificates), 1)
+	test.Assert(t, findExt(crl, freshestOID) == nil, "Delta CRL should not have Freshest CRL")
+	delta := findExt(crl, crl_x509.OIDExtensionDeltaCRLIndicator)
+	test.Assert(t, delta != nil, "Delta CRL should have Delta CRL Indicator")
+	test.Assert(t, delta.Critical, "Delta CRL Indicator should be critical")
+	var baseNumber *big.Int
+	_, err = asn1.Unmarshal(delta.Value, &baseNumber)
+	test.AssertNotError(t, err, "parsing Delta CRL Indicator")
+	test.AssertEquals(t, baseNumber.Cmp(bcrl.Number(baseThisUpdate)), 0)
+	test.AssertEquals(t, crl.NextUpdate, crl.ThisUpdate.Add(10*time.Minute-time.Second))
+
+	// Test that a Delta CRL can't precede its base.
+	_, err = generate(&capb.CRLMetadata{
+		IssuerNameID:   int64(nameID),
+		ThisUpdate:     now.UnixNano(),
+		BaseThisUpdate: now.UnixNano(),
+	})
+	test.AssertError(t, err, "can't generate Delta CRL with base that doesn't precede it")
+	test.AssertContains(t, err.Error(), "must precede")
+}
+
+func TestGenerateCRLShardMismatch(t *testing.T) {
+	testCtx := setup(t)
+	err := features.Set(map[string]bool{"StoreCRLShards": true})
+	test.AssertNotError(t, err, "setting features")
+	defer features.Reset()
+	profile, err := issuance.NewProfile(
+		issuance.ProfileConfig{
+			IncludeCRLDistributionPoints: true,
+			CRLDPBase:                    "http://c.boulder.test",
+			CRLShards:                    10,
+			MaxValidityPeriod:            config.Duration{Duration: time.Hour * 8760},
+			MaxValidityBackdate:          config.Duration{Duration: time.Hour},
+		},
+		issuance.IssuerConfig{
+			UseForRSALeaves: true,
+			IssuerURL:       "http://not-example.com/issuer-url",
+			OCSPURL:         "http://not-example.com/ocsp",
+		},
+	)
+	test.AssertNotError(t, err, "creating profile")
+	issuer := &issuance.Issuer{Cert: caCert, Signer: caKey, Profile: profile, Linter: caLinter, Clk: testCtx.fc}
+	crli, err := NewCRLImpl([]*issuance.Issuer{issuer}, time.Hour, 10*time.Minute, "http://c.boulder.test", 100, blog.NewMock())
+	test.AssertNotError(t, err, "creating crl impl")
+
+	generate := func(numShards int64) error {
+		ins := make(chan *capb.GenerateCRLRequest)
+		outs := make(chan *capb.GenerateCRLResponse)
+		errs := make(chan error, 1)
+		go func() {
+			errs <- crli.GenerateCRL(mockGenerateCRLBidiStream{input: ins, output: outs})
+			close(outs)
+		}()
+		ins <- &capb.GenerateCRLRequest{
+			Payload: &capb.GenerateCRLRequest_Metadata{
+				Metadata: &capb.CRLMetadata{
+					IssuerNameID: int64(caCert.NameID()),
+					ThisUpdate:   testCtx.fc.Now().UnixNano(),
+					ShardIdx:     1,
+					NumShards:    numShards,
+				},
+			},
+		}
+		close(ins)
+		for range outs {
+		}
+		return <-errs
+	}
+
+	// Certificates in shards beyond the crl-updater's would never appear on
+	// any CRL, so a mismatch in either direction is refused.
+	err = generate(5)
+	test.AssertError(t, err, "generated CRL with fewer shards than the profile")
+	test.AssertContains(t, err.Error(), "crl-updater uses 5 shards")
+	err = generate(20)
+	test.AssertError(t, err, "generated CRL with more shards than the profile")
+	test.AssertNotError(t, generate(10), "generating CRL with matching shards")
+}
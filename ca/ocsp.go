@@ -2,6 +2,7 @@ package ca
 
 import (
 	"context"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"strings"
@@ -29,13 +30,18 @@ type ocspIssuerMaps struct {
 // ocspImpl provides a backing implementation for the OCSP gRPC service.
 type ocspImpl struct {
 	capb.UnimplementedOCSPGeneratorServer
-	issuers        ocspIssuerMaps
-	ocspLifetime   time.Duration
-	ocspLogQueue   *ocspLogQueue
-	log            blog.Logger
-	signatureCount *prometheus.CounterVec
-	signErrorCount *prometheus.CounterVec
-	clk            clock.Clock
+	issuers         ocspIssuerMaps
+	ocspLifetime    time.Duration
+	ocspLogQueue    *ocspLogQueue
+	log             blog.Logger
+	signatureCount  *prometheus.CounterVec
+	signErrorCount  *prometheus.CounterVec
+	responderExpiry *prometheus.GaugeVec
+	// responderWarnPeriod is how long before an issuer's delegated responders
+	// become unusable that warnings about it begin to be logged.
+	responderWarnPeriod time.Duration
+	stopMonitor         chan struct{}
+	clk                 clock.Clock
 }
 
 // makeOCSPIssuerMaps processes a list of issuers into a set of maps, mapping
@@ -55,6 +61,7 @@ func makeOCSPIssuerMaps(issuers []*issuance.Issuer) ocspIssuerMaps {
 func NewOCSPImpl(
 	issuers []*issuance.Issuer,
 	ocspLifetime time.Duration,
+	responderWarnPeriod time.Duration,
 	ocspLogMaxLength int,
 	ocspLogPeriod time.Duration,
 	logger blog.Logger,
@@ -75,14 +82,23 @@ func NewOCSPImpl(
 
 	issuerMaps := makeOCSPIssuerMaps(issuers)
 
+	responderExpiry := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ocsp_delegated_responder_expiry",
+		Help: "Unix time at which the last-expiring delegated OCSP responder of each issuer expires",
+	}, []string{"issuer"})
+	stats.MustRegister(responderExpiry)
+
 	oi := &ocspImpl{
-		issuers:        issuerMaps,
-		ocspLifetime:   ocspLifetime,
-		ocspLogQueue:   ocspLogQueue,
-		log:            logger,
-		signatureCount: signatureCount,
-		signErrorCount: signErrorCount,
-		clk:            clk,
+		issuers:             issuerMaps,
+		ocspLifetime:        ocspLifetime,
+		ocspLogQueue:        ocspLogQueue,
+		log:                 logger,
+		signatureCount:      signatureCount,
+		signErrorCount:      signErrorCount,
+		responderExpiry:     responderExpiry,
+		responderWarnPeriod: responderWarnPeriod,
+		stopMonitor:         make(chan struct{}),
+		clk:                 clk,
 	}
 	return oi, nil
 }
@@ -95,11 +111,52 @@ func (oi *ocspImpl) LogOCSPLoop() {
 	}
 }
 
+// MonitorResponders checks the expiry of each issuer's delegated responders
+// once an hour until Stop is called.
+func (oi *ocspImpl) MonitorResponders() {
+	for {
+		oi.checkResponders()
+		select {
+		case <-oi.stopMonitor:
+			return
+		case <-oi.clk.After(time.Hour):
+		}
+	}
+}
+
+// checkResponders exports the expiry of the last delegated responder of each
+// issuer which has any, and logs a warning if that responder will soon be
+// unable to sign responses for their whole lifetime. Once it can't, responses
+// fall back to being signed by the issuer itself, so a new responder should be
+// issued and configured before then, and will be rotated to automatically.
+func (oi *ocspImpl) checkResponders() {
+	now := oi.clk.Now()
+	for _, issuer := range oi.issuers.byNameID {
+		if len(issuer.OCSPResponders) == 0 {
+			continue
+		}
+		var expiry time.Time
+		for _, r := range issuer.OCSPResponders {
+			if r.Cert.NotAfter.After(expiry) {
+				expiry = r.Cert.NotAfter
+			}
+		}
+		oi.responderExpiry.WithLabelValues(issuer.Name()).Set(float64(expiry.Unix()))
+
+		usableUntil := expiry.Add(-oi.ocspLifetime)
+		if usableUntil.Sub(now) < oi.responderWarnPeriod {
+			oi.log.Warningf("Delegated OCSP responders for issuer %q can only sign responses until %s: issue and configure a new one",
+				issuer.Name(), usableUntil.Format(time.RFC3339))
+		}
+	}
+}
+
 // Stop asks this ocspImpl to shut down. It must be called after the
 // corresponding RPC service is shut down and there are no longer any inflight
 // RPCs. It will attempt to drain any logging queues (which may block), and will
 // return only when done.
 func (oi *ocspImpl) Stop() {
+	close(oi.stopMonitor)
 	if oi.ocspLogQueue != nil {
 		oi.ocspLogQueue.stop()
 	}
@@ -139,13 +196,35 @@ func (oi *ocspImpl) GenerateOCSP(ctx context.Context, req *capb.GenerateOCSPRequ
 		tbsResponse.RevocationReason = int(req.Reason)
 	}
 
+	// Prefer a delegated responder, whose certificate is then included in the
+	// response so that clients can verify it, over the issuer's own key.
+	purpose := "ocsp"
+	responderCert := issuer.Cert.Certificate
+	signer := issuer.Signer
+	var lintResponder *x509.Certificate
+	responder := issuer.OCSPResponder(tbsResponse.ThisUpdate, tbsResponse.NextUpdate)
+	if responder != nil {
+		purpose = "ocsp_delegated"
+		responderCert = responder.Cert
+		signer = responder.Signer
+		lintResponder = responder.Cert
+		tbsResponse.Certificate = responder.Cert
+	}
+
+	// Sign the response with a throwaway key, as the chosen responder, and lint
+	// it before signing it for real.
+	err = issuer.Linter.CheckOCSP(tbsResponse, lintResponder)
+	if err != nil {
+		return nil, fmt.Errorf("OCSP response linting failed: %w", err)
+	}
+
 	if oi.ocspLogQueue != nil {
 		oi.ocspLogQueue.enqueue(serial.Bytes(), now, tbsResponse.Status, tbsResponse.RevocationReason)
 	}
 
-	ocspResponse, err := ocsp.CreateResponse(issuer.Cert.Certificate, issuer.Cert.Certificate, tbsResponse, issuer.Signer)
+	ocspResponse, err := ocsp.CreateResponse(issuer.Cert.Certificate, responderCert, tbsResponse, signer)
 	if err == nil {
-		oi.signatureCount.With(prometheus.Labels{"purpose": "ocsp", "issuer": issuer.Name()}).Inc()
+		oi.signatureCount.With(prometheus.Labels{"purpose": purpose, "issuer": issuer.Name()}).Inc()
 	} else {
 		var pkcs11Error *pkcs11.Error
 		if errors.As(err, &pkcs11Error) {
@@ -293,6 +372,9 @@ func (oi *disabledOCSPImpl) GenerateOCSP(ctx context.Context, req *capb.Generate
 // LogOCSPLoop is an no-op because there is no OCSP issuance to be logged.
 func (oi *disabledOCSPImpl) LogOCSPLoop() {}
 
+// MonitorResponders is a no-op because no delegated responders are used.
+func (oi *disabledOCSPImpl) MonitorResponders() {}
+
 // Stop is a no-op because there is no log loop to be stopped.
 func (oi *disabledOCSPImpl) Stop() {}
 
@@ -303,5 +385,6 @@ func (oi *disabledOCSPImpl) Stop() {}
 type OCSPGenerator interface {
 	capb.OCSPGeneratorServer
 	LogOCSPLoop()
+	MonitorResponders()
 	Stop()
 }
@@ -2,16 +2,24 @@ package ca
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/hex"
+	"math/big"
 	"testing"
 	"time"
 
 	capb "github.com/letsencrypt/boulder/ca/proto"
 	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/issuance"
+	ocsplints "github.com/letsencrypt/boulder/linter/lints/ocsp"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/test"
+	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/crypto/ocsp"
 )
 
@@ -107,6 +115,142 @@ func TestOCSP(t *testing.T) {
 	test.AssertNotError(t, err, "GenerateOCSP failed with fake-but-valid Serial")
 }
 
+func TestGenerateOCSPLintFailure(t *testing.T) {
+	testCtx := setup(t)
+	// An OCSP lifetime shorter than eight hours fails the validity interval
+	// lint, so no response should be signed.
+	ocspi, err := NewOCSPImpl(
+		testCtx.boulderIssuers,
+		time.Hour,
+		0,
+		0,
+		time.Second,
+		blog.NewMock(),
+		metrics.NoopRegisterer,
+		testCtx.signatureCount,
+		testCtx.signErrorCount,
+		testCtx.fc,
+	)
+	test.AssertNotError(t, err, "Failed to create ocsp impl")
+
+	_, err = ocspi.GenerateOCSP(context.Background(), &capb.GenerateOCSPRequest{
+		Serial:   "03DEADBEEFBADDECAFFADEFACECAFE30",
+		IssuerID: int64(testCtx.boulderIssuers[0].ID()),
+		Status:   string(core.OCSPStatusGood),
+	})
+	test.AssertError(t, err, "GenerateOCSP signed a response which fails lints")
+	test.AssertContains(t, err.Error(), "OCSP response linting failed")
+	test.AssertMetricWithLabelsEquals(t, testCtx.signatureCount, prometheus.Labels{"purpose": "ocsp"}, 0)
+}
+
+func TestGenerateOCSPDelegated(t *testing.T) {
+	testCtx := setup(t)
+	now := testCtx.fc.Now()
+	day := 24 * time.Hour
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating responder key")
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber:    big.NewInt(1),
+		Subject:         pkix.Name{CommonName: "happy hacker fake OCSP"},
+		NotBefore:       now.Add(-time.Hour),
+		NotAfter:        now.Add(60 * day),
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+		ExtraExtensions: []pkix.Extension{{Id: ocsplints.OIDOCSPNoCheck, Value: []byte{5, 0}}},
+	}, caCert.Certificate, key.Public(), caKey)
+	test.AssertNotError(t, err, "creating responder certificate")
+	responderCert, err := x509.ParseCertificate(der)
+	test.AssertNotError(t, err, "parsing responder certificate")
+
+	issuer := &issuance.Issuer{
+		Cert:           caCert,
+		Signer:         caKey,
+		Profile:        testCtx.boulderIssuers[0].Profile,
+		Linter:         caLinter,
+		Clk:            testCtx.fc,
+		OCSPResponders: []*issuance.DelegatedResponder{{Cert: responderCert, Signer: key}},
+	}
+	log := blog.NewMock()
+	ocspi, err := NewOCSPImpl(
+		[]*issuance.Issuer{issuer},
+		96*time.Hour,
+		30*day,
+		0,
+		time.Second,
+		log,
+		metrics.NoopRegisterer,
+		testCtx.signatureCount,
+		testCtx.signErrorCount,
+		testCtx.fc,
+	)
+	test.AssertNotError(t, err, "Failed to create ocsp impl")
+
+	generate := func() *ocsp.Response {
+		t.Helper()
+		resp, err := ocspi.GenerateOCSP(context.Background(), &capb.GenerateOCSPRequest{
+			Serial:   "03DEADBEEFBADDECAFFADEFACECAFE30",
+			IssuerID: int64(caCert.NameID()),
+			Status:   string(core.OCSPStatusGood),
+		})
+		test.AssertNotError(t, err, "Failed to generate OCSP")
+		parsed, err := ocsp.ParseResponse(resp.Response, caCert.Certificate)
+		test.AssertNotError(t, err, "Failed to parse / validate OCSP")
+		return parsed
+	}
+
+	// The delegated responder signs, and is included in, the response.
+	resp := generate()
+	test.AssertNotNil(t, resp.Certificate, "delegated responder not included in response")
+	test.AssertByteEquals(t, resp.Certificate.Raw, responderCert.Raw)
+	test.AssertByteEquals(t, resp.RawResponderName, responderCert.RawSubject)
+	test.AssertMetricWithLabelsEquals(t, testCtx.signatureCount, prometheus.Labels{"purpose": "ocsp_delegated", "issuer": issuer.Name()}, 1)
+	test.AssertMetricWithLabelsEquals(t, testCtx.signatureCount, prometheus.Labels{"purpose": "ocsp", "issuer": issuer.Name()}, 0)
+
+	ocspi.checkResponders()
+	test.AssertEquals(t, len(log.GetAllMatching("Delegated OCSP responders")), 0)
+	test.AssertMetricWithLabelsEquals(t, ocspi.responderExpiry, prometheus.Labels{"issuer": issuer.Name()}, float64(responderCert.NotAfter.Unix()))
+
+	// Within the warning period, the responder is still used, but its
+	// impending expiry is warned about.
+	testCtx.fc.Add(35 * day)
+	resp = generate()
+	test.AssertNotNil(t, resp.Certificate, "delegated responder not included in response")
+	ocspi.checkResponders()
+	test.AssertEquals(t, len(log.GetAllMatching("WARNING: Delegated OCSP responders for issuer")), 1)
+
+	// Once responses would outlive the responder, the issuer signs them itself.
+	testCtx.fc.Add(22 * day)
+	resp = generate()
+	test.Assert(t, resp.Certificate == nil, "expired delegated responder included in response")
+	test.AssertByteEquals(t, resp.RawResponderName, caCert.RawSubject)
+	test.AssertMetricWithLabelsEquals(t, testCtx.signatureCount, prometheus.Labels{"purpose": "ocsp", "issuer": issuer.Name()}, 1)
+
+	// The response is linted as the delegated responder would sign it, so a
+	// responder certificate without the ocsp-nocheck extension is caught
+	// before anything is signed.
+	der, err = x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "happy hacker fake OCSP"},
+		NotBefore:    testCtx.fc.Now().Add(-time.Hour),
+		NotAfter:     testCtx.fc.Now().Add(60 * day),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+	}, caCert.Certificate, key.Public(), caKey)
+	test.AssertNotError(t, err, "creating responder certificate")
+	badResponderCert, err := x509.ParseCertificate(der)
+	test.AssertNotError(t, err, "parsing responder certificate")
+	issuer.OCSPResponders = []*issuance.DelegatedResponder{{Cert: badResponderCert, Signer: key}}
+	_, err = ocspi.GenerateOCSP(context.Background(), &capb.GenerateOCSPRequest{
+		Serial:   "03DEADBEEFBADDECAFFADEFACECAFE30",
+		IssuerID: int64(caCert.NameID()),
+		Status:   string(core.OCSPStatusGood),
+	})
+	test.AssertError(t, err, "generated OCSP response from an invalid delegated responder")
+	test.AssertContains(t, err.Error(), "hasValidResponderCert")
+	test.AssertMetricWithLabelsEquals(t, testCtx.signatureCount, prometheus.Labels{"purpose": "ocsp_delegated", "issuer": issuer.Name()}, 2)
+}
+
 // Set up an ocspLogQueue with a very long period and a large maxLen,
 // to ensure any buffered entries get flushed on `.stop()`.
 func TestOcspLogFlushOnExit(t *testing.T) {
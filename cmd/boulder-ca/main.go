@@ -2,7 +2,9 @@ package notmain
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/beeker1121/goque"
 	"github.com/prometheus/client_golang/prometheus"
@@ -18,6 +20,7 @@ import (
 	bgrpc "github.com/letsencrypt/boulder/grpc"
 	"github.com/letsencrypt/boulder/issuance"
 	"github.com/letsencrypt/boulder/linter"
+	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/policy"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
 )
@@ -37,9 +40,20 @@ type Config struct {
 
 		// Issuance contains all information necessary to load and initialize issuers.
 		Issuance struct {
-			Profile      issuance.ProfileConfig
-			Issuers      []issuance.IssuerConfig `validate:"min=1,dive"`
+			Profile issuance.ProfileConfig
+			Issuers []issuance.IssuerConfig `validate:"min=1,dive"`
+			// Lints is the lint configuration for issuers which don't have
+			// their own.
+			Lints linter.Config
+			// IgnoredLints lists lints which are not run for any issuer, in
+			// addition to those in each lint configuration.
 			IgnoredLints []string
+			// IssuerStatesFile is the path to a YAML file, shared with
+			// crl-updater and ocsp-responder, mapping issuer certificate paths
+			// to the lifecycle state of that issuer. If it is set, every
+			// issuer's Location.CertFile must be listed, and an issuer's State,
+			// if set, must agree with the file.
+			IssuerStatesFile string `validate:"omitempty"`
 		}
 
 		// How long issued certificates are valid for.
@@ -59,11 +73,22 @@ type Config struct {
 		// Section 4.9.10, it MUST NOT be more than 10 days.
 		LifespanOCSP config.Duration
 
+		// OCSPResponderWarnPeriod is how long before an issuer's delegated OCSP
+		// responders can no longer sign responses for their full lifespan that
+		// the CA begins to warn about it, so that a replacement can be issued
+		// and configured in time. Defaults to 30 days.
+		OCSPResponderWarnPeriod config.Duration `validate:"-"`
+
 		// LifespanCRL is how long CRLs are valid for. It should be longer than the
 		// `period` field of the CRL Updater. Per the BRs, Section 4.9.7, it MUST
 		// NOT be more than 10 days.
 		LifespanCRL config.Duration
 
+		// LifespanDeltaCRL is how long Delta CRLs are valid for. It should be
+		// longer than the `deltaPeriod` field of the CRL Updater, and MUST NOT be
+		// longer than LifespanCRL. If unset, it defaults to LifespanCRL.
+		LifespanDeltaCRL config.Duration `validate:"-"`
+
 		// GoodKey is an embedded config stanza for the goodkey library.
 		GoodKey goodkey.Config
 
@@ -118,32 +143,82 @@ type Config struct {
 	Beeline cmd.BeelineConfig
 }
 
-func loadBoulderIssuers(profileConfig issuance.ProfileConfig, issuerConfigs []issuance.IssuerConfig, ignoredLints []string) ([]*issuance.Issuer, error) {
+// applyIssuerStates sets the State of each issuer config from the shared issuer
+// states file, refusing any issuer whose configured State disagrees with it.
+func applyIssuerStates(issuerConfigs []issuance.IssuerConfig, filename string) error {
+	paths := make([]string, 0, len(issuerConfigs))
+	for _, issuerConfig := range issuerConfigs {
+		paths = append(paths, issuerConfig.Location.CertFile)
+	}
+	states, err := issuance.LoadIssuerStates(filename, paths)
+	if err != nil {
+		return err
+	}
+	for i, issuerConfig := range issuerConfigs {
+		state := states[issuerConfig.Location.CertFile]
+		if issuerConfig.State != "" && issuerConfig.State != state {
+			return fmt.Errorf("issuer %q has state %q, but the issuer states file says %q",
+				issuerConfig.Location.CertFile, issuerConfig.State, state)
+		}
+		issuerConfigs[i].State = state
+	}
+	return nil
+}
+
+func loadBoulderIssuers(profileConfig issuance.ProfileConfig, issuerConfigs []issuance.IssuerConfig, lintConfig linter.Config, ignoredLints []string, poolMetrics *issuance.SignerPoolMetrics, logger blog.Logger) ([]*issuance.Issuer, []*issuance.PooledSigner, error) {
 	issuers := make([]*issuance.Issuer, 0, len(issuerConfigs))
+	var pooledSigners []*issuance.PooledSigner
 	for _, issuerConfig := range issuerConfigs {
+		if issuerConfig.State == issuance.StateArchived {
+			// Archived issuers' keys may no longer be available.
+			continue
+		}
 		profile, err := issuance.NewProfile(profileConfig, issuerConfig)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		cert, signer, err := issuance.LoadIssuer(issuerConfig.Location)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+
+		if issuerConfig.Location.SignerPool != nil {
+			pooled, err := issuance.NewPooledSigner(cert.Subject.CommonName, signer, issuerConfig.Location.NumSessions,
+				*issuerConfig.Location.SignerPool, poolMetrics, cmd.Clock(), logger)
+			if err != nil {
+				return nil, nil, err
+			}
+			pooledSigners = append(pooledSigners, pooled)
+			signer = pooled
 		}
 
-		linter, err := linter.New(cert.Certificate, signer, ignoredLints)
+		issuerLintConfig := lintConfig
+		if issuerConfig.Lints != nil {
+			issuerLintConfig = *issuerConfig.Lints
+		}
+		issuerLintConfig.IgnoredLints = append(append([]string{}, ignoredLints...), issuerLintConfig.IgnoredLints...)
+		linter, err := linter.NewWithConfig(cert.Certificate, signer, issuerLintConfig)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		issuer, err := issuance.NewIssuer(cert, signer, profile, linter, cmd.Clock())
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+
+		for _, location := range issuerConfig.OCSPResponders {
+			responder, err := issuance.LoadDelegatedResponder(location, cert)
+			if err != nil {
+				return nil, nil, err
+			}
+			issuer.OCSPResponders = append(issuer.OCSPResponders, responder)
 		}
 
 		issuers = append(issuers, issuer)
 	}
-	return issuers, nil
+	return issuers, pooledSigners, nil
 }
 
 func main() {
@@ -215,9 +290,27 @@ func main() {
 		cmd.FailOnError(err, "Failed to load CT Log List")
 	}
 
-	var boulderIssuers []*issuance.Issuer
-	boulderIssuers, err = loadBoulderIssuers(c.CA.Issuance.Profile, c.CA.Issuance.Issuers, c.CA.Issuance.IgnoredLints)
+	// Certificates' CRL distribution points must match the Issuing
+	// Distribution Points of the CRL shards which this CA signs.
+	if c.CA.Issuance.Profile.IncludeCRLDistributionPoints {
+		if c.CA.Issuance.Profile.CRLDPBase == "" {
+			c.CA.Issuance.Profile.CRLDPBase = c.CA.CRLDPBase
+		} else if c.CA.Issuance.Profile.CRLDPBase != c.CA.CRLDPBase {
+			cmd.Fail("Issuance profile's crlDPBase must match crldpBase")
+		}
+	}
+
+	if c.CA.Issuance.IssuerStatesFile != "" {
+		err = applyIssuerStates(c.CA.Issuance.Issuers, c.CA.Issuance.IssuerStatesFile)
+		cmd.FailOnError(err, "Couldn't load issuer states")
+	}
+
+	boulderIssuers, pooledSigners, err := loadBoulderIssuers(c.CA.Issuance.Profile, c.CA.Issuance.Issuers,
+		c.CA.Issuance.Lints, c.CA.Issuance.IgnoredLints, issuance.NewSignerPoolMetrics(scope), logger)
 	cmd.FailOnError(err, "Couldn't load issuers")
+	for _, pooled := range pooledSigners {
+		pooled.Start()
+	}
 
 	tlsConfig, err := c.CA.TLS.Load()
 	cmd.FailOnError(err, "TLS config")
@@ -261,9 +354,15 @@ func main() {
 	// no longer needs ocspi as an argument.
 	var ocspi ca.OCSPGenerator
 	if !c.CA.DisableOCSPService {
+		responderWarnPeriod := c.CA.OCSPResponderWarnPeriod.Duration
+		if responderWarnPeriod == 0 {
+			responderWarnPeriod = 30 * 24 * time.Hour
+		}
+
 		ocspi, err = ca.NewOCSPImpl(
 			boulderIssuers,
 			c.CA.LifespanOCSP.Duration,
+			responderWarnPeriod,
 			c.CA.OCSPLogMaxLength,
 			c.CA.OCSPLogPeriod.Duration,
 			logger,
@@ -274,6 +373,7 @@ func main() {
 		)
 		cmd.FailOnError(err, "Failed to create OCSP impl")
 		go ocspi.LogOCSPLoop()
+		go ocspi.MonitorResponders()
 
 		srv = srv.Add(&capb.OCSPGenerator_ServiceDesc, ocspi)
 	}
@@ -282,6 +382,7 @@ func main() {
 		crli, err := ca.NewCRLImpl(
 			boulderIssuers,
 			c.CA.LifespanCRL.Duration,
+			c.CA.LifespanDeltaCRL.Duration,
 			c.CA.CRLDPBase,
 			c.CA.OCSPLogMaxLength,
 			logger,
@@ -323,6 +424,9 @@ func main() {
 
 	go cmd.CatchSignals(logger, func() {
 		stop()
+		for _, pooled := range pooledSigners {
+			pooled.Stop()
+		}
 		ecdsaAllowList.Stop()
 		if ocspi != nil {
 			ocspi.Stop()
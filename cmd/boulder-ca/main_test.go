@@ -1 +1,34 @@
 package notmain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/letsencrypt/boulder/issuance"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestApplyIssuerStates(t *testing.T) {
+	statesFile := filepath.Join(t.TempDir(), "issuer-states.yaml")
+	err := os.WriteFile(statesFile, []byte("a.pem: active\nb.pem: retiring\n"), 0600)
+	test.AssertNotError(t, err, "writing issuer states file")
+
+	configs := []issuance.IssuerConfig{
+		{Location: issuance.IssuerLoc{CertFile: "a.pem"}},
+		{Location: issuance.IssuerLoc{CertFile: "b.pem"}, State: issuance.StateRetiring},
+	}
+	err = applyIssuerStates(configs, statesFile)
+	test.AssertNotError(t, err, "applyIssuerStates failed")
+	test.AssertEquals(t, configs[0].State, issuance.StateActive)
+	test.AssertEquals(t, configs[1].State, issuance.StateRetiring)
+
+	configs[1].State = issuance.StateActive
+	err = applyIssuerStates(configs, statesFile)
+	test.AssertError(t, err, "applyIssuerStates accepted a state which disagrees with the file")
+
+	configs = append(configs, issuance.IssuerConfig{Location: issuance.IssuerLoc{CertFile: "c.pem"}})
+	configs[1].State = ""
+	err = applyIssuerStates(configs, statesFile)
+	test.AssertError(t, err, "applyIssuerStates accepted an issuer missing from the file")
+}
@@ -9,6 +9,7 @@ import (
 	capb "github.com/letsencrypt/boulder/ca/proto"
 	"github.com/letsencrypt/boulder/cmd"
 	"github.com/letsencrypt/boulder/config"
+	cupb "github.com/letsencrypt/boulder/crl/updater/proto"
 	"github.com/letsencrypt/boulder/ctpolicy"
 	"github.com/letsencrypt/boulder/ctpolicy/ctconfig"
 	"github.com/letsencrypt/boulder/ctpolicy/loglist"
@@ -40,6 +41,9 @@ type Config struct {
 		OCSPService         *cmd.GRPCClientConfig
 		PublisherService    *cmd.GRPCClientConfig
 		AkamaiPurgerService *cmd.GRPCClientConfig
+		// CRLUpdaterService is optional. If set, the RA notifies the crl-updater
+		// of each revocation so that it can promptly regenerate the affected CRL.
+		CRLUpdaterService *cmd.GRPCClientConfig
 
 		MaxNames int `validate:"required,min=1"`
 
@@ -170,10 +174,13 @@ func main() {
 
 	issuerCertPaths := c.RA.IssuerCerts
 	issuerCerts := make([]*issuance.Certificate, len(issuerCertPaths))
+	issuerConstraints := make([]policy.NameConstraints, len(issuerCertPaths))
 	for i, issuerCertPath := range issuerCertPaths {
 		issuerCerts[i], err = issuance.LoadCertificate(issuerCertPath)
 		cmd.FailOnError(err, "Failed to load issuer certificate")
+		issuerConstraints[i] = policy.NameConstraintsFromCert(issuerCerts[i].Certificate)
 	}
+	pa.SetIssuerNameConstraints(issuerConstraints)
 
 	// Boulder's components assume that there will always be CT logs configured.
 	// Issuing a certificate without SCTs embedded is a misissuance event as per
@@ -254,6 +261,12 @@ func main() {
 	rai.OCSP = ocspc
 	rai.SA = sac
 
+	if c.RA.CRLUpdaterService != nil {
+		cuConn, err := bgrpc.ClientSetup(c.RA.CRLUpdaterService, tlsConfig, scope, clk)
+		cmd.FailOnError(err, "Unable to create CRL Updater client")
+		rai.CRLUpdater = cupb.NewCRLUpdaterClient(cuConn)
+	}
+
 	start, stop, err := bgrpc.NewServer(c.RA.GRPC).Add(
 		&rapb.RegistrationAuthority_ServiceDesc, rai).Build(tlsConfig, scope, clk)
 	cmd.FailOnError(err, "Unable to setup RA gRPC server")
@@ -1,14 +1,23 @@
 package notmain
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
 	"flag"
+	"fmt"
+	"net/http"
 	"os"
 	"time"
 
 	"github.com/letsencrypt/boulder/bdns"
 	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/config"
 	"github.com/letsencrypt/boulder/features"
 	bgrpc "github.com/letsencrypt/boulder/grpc"
+	"github.com/letsencrypt/boulder/privatekey"
 	"github.com/letsencrypt/boulder/va"
 	vapb "github.com/letsencrypt/boulder/va/proto"
 )
@@ -29,12 +38,39 @@ type Config struct {
 		DNSTimeout                string
 		DNSAllowLoopbackAddresses bool
 
-		RemoteVAs                   []cmd.GRPCClientConfig `validate:"omitempty,dive"`
+		// DNSQueryLog, if present, records every DNS query the VA sends to a
+		// resolver, along with the ID of the authorization it was sent for.
+		DNSQueryLog *DNSQueryLogConfig
+
+		RemoteVAs []RemoteVAConfig `validate:"omitempty,dive"`
+
+		// RemoteHTTPVAs are remote perspectives reached over HTTPS/JSON
+		// instead of gRPC. Each returns results signed with its own key,
+		// which are verified before being counted alongside RemoteVAs.
+		RemoteHTTPVAs               []HTTPPerspectiveConfig `validate:"omitempty,dive"`
 		MaxRemoteValidationFailures int
+		// MinRemoteRegions, if non-zero, additionally requires that the remote
+		// VAs which agree with the primary VA span at least this many distinct
+		// regions. Every remote VA must then have a Region.
+		MinRemoteRegions int `validate:"min=0"`
+
+		// PerspectiveServer, if present, additionally serves validation
+		// requests from a primary VA over HTTPS/JSON, signing each result.
+		// Only meaningful when running as boulder-remoteva.
+		PerspectiveServer *PerspectiveServerConfig
 
 		Features map[string]bool
 
 		AccountURIPrefixes []string
+
+		// RestrictHTTP01Redirects causes the VA to refuse to follow HTTP-01
+		// redirects to hosts whose registered domain (eTLD+1, per the full
+		// Public Suffix List) differs from that of the name being validated.
+		RestrictHTTP01Redirects bool
+
+		// CAACache, if present, enables an in-memory cache of CAA RRsets
+		// shared by all validations and IsCAAValid checks.
+		CAACache *CAACacheConfig
 	}
 
 	Syslog  cmd.SyslogConfig
@@ -50,6 +86,158 @@ type Config struct {
 	}
 }
 
+// DNSQueryLogConfig configures the VA's DNS query log.
+type DNSQueryLogConfig struct {
+	// File is the path of a local file to which queries are appended as JSON
+	// lines. If empty, queries are instead written to the VA's log.
+	File string
+	// MaxSizeMB is the size in megabytes at which File is rotated. Defaults
+	// to 100.
+	MaxSizeMB int `validate:"omitempty,min=1"`
+	// MaxBackups is the number of rotated files to keep. Defaults to 5.
+	MaxBackups int `validate:"omitempty,min=1"`
+}
+
+// CAACacheConfig configures the VA's CAA RRset cache.
+type CAACacheConfig struct {
+	// Disabled turns the cache off without removing its configuration.
+	Disabled bool
+	// Size is the maximum number of names held in the cache.
+	Size int `validate:"required,min=1"`
+	// MaxAge caps how long an RRset is cached, regardless of its TTL. It must
+	// be no greater than 8 hours.
+	MaxAge config.Duration `validate:"-"`
+	// EmptyTTL is how long an empty RRset is cached. Zero means empty
+	// RRsets are not cached.
+	EmptyTTL config.Duration `validate:"-"`
+}
+
+// PerspectiveMetadata describes the network location a remote VA validates
+// from.
+type PerspectiveMetadata struct {
+	// Region is the geographic region of the remote VA, e.g. "eu-west". Remote
+	// VAs sharing a region count once toward MinRemoteRegions.
+	Region string
+	// RIR is the Regional Internet Registry of the remote VA's network.
+	RIR string `validate:"omitempty,oneof=ARIN RIPE APNIC LACNIC AFRINIC"`
+	// ASN is the Autonomous System Number of the remote VA's network.
+	ASN uint32
+}
+
+// RemoteVAConfig describes a remote VA reached over gRPC.
+type RemoteVAConfig struct {
+	cmd.GRPCClientConfig
+	// Perspective is the name recorded for the remote VA's results. Defaults
+	// to its server address.
+	Perspective string
+	PerspectiveMetadata
+}
+
+// HTTPPerspectiveConfig describes a remote perspective reached over the
+// HTTPS/JSON perspective transport.
+type HTTPPerspectiveConfig struct {
+	// URL is the HTTPS endpoint of the remote perspective.
+	URL string `validate:"required,url"`
+	// Perspective is the name the remote perspective signs its results with.
+	Perspective string `validate:"required"`
+	// PublicKeyFile is a PEM-encoded ECDSA public key which must have signed
+	// every result from this perspective.
+	PublicKeyFile string `validate:"required"`
+	// CACertFile, if set, contains the roots used to verify the perspective's
+	// HTTPS certificate. Otherwise the system roots are used. The primary VA
+	// authenticates itself with the client certificate from its TLS config.
+	CACertFile string
+	// MaxResultAge is how old a signed result may be when it is received.
+	// Defaults to one minute.
+	MaxResultAge config.Duration `validate:"-"`
+	PerspectiveMetadata
+}
+
+// PerspectiveServerConfig configures the server half of the HTTPS/JSON
+// perspective transport.
+type PerspectiveServerConfig struct {
+	ListenAddress string `validate:"required,hostname_port"`
+	// Perspective is the name included in every signed result.
+	Perspective string `validate:"required"`
+	// SigningKeyFile is a PEM-encoded ECDSA P-256 or P-384 private key used to
+	// sign results.
+	SigningKeyFile string `validate:"required"`
+	// TLS contains the HTTPS server certificate and key, and the roots used to
+	// verify the client certificates which primary VAs must present.
+	TLS cmd.TLSConfig
+	// ClientNames lists the DNS names of the primary VAs allowed to request
+	// validations. A client certificate must have one of them as a SAN.
+	ClientNames []string `validate:"min=1,dive,hostname"`
+}
+
+func loadPublicKey(path string) (interface{}, error) {
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(pemBytes)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("no PEM PUBLIC KEY block found in %q", path)
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+func newHTTPPerspectiveClient(c HTTPPerspectiveConfig, clientCerts []tls.Certificate) (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		Certificates: clientCerts,
+		MinVersion:   tls.VersionTLS12,
+	}
+	if c.CACertFile != "" {
+		caCertBytes, err := os.ReadFile(c.CACertFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caCertBytes) {
+			return nil, fmt.Errorf("parsing CA certs from %q failed", c.CACertFile)
+		}
+	}
+	return &http.Client{
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		// Never follow redirects: the perspective URL is configured exactly.
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}, nil
+}
+
+// newPerspectiveServerTLSConfig returns the TLS config for the perspective
+// server, which requires clients to present a certificate issued by one of the
+// configured roots with one of the configured client names as a SAN, just as
+// the gRPC servers do.
+func newPerspectiveServerTLSConfig(ps *PerspectiveServerConfig) (*tls.Config, error) {
+	if len(ps.ClientNames) == 0 {
+		return nil, errors.New("perspective server must have at least one client name")
+	}
+	tlsConfig, err := ps.TLS.Load()
+	if err != nil {
+		return nil, err
+	}
+	accepted := make(map[string]bool, len(ps.ClientNames))
+	for _, name := range ps.ClientNames {
+		accepted[name] = true
+	}
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("no client certificate presented")
+		}
+		leaf := cs.PeerCertificates[0]
+		for _, name := range leaf.DNSNames {
+			if accepted[name] {
+				return nil
+			}
+		}
+		return fmt.Errorf("client certificate SANs %q are not among the accepted client names", leaf.DNSNames)
+	}
+	return tlsConfig, nil
+}
+
 func main() {
 	grpcAddr := flag.String("addr", "", "gRPC listen address override")
 	debugAddr := flag.String("debug-addr", "", "Debug server address override")
@@ -98,6 +286,26 @@ func main() {
 	servers, err = bdns.StartDynamicProvider(c.VA.DNSResolver, 60*time.Second)
 	cmd.FailOnError(err, "Couldn't start dynamic DNS server resolver")
 
+	var queryLog bdns.QueryLog
+	var fileQueryLog *bdns.FileQueryLog
+	if c.VA.DNSQueryLog != nil {
+		if c.VA.DNSQueryLog.File == "" {
+			queryLog = bdns.NewSinkQueryLog(logger)
+		} else {
+			maxSizeMB := c.VA.DNSQueryLog.MaxSizeMB
+			if maxSizeMB == 0 {
+				maxSizeMB = 100
+			}
+			maxBackups := c.VA.DNSQueryLog.MaxBackups
+			if maxBackups == 0 {
+				maxBackups = 5
+			}
+			fileQueryLog, err = bdns.NewFileQueryLog(c.VA.DNSQueryLog.File, int64(maxSizeMB)<<20, maxBackups, logger)
+			cmd.FailOnError(err, "Unable to open DNS query log")
+			queryLog = fileQueryLog
+		}
+	}
+
 	var resolver bdns.Client
 	if !(c.VA.DNSAllowLoopbackAddresses || c.Common.DNSAllowLoopbackAddresses) {
 		resolver = bdns.New(
@@ -106,7 +314,8 @@ func main() {
 			scope,
 			clk,
 			dnsTries,
-			logger)
+			logger,
+			queryLog)
 	} else {
 		resolver = bdns.NewTest(
 			dnsTimeout,
@@ -114,7 +323,19 @@ func main() {
 			scope,
 			clk,
 			dnsTries,
-			logger)
+			logger,
+			queryLog)
+	}
+
+	if c.VA.CAACache != nil && !c.VA.CAACache.Disabled {
+		resolver, err = va.NewCAACache(
+			resolver,
+			c.VA.CAACache.Size,
+			c.VA.CAACache.MaxAge.Duration,
+			c.VA.CAACache.EmptyTTL.Duration,
+			clk,
+			scope)
+		cmd.FailOnError(err, "Unable to create CAA cache")
 	}
 
 	tlsConfig, err := c.VA.TLS.Load()
@@ -124,28 +345,51 @@ func main() {
 	if len(c.VA.RemoteVAs) > 0 {
 		for _, rva := range c.VA.RemoteVAs {
 			rva := rva
-			vaConn, err := bgrpc.ClientSetup(&rva, tlsConfig, scope, clk)
+			vaConn, err := bgrpc.ClientSetup(&rva.GRPCClientConfig, tlsConfig, scope, clk)
 			cmd.FailOnError(err, "Unable to create remote VA client")
 			remotes = append(
 				remotes,
 				va.RemoteVA{
-					VAClient: vapb.NewVAClient(vaConn),
-					Address:  rva.ServerAddress,
+					VAClient:    vapb.NewVAClient(vaConn),
+					Address:     rva.ServerAddress,
+					Perspective: rva.Perspective,
+					Region:      rva.Region,
+					RIR:         rva.RIR,
+					ASN:         rva.ASN,
 				},
 			)
 		}
 	}
 
+	for _, rva := range c.VA.RemoteHTTPVAs {
+		pub, err := loadPublicKey(rva.PublicKeyFile)
+		cmd.FailOnError(err, "Unable to load remote perspective public key")
+		client, err := newHTTPPerspectiveClient(rva, tlsConfig.Certificates)
+		cmd.FailOnError(err, "Unable to create remote perspective HTTP client")
+		maxResultAge := rva.MaxResultAge.Duration
+		if maxResultAge == 0 {
+			maxResultAge = time.Minute
+		}
+		remote, err := va.NewHTTPRemoteVA(rva.URL, rva.Perspective, pub, client, maxResultAge, clk)
+		cmd.FailOnError(err, "Unable to create remote perspective client")
+		remote.Region = rva.Region
+		remote.RIR = rva.RIR
+		remote.ASN = rva.ASN
+		remotes = append(remotes, remote)
+	}
+
 	vai, err := va.NewValidationAuthorityImpl(
 		resolver,
 		remotes,
 		c.VA.MaxRemoteValidationFailures,
+		c.VA.MinRemoteRegions,
 		c.VA.UserAgent,
 		c.VA.IssuerDomain,
 		scope,
 		clk,
 		logger,
-		c.VA.AccountURIPrefixes)
+		c.VA.AccountURIPrefixes,
+		c.VA.RestrictHTTP01Redirects)
 	cmd.FailOnError(err, "Unable to create VA server")
 
 	start, stop, err := bgrpc.NewServer(c.VA.GRPC).Add(
@@ -153,9 +397,41 @@ func main() {
 		&vapb.CAA_ServiceDesc, vai).Build(tlsConfig, scope, clk)
 	cmd.FailOnError(err, "Unable to setup VA gRPC server")
 
+	var perspectiveSrv *http.Server
+	if ps := c.VA.PerspectiveServer; ps != nil {
+		key, _, err := privatekey.Load(ps.SigningKeyFile)
+		cmd.FailOnError(err, "Unable to load perspective signing key")
+		handler, err := va.NewPerspectiveHandler(vai, ps.Perspective, key, clk, logger)
+		cmd.FailOnError(err, "Unable to create perspective handler")
+		perspectiveTLSConfig, err := newPerspectiveServerTLSConfig(ps)
+		cmd.FailOnError(err, "Unable to load perspective server TLS config")
+		perspectiveSrv = &http.Server{
+			Addr:         ps.ListenAddress,
+			Handler:      handler,
+			ReadTimeout:  30 * time.Second,
+			WriteTimeout: 120 * time.Second,
+			IdleTimeout:  120 * time.Second,
+			TLSConfig:    perspectiveTLSConfig,
+		}
+		go func() {
+			err := perspectiveSrv.ListenAndServeTLS("", "")
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				cmd.FailOnError(err, "Running perspective HTTPS server")
+			}
+		}()
+	}
+
 	go cmd.CatchSignals(logger, func() {
 		servers.Stop()
 		stop()
+		if perspectiveSrv != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			_ = perspectiveSrv.Shutdown(ctx)
+		}
+		if fileQueryLog != nil {
+			_ = fileQueryLog.Close()
+		}
 	})
 
 	cmd.FailOnError(start(), "VA gRPC service failed")
@@ -1 +1,133 @@
 package notmain
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/test"
+)
+
+// testPKI issues certificates for the named hosts from a fresh CA, writing
+// them to a temporary directory.
+type testPKI struct {
+	t      *testing.T
+	dir    string
+	caCert *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	caFile string
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating CA key")
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	test.AssertNotError(t, err, "creating CA certificate")
+	cert, err := x509.ParseCertificate(der)
+	test.AssertNotError(t, err, "parsing CA certificate")
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	err = os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	test.AssertNotError(t, err, "writing CA certificate")
+	return &testPKI{t, dir, cert, key, caFile}
+}
+
+// tlsConfig issues a certificate for name and returns a cmd.TLSConfig using it.
+func (p *testPKI) tlsConfig(name string) cmd.TLSConfig {
+	p.t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(p.t, err, "generating key")
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}, p.caCert, key.Public(), p.caKey)
+	test.AssertNotError(p.t, err, "creating certificate")
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	test.AssertNotError(p.t, err, "marshalling key")
+
+	certFile := filepath.Join(p.dir, name+".pem")
+	keyFile := filepath.Join(p.dir, name+".key")
+	err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	test.AssertNotError(p.t, err, "writing certificate")
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	test.AssertNotError(p.t, err, "writing key")
+	return cmd.TLSConfig{
+		CACertFile: &p.caFile,
+		CertFile:   &certFile,
+		KeyFile:    &keyFile,
+	}
+}
+
+func TestPerspectiveServerRequiresClientCert(t *testing.T) {
+	pki := newTestPKI(t)
+
+	_, err := newPerspectiveServerTLSConfig(&PerspectiveServerConfig{TLS: pki.tlsConfig("rva.boulder")})
+	test.AssertError(t, err, "created perspective server TLS config without client names")
+
+	serverTLS, err := newPerspectiveServerTLSConfig(&PerspectiveServerConfig{
+		TLS:         pki.tlsConfig("rva.boulder"),
+		ClientNames: []string{"va.boulder"},
+	})
+	test.AssertNotError(t, err, "creating perspective server TLS config")
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	srv.TLS = serverTLS
+	srv.StartTLS()
+	defer srv.Close()
+
+	post := func(clientName string) error {
+		var certs []tls.Certificate
+		if clientName != "" {
+			clientConfig := pki.tlsConfig(clientName)
+			clientTLS, err := clientConfig.Load()
+			test.AssertNotError(t, err, "loading client TLS config")
+			certs = clientTLS.Certificates
+		}
+		client, err := newHTTPPerspectiveClient(HTTPPerspectiveConfig{CACertFile: pki.caFile}, certs)
+		test.AssertNotError(t, err, "creating perspective client")
+		// The server certificate is for rva.boulder, not the test server's
+		// address.
+		client.Transport.(*http.Transport).TLSClientConfig.ServerName = "rva.boulder"
+		resp, err := client.Post(srv.URL, "application/json", nil)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		return nil
+	}
+
+	test.AssertNotError(t, post("va.boulder"), "accepted client was refused")
+	test.AssertError(t, post("ra.boulder"), "client with an unlisted name was accepted")
+	test.AssertError(t, post(""), "client without a certificate was accepted")
+}
@@ -14,6 +14,7 @@ import (
 	"strings"
 	"time"
 
+	ocsplints "github.com/letsencrypt/boulder/linter/lints/ocsp"
 	"github.com/letsencrypt/boulder/policyasn1"
 )
 
@@ -185,8 +186,6 @@ var stringToKeyUsage = map[string]x509.KeyUsage{
 
 var (
 	oidExtensionCertificatePolicies = asn1.ObjectIdentifier{2, 5, 29, 32}
-
-	oidOCSPNoCheck = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 5}
 )
 
 func buildPolicies(policies []policyInfoConfig) (pkix.Extension, error) {
@@ -301,7 +300,7 @@ func makeTemplate(randReader io.Reader, profile *certProfile, pubKey []byte, ct
 	case ocspCert:
 		cert.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning}
 		// ASN.1 NULL is 0x05, 0x00
-		ocspNoCheckExt := pkix.Extension{Id: oidOCSPNoCheck, Value: []byte{5, 0}}
+		ocspNoCheckExt := pkix.Extension{Id: ocsplints.OIDOCSPNoCheck, Value: []byte{5, 0}}
 		cert.ExtraExtensions = append(cert.ExtraExtensions, ocspNoCheckExt)
 		cert.IsCA = false
 	case crlCert:
@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"testing"
 
+	ocsplints "github.com/letsencrypt/boulder/linter/lints/ocsp"
 	"github.com/letsencrypt/boulder/pkcs11helpers"
 	"github.com/letsencrypt/boulder/test"
 	"github.com/miekg/pkcs11"
@@ -186,7 +187,7 @@ func TestMakeTemplateOCSP(t *testing.T) {
 	hasExt := false
 	asnNULL := []byte{5, 0}
 	for _, ext := range cert.ExtraExtensions {
-		if ext.Id.Equal(oidOCSPNoCheck) {
+		if ext.Id.Equal(ocsplints.OIDOCSPNoCheck) {
 			if hasExt {
 				t.Error("template contains multiple id-pkix-ocsp-nocheck extensions")
 			}
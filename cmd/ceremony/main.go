@@ -6,14 +6,19 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/asn1"
+	"encoding/base64"
 	"encoding/pem"
 	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/jmhodges/clock"
+
 	"github.com/letsencrypt/boulder/cmd"
 	"github.com/letsencrypt/boulder/linter"
 	"github.com/letsencrypt/boulder/pkcs11helpers"
@@ -406,7 +411,7 @@ func equalPubKeys(a, b interface{}) bool {
 	return bytes.Equal(aBytes, bBytes)
 }
 
-func openSigner(cfg PKCS11SigningConfig, pubKey crypto.PublicKey) (crypto.Signer, *hsmRandReader, error) {
+func openSigner(cfg PKCS11SigningConfig, pubKey crypto.PublicKey, tr *ceremonyTranscript) (crypto.Signer, *hsmRandReader, error) {
 	session, err := pkcs11helpers.Initialize(cfg.Module, cfg.SigningSlot, cfg.PIN)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to setup session and PKCS#11 context for slot %d: %s",
@@ -421,13 +426,19 @@ func openSigner(cfg PKCS11SigningConfig, pubKey crypto.PublicKey) (crypto.Signer
 		return nil, nil, fmt.Errorf("signer pubkey did not match issuer pubkey")
 	}
 	log.Println("Retrieved private key handle")
+	err = tr.addSigningKey(session, cfg.SigningLabel, pubKey)
+	if err != nil {
+		return nil, nil, err
+	}
 	return signer, newRandReader(session), nil
 }
 
-func signAndWriteCert(tbs, issuer *x509.Certificate, subjectPubKey crypto.PublicKey, signer crypto.Signer, certPath string, skipLints []string) error {
+// signAndWriteCert lints, signs and verifies the certificate, and writes it to
+// certPath as PEM, returning its DER encoding.
+func signAndWriteCert(tbs, issuer *x509.Certificate, subjectPubKey crypto.PublicKey, signer crypto.Signer, certPath string, skipLints []string) ([]byte, []byte, error) {
 	err := linter.Check(tbs, subjectPubKey, issuer, signer, skipLints)
 	if err != nil {
-		return fmt.Errorf("certificate failed pre-issuance lint: %w", err)
+		return nil, nil, fmt.Errorf("certificate failed pre-issuance lint: %w", err)
 	}
 	// x509.CreateCertificate uses a io.Reader here for signing methods that require
 	// a source of randomness. Since PKCS#11 based signing generates needed randomness
@@ -436,13 +447,13 @@ func signAndWriteCert(tbs, issuer *x509.Certificate, subjectPubKey crypto.Public
 	// changes.
 	certBytes, err := x509.CreateCertificate(&failReader{}, tbs, issuer, subjectPubKey, signer)
 	if err != nil {
-		return fmt.Errorf("failed to create certificate: %s", err)
+		return nil, nil, fmt.Errorf("failed to create certificate: %s", err)
 	}
 	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certBytes})
 	log.Printf("Signed certificate PEM:\n%s", pemBytes)
 	cert, err := x509.ParseCertificate(certBytes)
 	if err != nil {
-		return fmt.Errorf("failed to parse signed certificate: %s", err)
+		return nil, nil, fmt.Errorf("failed to parse signed certificate: %s", err)
 	}
 	if tbs == issuer {
 		// If cert is self-signed we need to populate the issuer subject key to
@@ -453,17 +464,17 @@ func signAndWriteCert(tbs, issuer *x509.Certificate, subjectPubKey crypto.Public
 
 	err = cert.CheckSignatureFrom(issuer)
 	if err != nil {
-		return fmt.Errorf("failed to verify certificate signature: %s", err)
+		return nil, nil, fmt.Errorf("failed to verify certificate signature: %s", err)
 	}
 	err = writeFile(certPath, pemBytes)
 	if err != nil {
-		return fmt.Errorf("failed to write certificate to %q: %s", certPath, err)
+		return nil, nil, fmt.Errorf("failed to write certificate to %q: %s", certPath, err)
 	}
 	log.Printf("Certificate written to %q\n", certPath)
-	return nil
+	return certBytes, pemBytes, nil
 }
 
-func rootCeremony(configBytes []byte) error {
+func rootCeremony(configBytes []byte, tr *ceremonyTranscript) error {
 	var config rootConfig
 	err := strictyaml.Unmarshal(configBytes, &config)
 	if err != nil {
@@ -478,10 +489,15 @@ func rootCeremony(configBytes []byte) error {
 		return fmt.Errorf("failed to setup session and PKCS#11 context for slot %d: %s", config.PKCS11.StoreSlot, err)
 	}
 	log.Printf("Opened PKCS#11 session for slot %d\n", config.PKCS11.StoreSlot)
+	err = tr.addToken(session)
+	if err != nil {
+		return err
+	}
 	keyInfo, err := generateKey(session, config.PKCS11.StoreLabel, config.Outputs.PublicKeyPath, config.Key)
 	if err != nil {
 		return err
 	}
+	tr.addGeneratedKey(config.PKCS11.StoreLabel, config.Outputs.PublicKeyPath, keyInfo)
 	signer, err := session.NewSigner(config.PKCS11.StoreLabel, keyInfo.key)
 	if err != nil {
 		return fmt.Errorf("failed to retrieve signer: %s", err)
@@ -491,15 +507,16 @@ func rootCeremony(configBytes []byte) error {
 		return fmt.Errorf("failed to create certificate profile: %s", err)
 	}
 
-	err = signAndWriteCert(template, template, keyInfo.key, signer, config.Outputs.CertificatePath, config.SkipLints)
+	certDER, certPEM, err := signAndWriteCert(template, template, keyInfo.key, signer, config.Outputs.CertificatePath, config.SkipLints)
 	if err != nil {
 		return err
 	}
+	tr.addOutput("certificate", config.Outputs.CertificatePath, certDER, certPEM)
 
 	return nil
 }
 
-func intermediateCeremony(configBytes []byte, ct certType) error {
+func intermediateCeremony(configBytes []byte, ct certType, tr *ceremonyTranscript) error {
 	var config intermediateConfig
 	err := strictyaml.Unmarshal(configBytes, &config)
 	if err != nil {
@@ -522,31 +539,41 @@ func intermediateCeremony(configBytes []byte, ct certType) error {
 	if err != nil {
 		return fmt.Errorf("failed to parse public key: %s", err)
 	}
-	issuer, err := loadCert(config.Inputs.IssuerCertificatePath)
+
+	_, err = issueCertificate(config.PKCS11, config.Inputs.IssuerCertificatePath, &config.CertProfile, ct, pubPEM.Bytes, pub, config.Outputs.CertificatePath, config.SkipLints, tr)
+	return err
+}
+
+// issueCertificate signs a certificate of the given type for the public key,
+// using the key of the issuer certificate at issuerPath, and writes it to
+// certPath.
+func issueCertificate(pkcs11Config PKCS11SigningConfig, issuerPath string, profile *certProfile, ct certType, pubDER []byte, pub crypto.PublicKey, certPath string, skipLints []string, tr *ceremonyTranscript) (*x509.Certificate, error) {
+	issuer, err := loadCert(issuerPath)
 	if err != nil {
-		return fmt.Errorf("failed to load issuer certificate %q: %s", config.Inputs.IssuerCertificatePath, err)
+		return nil, fmt.Errorf("failed to load issuer certificate %q: %s", issuerPath, err)
 	}
 
-	signer, randReader, err := openSigner(config.PKCS11, issuer.PublicKey)
+	signer, randReader, err := openSigner(pkcs11Config, issuer.PublicKey, tr)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	template, err := makeTemplate(randReader, &config.CertProfile, pubPEM.Bytes, ct)
+	template, err := makeTemplate(randReader, profile, pubDER, ct)
 	if err != nil {
-		return fmt.Errorf("failed to create certificate profile: %s", err)
+		return nil, fmt.Errorf("failed to create certificate profile: %s", err)
 	}
 	template.AuthorityKeyId = issuer.SubjectKeyId
 
-	err = signAndWriteCert(template, issuer, pub, signer, config.Outputs.CertificatePath, config.SkipLints)
+	certDER, certPEM, err := signAndWriteCert(template, issuer, pub, signer, certPath, skipLints)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	tr.addOutput("certificate", certPath, certDER, certPEM)
 
-	return nil
+	return x509.ParseCertificate(certDER)
 }
 
-func csrCeremony(configBytes []byte) error {
+func csrCeremony(configBytes []byte, tr *ceremonyTranscript) error {
 	var config csrConfig
 	err := strictyaml.Unmarshal(configBytes, &config)
 	if err != nil {
@@ -570,7 +597,7 @@ func csrCeremony(configBytes []byte) error {
 		return fmt.Errorf("failed to parse public key: %s", err)
 	}
 
-	signer, _, err := openSigner(config.PKCS11, pub)
+	signer, _, err := openSigner(config.PKCS11, pub, tr)
 	if err != nil {
 		return err
 	}
@@ -585,11 +612,12 @@ func csrCeremony(configBytes []byte) error {
 		return fmt.Errorf("failed to write CSR to %q: %s", config.Outputs.CSRPath, err)
 	}
 	log.Printf("CSR written to %q\n", config.Outputs.CSRPath)
+	tr.addOutput("csr", config.Outputs.CSRPath, csrDER, csrPEM)
 
 	return nil
 }
 
-func keyCeremony(configBytes []byte) error {
+func keyCeremony(configBytes []byte, tr *ceremonyTranscript) error {
 	var config keyConfig
 	err := strictyaml.Unmarshal(configBytes, &config)
 	if err != nil {
@@ -604,16 +632,18 @@ func keyCeremony(configBytes []byte) error {
 		return fmt.Errorf("failed to setup session and PKCS#11 context for slot %d: %s", config.PKCS11.StoreSlot, err)
 	}
 	log.Printf("Opened PKCS#11 session for slot %d\n", config.PKCS11.StoreSlot)
-	if _, err = generateKey(session, config.PKCS11.StoreLabel, config.Outputs.PublicKeyPath, config.Key); err != nil {
+	err = tr.addToken(session)
+	if err != nil {
+		return err
+	}
+	keyInfo, err := generateKey(session, config.PKCS11.StoreLabel, config.Outputs.PublicKeyPath, config.Key)
+	if err != nil {
 		return err
 	}
+	tr.addGeneratedKey(config.PKCS11.StoreLabel, config.Outputs.PublicKeyPath, keyInfo)
 
 	if config.Outputs.PKCS11ConfigPath != "" {
-		contents := fmt.Sprintf(
-			`{"module": %q, "tokenLabel": %q, "pin": %q}`,
-			config.PKCS11.Module, config.PKCS11.StoreLabel, config.PKCS11.PIN,
-		)
-		err = writeFile(config.Outputs.PKCS11ConfigPath, []byte(contents))
+		err = writePKCS11Config(config.PKCS11, config.Outputs.PKCS11ConfigPath, tr)
 		if err != nil {
 			return err
 		}
@@ -622,7 +652,22 @@ func keyCeremony(configBytes []byte) error {
 	return nil
 }
 
-func ocspRespCeremony(configBytes []byte) error {
+// writePKCS11Config writes a pkcs11key config file, as used by the Boulder CA
+// to load a key, for the key pair generated with the given config.
+func writePKCS11Config(cfg PKCS11KeyGenConfig, path string, tr *ceremonyTranscript) error {
+	contents := []byte(fmt.Sprintf(
+		`{"module": %q, "tokenLabel": %q, "pin": %q}`,
+		cfg.Module, cfg.StoreLabel, cfg.PIN,
+	))
+	err := writeFile(path, contents)
+	if err != nil {
+		return err
+	}
+	tr.addOutput("pkcs11-config", path, nil, contents)
+	return nil
+}
+
+func ocspRespCeremony(configBytes []byte, tr *ceremonyTranscript) error {
 	var config ocspRespConfig
 	err := strictyaml.Unmarshal(configBytes, &config)
 	if err != nil {
@@ -649,12 +694,12 @@ func ocspRespCeremony(configBytes []byte) error {
 			return fmt.Errorf("failed to load delegated issuer certificate %q: %s", config.Inputs.DelegatedIssuerCertificatePath, err)
 		}
 
-		signer, _, err = openSigner(config.PKCS11, delegatedIssuer.PublicKey)
+		signer, _, err = openSigner(config.PKCS11, delegatedIssuer.PublicKey, tr)
 		if err != nil {
 			return err
 		}
 	} else {
-		signer, _, err = openSigner(config.PKCS11, issuer.PublicKey)
+		signer, _, err = openSigner(config.PKCS11, issuer.PublicKey, tr)
 		if err != nil {
 			return err
 		}
@@ -688,11 +733,16 @@ func ocspRespCeremony(configBytes []byte) error {
 	if err != nil {
 		return fmt.Errorf("failed to write OCSP response to %q: %s", config.Outputs.ResponsePath, err)
 	}
+	respDER, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(resp)))
+	if err != nil {
+		return fmt.Errorf("failed to decode OCSP response: %s", err)
+	}
+	tr.addOutput("ocsp-response", config.Outputs.ResponsePath, respDER, resp)
 
 	return nil
 }
 
-func crlCeremony(configBytes []byte) error {
+func crlCeremony(configBytes []byte, tr *ceremonyTranscript) error {
 	var config crlConfig
 	err := strictyaml.Unmarshal(configBytes, &config)
 	if err != nil {
@@ -707,7 +757,7 @@ func crlCeremony(configBytes []byte) error {
 	if err != nil {
 		return fmt.Errorf("failed to load issuer certificate %q: %s", config.Inputs.IssuerCertificatePath, err)
 	}
-	signer, _, err := openSigner(config.PKCS11, issuer.PublicKey)
+	signer, _, err := openSigner(config.PKCS11, issuer.PublicKey, tr)
 	if err != nil {
 		return err
 	}
@@ -757,14 +807,49 @@ func crlCeremony(configBytes []byte) error {
 	if err != nil {
 		return fmt.Errorf("failed to write CRL to %q: %s", config.Outputs.CRLPath, err)
 	}
+	crlPEM, _ := pem.Decode(crlBytes)
+	tr.addOutput("crl", config.Outputs.CRLPath, crlPEM.Bytes, crlBytes)
 
 	return nil
 }
 
+// ceremonies maps each ceremony-type to the function which performs it.
+var ceremonies = map[string]func(configBytes []byte, tr *ceremonyTranscript) error{
+	"root": rootCeremony,
+	"cross-certificate": func(configBytes []byte, tr *ceremonyTranscript) error {
+		return intermediateCeremony(configBytes, crossCert, tr)
+	},
+	"intermediate": func(configBytes []byte, tr *ceremonyTranscript) error {
+		return intermediateCeremony(configBytes, intermediateCert, tr)
+	},
+	"cross-csr": csrCeremony,
+	"ocsp-signer": func(configBytes []byte, tr *ceremonyTranscript) error {
+		return intermediateCeremony(configBytes, ocspCert, tr)
+	},
+	"key":           keyCeremony,
+	"ocsp-response": ocspRespCeremony,
+	"crl":           crlCeremony,
+	"crl-signer": func(configBytes []byte, tr *ceremonyTranscript) error {
+		return intermediateCeremony(configBytes, crlCert, tr)
+	},
+	"verify":   verifyCeremony,
+	"rollover": rolloverCeremony,
+}
+
 func main() {
 	configPath := flag.String("config", "", "Path to ceremony configuration file")
+	transcriptPath := flag.String("transcript", "", "Path to write a machine-readable transcript of the ceremony to (default: the config path with its extension replaced by the start time and .transcript.json)")
+	witnessKeyPath := flag.String("witness-key", "", "Path to PEM private key used to sign the transcript (default: the transcript is unsigned)")
 	flag.Parse()
 
+	if flag.Arg(0) == "verify-transcript" {
+		err := verifyTranscriptCommand(flag.Args()[1:])
+		if err != nil {
+			log.Fatalf("transcript verification failed: %s", err)
+		}
+		return
+	}
+
 	if *configPath == "" {
 		log.Fatal("--config is required")
 	}
@@ -777,63 +862,63 @@ func main() {
 	}
 
 	// We are intentionally using non-strict unmarshaling to read the top level
-	// tags to populate the "ct" struct for use in the switch statement below.
-	// Further strict processing of each yaml node is done on a case by case basis
-	// inside the switch statement.
+	// tags to populate the "ct" struct for use in the lookup below. Further
+	// strict processing of each yaml node is done by each ceremony.
 	err = yaml.Unmarshal(configBytes, &ct)
 	if err != nil {
 		log.Fatalf("Failed to parse config: %s", err)
 	}
 
-	switch ct.CeremonyType {
-	case "root":
-		err = rootCeremony(configBytes)
-		if err != nil {
-			log.Fatalf("root ceremony failed: %s", err)
-		}
-	case "cross-certificate":
-		err = intermediateCeremony(configBytes, crossCert)
-		if err != nil {
-			log.Fatalf("cross-certificate ceremony failed: %s", err)
-		}
-	case "intermediate":
-		err = intermediateCeremony(configBytes, intermediateCert)
-		if err != nil {
-			log.Fatalf("intermediate ceremony failed: %s", err)
-		}
-	case "cross-csr":
-		err = csrCeremony(configBytes)
-		if err != nil {
-			log.Fatalf("cross-csr ceremony failed: %s", err)
-		}
-	case "ocsp-signer":
-		err = intermediateCeremony(configBytes, ocspCert)
-		if err != nil {
-			log.Fatalf("ocsp signer ceremony failed: %s", err)
-		}
-	case "key":
-		err = keyCeremony(configBytes)
-		if err != nil {
-			log.Fatalf("key ceremony failed: %s", err)
-		}
-	case "ocsp-response":
-		err = ocspRespCeremony(configBytes)
-		if err != nil {
-			log.Fatalf("ocsp response ceremony failed: %s", err)
-		}
-	case "crl":
-		err = crlCeremony(configBytes)
-		if err != nil {
-			log.Fatalf("crl ceremony failed: %s", err)
-		}
-	case "crl-signer":
-		err = intermediateCeremony(configBytes, crlCert)
+	ceremony, ok := ceremonies[ct.CeremonyType]
+	if !ok {
+		log.Fatalf("unknown ceremony-type, must be one of: root, intermediate, cross-certificate, cross-csr, ocsp-signer, crl-signer, key, ocsp-response, crl, verify, rollover")
+	}
+
+	clk := clock.New()
+	if *transcriptPath == "" {
+		*transcriptPath = defaultTranscriptPath(*configPath, clk.Now())
+	}
+	if _, err := os.Stat(*transcriptPath); !os.IsNotExist(err) {
+		log.Fatalf("transcript path is %q, which already exists", *transcriptPath)
+	}
+	var witness crypto.Signer
+	if *witnessKeyPath != "" {
+		witness, err = loadWitnessKey(*witnessKeyPath)
 		if err != nil {
-			log.Fatalf("crl signer ceremony failed: %s", err)
+			log.Fatalf("Failed to load witness key: %s", err)
 		}
+	}
+
+	tr := newCeremonyTranscript(ct.CeremonyType, configBytes, clk)
+	err = ceremony(configBytes, tr)
+	tr.finish(err)
+	encoded, writeErr := encodeTranscript(tr, witness)
+	if writeErr == nil {
+		writeErr = writeFile(*transcriptPath, encoded)
+	}
+	switch {
+	case writeErr != nil && err == nil:
+		log.Fatalf("Failed to write transcript to %q: %s", *transcriptPath, writeErr)
+	case writeErr != nil:
+		// Don't mask the ceremony error, which is reported below.
+		log.Printf("Failed to write transcript to %q: %s", *transcriptPath, writeErr)
+	case witness != nil:
+		log.Printf("Signed transcript written to %q\n", *transcriptPath)
 	default:
-		log.Fatalf("unknown ceremony-type, must be one of: root, intermediate, ocsp-signer, crl-signer, key, ocsp-response")
+		log.Printf("Unsigned transcript written to %q\n", *transcriptPath)
 	}
+	if err != nil {
+		log.Fatalf("%s ceremony failed: %s", ct.CeremonyType, err)
+	}
+}
+
+// defaultTranscriptPath returns the path a ceremony's transcript is written to
+// if none is given: the config path with its extension replaced by the time the
+// ceremony started, so that re-running a failed ceremony doesn't collide with
+// the transcript of the earlier attempt.
+func defaultTranscriptPath(configPath string, now time.Time) string {
+	return fmt.Sprintf("%s.%s.transcript.json",
+		strings.TrimSuffix(configPath, filepath.Ext(configPath)), now.UTC().Format("20060102T150405Z"))
 }
 
 func init() {
@@ -0,0 +1,276 @@
+package notmain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/letsencrypt/boulder/pkcs11helpers"
+	"github.com/letsencrypt/boulder/strictyaml"
+)
+
+// rolloverSigningConfig describes one of the certificates issued for the new
+// key during a rollover ceremony.
+type rolloverSigningConfig struct {
+	PKCS11                PKCS11SigningConfig `yaml:"pkcs11"`
+	IssuerCertificatePath string              `yaml:"issuer-certificate-path"`
+	CertProfile           certProfile         `yaml:"certificate-profile"`
+}
+
+func (rsc rolloverSigningConfig) validate(field string) error {
+	err := rsc.PKCS11.validate()
+	if err != nil {
+		return fmt.Errorf("%s.%s", field, err)
+	}
+	if rsc.IssuerCertificatePath == "" {
+		return fmt.Errorf("%s.issuer-certificate-path is required", field)
+	}
+	err = rsc.CertProfile.verifyProfile(intermediateCert)
+	if err != nil {
+		return fmt.Errorf("%s.%s", field, err)
+	}
+	return nil
+}
+
+type rolloverConfig struct {
+	CeremonyType string                `yaml:"ceremony-type"`
+	PKCS11       PKCS11KeyGenConfig    `yaml:"pkcs11"`
+	Key          keyGenConfig          `yaml:"key"`
+	Intermediate rolloverSigningConfig `yaml:"intermediate"`
+	CrossSign    rolloverSigningConfig `yaml:"cross-sign"`
+	Outputs      struct {
+		PublicKeyPath            string `yaml:"public-key-path"`
+		PKCS11ConfigPath         string `yaml:"pkcs11-config-path"`
+		CertificatePath          string `yaml:"certificate-path"`
+		CrossCertificatePath     string `yaml:"cross-certificate-path"`
+		BoulderConfigSnippetPath string `yaml:"boulder-config-snippet-path"`
+	} `yaml:"outputs"`
+	Boulder struct {
+		IssuerURL         string `yaml:"issuer-url"`
+		OCSPURL           string `yaml:"ocsp-url"`
+		CRLURL            string `yaml:"crl-url"`
+		UseForRSALeaves   bool   `yaml:"use-for-rsa-leaves"`
+		UseForECDSALeaves bool   `yaml:"use-for-ecdsa-leaves"`
+		State             string `yaml:"state"`
+		Weight            int    `yaml:"weight"`
+		NumSessions       int    `yaml:"num-sessions"`
+		DeployDirectory   string `yaml:"deploy-directory"`
+	} `yaml:"boulder"`
+	SkipLints []string `yaml:"skip-lints"`
+}
+
+// crossSign returns true if the config requests a cross-signed certificate
+// for the new key.
+func (rc rolloverConfig) crossSign() bool {
+	return rc.CrossSign.IssuerCertificatePath != ""
+}
+
+func (rc rolloverConfig) validate() error {
+	err := rc.PKCS11.validate()
+	if err != nil {
+		return err
+	}
+	err = rc.Key.validate()
+	if err != nil {
+		return err
+	}
+
+	err = rc.Intermediate.validate("intermediate")
+	if err != nil {
+		return err
+	}
+	if rc.crossSign() {
+		err = rc.CrossSign.validate("cross-sign")
+		if err != nil {
+			return err
+		}
+		ip, cp := rc.Intermediate.CertProfile, rc.CrossSign.CertProfile
+		if ip.CommonName != cp.CommonName || ip.Organization != cp.Organization || ip.Country != cp.Country {
+			return errors.New("cross-sign.certificate-profile subject must match intermediate.certificate-profile subject")
+		}
+	}
+
+	// Output fields
+	err = checkOutputFile(rc.Outputs.PublicKeyPath, "public-key-path")
+	if err != nil {
+		return err
+	}
+	err = checkOutputFile(rc.Outputs.PKCS11ConfigPath, "pkcs11-config-path")
+	if err != nil {
+		return err
+	}
+	err = checkOutputFile(rc.Outputs.CertificatePath, "certificate-path")
+	if err != nil {
+		return err
+	}
+	if rc.crossSign() {
+		err = checkOutputFile(rc.Outputs.CrossCertificatePath, "cross-certificate-path")
+		if err != nil {
+			return err
+		}
+	} else if rc.Outputs.CrossCertificatePath != "" {
+		return errors.New("outputs.cross-certificate-path is only used with cross-sign")
+	}
+	err = checkOutputFile(rc.Outputs.BoulderConfigSnippetPath, "boulder-config-snippet-path")
+	if err != nil {
+		return err
+	}
+
+	// Boulder config fields
+	if rc.Boulder.IssuerURL == "" {
+		return errors.New("boulder.issuer-url is required")
+	}
+	if rc.Boulder.OCSPURL == "" {
+		return errors.New("boulder.ocsp-url is required")
+	}
+	switch rc.Boulder.State {
+	case "active", "retiring":
+	case "":
+		return errors.New("boulder.state is required")
+	default:
+		return fmt.Errorf("boulder.state must be active or retiring, not %q", rc.Boulder.State)
+	}
+	if rc.Boulder.Weight < 0 {
+		return errors.New("boulder.weight must not be negative")
+	}
+	if rc.Boulder.NumSessions < 0 {
+		return errors.New("boulder.num-sessions must not be negative")
+	}
+
+	return nil
+}
+
+// rolloverIssuerConfig is the JSON form of an issuance.IssuerConfig, as found
+// in the Boulder CA's config.
+type rolloverIssuerConfig struct {
+	UseForRSALeaves   bool   `json:"useForRSALeaves"`
+	UseForECDSALeaves bool   `json:"useForECDSALeaves"`
+	State             string `json:"state"`
+	Weight            int    `json:"weight"`
+	IssuerURL         string `json:"issuerURL"`
+	OCSPURL           string `json:"ocspURL"`
+	CRLURL            string `json:"crlURL,omitempty"`
+	Location          struct {
+		ConfigFile  string `json:"configFile"`
+		CertFile    string `json:"certFile"`
+		NumSessions int    `json:"numSessions,omitempty"`
+	} `json:"location"`
+}
+
+// rolloverConfigSnippet contains the parts of the Boulder CA, WFE and
+// crl-updater configs which need to be added for the new issuer, nested as
+// they are in those components' config files.
+type rolloverConfigSnippet struct {
+	CA struct {
+		Issuance struct {
+			Issuers []rolloverIssuerConfig `json:"issuers"`
+		} `json:"issuance"`
+	} `json:"ca"`
+	WFE struct {
+		Chains [][]string `json:"chains"`
+	} `json:"wfe"`
+	CRLUpdater struct {
+		IssuerCerts []string `json:"issuerCerts"`
+	} `json:"crlUpdater"`
+}
+
+// configSnippet returns the Boulder config snippet for the new issuer.
+func (rc rolloverConfig) configSnippet() rolloverConfigSnippet {
+	// Files are referred to by their path on the ceremony machine, unless
+	// they will be deployed to a different directory.
+	deployed := func(path string) string {
+		if rc.Boulder.DeployDirectory == "" {
+			return path
+		}
+		return filepath.Join(rc.Boulder.DeployDirectory, filepath.Base(path))
+	}
+
+	issuer := rolloverIssuerConfig{
+		UseForRSALeaves:   rc.Boulder.UseForRSALeaves,
+		UseForECDSALeaves: rc.Boulder.UseForECDSALeaves,
+		State:             rc.Boulder.State,
+		Weight:            rc.Boulder.Weight,
+		IssuerURL:         rc.Boulder.IssuerURL,
+		OCSPURL:           rc.Boulder.OCSPURL,
+		CRLURL:            rc.Boulder.CRLURL,
+	}
+	issuer.Location.ConfigFile = deployed(rc.Outputs.PKCS11ConfigPath)
+	issuer.Location.CertFile = deployed(rc.Outputs.CertificatePath)
+	issuer.Location.NumSessions = rc.Boulder.NumSessions
+
+	var snippet rolloverConfigSnippet
+	snippet.CA.Issuance.Issuers = []rolloverIssuerConfig{issuer}
+	snippet.WFE.Chains = [][]string{{
+		deployed(rc.Outputs.CertificatePath),
+		deployed(rc.Intermediate.IssuerCertificatePath),
+	}}
+	if rc.crossSign() {
+		snippet.WFE.Chains = append(snippet.WFE.Chains, []string{
+			deployed(rc.Outputs.CrossCertificatePath),
+			deployed(rc.CrossSign.IssuerCertificatePath),
+		})
+	}
+	// The cross-signed certificate has the same subject and key as the
+	// intermediate, so CRLs are only published for one of them.
+	snippet.CRLUpdater.IssuerCerts = []string{deployed(rc.Outputs.CertificatePath)}
+	return snippet
+}
+
+func rolloverCeremony(configBytes []byte, tr *ceremonyTranscript) error {
+	var config rolloverConfig
+	err := strictyaml.Unmarshal(configBytes, &config)
+	if err != nil {
+		return fmt.Errorf("failed to parse config: %s", err)
+	}
+	err = config.validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate config: %s", err)
+	}
+
+	session, err := pkcs11helpers.Initialize(config.PKCS11.Module, config.PKCS11.StoreSlot, config.PKCS11.PIN)
+	if err != nil {
+		return fmt.Errorf("failed to setup session and PKCS#11 context for slot %d: %s", config.PKCS11.StoreSlot, err)
+	}
+	log.Printf("Opened PKCS#11 session for slot %d\n", config.PKCS11.StoreSlot)
+	err = tr.addToken(session)
+	if err != nil {
+		return err
+	}
+	keyInfo, err := generateKey(session, config.PKCS11.StoreLabel, config.Outputs.PublicKeyPath, config.Key)
+	if err != nil {
+		return err
+	}
+	tr.addGeneratedKey(config.PKCS11.StoreLabel, config.Outputs.PublicKeyPath, keyInfo)
+	err = writePKCS11Config(config.PKCS11, config.Outputs.PKCS11ConfigPath, tr)
+	if err != nil {
+		return fmt.Errorf("failed to write PKCS#11 config to %q: %s", config.Outputs.PKCS11ConfigPath, err)
+	}
+
+	_, err = issueCertificate(config.Intermediate.PKCS11, config.Intermediate.IssuerCertificatePath, &config.Intermediate.CertProfile,
+		intermediateCert, keyInfo.der, keyInfo.key, config.Outputs.CertificatePath, config.SkipLints, tr)
+	if err != nil {
+		return fmt.Errorf("failed to issue intermediate: %w", err)
+	}
+	if config.crossSign() {
+		_, err = issueCertificate(config.CrossSign.PKCS11, config.CrossSign.IssuerCertificatePath, &config.CrossSign.CertProfile,
+			intermediateCert, keyInfo.der, keyInfo.key, config.Outputs.CrossCertificatePath, config.SkipLints, tr)
+		if err != nil {
+			return fmt.Errorf("failed to issue cross-signed intermediate: %w", err)
+		}
+	}
+
+	snippet, err := json.MarshalIndent(config.configSnippet(), "", "\t")
+	if err != nil {
+		return fmt.Errorf("failed to marshal Boulder config snippet: %s", err)
+	}
+	err = writeFile(config.Outputs.BoulderConfigSnippetPath, snippet)
+	if err != nil {
+		return fmt.Errorf("failed to write Boulder config snippet to %q: %s", config.Outputs.BoulderConfigSnippetPath, err)
+	}
+	log.Printf("Boulder config snippet written to %q\n", config.Outputs.BoulderConfigSnippetPath)
+	tr.addOutput("boulder-config-snippet", config.Outputs.BoulderConfigSnippetPath, nil, snippet)
+
+	return nil
+}
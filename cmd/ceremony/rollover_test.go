@@ -0,0 +1,213 @@
+package notmain
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/letsencrypt/validator/v10"
+
+	"github.com/letsencrypt/boulder/issuance"
+	"github.com/letsencrypt/boulder/strictyaml"
+	"github.com/letsencrypt/boulder/test"
+)
+
+const rolloverTestConfig = `
+ceremony-type: rollover
+pkcs11:
+    module: module
+    store-key-in-slot: 1
+    store-key-with-label: intermediate key
+key:
+    type: ecdsa
+    ecdsa-curve: P-384
+intermediate:
+    pkcs11:
+        module: module
+        signing-key-slot: 0
+        signing-key-label: root key
+    issuer-certificate-path: /ceremony/root-x2.pem
+    certificate-profile:
+        signature-algorithm: ECDSAWithSHA384
+        common-name: E9
+        organization: good guys
+        country: US
+        not-before: 2020-01-01 12:00:00
+        not-after: 2025-01-01 12:00:00
+        ocsp-url: http://x2.o.example.com
+        crl-url: http://x2.c.example.com
+        issuer-url: http://x2.i.example.com
+cross-sign:
+    pkcs11:
+        module: module
+        signing-key-slot: 2
+        signing-key-label: other root key
+    issuer-certificate-path: /ceremony/root-x1.pem
+    certificate-profile:
+        signature-algorithm: SHA256WithRSA
+        common-name: E9
+        organization: good guys
+        country: US
+        not-before: 2020-01-01 12:00:00
+        not-after: 2025-01-01 12:00:00
+        ocsp-url: http://x1.o.example.com
+        crl-url: http://x1.c.example.com
+        issuer-url: http://x1.i.example.com
+outputs:
+    public-key-path: /ceremony/e9.pubkey.pem
+    pkcs11-config-path: /ceremony/e9.pkcs11.json
+    certificate-path: /ceremony/e9.pem
+    cross-certificate-path: /ceremony/e9-cross.pem
+    boulder-config-snippet-path: /ceremony/e9.boulder.json
+boulder:
+    issuer-url: http://e9.i.example.com/
+    ocsp-url: http://e9.o.example.com/
+    crl-url: http://e9.c.example.com/
+    use-for-ecdsa-leaves: true
+    state: active
+    weight: 3
+    num-sessions: 2
+    deploy-directory: /hierarchy
+`
+
+func TestRolloverConfigValidate(t *testing.T) {
+	cases := []struct {
+		name          string
+		config        string
+		expectedError string
+	}{
+		{
+			name:   "good config",
+			config: rolloverTestConfig,
+		},
+		{
+			name:          "no pkcs11.store-key-with-label",
+			config:        strings.Replace(rolloverTestConfig, "store-key-with-label: intermediate key", "store-key-with-label: ''", 1),
+			expectedError: "pkcs11.store-key-with-label is required",
+		},
+		{
+			name:          "bad key",
+			config:        strings.Replace(rolloverTestConfig, "ecdsa-curve: P-384", "ecdsa-curve: P-123", 1),
+			expectedError: "key.ecdsa-curve can only be",
+		},
+		{
+			name:          "no intermediate signing key",
+			config:        strings.Replace(rolloverTestConfig, "signing-key-label: root key", "signing-key-label: ''", 1),
+			expectedError: "intermediate.pkcs11.signing-key-label is required",
+		},
+		{
+			name:          "no intermediate issuer",
+			config:        strings.Replace(rolloverTestConfig, "issuer-certificate-path: /ceremony/root-x2.pem", "issuer-certificate-path: ''", 1),
+			expectedError: "intermediate.issuer-certificate-path is required",
+		},
+		{
+			name:          "bad intermediate profile",
+			config:        strings.Replace(rolloverTestConfig, "common-name: E9", "common-name: ''", 1),
+			expectedError: "intermediate.common-name is required",
+		},
+		{
+			name:          "mismatched cross-sign subject",
+			config:        strings.Replace(rolloverTestConfig, "common-name: E9\n        organization: good guys\n        country: US\n        not-before: 2020-01-01 12:00:00\n        not-after: 2025-01-01 12:00:00\n        ocsp-url: http://x1", "common-name: E10\n        organization: good guys\n        country: US\n        not-before: 2020-01-01 12:00:00\n        not-after: 2025-01-01 12:00:00\n        ocsp-url: http://x1", 1),
+			expectedError: "cross-sign.certificate-profile subject must match intermediate.certificate-profile subject",
+		},
+		{
+			name:          "cross-certificate-path without cross-sign",
+			config:        strings.Replace(rolloverTestConfig, "issuer-certificate-path: /ceremony/root-x1.pem", "issuer-certificate-path: ''", 1),
+			expectedError: "outputs.cross-certificate-path is only used with cross-sign",
+		},
+		{
+			name:          "no boulder-config-snippet-path",
+			config:        strings.Replace(rolloverTestConfig, "boulder-config-snippet-path: /ceremony/e9.boulder.json", "boulder-config-snippet-path: ''", 1),
+			expectedError: "outputs.boulder-config-snippet-path is required",
+		},
+		{
+			name:          "no boulder.state",
+			config:        strings.Replace(rolloverTestConfig, "state: active", "state: ''", 1),
+			expectedError: "boulder.state is required",
+		},
+		{
+			name:          "archived boulder.state",
+			config:        strings.Replace(rolloverTestConfig, "state: active", "state: archived", 1),
+			expectedError: "boulder.state must be active or retiring",
+		},
+		{
+			name:          "negative boulder.weight",
+			config:        strings.Replace(rolloverTestConfig, "weight: 3", "weight: -1", 1),
+			expectedError: "boulder.weight must not be negative",
+		},
+		{
+			name:          "no boulder.ocsp-url",
+			config:        strings.Replace(rolloverTestConfig, "ocsp-url: http://e9.o.example.com/", "ocsp-url: ''", 1),
+			expectedError: "boulder.ocsp-url is required",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var config rolloverConfig
+			err := strictyaml.Unmarshal([]byte(tc.config), &config)
+			test.AssertNotError(t, err, "failed to parse config")
+			err = config.validate()
+			if tc.expectedError == "" {
+				test.AssertNotError(t, err, "config failed validation")
+			} else {
+				test.AssertError(t, err, "config passed validation")
+				test.AssertContains(t, err.Error(), tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestRolloverConfigSnippet(t *testing.T) {
+	var config rolloverConfig
+	err := strictyaml.Unmarshal([]byte(rolloverTestConfig), &config)
+	test.AssertNotError(t, err, "failed to parse config")
+
+	snippetJSON, err := json.Marshal(config.configSnippet())
+	test.AssertNotError(t, err, "failed to marshal snippet")
+
+	// The snippet must be loadable as the config of each component.
+	var snippet struct {
+		CA struct {
+			Issuance struct {
+				Issuers []issuance.IssuerConfig
+			}
+		}
+		WFE struct {
+			Chains [][]string
+		}
+		CRLUpdater struct {
+			IssuerCerts []string
+		}
+	}
+	err = json.Unmarshal(snippetJSON, &snippet)
+	test.AssertNotError(t, err, "failed to unmarshal snippet")
+
+	test.AssertEquals(t, len(snippet.CA.Issuance.Issuers), 1)
+	issuer := snippet.CA.Issuance.Issuers[0]
+	test.AssertNotError(t, validator.New().Struct(issuer), "issuer config failed validation")
+	test.AssertDeepEquals(t, issuer, issuance.IssuerConfig{
+		UseForECDSALeaves: true,
+		State:             issuance.StateActive,
+		Weight:            3,
+		IssuerURL:         "http://e9.i.example.com/",
+		OCSPURL:           "http://e9.o.example.com/",
+		CRLURL:            "http://e9.c.example.com/",
+		Location: issuance.IssuerLoc{
+			ConfigFile:  "/hierarchy/e9.pkcs11.json",
+			CertFile:    "/hierarchy/e9.pem",
+			NumSessions: 2,
+		},
+	})
+	test.AssertDeepEquals(t, snippet.WFE.Chains, [][]string{
+		{"/hierarchy/e9.pem", "/hierarchy/root-x2.pem"},
+		{"/hierarchy/e9-cross.pem", "/hierarchy/root-x1.pem"},
+	})
+	test.AssertDeepEquals(t, snippet.CRLUpdater.IssuerCerts, []string{"/hierarchy/e9.pem"})
+
+	// Without cross-signing or a deploy directory, there is a single chain
+	// which uses the paths on the ceremony machine.
+	config.CrossSign = rolloverSigningConfig{}
+	config.Boulder.DeployDirectory = ""
+	chains := config.configSnippet().WFE.Chains
+	test.AssertDeepEquals(t, chains, [][]string{{"/ceremony/e9.pem", "/ceremony/root-x2.pem"}})
+}
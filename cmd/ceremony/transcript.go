@@ -0,0 +1,401 @@
+package notmain
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/pkcs11helpers"
+)
+
+// ceremonyTranscript is a machine-readable record of a single ceremony,
+// intended to be signed by a witness and handed to auditors in place of
+// re-typed log output.
+type ceremonyTranscript struct {
+	CeremonyType string             `json:"ceremonyType"`
+	ConfigSHA256 string             `json:"configSHA256"`
+	StartTime    time.Time          `json:"startTime"`
+	EndTime      time.Time          `json:"endTime"`
+	Tokens       []transcriptToken  `json:"tokens,omitempty"`
+	Keys         []transcriptKey    `json:"keys,omitempty"`
+	Outputs      []transcriptOutput `json:"outputs,omitempty"`
+	Error        string             `json:"error,omitempty"`
+
+	clk clock.Clock
+}
+
+// transcriptToken describes a HSM token a ceremony opened a session with.
+type transcriptToken struct {
+	Slot            uint   `json:"slot"`
+	Label           string `json:"label"`
+	Manufacturer    string `json:"manufacturer"`
+	Model           string `json:"model"`
+	SerialNumber    string `json:"serialNumber"`
+	FirmwareVersion string `json:"firmwareVersion"`
+}
+
+// transcriptKey describes a key pair which was generated or used for signing
+// during a ceremony. ID is the hex encoded PKCS#11 CKA_ID, and is only known
+// for generated keys.
+type transcriptKey struct {
+	Usage           string `json:"usage"`
+	Label           string `json:"label"`
+	ID              string `json:"id,omitempty"`
+	PublicKeySHA256 string `json:"publicKeySHA256"`
+}
+
+// transcriptOutput describes a file written by a ceremony. DERSHA256 is the
+// hash of the DER encoded object the file contains, if any, and FileSHA256
+// the hash of the file exactly as written.
+type transcriptOutput struct {
+	Type       string    `json:"type"`
+	Path       string    `json:"path"`
+	DERSHA256  string    `json:"derSHA256,omitempty"`
+	FileSHA256 string    `json:"fileSHA256"`
+	Time       time.Time `json:"time"`
+}
+
+func sha256Hex(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+func newCeremonyTranscript(ceremonyType string, configBytes []byte, clk clock.Clock) *ceremonyTranscript {
+	return &ceremonyTranscript{
+		CeremonyType: ceremonyType,
+		ConfigSHA256: sha256Hex(configBytes),
+		StartTime:    clk.Now().UTC(),
+		clk:          clk,
+	}
+}
+
+// addToken records the token the session was opened with. Tokens are only
+// recorded once per slot.
+func (ct *ceremonyTranscript) addToken(session *pkcs11helpers.Session) error {
+	slot, info, err := session.TokenInfo()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve token info for transcript: %s", err)
+	}
+	for _, t := range ct.Tokens {
+		if t.Slot == slot {
+			return nil
+		}
+	}
+	ct.Tokens = append(ct.Tokens, transcriptToken{
+		Slot:            slot,
+		Label:           strings.TrimSpace(info.Label),
+		Manufacturer:    strings.TrimSpace(info.ManufacturerID),
+		Model:           strings.TrimSpace(info.Model),
+		SerialNumber:    strings.TrimSpace(info.SerialNumber),
+		FirmwareVersion: fmt.Sprintf("%d.%d", info.FirmwareVersion.Major, info.FirmwareVersion.Minor),
+	})
+	return nil
+}
+
+// addKey records a key pair, identified by the DER encoding of its
+// SubjectPublicKeyInfo. usage is either "generated" or "signing".
+func (ct *ceremonyTranscript) addKey(usage, label string, id []byte, spki []byte) {
+	ct.Keys = append(ct.Keys, transcriptKey{
+		Usage:           usage,
+		Label:           label,
+		ID:              hex.EncodeToString(id),
+		PublicKeySHA256: sha256Hex(spki),
+	})
+}
+
+// addGeneratedKey records a key pair generated by the ceremony, and the public
+// key file written for it.
+func (ct *ceremonyTranscript) addGeneratedKey(label, publicKeyPath string, info *keyInfo) {
+	ct.addKey("generated", label, info.id, info.der)
+	ct.addOutput("public-key", publicKeyPath, info.der, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: info.der}))
+}
+
+// addSigningKey records the key pair used for signing, as well as the token
+// it is stored on.
+func (ct *ceremonyTranscript) addSigningKey(session *pkcs11helpers.Session, label string, pub crypto.PublicKey) error {
+	err := ct.addToken(session)
+	if err != nil {
+		return err
+	}
+	spki, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return fmt.Errorf("failed to marshal signing public key for transcript: %s", err)
+	}
+	ct.addKey("signing", label, nil, spki)
+	return nil
+}
+
+// addOutput records a file written by the ceremony. der may be nil for
+// outputs which don't contain a DER encoded object.
+func (ct *ceremonyTranscript) addOutput(outputType, path string, der, contents []byte) {
+	output := transcriptOutput{
+		Type:       outputType,
+		Path:       path,
+		FileSHA256: sha256Hex(contents),
+		Time:       ct.clk.Now().UTC(),
+	}
+	if der != nil {
+		output.DERSHA256 = sha256Hex(der)
+	}
+	ct.Outputs = append(ct.Outputs, output)
+}
+
+// finish records the end time of the ceremony and, if it failed, the error it
+// failed with.
+func (ct *ceremonyTranscript) finish(err error) {
+	ct.EndTime = ct.clk.Now().UTC()
+	if err != nil {
+		ct.Error = err.Error()
+	}
+}
+
+// signedTranscript is the format transcripts are written in. Signature is a
+// signature by the witness key over Transcript, in its compact JSON form. The
+// witness fields are empty if the ceremony was run without a witness key.
+type signedTranscript struct {
+	Transcript         json.RawMessage `json:"transcript"`
+	WitnessKeySHA256   string          `json:"witnessKeySHA256,omitempty"`
+	SignatureAlgorithm string          `json:"signatureAlgorithm,omitempty"`
+	Signature          []byte          `json:"signature,omitempty"`
+}
+
+// loadWitnessKey loads a PEM encoded PKCS#8, PKCS#1 or SEC 1 private key.
+func loadWitnessKey(filename string) (crypto.Signer, error) {
+	keyPEM, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in witness key file %s", filename)
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported witness key type %T", key)
+	}
+	return signer, nil
+}
+
+// witnessAlgorithm returns the name of the signature algorithm used with the
+// given witness public key, and the hash used when signing with it.
+func witnessAlgorithm(pub crypto.PublicKey) (string, crypto.Hash, error) {
+	switch pub.(type) {
+	case *ecdsa.PublicKey:
+		return "ECDSA-SHA256", crypto.SHA256, nil
+	case *rsa.PublicKey:
+		return "RSA-PKCS1v15-SHA256", crypto.SHA256, nil
+	case ed25519.PublicKey:
+		return "Ed25519", crypto.Hash(0), nil
+	default:
+		return "", 0, fmt.Errorf("unsupported witness key type %T", pub)
+	}
+}
+
+// signTranscript serializes the transcript and signs it with the witness key,
+// returning the JSON encoded signedTranscript.
+func signTranscript(ct *ceremonyTranscript, witness crypto.Signer) ([]byte, error) {
+	transcriptBytes, err := json.Marshal(ct)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal transcript: %s", err)
+	}
+	alg, hash, err := witnessAlgorithm(witness.Public())
+	if err != nil {
+		return nil, err
+	}
+	spki, err := x509.MarshalPKIXPublicKey(witness.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal witness public key: %s", err)
+	}
+	digest := transcriptBytes
+	if hash != 0 {
+		h := hash.New()
+		h.Write(transcriptBytes)
+		digest = h.Sum(nil)
+	}
+	// The witness key is a local software key, so unlike the HSM signers used
+	// elsewhere it needs a real source of randomness.
+	signature, err := witness.Sign(rand.Reader, digest, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transcript: %s", err)
+	}
+	return json.MarshalIndent(signedTranscript{
+		Transcript:         transcriptBytes,
+		WitnessKeySHA256:   sha256Hex(spki),
+		SignatureAlgorithm: alg,
+		Signature:          signature,
+	}, "", "  ")
+}
+
+// encodeTranscript serializes the transcript, signing it with the witness key
+// if there is one, and returns the JSON encoded signedTranscript.
+func encodeTranscript(ct *ceremonyTranscript, witness crypto.Signer) ([]byte, error) {
+	if witness != nil {
+		return signTranscript(ct, witness)
+	}
+	transcriptBytes, err := json.Marshal(ct)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal transcript: %s", err)
+	}
+	return json.MarshalIndent(signedTranscript{Transcript: transcriptBytes}, "", "  ")
+}
+
+// verifyTranscriptSignature checks that signed is a transcript signed by the
+// witness public key, and returns the transcript.
+func verifyTranscriptSignature(signed []byte, witness crypto.PublicKey) (*ceremonyTranscript, error) {
+	var st signedTranscript
+	err := json.Unmarshal(signed, &st)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signed transcript: %s", err)
+	}
+	if len(st.Signature) == 0 {
+		return nil, errors.New("transcript was not signed by a witness")
+	}
+	spki, err := x509.MarshalPKIXPublicKey(witness)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal witness public key: %s", err)
+	}
+	if st.WitnessKeySHA256 != sha256Hex(spki) {
+		return nil, fmt.Errorf("transcript was signed by witness key %s, not %s", st.WitnessKeySHA256, sha256Hex(spki))
+	}
+	alg, hash, err := witnessAlgorithm(witness)
+	if err != nil {
+		return nil, err
+	}
+	if st.SignatureAlgorithm != alg {
+		return nil, fmt.Errorf("transcript signature algorithm is %q, expected %q", st.SignatureAlgorithm, alg)
+	}
+
+	// The transcript was signed in its compact form, before the signed
+	// transcript was indented.
+	var transcriptBytes bytes.Buffer
+	err = json.Compact(&transcriptBytes, st.Transcript)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compact transcript: %s", err)
+	}
+	var valid bool
+	switch pub := witness.(type) {
+	case *ecdsa.PublicKey:
+		h := hash.New()
+		h.Write(transcriptBytes.Bytes())
+		valid = ecdsa.VerifyASN1(pub, h.Sum(nil), st.Signature)
+	case *rsa.PublicKey:
+		h := hash.New()
+		h.Write(transcriptBytes.Bytes())
+		valid = rsa.VerifyPKCS1v15(pub, hash, h.Sum(nil), st.Signature) == nil
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(pub, transcriptBytes.Bytes(), st.Signature)
+	}
+	if !valid {
+		return nil, errors.New("transcript signature is invalid")
+	}
+
+	var ct ceremonyTranscript
+	err = json.Unmarshal(transcriptBytes.Bytes(), &ct)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse transcript: %s", err)
+	}
+	return &ct, nil
+}
+
+// checkTranscript verifies a signed transcript and, optionally, that the
+// config it records matches configBytes and that the outputs it records are
+// still present and unmodified.
+func checkTranscript(signed []byte, witness crypto.PublicKey, configBytes []byte, checkOutputs bool) (*ceremonyTranscript, error) {
+	ct, err := verifyTranscriptSignature(signed, witness)
+	if err != nil {
+		return nil, err
+	}
+	if configBytes != nil && sha256Hex(configBytes) != ct.ConfigSHA256 {
+		return nil, fmt.Errorf("config hash %s does not match transcript's %s", sha256Hex(configBytes), ct.ConfigSHA256)
+	}
+	if checkOutputs {
+		for _, output := range ct.Outputs {
+			contents, err := os.ReadFile(output.Path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s output %q: %s", output.Type, output.Path, err)
+			}
+			if sha256Hex(contents) != output.FileSHA256 {
+				return nil, fmt.Errorf("%s output %q does not match transcript", output.Type, output.Path)
+			}
+		}
+	}
+	return ct, nil
+}
+
+// verifyTranscriptCommand implements the verify-transcript subcommand.
+func verifyTranscriptCommand(args []string) error {
+	fs := flag.NewFlagSet("verify-transcript", flag.ContinueOnError)
+	transcriptPath := fs.String("transcript", "", "Path to signed ceremony transcript")
+	witnessPath := fs.String("witness-public-key", "", "Path to PEM witness public key")
+	configPath := fs.String("config", "", "Path to ceremony configuration file to check against the transcript")
+	checkOutputs := fs.Bool("check-outputs", false, "Check that the ceremony outputs recorded in the transcript are unmodified")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *transcriptPath == "" || *witnessPath == "" {
+		return errors.New("--transcript and --witness-public-key are required")
+	}
+
+	signed, err := os.ReadFile(*transcriptPath)
+	if err != nil {
+		return fmt.Errorf("failed to read transcript: %s", err)
+	}
+	pubPEMBytes, err := os.ReadFile(*witnessPath)
+	if err != nil {
+		return fmt.Errorf("failed to read witness public key %q: %s", *witnessPath, err)
+	}
+	pubPEM, _ := pem.Decode(pubPEMBytes)
+	if pubPEM == nil {
+		return fmt.Errorf("failed to parse witness public key")
+	}
+	witness, err := x509.ParsePKIXPublicKey(pubPEM.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse witness public key: %s", err)
+	}
+	var configBytes []byte
+	if *configPath != "" {
+		configBytes, err = os.ReadFile(*configPath)
+		if err != nil {
+			return fmt.Errorf("failed to read config file: %s", err)
+		}
+	}
+
+	ct, err := checkTranscript(signed, witness, configBytes, *checkOutputs)
+	if err != nil {
+		return err
+	}
+	log.Printf("Transcript of %s ceremony started at %s is validly signed by witness key %s\n",
+		ct.CeremonyType, ct.StartTime.Format(time.RFC3339), sha256Hex(pubPEM.Bytes))
+	if ct.Error != "" {
+		return fmt.Errorf("transcript records a failed ceremony: %s", ct.Error)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package notmain
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/miekg/pkcs11"
+
+	"github.com/letsencrypt/boulder/pkcs11helpers"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func testTranscript(t *testing.T) *ceremonyTranscript {
+	t.Helper()
+	fc := clock.NewFake()
+	fc.Set(time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC))
+	tr := newCeremonyTranscript("root", []byte("config"), fc)
+	tr.addKey("generated", "root key", []byte{1, 2}, []byte("spki"))
+	fc.Add(time.Minute)
+	tr.addOutput("certificate", "/tmp/cert.pem", []byte("der"), []byte("pem"))
+	tr.finish(nil)
+	return tr
+}
+
+func TestTranscriptRecording(t *testing.T) {
+	tr := testTranscript(t)
+	test.AssertEquals(t, tr.ConfigSHA256, sha256Hex([]byte("config")))
+	test.AssertDeepEquals(t, tr.Keys, []transcriptKey{{
+		Usage:           "generated",
+		Label:           "root key",
+		ID:              "0102",
+		PublicKeySHA256: sha256Hex([]byte("spki")),
+	}})
+	test.AssertDeepEquals(t, tr.Outputs, []transcriptOutput{{
+		Type:       "certificate",
+		Path:       "/tmp/cert.pem",
+		DERSHA256:  sha256Hex([]byte("der")),
+		FileSHA256: sha256Hex([]byte("pem")),
+		Time:       time.Date(2020, 1, 1, 12, 1, 0, 0, time.UTC),
+	}})
+	test.AssertEquals(t, tr.EndTime.Sub(tr.StartTime), time.Minute)
+	test.AssertEquals(t, tr.Error, "")
+
+	tr.finish(os.ErrNotExist)
+	test.AssertEquals(t, tr.Error, os.ErrNotExist.Error())
+}
+
+func TestTranscriptAddToken(t *testing.T) {
+	s, ctx := pkcs11helpers.NewSessionWithMock()
+	ctx.GetSessionInfoFunc = func(pkcs11.SessionHandle) (pkcs11.SessionInfo, error) {
+		return pkcs11.SessionInfo{SlotID: 3}, nil
+	}
+	ctx.GetTokenInfoFunc = func(uint) (pkcs11.TokenInfo, error) {
+		return pkcs11.TokenInfo{
+			Label:           "root token      ",
+			ManufacturerID:  "HSM Co",
+			Model:           "HSM 1",
+			SerialNumber:    "1234",
+			FirmwareVersion: pkcs11.Version{Major: 2, Minor: 5},
+		}, nil
+	}
+
+	tr := newCeremonyTranscript("key", nil, clock.NewFake())
+	test.AssertNotError(t, tr.addToken(s), "addToken failed")
+	test.AssertNotError(t, tr.addToken(s), "addToken failed")
+	test.AssertDeepEquals(t, tr.Tokens, []transcriptToken{{
+		Slot:            3,
+		Label:           "root token",
+		Manufacturer:    "HSM Co",
+		Model:           "HSM 1",
+		SerialNumber:    "1234",
+		FirmwareVersion: "2.5",
+	}})
+
+	ctx.GetTokenInfoFunc = func(uint) (pkcs11.TokenInfo, error) {
+		return pkcs11.TokenInfo{}, pkcs11.Error(pkcs11.CKR_GENERAL_ERROR)
+	}
+	err := tr.addToken(s)
+	test.AssertError(t, err, "addToken didn't fail when token info was unavailable")
+}
+
+func TestSignAndVerifyTranscript(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "failed to generate key")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "failed to generate key")
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	test.AssertNotError(t, err, "failed to generate key")
+
+	for _, witness := range []crypto.Signer{ecKey, rsaKey, edKey} {
+		tr := testTranscript(t)
+		signed, err := signTranscript(tr, witness)
+		test.AssertNotError(t, err, "signTranscript failed")
+
+		verified, err := verifyTranscriptSignature(signed, witness.Public())
+		test.AssertNotError(t, err, "verifyTranscriptSignature failed")
+		test.AssertEquals(t, verified.ConfigSHA256, tr.ConfigSHA256)
+		test.AssertDeepEquals(t, verified.Keys, tr.Keys)
+		test.AssertDeepEquals(t, verified.Outputs, tr.Outputs)
+
+		tampered := []byte(strings.Replace(string(signed), `"root key"`, `"other key"`, 1))
+		_, err = verifyTranscriptSignature(tampered, witness.Public())
+		test.AssertError(t, err, "verifyTranscriptSignature accepted a modified transcript")
+		test.AssertContains(t, err.Error(), "transcript signature is invalid")
+	}
+
+	tr := testTranscript(t)
+	signed, err := signTranscript(tr, ecKey)
+	test.AssertNotError(t, err, "signTranscript failed")
+	_, err = verifyTranscriptSignature(signed, rsaKey.Public())
+	test.AssertError(t, err, "verifyTranscriptSignature accepted the wrong witness key")
+	test.AssertContains(t, err.Error(), "transcript was signed by witness key")
+}
+
+func TestEncodeTranscriptUnsigned(t *testing.T) {
+	tr := testTranscript(t)
+	encoded, err := encodeTranscript(tr, nil)
+	test.AssertNotError(t, err, "encodeTranscript failed")
+	test.AssertNotContains(t, string(encoded), "signature")
+
+	var st signedTranscript
+	err = json.Unmarshal(encoded, &st)
+	test.AssertNotError(t, err, "failed to parse unsigned transcript")
+	var recorded ceremonyTranscript
+	err = json.Unmarshal(st.Transcript, &recorded)
+	test.AssertNotError(t, err, "failed to parse recorded transcript")
+	test.AssertEquals(t, recorded.ConfigSHA256, tr.ConfigSHA256)
+	test.AssertDeepEquals(t, recorded.Outputs, tr.Outputs)
+
+	witness, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "failed to generate key")
+	_, err = verifyTranscriptSignature(encoded, witness.Public())
+	test.AssertError(t, err, "verifyTranscriptSignature accepted an unsigned transcript")
+	test.AssertContains(t, err.Error(), "not signed by a witness")
+}
+
+func TestDefaultTranscriptPath(t *testing.T) {
+	start := time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC)
+	test.AssertEquals(t, defaultTranscriptPath("/ceremonies/root.yaml", start), "/ceremonies/root.20230102T150405Z.transcript.json")
+	test.AssertEquals(t, defaultTranscriptPath("root-ceremony", start), "root-ceremony.20230102T150405Z.transcript.json")
+	test.AssertNotEquals(t,
+		defaultTranscriptPath("root-ceremony", start),
+		defaultTranscriptPath("root-ceremony", start.Add(time.Second)))
+}
+
+func TestCheckTranscript(t *testing.T) {
+	tmp := t.TempDir()
+	outputPath := path.Join(tmp, "cert.pem")
+	err := os.WriteFile(outputPath, []byte("pem"), 0644)
+	test.AssertNotError(t, err, "failed to write output")
+
+	witness, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "failed to generate key")
+	tr := newCeremonyTranscript("root", []byte("config"), clock.NewFake())
+	tr.addOutput("certificate", outputPath, []byte("der"), []byte("pem"))
+	tr.finish(nil)
+	signed, err := signTranscript(tr, witness)
+	test.AssertNotError(t, err, "signTranscript failed")
+
+	_, err = checkTranscript(signed, witness.Public(), []byte("config"), true)
+	test.AssertNotError(t, err, "checkTranscript failed")
+
+	_, err = checkTranscript(signed, witness.Public(), []byte("other config"), false)
+	test.AssertError(t, err, "checkTranscript accepted the wrong config")
+	test.AssertContains(t, err.Error(), "does not match transcript's")
+
+	err = os.WriteFile(outputPath, []byte("modified"), 0644)
+	test.AssertNotError(t, err, "failed to write output")
+	_, err = checkTranscript(signed, witness.Public(), nil, false)
+	test.AssertNotError(t, err, "checkTranscript checked outputs when not asked to")
+	_, err = checkTranscript(signed, witness.Public(), nil, true)
+	test.AssertError(t, err, "checkTranscript accepted a modified output")
+	test.AssertContains(t, err.Error(), "does not match transcript")
+}
+
+func TestVerifyTranscriptCommand(t *testing.T) {
+	tmp := t.TempDir()
+	witness, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "failed to generate key")
+	keyDER, err := x509.MarshalPKCS8PrivateKey(witness)
+	test.AssertNotError(t, err, "failed to marshal key")
+	keyPath := path.Join(tmp, "witness.key")
+	err = os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600)
+	test.AssertNotError(t, err, "failed to write key")
+	pubDER, err := x509.MarshalPKIXPublicKey(witness.Public())
+	test.AssertNotError(t, err, "failed to marshal public key")
+	pubPath := path.Join(tmp, "witness.pem")
+	err = os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0644)
+	test.AssertNotError(t, err, "failed to write public key")
+
+	loaded, err := loadWitnessKey(keyPath)
+	test.AssertNotError(t, err, "loadWitnessKey failed")
+	test.Assert(t, loaded.Public().(*ecdsa.PublicKey).Equal(witness.Public()), "loaded wrong witness key")
+
+	tr := testTranscript(t)
+	signed, err := signTranscript(tr, loaded)
+	test.AssertNotError(t, err, "signTranscript failed")
+	transcriptPath := path.Join(tmp, "transcript.json")
+	err = writeFile(transcriptPath, signed)
+	test.AssertNotError(t, err, "failed to write transcript")
+
+	err = verifyTranscriptCommand([]string{"-transcript", transcriptPath, "-witness-public-key", pubPath})
+	test.AssertNotError(t, err, "verifyTranscriptCommand failed")
+
+	err = verifyTranscriptCommand([]string{"-transcript", transcriptPath})
+	test.AssertError(t, err, "verifyTranscriptCommand succeeded without a witness key")
+
+	tr.finish(os.ErrNotExist)
+	signed, err = signTranscript(tr, loaded)
+	test.AssertNotError(t, err, "signTranscript failed")
+	failedPath := path.Join(tmp, "failed.json")
+	err = writeFile(failedPath, signed)
+	test.AssertNotError(t, err, "failed to write transcript")
+	err = verifyTranscriptCommand([]string{"-transcript", failedPath, "-witness-public-key", pubPath})
+	test.AssertError(t, err, "verifyTranscriptCommand accepted a failed ceremony")
+	test.AssertContains(t, err.Error(), "transcript records a failed ceremony")
+}
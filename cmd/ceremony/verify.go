@@ -0,0 +1,434 @@
+package notmain
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/miekg/pkcs11"
+
+	"github.com/letsencrypt/boulder/linter"
+	"github.com/letsencrypt/boulder/pkcs11helpers"
+	"github.com/letsencrypt/boulder/strictyaml"
+)
+
+type verifyConfig struct {
+	CeremonyType string `yaml:"ceremony-type"`
+	PKCS11       struct {
+		Module string `yaml:"module"`
+		PIN    string `yaml:"pin"`
+		Slot   uint   `yaml:"slot"`
+	} `yaml:"pkcs11"`
+	Keys []struct {
+		Label            string       `yaml:"label"`
+		Key              keyGenConfig `yaml:"key"`
+		CertificatePaths []string     `yaml:"certificate-paths"`
+	} `yaml:"keys"`
+	AllowUnexpectedObjects bool `yaml:"allow-unexpected-objects"`
+	Certificates           []struct {
+		CertificatePath       string   `yaml:"certificate-path"`
+		IssuerCertificatePath string   `yaml:"issuer-certificate-path"`
+		SkipLints             []string `yaml:"skip-lints"`
+	} `yaml:"certificates"`
+	Operators []string `yaml:"operators"`
+	Outputs   struct {
+		TranscriptPath   string `yaml:"transcript-path"`
+		TranscriptFormat string `yaml:"transcript-format"`
+	} `yaml:"outputs"`
+}
+
+func (vc verifyConfig) validate() error {
+	if vc.PKCS11.Module == "" {
+		return errors.New("pkcs11.module is required")
+	}
+	// slot is allowed to be 0 (which is a valid slot).
+
+	if len(vc.Keys) == 0 && len(vc.Certificates) == 0 {
+		return errors.New("at least one of keys or certificates is required")
+	}
+	labels := make(map[string]bool)
+	for _, k := range vc.Keys {
+		if k.Label == "" {
+			return errors.New("keys.label is required")
+		}
+		if labels[k.Label] {
+			return fmt.Errorf("keys.label %q is duplicated", k.Label)
+		}
+		labels[k.Label] = true
+		err := k.Key.validate()
+		if err != nil {
+			return err
+		}
+	}
+	for _, c := range vc.Certificates {
+		if c.CertificatePath == "" {
+			return errors.New("certificates.certificate-path is required")
+		}
+		if c.IssuerCertificatePath == "" {
+			return errors.New("certificates.issuer-certificate-path is required")
+		}
+	}
+
+	if len(vc.Operators) == 0 {
+		return errors.New("at least one operator is required")
+	}
+
+	err := checkOutputFile(vc.Outputs.TranscriptPath, "transcript-path")
+	if err != nil {
+		return err
+	}
+	switch vc.Outputs.TranscriptFormat {
+	case "", "json", "text":
+	default:
+		return errors.New("outputs.transcript-format can only be 'json' or 'text'")
+	}
+
+	return nil
+}
+
+// verifyObject describes an object found in the PKCS#11 slot being verified.
+type verifyObject struct {
+	Handle   uint   `json:"handle"`
+	Class    string `json:"class"`
+	Label    string `json:"label"`
+	ID       string `json:"id"`
+	KeyType  string `json:"keyType,omitempty"`
+	Expected bool   `json:"expected"`
+}
+
+// verifyCheck is the result of a single verification step.
+type verifyCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// verifyTranscript records everything examined and checked by a verify
+// ceremony, along with who performed it.
+type verifyTranscript struct {
+	CeremonyType string         `json:"ceremonyType"`
+	Time         time.Time      `json:"time"`
+	Module       string         `json:"module"`
+	Slot         uint           `json:"slot"`
+	Operators    []string       `json:"operators"`
+	Objects      []verifyObject `json:"objects"`
+	Checks       []verifyCheck  `json:"checks"`
+	Passed       bool           `json:"passed"`
+}
+
+func (vt *verifyTranscript) check(name string, err error) {
+	c := verifyCheck{Name: name, Passed: err == nil}
+	if err != nil {
+		c.Detail = err.Error()
+		vt.Passed = false
+		log.Printf("FAIL %s: %s\n", name, err)
+	} else {
+		log.Printf("PASS %s\n", name)
+	}
+	vt.Checks = append(vt.Checks, c)
+}
+
+var (
+	objectClasses = map[uint]string{
+		pkcs11.CKO_DATA:        "data",
+		pkcs11.CKO_CERTIFICATE: "certificate",
+		pkcs11.CKO_PUBLIC_KEY:  "public key",
+		pkcs11.CKO_PRIVATE_KEY: "private key",
+		pkcs11.CKO_SECRET_KEY:  "secret key",
+	}
+	keyTypes = map[uint]string{
+		pkcs11.CKK_RSA: "rsa",
+		pkcs11.CKK_EC:  "ecdsa",
+		pkcs11.CKK_AES: "aes",
+	}
+)
+
+// decodeULong returns the name of the entry in names whose PKCS#11 CK_ULONG
+// encoding matches the given attribute value.
+func decodeULong(typ uint, value []byte, names map[uint]string) string {
+	for v, name := range names {
+		if bytes.Equal(value, pkcs11.NewAttribute(typ, v).Value) {
+			return name
+		}
+	}
+	return fmt.Sprintf("unknown (%x)", value)
+}
+
+// listObjects enumerates every object visible in the session.
+func listObjects(session *pkcs11helpers.Session) ([]verifyObject, error) {
+	handles, err := session.FindObjects(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enumerate objects: %s", err)
+	}
+	var objects []verifyObject
+	for _, handle := range handles {
+		attrs, err := session.GetAttributeValue(handle, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_CLASS, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_ID, nil),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve attributes of object %d: %s", handle, err)
+		}
+		obj := verifyObject{Handle: uint(handle)}
+		for _, a := range attrs {
+			switch a.Type {
+			case pkcs11.CKA_CLASS:
+				obj.Class = decodeULong(pkcs11.CKA_CLASS, a.Value, objectClasses)
+			case pkcs11.CKA_LABEL:
+				obj.Label = string(a.Value)
+			case pkcs11.CKA_ID:
+				obj.ID = hex.EncodeToString(a.Value)
+			}
+		}
+		// Only key objects have a key type; asking for it of other objects
+		// is an error in some modules.
+		if strings.HasSuffix(obj.Class, " key") {
+			attrs, err := session.GetAttributeValue(handle, []*pkcs11.Attribute{
+				pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, nil),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to retrieve key type of object %d: %s", handle, err)
+			}
+			if len(attrs) == 1 {
+				obj.KeyType = decodeULong(pkcs11.CKA_KEY_TYPE, attrs[0].Value, keyTypes)
+			}
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// checkKeyParams returns an error unless pub matches the expected key type and
+// size or curve.
+func checkKeyParams(pub crypto.PublicKey, expected keyGenConfig) error {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		if expected.Type != "rsa" {
+			return fmt.Errorf("expected %s key, found rsa", expected.Type)
+		}
+		if uint(k.N.BitLen()) != expected.RSAModLength {
+			return fmt.Errorf("expected %d bit modulus, found %d", expected.RSAModLength, k.N.BitLen())
+		}
+	case *ecdsa.PublicKey:
+		if expected.Type != "ecdsa" {
+			return fmt.Errorf("expected %s key, found ecdsa", expected.Type)
+		}
+		if k.Curve.Params().Name != expected.ECDSACurve {
+			return fmt.Errorf("expected curve %s, found %s", expected.ECDSACurve, k.Curve.Params().Name)
+		}
+	default:
+		return fmt.Errorf("unsupported public key of type %T", pub)
+	}
+	return nil
+}
+
+// findKeyPair returns the public key labelled label, after checking that a
+// private key with the same CKA_ID exists.
+func findKeyPair(session *pkcs11helpers.Session, label string) (crypto.PublicKey, error) {
+	pubHandle, err := session.FindObject([]*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PUBLIC_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, []byte(label)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find public key: %s", err)
+	}
+	pub, err := session.GetPublicKey(pubHandle)
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := session.GetAttributeValue(pubHandle, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_ID, nil),
+	})
+	if err != nil || len(attrs) != 1 {
+		return nil, fmt.Errorf("failed to retrieve public key ID: %v", err)
+	}
+	_, err = session.FindObject([]*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, attrs[0].Value),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find private key with ID %x: %s", attrs[0].Value, err)
+	}
+	return pub, nil
+}
+
+// checkIssuedBy returns an error unless cert chains to issuer: its issuer name
+// and authority key identifier match the issuer's, and the issuer's key signed
+// it.
+func checkIssuedBy(cert, issuer *x509.Certificate) error {
+	if !bytes.Equal(cert.RawIssuer, issuer.RawSubject) {
+		return fmt.Errorf("issuer name %q does not match issuer subject %q", cert.Issuer, issuer.Subject)
+	}
+	if len(cert.AuthorityKeyId) > 0 && !bytes.Equal(cert.AuthorityKeyId, issuer.SubjectKeyId) {
+		return fmt.Errorf("authority key identifier %x does not match issuer subject key identifier %x",
+			cert.AuthorityKeyId, issuer.SubjectKeyId)
+	}
+	err := cert.CheckSignatureFrom(issuer)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %s", err)
+	}
+	return nil
+}
+
+// runVerify checks the contents of the session's slot and the configured
+// certificates against the config, returning a transcript of the results.
+func runVerify(session *pkcs11helpers.Session, config verifyConfig, now time.Time) (*verifyTranscript, error) {
+	transcript := &verifyTranscript{
+		CeremonyType: "verify",
+		Time:         now.UTC(),
+		Module:       config.PKCS11.Module,
+		Slot:         config.PKCS11.Slot,
+		Operators:    config.Operators,
+		Passed:       true,
+	}
+
+	objects, err := listObjects(session)
+	if err != nil {
+		return nil, err
+	}
+	expectedLabels := make(map[string]bool)
+	for _, k := range config.Keys {
+		expectedLabels[k.Label] = true
+	}
+	var unexpected []string
+	for i, obj := range objects {
+		if expectedLabels[obj.Label] && (obj.Class == "public key" || obj.Class == "private key") {
+			objects[i].Expected = true
+		} else {
+			unexpected = append(unexpected, fmt.Sprintf("%s %q", obj.Class, obj.Label))
+		}
+	}
+	transcript.Objects = objects
+	if !config.AllowUnexpectedObjects {
+		var err error
+		if len(unexpected) > 0 {
+			err = fmt.Errorf("found unexpected objects: %s", strings.Join(unexpected, ", "))
+		}
+		transcript.check("slot contains only expected objects", err)
+	}
+
+	for _, k := range config.Keys {
+		pub, err := findKeyPair(session, k.Label)
+		transcript.check(fmt.Sprintf("key %q: key pair present", k.Label), err)
+		if err != nil {
+			continue
+		}
+		transcript.check(fmt.Sprintf("key %q: key parameters", k.Label), checkKeyParams(pub, k.Key))
+		for _, path := range k.CertificatePaths {
+			cert, err := loadCert(path)
+			if err == nil && !equalPubKeys(cert.PublicKey, pub) {
+				err = errors.New("certificate public key does not match HSM public key")
+			}
+			transcript.check(fmt.Sprintf("key %q: certificate %q matches", k.Label, path), err)
+		}
+	}
+
+	for _, c := range config.Certificates {
+		cert, err := loadCert(c.CertificatePath)
+		if err != nil {
+			transcript.check(fmt.Sprintf("certificate %q: load", c.CertificatePath), err)
+			continue
+		}
+		issuer, err := loadCert(c.IssuerCertificatePath)
+		if err == nil {
+			err = checkIssuedBy(cert, issuer)
+		}
+		transcript.check(fmt.Sprintf("certificate %q: issued by %q", c.CertificatePath, c.IssuerCertificatePath), err)
+		err = linter.CheckCertificate(cert.Raw, c.SkipLints)
+		transcript.check(fmt.Sprintf("certificate %q: lints", c.CertificatePath), err)
+	}
+
+	return transcript, nil
+}
+
+// text renders the transcript in human-readable form, ending with a line for
+// each operator to sign.
+func (vt *verifyTranscript) text() []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Ceremony: %s\n", vt.CeremonyType)
+	fmt.Fprintf(&b, "Time: %s\n", vt.Time.Format(time.RFC3339))
+	fmt.Fprintf(&b, "Module: %s\n", vt.Module)
+	fmt.Fprintf(&b, "Slot: %d\n", vt.Slot)
+	fmt.Fprintf(&b, "\nObjects:\n")
+	for _, obj := range vt.Objects {
+		expected := "unexpected"
+		if obj.Expected {
+			expected = "expected"
+		}
+		fmt.Fprintf(&b, "  %d: %s %q id=%s %s (%s)\n", obj.Handle, obj.Class, obj.Label, obj.ID, obj.KeyType, expected)
+	}
+	fmt.Fprintf(&b, "\nChecks:\n")
+	for _, c := range vt.Checks {
+		if c.Passed {
+			fmt.Fprintf(&b, "  PASS %s\n", c.Name)
+		} else {
+			fmt.Fprintf(&b, "  FAIL %s: %s\n", c.Name, c.Detail)
+		}
+	}
+	result := "FAILED"
+	if vt.Passed {
+		result = "PASSED"
+	}
+	fmt.Fprintf(&b, "\nResult: %s\n\nSigned off by:\n", result)
+	for _, op := range vt.Operators {
+		fmt.Fprintf(&b, "  %s: ______________________________\n", op)
+	}
+	return []byte(b.String())
+}
+
+func verifyCeremony(configBytes []byte, tr *ceremonyTranscript) error {
+	var config verifyConfig
+	err := strictyaml.Unmarshal(configBytes, &config)
+	if err != nil {
+		return fmt.Errorf("failed to parse config: %s", err)
+	}
+	err = config.validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate config: %s", err)
+	}
+	session, err := pkcs11helpers.Initialize(config.PKCS11.Module, config.PKCS11.Slot, config.PKCS11.PIN)
+	if err != nil {
+		return fmt.Errorf("failed to setup session and PKCS#11 context for slot %d: %s", config.PKCS11.Slot, err)
+	}
+	log.Printf("Opened PKCS#11 session for slot %d\n", config.PKCS11.Slot)
+	err = tr.addToken(session)
+	if err != nil {
+		return err
+	}
+
+	transcript, err := runVerify(session, config, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var out []byte
+	if config.Outputs.TranscriptFormat == "text" {
+		out = transcript.text()
+	} else {
+		out, err = json.MarshalIndent(transcript, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal transcript: %s", err)
+		}
+	}
+	err = writeFile(config.Outputs.TranscriptPath, out)
+	if err != nil {
+		return fmt.Errorf("failed to write transcript to %q: %s", config.Outputs.TranscriptPath, err)
+	}
+	log.Printf("Transcript written to %q\n", config.Outputs.TranscriptPath)
+	tr.addOutput("verify-transcript", config.Outputs.TranscriptPath, nil, out)
+
+	if !transcript.Passed {
+		return errors.New("one or more checks failed, see transcript for details")
+	}
+	return nil
+}
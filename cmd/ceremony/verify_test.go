@@ -0,0 +1,320 @@
+package notmain
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/pkcs11"
+
+	"github.com/letsencrypt/boulder/pkcs11helpers"
+	"github.com/letsencrypt/boulder/strictyaml"
+	"github.com/letsencrypt/boulder/test"
+)
+
+// fakeHSM is a PKCS#11 slot containing the given objects, each a list of
+// attributes, whose handles are their indices plus one.
+type fakeHSM struct {
+	objects  [][]*pkcs11.Attribute
+	template []*pkcs11.Attribute
+	searched bool
+}
+
+func (f *fakeHSM) object(handle pkcs11.ObjectHandle) []*pkcs11.Attribute {
+	return f.objects[handle-1]
+}
+
+func (f *fakeHSM) session() *pkcs11helpers.Session {
+	ctx := pkcs11helpers.MockCtx{
+		FindObjectsInitFunc: func(_ pkcs11.SessionHandle, tmpl []*pkcs11.Attribute) error {
+			f.template = tmpl
+			f.searched = false
+			return nil
+		},
+		FindObjectsFunc: func(pkcs11.SessionHandle, int) ([]pkcs11.ObjectHandle, bool, error) {
+			if f.searched {
+				return nil, false, nil
+			}
+			f.searched = true
+			var handles []pkcs11.ObjectHandle
+			for i, obj := range f.objects {
+				if matchesTemplate(obj, f.template) {
+					handles = append(handles, pkcs11.ObjectHandle(i+1))
+				}
+			}
+			return handles, false, nil
+		},
+		FindObjectsFinalFunc: func(pkcs11.SessionHandle) error {
+			return nil
+		},
+		GetAttributeValueFunc: func(_ pkcs11.SessionHandle, handle pkcs11.ObjectHandle, attrs []*pkcs11.Attribute) ([]*pkcs11.Attribute, error) {
+			var res []*pkcs11.Attribute
+			for _, want := range attrs {
+				a := findAttribute(f.object(handle), want.Type)
+				if a == nil {
+					return nil, pkcs11.Error(pkcs11.CKR_ATTRIBUTE_TYPE_INVALID)
+				}
+				res = append(res, a)
+			}
+			return res, nil
+		},
+	}
+	return &pkcs11helpers.Session{Module: &ctx, Session: 0}
+}
+
+func findAttribute(obj []*pkcs11.Attribute, typ uint) *pkcs11.Attribute {
+	for _, a := range obj {
+		if a.Type == typ {
+			return a
+		}
+	}
+	return nil
+}
+
+func matchesTemplate(obj, tmpl []*pkcs11.Attribute) bool {
+	for _, want := range tmpl {
+		a := findAttribute(obj, want.Type)
+		if a == nil || !bytes.Equal(a.Value, want.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// ecKeyPairObjects returns the PKCS#11 public and private key objects for a
+// P-256 key.
+func ecKeyPairObjects(label string, id []byte, key *ecdsa.PrivateKey) [][]*pkcs11.Attribute {
+	return [][]*pkcs11.Attribute{
+		{
+			pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PUBLIC_KEY),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+			pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+			pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_EC),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, []byte{0x06, 0x08, 0x2A, 0x86, 0x48, 0xCE, 0x3D, 0x03, 0x01, 0x07}),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, elliptic.Marshal(key.Curve, key.X, key.Y)),
+		},
+		{
+			pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+			pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+			pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_EC),
+		},
+	}
+}
+
+func writeTestCert(t *testing.T, dir, name string, template, parent *x509.Certificate, pub, priv interface{}) (string, *x509.Certificate) {
+	t.Helper()
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, priv)
+	test.AssertNotError(t, err, "failed to create certificate")
+	cert, err := x509.ParseCertificate(der)
+	test.AssertNotError(t, err, "failed to parse certificate")
+	certPath := path.Join(dir, name)
+	err = os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	test.AssertNotError(t, err, "failed to write certificate")
+	return certPath, cert
+}
+
+func checkResults(transcript *verifyTranscript) map[string]bool {
+	res := make(map[string]bool)
+	for _, c := range transcript.Checks {
+		res[c.Name] = c.Passed
+	}
+	return res
+}
+
+func TestRunVerify(t *testing.T) {
+	tmp := t.TempDir()
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "failed to generate key")
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "failed to generate key")
+
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "root"},
+		SubjectKeyId:          []byte{1},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	rootPath, root := writeTestCert(t, tmp, "root.pem", rootTemplate, rootTemplate, rootKey.Public(), rootKey)
+	intTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "intermediate"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	intPath, _ := writeTestCert(t, tmp, "int.pem", intTemplate, root, otherKey.Public(), rootKey)
+	otherPath, _ := writeTestCert(t, tmp, "other.pem", rootTemplate, rootTemplate, otherKey.Public(), otherKey)
+
+	hsm := &fakeHSM{objects: ecKeyPairObjects("root key", []byte{1}, rootKey)}
+	hsm.objects = append(hsm.objects, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_DATA),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, "stray"),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, []byte{9}),
+	})
+
+	var config verifyConfig
+	err = strictyaml.Unmarshal([]byte(`
+ceremony-type: verify
+pkcs11:
+    module: module
+    slot: 1
+keys:
+    - label: root key
+      key:
+          type: ecdsa
+          ecdsa-curve: P-256
+      certificate-paths:
+          - `+rootPath+`
+          - `+otherPath+`
+    - label: missing key
+      key:
+          type: rsa
+          rsa-mod-length: 2048
+certificates:
+    - certificate-path: `+intPath+`
+      issuer-certificate-path: `+rootPath+`
+    - certificate-path: `+intPath+`
+      issuer-certificate-path: `+otherPath+`
+operators:
+    - Alice
+outputs:
+    transcript-path: `+path.Join(tmp, "transcript.json")+`
+`), &config)
+	test.AssertNotError(t, err, "failed to parse config")
+	test.AssertNotError(t, config.validate(), "config failed validation")
+
+	transcript, err := runVerify(hsm.session(), config, time.Now())
+	test.AssertNotError(t, err, "runVerify failed")
+	test.Assert(t, !transcript.Passed, "transcript should record failures")
+	test.AssertEquals(t, transcript.Slot, uint(1))
+	test.AssertEquals(t, len(transcript.Objects), 3)
+	test.AssertDeepEquals(t, transcript.Objects[0], verifyObject{
+		Handle: 1, Class: "public key", Label: "root key", ID: "01", KeyType: "ecdsa", Expected: true,
+	})
+	test.Assert(t, !transcript.Objects[2].Expected, "data object should be unexpected")
+
+	test.AssertDeepEquals(t, checkResults(transcript), map[string]bool{
+		"slot contains only expected objects":                          false,
+		`key "root key": key pair present`:                             true,
+		`key "root key": key parameters`:                               true,
+		`key "root key": certificate "` + rootPath + `" matches`:       true,
+		`key "root key": certificate "` + otherPath + `" matches`:      false,
+		`key "missing key": key pair present`:                          false,
+		`certificate "` + intPath + `": issued by "` + rootPath + `"`:  true,
+		`certificate "` + intPath + `": issued by "` + otherPath + `"`: false,
+		`certificate "` + intPath + `": lints`:                         false,
+	})
+
+	// Allowing unexpected objects skips that check, and a mismatched
+	// expectation fails the parameter check.
+	config.AllowUnexpectedObjects = true
+	config.Keys = config.Keys[:1]
+	config.Keys[0].Key = keyGenConfig{Type: "ecdsa", ECDSACurve: "P-384"}
+	config.Keys[0].CertificatePaths = nil
+	config.Certificates = nil
+	transcript, err = runVerify(hsm.session(), config, time.Now())
+	test.AssertNotError(t, err, "runVerify failed")
+	test.AssertDeepEquals(t, checkResults(transcript), map[string]bool{
+		`key "root key": key pair present`: true,
+		`key "root key": key parameters`:   false,
+	})
+	test.AssertContains(t, transcript.Checks[1].Detail, "expected curve P-384, found P-256")
+
+	text := string(transcript.text())
+	test.AssertContains(t, text, "FAIL key \"root key\": key parameters: expected curve P-384")
+	test.AssertContains(t, text, "Result: FAILED")
+	test.AssertContains(t, text, "Signed off by:\n  Alice: ")
+}
+
+func TestVerifyConfigValidate(t *testing.T) {
+	existing := path.Join(t.TempDir(), "exists")
+	err := os.WriteFile(existing, nil, 0644)
+	test.AssertNotError(t, err, "failed to write file")
+
+	base := `
+ceremony-type: verify
+pkcs11:
+    module: module
+keys:
+    - label: key
+      key:
+          type: ecdsa
+          ecdsa-curve: P-384
+operators:
+    - Alice
+outputs:
+    transcript-path: transcript.json
+`
+	cases := []struct {
+		name          string
+		config        string
+		expectedError string
+	}{
+		{
+			name:   "good config",
+			config: base,
+		},
+		{
+			name:          "no pkcs11.module",
+			config:        strings.Replace(base, "module: module", "module: ''", 1),
+			expectedError: "pkcs11.module is required",
+		},
+		{
+			name:          "no keys or certificates",
+			config:        strings.Replace(base, "keys:", "unused-keys:", 1),
+			expectedError: "field unused-keys not found",
+		},
+		{
+			name:          "bad key",
+			config:        strings.Replace(base, "P-384", "P-123", 1),
+			expectedError: "key.ecdsa-curve can only be",
+		},
+		{
+			name:          "no operators",
+			config:        strings.Replace(base, "    - Alice\n", "", 1),
+			expectedError: "at least one operator is required",
+		},
+		{
+			name:          "existing transcript",
+			config:        strings.Replace(base, "transcript.json", existing, 1),
+			expectedError: "outputs.transcript-path is \"" + existing + "\", which already exists",
+		},
+		{
+			name:          "bad transcript format",
+			config:        base + "    transcript-format: yaml\n",
+			expectedError: "outputs.transcript-format can only be 'json' or 'text'",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var config verifyConfig
+			err := strictyaml.Unmarshal([]byte(tc.config), &config)
+			if err == nil {
+				err = config.validate()
+			}
+			if tc.expectedError == "" {
+				test.AssertNotError(t, err, "config failed validation")
+			} else {
+				test.AssertError(t, err, "config passed validation")
+				test.AssertContains(t, err.Error(), tc.expectedError)
+			}
+		})
+	}
+}
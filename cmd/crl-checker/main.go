@@ -1,6 +1,7 @@
 package notmain
 
 import (
+	"context"
 	"crypto/x509"
 	"encoding/json"
 	"flag"
@@ -9,6 +10,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -16,6 +18,11 @@ import (
 	"github.com/letsencrypt/boulder/core"
 	"github.com/letsencrypt/boulder/crl/checker"
 	"github.com/letsencrypt/boulder/crl/crl_x509"
+	bgrpc "github.com/letsencrypt/boulder/grpc"
+	"github.com/letsencrypt/boulder/issuance"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
 )
 
 func downloadShard(url string) (*crl_x509.RevocationList, error) {
@@ -40,28 +47,93 @@ func downloadShard(url string) (*crl_x509.RevocationList, error) {
 	return crl, nil
 }
 
+// readShard reads and parses a CRL from a file on disk, such as one written by
+// the crl-storer's local backend.
+func readShard(path string) (*crl_x509.RevocationList, error) {
+	crlBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading CRL bytes: %w", err)
+	}
+
+	crl, err := crl_x509.ParseRevocationList(crlBytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing CRL: %w", err)
+	}
+
+	return crl, nil
+}
+
+// auditConfig holds the configuration required to audit CRLs against the
+// contents of the database.
+type auditConfig struct {
+	CRLChecker struct {
+		TLS       cmd.TLSConfig
+		SAService *cmd.GRPCClientConfig
+	}
+}
+
+// audit compares the given complete CRL shards for an issuer against the
+// revoked certificates in the database, logs every discrepancy it finds, and
+// returns how many new ones there were.
+func audit(configFile string, issuer *x509.Certificate, shards []*crl_x509.RevocationList, logger blog.Logger) (int, error) {
+	var c auditConfig
+	err := cmd.ReadConfigFile(configFile, &c)
+	if err != nil {
+		return 0, fmt.Errorf("reading audit config: %w", err)
+	}
+
+	tlsConfig, err := c.CRLChecker.TLS.Load()
+	if err != nil {
+		return 0, fmt.Errorf("loading TLS config: %w", err)
+	}
+
+	clk := cmd.Clock()
+	conn, err := bgrpc.ClientSetup(c.CRLChecker.SAService, tlsConfig, metrics.NoopRegisterer, clk)
+	if err != nil {
+		return 0, fmt.Errorf("connecting to SA: %w", err)
+	}
+	defer conn.Close()
+	sac := sapb.NewStorageAuthorityReadOnlyClient(conn)
+
+	res, err := checker.Audit(context.Background(), sac, issuance.GetIssuerNameID(issuer), shards, clk.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	for _, serial := range res.Missing {
+		logger.Errf("revoked serial missing from all shards: %s", serial)
+	}
+	for _, serial := range res.Extra {
+		logger.Errf("serial in shards is not revoked: %s", serial)
+	}
+	for _, mismatch := range res.ReasonMismatches {
+		logger.Errf("reason code mismatch: %s", mismatch)
+	}
+	// Serials seen in multiple shards have already been reported while
+	// validating each shard, so don't count them twice.
+	return res.Count() - len(res.Duplicated), nil
+}
+
 func main() {
 	urlFile := flag.String("crls", "", "path to a file containing a JSON Array of CRL URLs")
 	issuerFile := flag.String("issuer", "", "path to an issuer certificate on disk, required, '-' to disable validation")
 	ageLimitStr := flag.String("ageLimit", "168h", "maximum allowable age of a CRL shard")
 	emitRevoked := flag.Bool("emitRevoked", false, "emit revoked serial numbers on stdout, one per line, hex-encoded")
 	save := flag.Bool("save", false, "save CRLs to files named after the URL")
+	crlBase := flag.String("crlBase", "", "base URL from which to download every shard of the issuer's CRL, instead of -crls")
+	numShards := flag.Int("numShards", 0, "number of shards to download from -crlBase")
+	crlDir := flag.String("crlDir", "", "directory written by the crl-storer's local backend from which to read every shard of the issuer's CRL, instead of -crls")
+	auditConfigFile := flag.String("audit", "", "path to a JSON config with an SA client, to check that every revoked, unexpired serial from the issuer appears in exactly one shard")
 	flag.Parse()
 
 	logger := cmd.NewLogger(cmd.SyslogConfig{StdoutLevel: 6, SyslogLevel: -1})
 
-	urlFileContents, err := os.ReadFile(*urlFile)
-	cmd.FailOnError(err, "Reading CRL URLs file")
-
-	var urls []string
-	err = json.Unmarshal(urlFileContents, &urls)
-	cmd.FailOnError(err, "Parsing JSON Array of CRL URLs")
-
 	if *issuerFile == "" {
 		cmd.Fail("-issuer is required, but may be '-' to disable validation")
 	}
 
 	var issuer *x509.Certificate
+	var err error
 	if *issuerFile != "-" {
 		issuer, err = core.LoadCert(*issuerFile)
 		cmd.FailOnError(err, "Loading issuer certificate")
@@ -69,6 +141,36 @@ func main() {
 		logger.Warning("CRL signature validation disabled")
 	}
 
+	if *auditConfigFile != "" && issuer == nil {
+		cmd.Fail("-audit requires an -issuer")
+	}
+
+	// Each shard is named by a URL to download, or a path to read from disk.
+	var urls []string
+	fetch := downloadShard
+	switch {
+	case *crlDir != "":
+		if issuer == nil {
+			cmd.Fail("-crlDir requires an -issuer")
+		}
+		urls, err = filepath.Glob(filepath.Join(*crlDir, fmt.Sprint(issuance.GetIssuerNameID(issuer)), "*.crl"))
+		cmd.FailOnError(err, "Listing CRL directory")
+		fetch = readShard
+	case *crlBase != "":
+		if issuer == nil || *numShards <= 0 {
+			cmd.Fail("-crlBase requires an -issuer and a positive -numShards")
+		}
+		for i := 0; i < *numShards; i++ {
+			urls = append(urls, issuance.ShardedCRLURL(*crlBase, issuance.GetIssuerNameID(issuer), int64(i)))
+		}
+	default:
+		urlFileContents, err := os.ReadFile(*urlFile)
+		cmd.FailOnError(err, "Reading CRL URLs file")
+
+		err = json.Unmarshal(urlFileContents, &urls)
+		cmd.FailOnError(err, "Parsing JSON Array of CRL URLs")
+	}
+
 	ageLimit, err := time.ParseDuration(*ageLimitStr)
 	cmd.FailOnError(err, "Parsing age limit")
 
@@ -76,8 +178,9 @@ func main() {
 	seenSerials := make(map[string]struct{})
 	totalBytes := 0
 	oldestTimestamp := time.Time{}
+	var shards []*crl_x509.RevocationList
 	for _, u := range urls {
-		crl, err := downloadShard(u)
+		crl, err := fetch(u)
 		if err != nil {
 			errCount += 1
 			logger.Errf("fetching CRL %q failed: %s", u, err)
@@ -107,6 +210,13 @@ func main() {
 			continue
 		}
 
+		// Delta CRLs are validated, but don't contain every revoked serial and
+		// always repeat entries from the complete CRL which they update.
+		if crl.IsDelta() {
+			continue
+		}
+		shards = append(shards, crl)
+
 		if oldestTimestamp.IsZero() || crl.ThisUpdate.Before(oldestTimestamp) {
 			oldestTimestamp = crl.ThisUpdate
 		}
@@ -128,6 +238,15 @@ func main() {
 		}
 	}
 
+	if *auditConfigFile != "" {
+		if errCount != 0 {
+			logger.Warning("Some shards failed to validate, so the audit may report spurious missing serials")
+		}
+		discrepancies, err := audit(*auditConfigFile, issuer, shards, logger)
+		cmd.FailOnError(err, "Auditing CRLs")
+		errCount += discrepancies
+	}
+
 	if errCount != 0 {
 		cmd.Fail(fmt.Sprintf("Encountered %d errors", errCount))
 	}
@@ -29,6 +29,21 @@ type Config struct {
 		// them.
 		IssuerCerts []string `validate:"min=1,dive,required"`
 
+		// Backend selects where validated CRLs are written: "s3" (the default),
+		// "local" to write them beneath LocalDir, or "http" to PUT them beneath
+		// HTTPPutURL.
+		Backend string `validate:"omitempty,oneof=s3 local http"`
+		// LocalDir is the directory beneath which CRLs are written when using the
+		// "local" backend. Each CRL is replaced atomically, and prior versions
+		// are kept alongside it with their CRL Number as a suffix. The directory
+		// must already exist.
+		LocalDir string `validate:"required_if=Backend local"`
+		// HTTPPutURL is the base URL to which CRLs are uploaded with HTTP PUT
+		// requests when using the "http" backend. Each complete CRL's path
+		// relative to it is "<issuerNameID>/<shardIdx>.crl", and each Delta
+		// CRL's is "<issuerNameID>/<shardIdx>-delta.crl".
+		HTTPPutURL string `validate:"required_if=Backend http,omitempty,url"`
+
 		// S3Endpoint is the URL at which the S3-API-compatible object storage
 		// service can be reached. This can be used to point to a non-Amazon storage
 		// service, or to point to a fake service for testing. It should be left
@@ -67,6 +82,33 @@ func (log awsLogger) Logf(c awsl.Classification, format string, v ...interface{}
 	}
 }
 
+// s3Backend returns a backend which uploads CRLs to the configured S3 bucket.
+func s3Backend(c Config, logger blog.Logger) storer.Backend {
+	// Load the "default" AWS configuration, but override the set of config and
+	// credential files it reads from to just those specified in our JSON config,
+	// to ensure that it's not accidentally reading anything from the homedir or
+	// its other default config locations.
+	awsConfig, err := config.LoadDefaultConfig(
+		context.Background(),
+		config.WithSharedConfigFiles([]string{c.CRLStorer.AWSConfigFile}),
+		config.WithSharedCredentialsFiles([]string{c.CRLStorer.AWSCredsFile}),
+		config.WithHTTPClient(new(http.Client)),
+		config.WithLogger(awsLogger{logger}),
+		config.WithClientLogMode(aws.LogRequestEventMessage|aws.LogResponseEventMessage),
+	)
+	cmd.FailOnError(err, "Failed to load AWS config")
+
+	s3opts := make([]func(*s3.Options), 0)
+	if c.CRLStorer.S3Endpoint != "" {
+		s3opts = append(
+			s3opts,
+			s3.WithEndpointResolver(s3.EndpointResolverFromURL(c.CRLStorer.S3Endpoint)),
+			func(o *s3.Options) { o.UsePathStyle = true },
+		)
+	}
+	return storer.NewS3Backend(s3.NewFromConfig(awsConfig, s3opts...), c.CRLStorer.S3Bucket)
+}
+
 func main() {
 	configFile := flag.String("config", "", "File path to the configuration file for this service")
 	flag.Parse()
@@ -97,31 +139,19 @@ func main() {
 		issuers = append(issuers, cert)
 	}
 
-	// Load the "default" AWS configuration, but override the set of config and
-	// credential files it reads from to just those specified in our JSON config,
-	// to ensure that it's not accidentally reading anything from the homedir or
-	// its other default config locations.
-	awsConfig, err := config.LoadDefaultConfig(
-		context.Background(),
-		config.WithSharedConfigFiles([]string{c.CRLStorer.AWSConfigFile}),
-		config.WithSharedCredentialsFiles([]string{c.CRLStorer.AWSCredsFile}),
-		config.WithHTTPClient(new(http.Client)),
-		config.WithLogger(awsLogger{logger}),
-		config.WithClientLogMode(aws.LogRequestEventMessage|aws.LogResponseEventMessage),
-	)
-	cmd.FailOnError(err, "Failed to load AWS config")
-
-	s3opts := make([]func(*s3.Options), 0)
-	if c.CRLStorer.S3Endpoint != "" {
-		s3opts = append(
-			s3opts,
-			s3.WithEndpointResolver(s3.EndpointResolverFromURL(c.CRLStorer.S3Endpoint)),
-			func(o *s3.Options) { o.UsePathStyle = true },
-		)
+	var backend storer.Backend
+	switch c.CRLStorer.Backend {
+	case "local":
+		backend, err = storer.NewLocalBackend(c.CRLStorer.LocalDir)
+		cmd.FailOnError(err, "Failed to set up local CRL directory")
+	case "http":
+		backend, err = storer.NewHTTPBackend(new(http.Client), c.CRLStorer.HTTPPutURL)
+		cmd.FailOnError(err, "Failed to set up HTTP CRL backend")
+	default:
+		backend = s3Backend(c, logger)
 	}
-	s3client := s3.NewFromConfig(awsConfig, s3opts...)
 
-	csi, err := storer.New(issuers, s3client, c.CRLStorer.S3Bucket, scope, logger, clk)
+	csi, err := storer.New(issuers, backend, scope, logger, clk)
 	cmd.FailOnError(err, "Failed to create CRLStorer impl")
 
 	start, stop, err := bgrpc.NewServer(c.CRLStorer.GRPC).Add(
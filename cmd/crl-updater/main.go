@@ -11,6 +11,7 @@ import (
 	"github.com/letsencrypt/boulder/config"
 	cspb "github.com/letsencrypt/boulder/crl/storer/proto"
 	"github.com/letsencrypt/boulder/crl/updater"
+	cupb "github.com/letsencrypt/boulder/crl/updater/proto"
 	"github.com/letsencrypt/boulder/features"
 	bgrpc "github.com/letsencrypt/boulder/grpc"
 	"github.com/letsencrypt/boulder/issuance"
@@ -24,6 +25,12 @@ type Config struct {
 		// TLS client certificate, private key, and trusted root bundle.
 		TLS cmd.TLSConfig
 
+		// GRPC optionally configures a gRPC server on which the updater accepts
+		// revocation events from the RA, so that the affected CRL shards can be
+		// regenerated promptly rather than at the next UpdatePeriod. It is not
+		// started in runOnce mode.
+		GRPC *cmd.GRPCServerConfig
+
 		SAService           *cmd.GRPCClientConfig
 		CRLGeneratorService *cmd.GRPCClientConfig
 		CRLStorerService    *cmd.GRPCClientConfig
@@ -33,8 +40,19 @@ type Config struct {
 		// publish one set of NumShards CRL shards for each issuer in this list.
 		IssuerCerts []string `validate:"min=1,dive,required"`
 
+		// IssuerStatesFile is the path to a YAML file, shared with boulder-ca and
+		// ocsp-responder, mapping issuer certificate paths to the lifecycle
+		// state of that issuer: "active", "retiring" or "archived". If it is
+		// set, every path in IssuerCerts must be listed; if not, all issuers
+		// are active. CRLs continue to be published for retiring issuers, so
+		// that they can be marked archived once their last certificate has
+		// expired; no CRLs are published for archived issuers.
+		IssuerStatesFile string `validate:"omitempty"`
+
 		// NumShards is the number of shards into which each issuer's "full and
-		// complete" CRL will be split.
+		// complete" CRL will be split. It must match the crlShards of any
+		// issuance profile which includes CRL distribution points, or the CA
+		// will refuse to sign the CRLs.
 		// WARNING: When this number is changed, the "JSON Array of CRL URLs" field
 		// in CCADB MUST be updated.
 		NumShards int `validate:"min=1"`
@@ -80,6 +98,26 @@ type Config struct {
 		// less than the UpdatePeriod.
 		UpdateOffset config.Duration
 
+		// DeltaPeriod controls how frequently the crl-updater publishes Delta CRLs
+		// (RFC 5280, Section 5.2.4) for every CRL shard, between full updates.
+		// Each delta contains the certificates revoked, or whose revocation
+		// reason changed, since the last complete CRL this process published for
+		// that shard. As that is only known in memory, a full update is run
+		// immediately at startup, and complete CRLs only point at their deltas
+		// once this process has a base for them. If zero, Delta CRLs are
+		// disabled. This value must be strictly less than the UpdatePeriod, and
+		// the CA's lifespanDeltaCRL must be longer than it.
+		DeltaPeriod config.Duration `validate:"-"`
+
+		// DebouncePeriod controls how long the crl-updater waits after the first
+		// revocation event for a CRL shard, coalescing any further events for it,
+		// before regenerating that shard. Queued shards are checked once every
+		// DebouncePeriod, so a shard is regenerated between one and two
+		// DebouncePeriods after its first event. Only used if GRPC is configured,
+		// in which case it defaults to one minute. This value must be strictly
+		// less than the UpdatePeriod.
+		DebouncePeriod config.Duration `validate:"-"`
+
 		// MaxParallelism controls how many workers may be running in parallel.
 		// A higher value reduces the total time necessary to update all CRL shards
 		// that this updater is responsible for, but also increases the memory used
@@ -122,19 +160,26 @@ func main() {
 	logger.Info(cmd.VersionString())
 	clk := cmd.Clock()
 
-	issuers := make([]*issuance.Certificate, 0, len(c.CRLUpdater.IssuerCerts))
-	for _, filepath := range c.CRLUpdater.IssuerCerts {
-		cert, err := issuance.LoadCertificate(filepath)
-		cmd.FailOnError(err, "Failed to load issuer cert")
-		issuers = append(issuers, cert)
+	var issuerStates map[string]issuance.IssuerState
+	if c.CRLUpdater.IssuerStatesFile != "" {
+		issuerStates, err = issuance.LoadIssuerStates(c.CRLUpdater.IssuerStatesFile, c.CRLUpdater.IssuerCerts)
+		cmd.FailOnError(err, "Failed to load issuer states")
 	}
 
+	issuers, err := issuance.LoadCertificates(c.CRLUpdater.IssuerCerts, issuerStates)
+	cmd.FailOnError(err, "Failed to load issuer certs")
+
 	if c.CRLUpdater.ShardWidth.Duration == 0 {
 		c.CRLUpdater.ShardWidth.Duration = 16 * time.Hour
 	}
 	if c.CRLUpdater.LookbackPeriod.Duration == 0 {
 		c.CRLUpdater.LookbackPeriod.Duration = 24 * time.Hour
 	}
+	if c.CRLUpdater.GRPC == nil {
+		c.CRLUpdater.DebouncePeriod.Duration = 0
+	} else if c.CRLUpdater.DebouncePeriod.Duration == 0 {
+		c.CRLUpdater.DebouncePeriod.Duration = time.Minute
+	}
 
 	saConn, err := bgrpc.ClientSetup(c.CRLUpdater.SAService, tlsConfig, scope, clk)
 	cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to SA")
@@ -155,6 +200,8 @@ func main() {
 		c.CRLUpdater.LookbackPeriod.Duration,
 		c.CRLUpdater.UpdatePeriod.Duration,
 		c.CRLUpdater.UpdateOffset.Duration,
+		c.CRLUpdater.DeltaPeriod.Duration,
+		c.CRLUpdater.DebouncePeriod.Duration,
 		c.CRLUpdater.MaxParallelism,
 		sac,
 		cac,
@@ -166,7 +213,22 @@ func main() {
 	cmd.FailOnError(err, "Failed to create crl-updater")
 
 	ctx, cancel := context.WithCancel(context.Background())
-	go cmd.CatchSignals(logger, cancel)
+	shutdown := cancel
+
+	if c.CRLUpdater.GRPC != nil && !*runOnce {
+		start, stop, err := bgrpc.NewServer(c.CRLUpdater.GRPC).Add(
+			&cupb.CRLUpdater_ServiceDesc, u).Build(tlsConfig, scope, clk)
+		cmd.FailOnError(err, "Unable to setup CRLUpdater gRPC server")
+		go func() {
+			cmd.FailOnError(start(), "CRLUpdater gRPC service failed")
+		}()
+		shutdown = func() {
+			stop()
+			cancel()
+		}
+	}
+
+	go cmd.CatchSignals(logger, shutdown)
 
 	if *runOnce {
 		err = u.Tick(ctx, clk.Now())
@@ -0,0 +1,138 @@
+package notmain
+
+import (
+	"context"
+	"flag"
+	"os"
+	"time"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/config"
+	"github.com/letsencrypt/boulder/features"
+	bgrpc "github.com/letsencrypt/boulder/grpc"
+	"github.com/letsencrypt/boulder/ocsp/presigner"
+	rapb "github.com/letsencrypt/boulder/ra/proto"
+	rocsp_config "github.com/letsencrypt/boulder/rocsp/config"
+	"github.com/letsencrypt/boulder/sa"
+)
+
+type Config struct {
+	OCSPPresigner struct {
+		DebugAddr string `validate:"required,hostname_port"`
+		TLS       cmd.TLSConfig
+
+		// DB is used to find unexpired certificates. Read-only access to the
+		// certificateStatus table is sufficient.
+		DB cmd.DBConfig
+
+		// Redis holds the cached responses which are checked and refreshed.
+		// This configuration should allow for both read and write access.
+		Redis rocsp_config.RedisConfig
+
+		// RAService is used to sign fresh responses.
+		RAService *cmd.GRPCClientConfig `validate:"required"`
+
+		// RefreshAge is the age at which a cached response is re-signed. It
+		// should be comfortably below ocsp-responder's LiveSigningPeriod, so
+		// that responses are refreshed here rather than on the request path.
+		// Defaults to 48h.
+		RefreshAge config.Duration `validate:"-"`
+
+		// PassInterval is the minimum time between the starts of two passes
+		// over all unexpired certificates. Defaults to 1h.
+		PassInterval config.Duration `validate:"-"`
+
+		// BatchSize is the number of certificateStatus rows read at a time.
+		BatchSize int `validate:"required,min=1"`
+
+		// LookupsPerSecond limits the rate of reads from Redis.
+		LookupsPerSecond int `validate:"required,min=1"`
+
+		// SignsPerSecond limits the rate of requests to the RA for fresh
+		// responses, and ParallelSigns limits how many may be in flight at
+		// once.
+		SignsPerSecond int `validate:"required,min=1"`
+		ParallelSigns  int `validate:"min=0"`
+
+		// MaxQueued limits how many stale responses are held in memory
+		// awaiting signing. Once it is reached, reading certificateStatus
+		// pauses until some have been signed, so responses are only signed
+		// in expiry order among those held at once. Defaults to 100,000.
+		MaxQueued int `validate:"min=0"`
+
+		Features map[string]bool
+	}
+
+	Syslog  cmd.SyslogConfig
+	Beeline cmd.BeelineConfig
+}
+
+func main() {
+	configFile := flag.String("config", "", "File path to the configuration file for this service")
+	flag.Parse()
+	if *configFile == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var c Config
+	err := cmd.ReadConfigFile(*configFile, &c)
+	cmd.FailOnError(err, "Reading JSON config file into config structure")
+
+	conf := c.OCSPPresigner
+	err = features.Set(conf.Features)
+	cmd.FailOnError(err, "Failed to set feature flags")
+
+	scope, logger := cmd.StatsAndLogging(c.Syslog, conf.DebugAddr)
+	defer logger.AuditPanic()
+	logger.Info(cmd.VersionString())
+
+	clk := cmd.Clock()
+
+	dbMap, err := sa.InitWrappedDb(conf.DB, scope, logger)
+	cmd.FailOnError(err, "Failed to initialize database client")
+
+	redisClient, err := rocsp_config.MakeClient(&conf.Redis, clk, scope)
+	cmd.FailOnError(err, "Could not make redis client")
+
+	tlsConfig, err := conf.TLS.Load()
+	cmd.FailOnError(err, "TLS config")
+
+	raConn, err := bgrpc.ClientSetup(conf.RAService, tlsConfig, scope, clk)
+	cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to RA")
+	rac := rapb.NewRegistrationAuthorityClient(raConn)
+
+	refreshAge := conf.RefreshAge.Duration
+	if refreshAge == 0 {
+		refreshAge = 48 * time.Hour
+	}
+	passInterval := conf.PassInterval.Duration
+	if passInterval == 0 {
+		passInterval = time.Hour
+	}
+
+	p, err := presigner.New(
+		dbMap,
+		redisClient,
+		rac,
+		refreshAge,
+		passInterval,
+		conf.BatchSize,
+		conf.LookupsPerSecond,
+		conf.SignsPerSecond,
+		conf.ParallelSigns,
+		conf.MaxQueued,
+		scope,
+		clk,
+		logger,
+	)
+	cmd.FailOnError(err, "Failed to create presigner")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go cmd.CatchSignals(logger, cancel)
+	p.Run(ctx)
+}
+
+func init() {
+	cmd.RegisterCommand("ocsp-presigner", main, &cmd.ConfigValidator{Config: &Config{}})
+}
@@ -2,6 +2,7 @@ package notmain
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jmhodges/clock"
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/letsencrypt/boulder/cmd"
@@ -44,6 +46,27 @@ type Config struct {
 		// are checked to ensure we're not responding for anyone else's certs.
 		IssuerCerts []string `validate:"min=1,dive,required"`
 
+		// IssuerStatesFile is the path to a YAML file, shared with boulder-ca and
+		// crl-updater, mapping issuer certificate paths to the lifecycle state
+		// of that issuer: "active", "retiring" or "archived". If it is set,
+		// every path in IssuerCerts must be listed; if not, all issuers are
+		// active. Responses continue to be served for retiring issuers.
+		// Requests for archived issuers' certificates are answered only from
+		// an "archive:" source in IssuerSources, and otherwise filtered out.
+		IssuerStatesFile string `validate:"omitempty"`
+
+		// IssuerSources optionally maps paths in IssuerCerts to the source of
+		// OCSP responses for that issuer, overriding the Redis and live signing
+		// path used for issuers which are not listed. A "file:" URL names a file
+		// of pre-signed responses in the format accepted by Source, which is
+		// suitable for legacy issuers with few certificates. An "archive:" URL
+		// names a directory of pre-signed responses, one per file, each named
+		// for the hex serial it covers with a ".der" suffix; this is suitable
+		// for retired issuers, and is the only source allowed for archived
+		// ones. Requests for issuers with the same URL are served by the same
+		// source. Ignored if Source is a file URL.
+		IssuerSources map[string]string `validate:"omitempty,dive,startswith=file:|startswith=archive:"`
+
 		Path string
 
 		// ListenAddress is the address:port on which to listen for incoming
@@ -158,78 +181,22 @@ as generated by Boulder's ceremony command.
 	var source responder.Source
 
 	if strings.HasPrefix(c.OCSPResponder.Source, "file:") {
-		url, err := url.Parse(c.OCSPResponder.Source)
+		filename, err := urlPath(c.OCSPResponder.Source)
 		cmd.FailOnError(err, "Source was not a URL")
-		filename := url.Path
-		// Go interprets cwd-relative file urls (file:test/foo.txt) as having the
-		// relative part of the path in the 'Opaque' field.
-		if filename == "" {
-			filename = url.Opaque
-		}
 		source, err = responder.NewMemorySourceFromFile(filename, logger)
-		cmd.FailOnError(err, fmt.Sprintf("Couldn't read file: %s", url.Path))
+		cmd.FailOnError(err, fmt.Sprintf("Couldn't read file: %s", filename))
 	} else {
-		// Set up the redis source and the combined multiplex source.
-		rocspRWClient, err := rocsp_config.MakeClient(&c.OCSPResponder.Redis, clk, scope)
-		cmd.FailOnError(err, "Could not make redis client")
-
-		err = rocspRWClient.Ping(context.Background())
-		cmd.FailOnError(err, "pinging Redis")
-
-		liveSigningPeriod := c.OCSPResponder.LiveSigningPeriod.Duration
-		if liveSigningPeriod == 0 {
-			liveSigningPeriod = 60 * time.Hour
-		}
-
-		tlsConfig, err := c.OCSPResponder.TLS.Load()
-		cmd.FailOnError(err, "TLS config")
-
-		raConn, err := bgrpc.ClientSetup(c.OCSPResponder.RAService, tlsConfig, scope, clk)
-		cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to RA")
-		rac := rapb.NewRegistrationAuthorityClient(raConn)
-
-		maxInflight := c.OCSPResponder.MaxInflightSignings
-		if maxInflight == 0 {
-			maxInflight = 1000
-		}
-		liveSource := live.New(rac, int64(maxInflight), c.OCSPResponder.MaxSigningWaiters)
-
-		rocspSource, err := redis_responder.NewRedisSource(rocspRWClient, liveSource, liveSigningPeriod, clk, scope, logger)
-		cmd.FailOnError(err, "Could not create redis source")
-
-		var dbMap *db.WrappedMap
-		if c.OCSPResponder.DB != (cmd.DBConfig{}) {
-			dbMap, err = sa.InitWrappedDb(c.OCSPResponder.DB, scope, logger)
-			cmd.FailOnError(err, "While initializing dbMap")
-		}
-
-		var sac sapb.StorageAuthorityReadOnlyClient
-		if c.OCSPResponder.SAService != nil {
-			saConn, err := bgrpc.ClientSetup(c.OCSPResponder.SAService, tlsConfig, scope, clk)
-			cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to SA")
-			sac = sapb.NewStorageAuthorityReadOnlyClient(saConn)
-		}
-
-		source, err = redis_responder.NewCheckedRedisSource(rocspSource, dbMap, sac, scope, logger)
-		cmd.FailOnError(err, "Could not create checkedRedis source")
-
-		// Load the certificate from the file path.
-		issuerCerts := make([]*issuance.Certificate, len(c.OCSPResponder.IssuerCerts))
-		for i, issuerFile := range c.OCSPResponder.IssuerCerts {
-			issuerCert, err := issuance.LoadCertificate(issuerFile)
-			cmd.FailOnError(err, "Could not load issuer cert")
-			issuerCerts[i] = issuerCert
+		// Load the certificates from their file paths.
+		var issuerStates map[string]issuance.IssuerState
+		if c.OCSPResponder.IssuerStatesFile != "" {
+			issuerStates, err = issuance.LoadIssuerStates(c.OCSPResponder.IssuerStatesFile, c.OCSPResponder.IssuerCerts)
+			cmd.FailOnError(err, "Could not load issuer states")
 		}
 
-		source, err = responder.NewFilterSource(
-			issuerCerts,
-			c.OCSPResponder.RequiredSerialPrefixes,
-			source,
-			scope,
-			logger,
-			clk,
-		)
-		cmd.FailOnError(err, "Could not create filtered source")
+		source, err = newIssuerSource(&c, issuerStates, func() responder.Source {
+			return newRedisSource(&c, clk, scope, logger)
+		}, clk, scope, logger)
+		cmd.FailOnError(err, "Could not create issuer source")
 	}
 
 	m := mux(c.OCSPResponder.Path, source, c.OCSPResponder.Timeout.Duration, scope, logger, c.OCSPResponder.LogSampleRate)
@@ -263,6 +230,169 @@ as generated by Boulder's ceremony command.
 	<-done
 }
 
+// newIssuerSource returns the Source for requests about certificates issued by
+// the issuers in IssuerCerts, routing each to its own source if IssuerSources
+// configures one and filtering out those for archived issuers which have no
+// archive. newRedis is only called if some issuer which is not archived needs
+// the Redis and live signing path.
+func newIssuerSource(c *Config, states map[string]issuance.IssuerState, newRedis func() responder.Source, clk clock.Clock, scope prometheus.Registerer, logger blog.Logger) (responder.Source, error) {
+	issuerCerts, err := issuance.LoadCertificates(c.OCSPResponder.IssuerCerts, states)
+	if err != nil {
+		return nil, fmt.Errorf("loading issuer certs: %w", err)
+	}
+
+	routes, archived, err := loadIssuerSources(c.OCSPResponder.IssuerCerts, states, c.OCSPResponder.IssuerSources, scope, logger)
+	if err != nil {
+		return nil, fmt.Errorf("loading issuer sources: %w", err)
+	}
+
+	var source responder.Source
+	if len(routes)-len(archived) < len(issuerCerts) {
+		source = newRedis()
+	}
+
+	if len(routes) > 0 {
+		source, err = responder.NewRoutingSource(routes, source, scope)
+		if err != nil {
+			return nil, fmt.Errorf("creating routing source: %w", err)
+		}
+	}
+
+	source, err = responder.NewFilterSource(
+		append(issuerCerts, archived...),
+		c.OCSPResponder.RequiredSerialPrefixes,
+		source,
+		scope,
+		logger,
+		clk,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating filtered source: %w", err)
+	}
+	return source, nil
+}
+
+// newRedisSource sets up the Redis source, which signs fresh responses via the
+// RA when the cached ones are stale, and checks them against the database or
+// SA if configured.
+func newRedisSource(c *Config, clk clock.Clock, scope prometheus.Registerer, logger blog.Logger) responder.Source {
+	rocspRWClient, err := rocsp_config.MakeClient(&c.OCSPResponder.Redis, clk, scope)
+	cmd.FailOnError(err, "Could not make redis client")
+
+	err = rocspRWClient.Ping(context.Background())
+	cmd.FailOnError(err, "pinging Redis")
+
+	liveSigningPeriod := c.OCSPResponder.LiveSigningPeriod.Duration
+	if liveSigningPeriod == 0 {
+		liveSigningPeriod = 60 * time.Hour
+	}
+
+	tlsConfig, err := c.OCSPResponder.TLS.Load()
+	cmd.FailOnError(err, "TLS config")
+
+	raConn, err := bgrpc.ClientSetup(c.OCSPResponder.RAService, tlsConfig, scope, clk)
+	cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to RA")
+	rac := rapb.NewRegistrationAuthorityClient(raConn)
+
+	maxInflight := c.OCSPResponder.MaxInflightSignings
+	if maxInflight == 0 {
+		maxInflight = 1000
+	}
+	liveSource := live.New(rac, int64(maxInflight), c.OCSPResponder.MaxSigningWaiters)
+
+	rocspSource, err := redis_responder.NewRedisSource(rocspRWClient, liveSource, liveSigningPeriod, clk, scope, logger)
+	cmd.FailOnError(err, "Could not create redis source")
+
+	var dbMap *db.WrappedMap
+	if c.OCSPResponder.DB != (cmd.DBConfig{}) {
+		dbMap, err = sa.InitWrappedDb(c.OCSPResponder.DB, scope, logger)
+		cmd.FailOnError(err, "While initializing dbMap")
+	}
+
+	var sac sapb.StorageAuthorityReadOnlyClient
+	if c.OCSPResponder.SAService != nil {
+		saConn, err := bgrpc.ClientSetup(c.OCSPResponder.SAService, tlsConfig, scope, clk)
+		cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to SA")
+		sac = sapb.NewStorageAuthorityReadOnlyClient(saConn)
+	}
+
+	source, err := redis_responder.NewCheckedRedisSource(rocspSource, dbMap, sac, scope, logger)
+	cmd.FailOnError(err, "Could not create checkedRedis source")
+	return source
+}
+
+// loadIssuerSources returns the Source configured for each issuer listed in
+// sources, which maps paths in issuerPaths to "file:" or "archive:" URLs.
+// Issuers configured with the same URL share a Source. Archived issuers may
+// only be configured with "archive:" URLs; their certificates are returned
+// separately, as they are loaded only so that requests for them can be routed
+// to their archives.
+func loadIssuerSources(issuerPaths []string, states map[string]issuance.IssuerState, sources map[string]string, scope prometheus.Registerer, logger blog.Logger) (map[*issuance.Certificate]responder.Source, []*issuance.Certificate, error) {
+	known := make(map[string]bool, len(issuerPaths))
+	for _, path := range issuerPaths {
+		known[path] = true
+	}
+
+	routes := make(map[*issuance.Certificate]responder.Source, len(sources))
+	byURL := make(map[string]responder.Source)
+	var archived []*issuance.Certificate
+	for path, sourceURL := range sources {
+		if !known[path] {
+			return nil, nil, fmt.Errorf("source %q configured for unknown issuer certificate %q", sourceURL, path)
+		}
+		if states[path] == issuance.StateArchived && !strings.HasPrefix(sourceURL, "archive:") {
+			return nil, nil, fmt.Errorf("source %q configured for archived issuer certificate %q is not an archive", sourceURL, path)
+		}
+
+		source, ok := byURL[sourceURL]
+		if !ok {
+			location, err := urlPath(sourceURL)
+			if err != nil {
+				return nil, nil, fmt.Errorf("parsing source %q for issuer certificate %q: %w", sourceURL, path, err)
+			}
+			switch {
+			case strings.HasPrefix(sourceURL, "file:"):
+				source, err = responder.NewMemorySourceFromFile(location, logger)
+			case strings.HasPrefix(sourceURL, "archive:"):
+				// Archive sources are labelled by directory, since several
+				// may be configured.
+				source, err = responder.NewArchiveSource(location, prometheus.WrapRegistererWith(prometheus.Labels{"archive": location}, scope))
+			default:
+				err = errors.New("unsupported scheme")
+			}
+			if err != nil {
+				return nil, nil, fmt.Errorf("loading source %q for issuer certificate %q: %w", sourceURL, path, err)
+			}
+			byURL[sourceURL] = source
+		}
+
+		cert, err := issuance.LoadCertificate(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		routes[cert] = source
+		if states[path] == issuance.StateArchived {
+			archived = append(archived, cert)
+		}
+	}
+	return routes, archived, nil
+}
+
+// urlPath returns the path named by a URL such as "file:/foo/bar.txt" or
+// "archive:test/foo".
+func urlPath(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	// Go interprets cwd-relative file urls (file:test/foo.txt) as having the
+	// relative part of the path in the 'Opaque' field.
+	if u.Path == "" {
+		return u.Opaque, nil
+	}
+	return u.Path, nil
+}
+
 // ocspMux partially implements the interface defined for http.ServeMux but doesn't implement
 // the path cleaning its Handler method does. Notably http.ServeMux will collapse repeated
 // slashes into a single slash which breaks the base64 encoding that is used in OCSP GET
@@ -2,15 +2,20 @@ package notmain
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/jmhodges/clock"
 	"golang.org/x/crypto/ocsp"
 
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/issuance"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/ocsp/responder"
@@ -68,3 +73,104 @@ func TestMux(t *testing.T) {
 		}
 	}
 }
+
+func TestLoadIssuerSources(t *testing.T) {
+	issuerA := "./testdata/test-ca.der.pem"
+	issuerB := "../../test/test-ca.pem"
+	issuerC := "../../test/test-ca2.pem"
+	paths := []string{issuerA, issuerB, issuerC}
+
+	respBytes, err := os.ReadFile("./testdata/ocsp.resp")
+	test.AssertNotError(t, err, "failed to read OCSP response")
+	dir := t.TempDir()
+	responsesFile := filepath.Join(dir, "responses.txt")
+	err = os.WriteFile(responsesFile, []byte(base64.StdEncoding.EncodeToString(respBytes)), 0600)
+	test.AssertNotError(t, err, "writing responses file")
+
+	routes, archived, err := loadIssuerSources(paths, nil, map[string]string{
+		issuerA: "file:" + responsesFile,
+		issuerB: "archive:" + dir,
+		issuerC: "archive:" + dir,
+	}, metrics.NoopRegisterer, blog.NewMock())
+	test.AssertNotError(t, err, "loading issuer sources")
+	test.AssertEquals(t, len(routes), 3)
+	test.AssertEquals(t, len(archived), 0)
+	sources := make(map[string]responder.Source)
+	for cert, source := range routes {
+		sources[string(cert.Raw)] = source
+	}
+	sourceFor := func(path string) responder.Source {
+		cert, err := issuance.LoadCertificate(path)
+		test.AssertNotError(t, err, "loading issuer certificate")
+		return sources[string(cert.Raw)]
+	}
+	// Issuers configured with the same URL share a source.
+	test.AssertEquals(t, sourceFor(issuerB), sourceFor(issuerC))
+	test.Assert(t, sourceFor(issuerA) != sourceFor(issuerB), "issuers with different URLs share a source")
+
+	_, _, err = loadIssuerSources(paths, nil, map[string]string{
+		"../../test/test-root.pem": "archive:" + dir,
+	}, metrics.NoopRegisterer, blog.NewMock())
+	test.AssertError(t, err, "loaded source for unknown issuer")
+
+	routes, archived, err = loadIssuerSources(paths, map[string]issuance.IssuerState{issuerB: issuance.StateArchived}, map[string]string{
+		issuerB: "archive:" + dir,
+	}, metrics.NoopRegisterer, blog.NewMock())
+	test.AssertNotError(t, err, "loading archive source for archived issuer")
+	test.AssertEquals(t, len(routes), 1)
+	test.AssertEquals(t, len(archived), 1)
+	test.AssertEquals(t, sources[string(archived[0].Raw)], sourceFor(issuerB))
+
+	_, _, err = loadIssuerSources(paths, map[string]issuance.IssuerState{issuerA: issuance.StateArchived}, map[string]string{
+		issuerA: "file:" + responsesFile,
+	}, metrics.NoopRegisterer, blog.NewMock())
+	test.AssertError(t, err, "loaded file source for archived issuer")
+
+	_, _, err = loadIssuerSources(paths, nil, map[string]string{
+		issuerB: "archive:" + responsesFile,
+	}, metrics.NoopRegisterer, blog.NewMock())
+	test.AssertError(t, err, "loaded archive source from a file")
+}
+
+func TestNewIssuerSourceArchived(t *testing.T) {
+	issuer := "./testdata/test-ca.der.pem"
+	activeIssuer := "../../test/test-ca2.pem"
+	reqBytes, err := os.ReadFile("./testdata/ocsp.req")
+	test.AssertNotError(t, err, "failed to read OCSP request")
+	req, err := ocsp.ParseRequest(reqBytes)
+	test.AssertNotError(t, err, "failed to parse OCSP request")
+	respBytes, err := os.ReadFile("./testdata/ocsp.resp")
+	test.AssertNotError(t, err, "failed to read OCSP response")
+	dir := t.TempDir()
+	err = os.WriteFile(filepath.Join(dir, core.SerialToString(req.SerialNumber)+".der"), respBytes, 0600)
+	test.AssertNotError(t, err, "writing archived response")
+
+	clk := clock.NewFake()
+	clk.Set(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	states := map[string]issuance.IssuerState{issuer: issuance.StateArchived}
+	newRedis := func() responder.Source {
+		t.Error("set up Redis source for an archived issuer")
+		return nil
+	}
+
+	// A retired issuer's responses are served from its archive.
+	var c Config
+	c.OCSPResponder.IssuerCerts = []string{issuer}
+	c.OCSPResponder.IssuerSources = map[string]string{issuer: "archive:" + dir}
+	source, err := newIssuerSource(&c, states, newRedis, clk, metrics.NoopRegisterer, blog.NewMock())
+	test.AssertNotError(t, err, "creating source for archived issuer")
+	resp, err := source.Response(context.Background(), req)
+	test.AssertNotError(t, err, "getting response for archived issuer")
+	test.AssertDeepEquals(t, resp.Raw, respBytes)
+
+	// Without an archive, requests for its certificates are filtered out.
+	responsesFile := filepath.Join(dir, "responses.txt")
+	err = os.WriteFile(responsesFile, []byte(base64.StdEncoding.EncodeToString(respBytes)), 0600)
+	test.AssertNotError(t, err, "writing responses file")
+	c.OCSPResponder.IssuerCerts = []string{issuer, activeIssuer}
+	c.OCSPResponder.IssuerSources = map[string]string{activeIssuer: "file:" + responsesFile}
+	source, err = newIssuerSource(&c, states, newRedis, clk, metrics.NoopRegisterer, blog.NewMock())
+	test.AssertNotError(t, err, "creating source without archive")
+	_, err = source.Response(context.Background(), req)
+	test.AssertErrorIs(t, err, responder.ErrNotFound)
+}
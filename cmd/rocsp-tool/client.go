@@ -1,7 +1,9 @@
 package notmain
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"os"
@@ -14,6 +16,7 @@ import (
 	"github.com/letsencrypt/boulder/db"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/rocsp"
+	rocsp_config "github.com/letsencrypt/boulder/rocsp/config"
 	"github.com/letsencrypt/boulder/sa"
 	"github.com/letsencrypt/boulder/test/ocsp/helper"
 	"golang.org/x/crypto/ocsp"
@@ -294,3 +297,95 @@ func (cl *client) storeResponse(ctx context.Context, respBytes []byte) error {
 	cl.logger.Infof("retrieved %s", helper.PrettyResponse(parsedRetrievedResponse))
 	return nil
 }
+
+// responseStore is somewhere OCSP responses can be migrated to.
+type responseStore interface {
+	StoreResponse(ctx context.Context, resp *ocsp.Response) error
+	GetResponse(ctx context.Context, serial string) ([]byte, error)
+}
+
+// primaryOnly returns a copy of the given config with reads from replicas
+// turned off. migrate verifies each copy by reading it back from the
+// destination, which a replica may not have caught up with yet, or may hold
+// a stale copy from an earlier run.
+func primaryOnly(c *rocsp_config.RedisConfig) *rocsp_config.RedisConfig {
+	primary := *c
+	primary.ReadOnly = false
+	primary.RouteByLatency = false
+	primary.RouteRandomly = false
+	return &primary
+}
+
+// migrateResult counts the responses seen by a migration, by outcome.
+type migrateResult struct {
+	copied  int
+	expired int
+	failed  int
+}
+
+// migrate copies every unexpired OCSP response in Redis to dest, for instance
+// to move from a Ring to a Cluster. Each copy is verified by reading it back
+// from dest and comparing it to the original, so dest must read from
+// primaries; see primaryOnly. Failures are logged, and counted
+// rather than stopping the migration, which can safely be rerun; migrate only
+// returns early if scanning the source fails.
+func (cl *client) migrate(ctx context.Context, dest responseStore) (migrateResult, error) {
+	var result migrateResult
+	for r := range cl.redis.ScanResponses(ctx, "*") {
+		if r.Err != nil {
+			return result, fmt.Errorf("scanning responses: %w", r.Err)
+		}
+
+		err := cl.migrateResponse(ctx, dest, r.Serial, r.Body)
+		if err != nil {
+			var expired expiredError
+			if errors.As(err, &expired) {
+				result.expired++
+				continue
+			}
+			cl.logger.Errf("migrating response for %s: %s", r.Serial, err)
+			result.failed++
+			continue
+		}
+
+		result.copied++
+		if result.copied%10000 == 0 {
+			cl.logger.Infof("migrated %d responses so far", result.copied)
+		}
+	}
+	return result, nil
+}
+
+// migrateResponse stores the response found under the given key in dest, and
+// verifies that it can be retrieved intact.
+func (cl *client) migrateResponse(ctx context.Context, dest responseStore, key string, respBytes []byte) error {
+	resp, err := ocsp.ParseResponse(respBytes, nil)
+	if err != nil {
+		return fmt.Errorf("parsing response: %w", err)
+	}
+
+	serial := core.SerialToString(resp.SerialNumber)
+	if serial != key {
+		return fmt.Errorf("response is for serial %s", serial)
+	}
+	if resp.NextUpdate.Before(cl.clk.Now()) {
+		return expiredError{
+			serial: serial,
+			ago:    cl.clk.Now().Sub(resp.NextUpdate),
+		}
+	}
+
+	err = dest.StoreResponse(ctx, resp)
+	if err != nil {
+		return fmt.Errorf("storing response: %w", err)
+	}
+
+	retrieved, err := dest.GetResponse(ctx, serial)
+	if err != nil {
+		return fmt.Errorf("verifying response: %w", err)
+	}
+	if !bytes.Equal(retrieved, respBytes) {
+		return errors.New("verifying response: stored response differs from original")
+	}
+	return nil
+}
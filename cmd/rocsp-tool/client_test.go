@@ -2,6 +2,7 @@ package notmain
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"testing"
@@ -15,6 +16,7 @@ import (
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/rocsp"
+	rocsp_config "github.com/letsencrypt/boulder/rocsp/config"
 	"github.com/letsencrypt/boulder/sa"
 	"github.com/letsencrypt/boulder/test"
 	"github.com/letsencrypt/boulder/test/vars"
@@ -159,3 +161,78 @@ func TestLoadFromDB(t *testing.T) {
 		t.Fatalf("loading from DB: %s", err)
 	}
 }
+
+// mapStore is a responseStore backed by a map, which can be made to corrupt
+// the responses it stores.
+type mapStore struct {
+	responses map[string][]byte
+	corrupt   bool
+}
+
+func (m *mapStore) StoreResponse(_ context.Context, resp *ocsp.Response) error {
+	stored := append([]byte{}, resp.Raw...)
+	if m.corrupt {
+		stored[len(stored)-1]++
+	}
+	m.responses[core.SerialToString(resp.SerialNumber)] = stored
+	return nil
+}
+
+func (m *mapStore) GetResponse(_ context.Context, serial string) ([]byte, error) {
+	resp, ok := m.responses[serial]
+	if !ok {
+		return nil, rocsp.ErrRedisNotFound
+	}
+	return resp, nil
+}
+
+func TestMigrateResponse(t *testing.T) {
+	clk := clock.NewFake()
+	issuer, err := core.LoadCert("../../test/hierarchy/int-e1.cert.pem")
+	test.AssertNotError(t, err, "loading int-e1")
+	issuerKey, err := test.LoadSigner("../../test/hierarchy/int-e1.key.pem")
+	test.AssertNotError(t, err, "loading int-e1 key ")
+	response, err := ocsp.CreateResponse(issuer, issuer, ocsp.Response{
+		SerialNumber: big.NewInt(1337),
+		Status:       0,
+		ThisUpdate:   clk.Now(),
+		NextUpdate:   clk.Now().Add(time.Hour),
+	}, issuerKey)
+	test.AssertNotError(t, err, "creating OCSP response")
+	serial := core.SerialToString(big.NewInt(1337))
+
+	cl := client{clk: clk, logger: blog.NewMock()}
+	dest := &mapStore{responses: make(map[string][]byte)}
+
+	err = cl.migrateResponse(context.Background(), dest, serial, response)
+	test.AssertNotError(t, err, "migrating response")
+	test.AssertByteEquals(t, dest.responses[serial], response)
+
+	err = cl.migrateResponse(context.Background(), dest, "1338", response)
+	test.AssertError(t, err, "migrated response stored under the wrong serial")
+
+	dest.corrupt = true
+	err = cl.migrateResponse(context.Background(), dest, serial, response)
+	test.AssertError(t, err, "corrupted migration passed verification")
+	dest.corrupt = false
+
+	clk.Add(2 * time.Hour)
+	err = cl.migrateResponse(context.Background(), dest, serial, response)
+	var expired expiredError
+	test.Assert(t, errors.As(err, &expired), "expired response was migrated")
+}
+
+func TestPrimaryOnly(t *testing.T) {
+	conf := &rocsp_config.RedisConfig{
+		ClusterAddrs:   []string{"10.33.33.2:4218"},
+		ReadOnly:       true,
+		RouteByLatency: true,
+		RouteRandomly:  true,
+		MaxRedirects:   5,
+	}
+	primary := primaryOnly(conf)
+	test.Assert(t, !primary.ReadOnly && !primary.RouteByLatency && !primary.RouteRandomly,
+		"migrate destination may read from replicas")
+	test.AssertEquals(t, primary.MaxRedirects, 5)
+	test.Assert(t, conf.ReadOnly && conf.RouteByLatency && conf.RouteRandomly, "original config was modified")
+}
@@ -32,6 +32,10 @@ type Config struct {
 		// If using load-from-db, this provides credentials to connect to the DB
 		// and the CA. Otherwise, it's optional.
 		LoadFromDB *LoadFromDBConfig
+
+		// If using migrate, this is the Redis deployment, typically a Cluster,
+		// to which responses are copied from Redis. Otherwise, it's optional.
+		MigrateTo *rocsp_config.RedisConfig
 	}
 	Syslog cmd.SyslogConfig
 }
@@ -209,6 +213,32 @@ var (
 			return nil
 		},
 	}
+	Migrate = subCommand{"migrate", "copy every unexpired OCSP response from Redis to MigrateTo, verifying each copy",
+		func(ctx context.Context, cl client, c Config, args []string) error {
+			if c.ROCSPTool.MigrateTo == nil {
+				return fmt.Errorf("config field MigrateTo was missing")
+			}
+			dest, err := rocsp_config.MakeClient(primaryOnly(c.ROCSPTool.MigrateTo), cl.clk, metrics.NoopRegisterer)
+			if err != nil {
+				return fmt.Errorf("making destination client: %w", err)
+			}
+			err = dest.Ping(ctx)
+			if err != nil {
+				return fmt.Errorf("pinging destination: %w", err)
+			}
+
+			result, err := cl.migrate(ctx, dest)
+			cl.logger.Infof("migrated %d responses, skipped %d expired, %d failed",
+				result.copied, result.expired, result.failed)
+			if err != nil {
+				return err
+			}
+			if result.failed > 0 {
+				return fmt.Errorf("%d responses failed to migrate", result.failed)
+			}
+			return nil
+		},
+	}
 	ScanResponses = subCommand{"scan-responses", "scan Redis for OCSP response entries. For each entry, print the serial and base64-encoded response",
 		func(ctx context.Context, cl client, _ Config, args []string) error {
 			results := cl.redis.ScanResponses(ctx, "*")
@@ -224,7 +254,7 @@ var (
 )
 
 var subCommands = []subCommand{
-	Store, Get, GetPEM, LoadFromDB, ScanResponses,
+	Store, Get, GetPEM, LoadFromDB, ScanResponses, Migrate,
 }
 
 func helpExit() {
@@ -139,6 +139,10 @@ type ValidationRecord struct {
 	//   ...
 	// }
 	AddressesTried []net.IP `json:"addressesTried,omitempty"`
+	// Perspectives contains the names of the remote VA perspectives which
+	// agreed with the primary VA's result. It is only set on the final record
+	// of a validation which was corroborated by multi-perspective validation.
+	Perspectives []string `json:"perspectives,omitempty"`
 }
 
 func looksLikeKeyAuthorization(str string) error {
@@ -0,0 +1,127 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/crl/crl_x509"
+	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/issuance"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+)
+
+// AuditResult describes the discrepancies found between a complete set of CRL
+// shards and the revocation status recorded in the database. Each field holds
+// hex-encoded serials, sorted for stable output.
+type AuditResult struct {
+	// Missing serials are revoked and unexpired, and were revoked before every
+	// shard was generated, but appear in none of them.
+	Missing []string
+	// Duplicated serials appear more than once across all of the shards.
+	Duplicated []string
+	// Extra serials appear in a shard, but are not revoked in the database.
+	Extra []string
+	// ReasonMismatches are serials whose reason code in a shard differs from
+	// the one recorded in the database, formatted as "serial: crl=X sa=Y".
+	ReasonMismatches []string
+}
+
+// Count returns the total number of discrepancies in the result.
+func (r *AuditResult) Count() int {
+	return len(r.Missing) + len(r.Duplicated) + len(r.Extra) + len(r.ReasonMismatches)
+}
+
+// Audit checks that every revoked, unexpired certificate from the given issuer
+// appears in exactly one of the given shards, with the correct reason code,
+// and that the shards contain nothing else. The shards must be the complete,
+// not delta, CRLs covering every shard for the issuer. Certificates revoked
+// after the oldest shard was generated are not required to appear, but their
+// reason codes are still checked if they do.
+func Audit(ctx context.Context, sa sapb.StorageAuthorityReadOnlyClient, issuerNameID issuance.IssuerNameID, shards []*crl_x509.RevocationList, now time.Time) (*AuditResult, error) {
+	if len(shards) == 0 {
+		return nil, errors.New("no shards to audit")
+	}
+
+	// Index every entry across all shards, noting those seen more than once.
+	result := &AuditResult{}
+	published := make(map[string]int)
+	oldest := shards[0].ThisUpdate
+	for _, shard := range shards {
+		if shard.ThisUpdate.Before(oldest) {
+			oldest = shard.ThisUpdate
+		}
+		for _, rc := range shard.RevokedCertificates {
+			serial := core.SerialToString(rc.SerialNumber)
+			if _, seen := published[serial]; seen {
+				result.Duplicated = append(result.Duplicated, serial)
+				continue
+			}
+			reason := 0
+			if rc.ReasonCode != nil {
+				reason = *rc.ReasonCode
+			}
+			published[serial] = reason
+		}
+	}
+
+	stream, err := sa.GetRevokedCerts(ctx, &sapb.GetRevokedCertsRequest{
+		IssuerNameID:  int64(issuerNameID),
+		ExpiresAfter:  now.UnixNano(),
+		ExpiresBefore: math.MaxInt64,
+		RevokedBefore: oldest.UnixNano(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("connecting to SA: %w", err)
+	}
+
+	// Every entry streamed by the SA must have been published. Those which
+	// have are removed from the index, leaving only unexpected entries behind.
+	for {
+		entry, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("retrieving entry from SA: %w", err)
+		}
+		reason, ok := published[entry.Serial]
+		if !ok {
+			result.Missing = append(result.Missing, entry.Serial)
+			continue
+		}
+		if reason != int(entry.Reason) {
+			result.ReasonMismatches = append(result.ReasonMismatches,
+				fmt.Sprintf("%s: crl=%d sa=%d", entry.Serial, reason, entry.Reason))
+		}
+		delete(published, entry.Serial)
+	}
+
+	// The remaining entries may legitimately belong to certificates which have
+	// recently expired or been revoked, so check each one individually.
+	for serial, reason := range published {
+		status, err := sa.GetCertificateStatus(ctx, &sapb.Serial{Serial: serial})
+		if err != nil && !errors.Is(err, berrors.NotFound) {
+			return nil, fmt.Errorf("getting status of serial %q: %w", serial, err)
+		}
+		if err != nil || status.Status != string(core.OCSPStatusRevoked) {
+			result.Extra = append(result.Extra, serial)
+			continue
+		}
+		if reason != int(status.RevokedReason) {
+			result.ReasonMismatches = append(result.ReasonMismatches,
+				fmt.Sprintf("%s: crl=%d sa=%d", serial, reason, status.RevokedReason))
+		}
+	}
+
+	sort.Strings(result.Missing)
+	sort.Strings(result.Duplicated)
+	sort.Strings(result.Extra)
+	sort.Strings(result.ReasonMismatches)
+	return result, nil
+}
@@ -0,0 +1,119 @@
+package checker
+
+import (
+	"context"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"google.golang.org/grpc"
+
+	"github.com/letsencrypt/boulder/core"
+	corepb "github.com/letsencrypt/boulder/core/proto"
+	"github.com/letsencrypt/boulder/crl/crl_x509"
+	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/mocks"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+	"github.com/letsencrypt/boulder/test"
+)
+
+// fakeGRCC is a fake sapb.StorageAuthorityReadOnly_GetRevokedCertsClient which
+// returns a fixed list of CRL entries.
+type fakeGRCC struct {
+	grpc.ClientStream
+	entries []*corepb.CRLEntry
+}
+
+func (f *fakeGRCC) Recv() (*corepb.CRLEntry, error) {
+	if len(f.entries) == 0 {
+		return nil, io.EOF
+	}
+	next := f.entries[0]
+	f.entries = f.entries[1:]
+	return next, nil
+}
+
+// fakeAuditSA returns the given revoked entries from GetRevokedCerts, and
+// answers GetCertificateStatus from the given map of statuses.
+type fakeAuditSA struct {
+	mocks.StorageAuthorityReadOnly
+	revoked  []*corepb.CRLEntry
+	statuses map[string]*corepb.CertificateStatus
+	req      *sapb.GetRevokedCertsRequest
+}
+
+func (f *fakeAuditSA) GetRevokedCerts(_ context.Context, req *sapb.GetRevokedCertsRequest, _ ...grpc.CallOption) (sapb.StorageAuthorityReadOnly_GetRevokedCertsClient, error) {
+	f.req = req
+	return &fakeGRCC{entries: f.revoked}, nil
+}
+
+func (f *fakeAuditSA) GetCertificateStatus(_ context.Context, req *sapb.Serial, _ ...grpc.CallOption) (*corepb.CertificateStatus, error) {
+	status, ok := f.statuses[req.Serial]
+	if !ok {
+		return nil, berrors.NotFoundError("no status for %s", req.Serial)
+	}
+	return status, nil
+}
+
+func TestAudit(t *testing.T) {
+	clk := clock.NewFake()
+	now := clk.Now()
+	reason := func(r int) *int { return &r }
+	serial := func(n int64) string { return core.SerialToString(big.NewInt(n)) }
+
+	shards := []*crl_x509.RevocationList{
+		{
+			ThisUpdate: now.Add(-time.Hour),
+			RevokedCertificates: []crl_x509.RevokedCertificate{
+				{SerialNumber: big.NewInt(1)},
+				{SerialNumber: big.NewInt(2), ReasonCode: reason(1)},
+				{SerialNumber: big.NewInt(3), ReasonCode: reason(4)},
+			},
+		},
+		{
+			ThisUpdate: now.Add(-2 * time.Hour),
+			RevokedCertificates: []crl_x509.RevokedCertificate{
+				{SerialNumber: big.NewInt(3), ReasonCode: reason(4)},
+				{SerialNumber: big.NewInt(5)},
+				{SerialNumber: big.NewInt(6), ReasonCode: reason(1)},
+				{SerialNumber: big.NewInt(7)},
+			},
+		},
+	}
+	sa := &fakeAuditSA{
+		StorageAuthorityReadOnly: *mocks.NewStorageAuthorityReadOnly(clk),
+		revoked: []*corepb.CRLEntry{
+			{Serial: serial(1)},
+			{Serial: serial(2), Reason: 4},
+			{Serial: serial(3), Reason: 4},
+			{Serial: serial(4), Reason: 1},
+		},
+		statuses: map[string]*corepb.CertificateStatus{
+			// Recently expired, so no longer returned by GetRevokedCerts.
+			serial(5): {Status: string(core.OCSPStatusRevoked)},
+			// Reason changed since being returned by GetRevokedCerts.
+			serial(6): {Status: string(core.OCSPStatusRevoked), RevokedReason: 0},
+			// Serial 7 has no status at all.
+		},
+	}
+
+	_, err := Audit(context.Background(), sa, 1234, nil, now)
+	test.AssertError(t, err, "auditing no shards")
+
+	res, err := Audit(context.Background(), sa, 1234, shards, now)
+	test.AssertNotError(t, err, "auditing shards")
+	test.AssertEquals(t, sa.req.IssuerNameID, int64(1234))
+	test.AssertEquals(t, sa.req.ExpiresAfter, now.UnixNano())
+	test.AssertEquals(t, sa.req.RevokedBefore, now.Add(-2*time.Hour).UnixNano())
+
+	test.AssertDeepEquals(t, res.Missing, []string{serial(4)})
+	test.AssertDeepEquals(t, res.Duplicated, []string{serial(3)})
+	test.AssertDeepEquals(t, res.Extra, []string{serial(7)})
+	test.AssertDeepEquals(t, res.ReasonMismatches, []string{
+		serial(2) + ": crl=1 sa=4",
+		serial(6) + ": crl=1 sa=0",
+	})
+	test.AssertEquals(t, res.Count(), 5)
+}
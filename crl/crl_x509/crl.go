@@ -26,6 +26,15 @@ var (
 	oidExtensionReasonCode = []int{2, 5, 29, 21}
 )
 
+// OIDExtensionDeltaCRLIndicator is id-ce-deltaCRLIndicator, RFC 5280 Section
+// 5.2.4.
+// NOTE: This variable does not exist in upstream.
+var OIDExtensionDeltaCRLIndicator = asn1.ObjectIdentifier{2, 5, 29, 27}
+
+// OIDExtensionFreshestCRL is id-ce-freshestCRL, RFC 5280 Section 5.2.6.
+// NOTE: This variable does not exist in upstream.
+var OIDExtensionFreshestCRL = asn1.ObjectIdentifier{2, 5, 29, 46}
+
 // RevokedCertificate represents an entry in the revokedCertificates sequence of
 // a CRL.
 // NOTE: This type does not exist in upstream.
@@ -121,6 +130,17 @@ type RevocationList struct {
 	ExtraExtensions []pkix.Extension
 }
 
+// IsDelta returns true if the CRL contains a Delta CRL Indicator extension.
+// NOTE: This method does not exist in upstream.
+func (rl *RevocationList) IsDelta() bool {
+	for _, ext := range rl.Extensions {
+		if ext.Id.Equal(OIDExtensionDeltaCRLIndicator) {
+			return true
+		}
+	}
+	return false
+}
+
 // ParseRevocationList parses a X509 v2 Certificate Revocation List from the given
 // ASN.1 DER data.
 func ParseRevocationList(der []byte) (*RevocationList, error) {
@@ -573,3 +573,16 @@ func TestRevocationListCheckSignatureFrom(t *testing.T) {
 		})
 	}
 }
+
+func TestRevocationListIsDelta(t *testing.T) {
+	complete := &RevocationList{Extensions: []pkix.Extension{
+		{Id: oidExtensionCRLNumber, Value: []byte{2, 1, 6}},
+	}}
+	test.Assert(t, !complete.IsDelta(), "CRL without Delta CRL Indicator should not be a delta")
+
+	delta := &RevocationList{Extensions: []pkix.Extension{
+		{Id: oidExtensionCRLNumber, Value: []byte{2, 1, 6}},
+		{Id: OIDExtensionDeltaCRLIndicator, Critical: true, Value: []byte{2, 1, 5}},
+	}}
+	test.Assert(t, delta.IsDelta(), "CRL with Delta CRL Indicator should be a delta")
+}
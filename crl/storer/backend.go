@@ -0,0 +1,64 @@
+package storer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// crlContentType is the media type of DER-encoded CRLs, RFC 5280 Section 4.2.1.13.
+const crlContentType = "application/pkix-crl"
+
+// Backend is a destination to which the crl-storer writes CRLs once they have
+// been validated.
+type Backend interface {
+	// Put stores the CRL under the given key, replacing any CRL previously
+	// stored there. Keys are slash-separated relative paths, such as
+	// "123456/7.crl".
+	Put(ctx context.Context, key string, number *big.Int, crlBytes []byte) error
+}
+
+// s3Putter matches the subset of the s3.Client interface which we use, to allow
+// simpler mocking in tests.
+type s3Putter interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// s3Backend uploads CRLs as objects in an S3 bucket.
+type s3Backend struct {
+	client s3Putter
+	bucket string
+}
+
+var _ Backend = (*s3Backend)(nil)
+
+// NewS3Backend returns a Backend which uploads CRLs to the given S3 bucket.
+func NewS3Backend(client s3Putter, bucket string) *s3Backend {
+	return &s3Backend{client: client, bucket: bucket}
+}
+
+// Put implements Backend.
+func (b *s3Backend) Put(ctx context.Context, key string, number *big.Int, crlBytes []byte) error {
+	checksum := sha256.Sum256(crlBytes)
+	checksumb64 := base64.StdEncoding.EncodeToString(checksum[:])
+	contentType := crlContentType
+	_, err := b.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:            &b.bucket,
+		Key:               &key,
+		Body:              bytes.NewReader(crlBytes),
+		ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
+		ChecksumSHA256:    &checksumb64,
+		ContentType:       &contentType,
+		Metadata:          map[string]string{"crlNumber": number.String()},
+	})
+	if err != nil {
+		return fmt.Errorf("uploading to S3: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package storer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+)
+
+// httpBackend uploads CRLs with HTTP PUT requests, for use with generic object
+// stores and WebDAV-style web servers.
+type httpBackend struct {
+	client  *http.Client
+	baseURL *url.URL
+}
+
+var _ Backend = (*httpBackend)(nil)
+
+// NewHTTPBackend returns a Backend which PUTs each CRL to its key, resolved
+// relative to the given base URL. Requests are bounded by the deadline of the
+// context passed to Put, rather than by the client.
+func NewHTTPBackend(client *http.Client, baseURL string) (*httpBackend, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("base URL %q must be http or https", baseURL)
+	}
+	return &httpBackend{client: client, baseURL: u}, nil
+}
+
+// Put implements Backend.
+func (b *httpBackend) Put(ctx context.Context, key string, number *big.Int, crlBytes []byte) error {
+	target := b.baseURL.JoinPath(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target.String(), bytes.NewReader(crlBytes))
+	if err != nil {
+		return fmt.Errorf("creating PUT request for %q: %w", key, err)
+	}
+
+	checksum := sha256.Sum256(crlBytes)
+	req.Header.Set("Content-Type", crlContentType)
+	req.Header.Set("Content-Digest", fmt.Sprintf("sha-256=:%s:", base64.StdEncoding.EncodeToString(checksum[:])))
+	req.Header.Set("CRL-Number", number.String())
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("uploading to %s: %w", target.Redacted(), err)
+	}
+	defer resp.Body.Close()
+	// Drain a bounded amount of the body so that the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("uploading to %s: got status %q", target.Redacted(), resp.Status)
+	}
+	return nil
+}
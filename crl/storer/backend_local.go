@@ -0,0 +1,97 @@
+package storer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// localBackend writes CRLs to files beneath a directory on local disk, from
+// which they can be served by an ordinary web server.
+type localBackend struct {
+	dir string
+}
+
+var _ Backend = (*localBackend)(nil)
+
+// NewLocalBackend returns a Backend which writes CRLs beneath the given
+// directory, which must already exist.
+func NewLocalBackend(dir string) (*localBackend, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%q is not a directory", dir)
+	}
+	return &localBackend{dir: dir}, nil
+}
+
+// Put implements Backend. Each CRL is first written in full to a temporary file
+// alongside its destination, so that readers never observe a partially-written
+// CRL. That file is then hard-linked to a versioned name (e.g. "7.crl.<number>")
+// which is kept indefinitely, and finally renamed over the current CRL.
+func (b *localBackend) Put(_ context.Context, key string, number *big.Int, crlBytes []byte) error {
+	path, err := b.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return fmt.Errorf("creating directory for %q: %w", key, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("creating temporary file for %q: %w", key, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(crlBytes)
+	if err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	closeErr := tmp.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("writing temporary file for %q: %w", key, err)
+	}
+
+	// A CRL with the same number may be uploaded more than once if the
+	// crl-updater retries, in which case the most recent upload wins.
+	version := fmt.Sprintf("%s.%s", path, number)
+	err = os.Remove(version)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("replacing previous version of %q: %w", key, err)
+	}
+	err = os.Link(tmp.Name(), version)
+	if err != nil {
+		return fmt.Errorf("keeping version of %q: %w", key, err)
+	}
+
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return fmt.Errorf("replacing %q: %w", key, err)
+	}
+	return nil
+}
+
+// path returns the absolute location of the given key, refusing keys which
+// would escape the backend's directory.
+func (b *localBackend) path(key string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(key)) || strings.Contains(key, `\`) {
+		return "", fmt.Errorf("invalid CRL key %q", key)
+	}
+	return filepath.Join(b.dir, filepath.FromSlash(key)), nil
+}
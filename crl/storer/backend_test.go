@@ -0,0 +1,86 @@
+package storer
+
+import (
+	"context"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestLocalBackend(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := NewLocalBackend(filepath.Join(dir, "missing"))
+	test.AssertError(t, err, "nonexistent directory")
+
+	b, err := NewLocalBackend(dir)
+	test.AssertNotError(t, err, "creating local backend")
+
+	err = b.Put(context.Background(), "../1.crl", big.NewInt(1), []byte("first"))
+	test.AssertError(t, err, "key escaping the directory")
+	err = b.Put(context.Background(), "/1.crl", big.NewInt(1), []byte("first"))
+	test.AssertError(t, err, "absolute key")
+
+	err = b.Put(context.Background(), "123/1.crl", big.NewInt(1), []byte("first"))
+	test.AssertNotError(t, err, "writing first CRL")
+	err = b.Put(context.Background(), "123/1.crl", big.NewInt(2), []byte("second"))
+	test.AssertNotError(t, err, "writing second CRL")
+	err = b.Put(context.Background(), "123/1.crl", big.NewInt(2), []byte("retried"))
+	test.AssertNotError(t, err, "rewriting second CRL")
+
+	// The current CRL has been replaced, and every version has been kept.
+	for name, expected := range map[string]string{
+		"1.crl":   "retried",
+		"1.crl.1": "first",
+		"1.crl.2": "retried",
+	} {
+		got, err := os.ReadFile(filepath.Join(dir, "123", name))
+		test.AssertNotError(t, err, "reading "+name)
+		test.AssertEquals(t, string(got), expected)
+	}
+
+	// No temporary files are left behind.
+	entries, err := os.ReadDir(filepath.Join(dir, "123"))
+	test.AssertNotError(t, err, "reading directory")
+	test.AssertEquals(t, len(entries), 3)
+}
+
+func TestHTTPBackend(t *testing.T) {
+	_, err := NewHTTPBackend(http.DefaultClient, "ftp://example.com/crls")
+	test.AssertError(t, err, "non-HTTP base URL")
+
+	var gotMethod, gotPath, gotType, gotNumber string
+	var gotBody []byte
+	status := http.StatusCreated
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.Path
+		gotType = r.Header.Get("Content-Type")
+		gotNumber = r.Header.Get("CRL-Number")
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	b, err := NewHTTPBackend(srv.Client(), srv.URL+"/crls/")
+	test.AssertNotError(t, err, "creating HTTP backend")
+
+	err = b.Put(context.Background(), "123/1-delta.crl", big.NewInt(7), []byte("crl"))
+	test.AssertNotError(t, err, "uploading CRL")
+	test.AssertEquals(t, gotMethod, http.MethodPut)
+	test.AssertEquals(t, gotPath, "/crls/123/1-delta.crl")
+	test.AssertEquals(t, gotType, "application/pkix-crl")
+	test.AssertEquals(t, gotNumber, "7")
+	test.AssertEquals(t, string(gotBody), "crl")
+
+	status = http.StatusForbidden
+	err = b.Put(context.Background(), "123/1.crl", big.NewInt(7), []byte("crl"))
+	test.AssertError(t, err, "upload rejected by server")
+	test.AssertContains(t, err.Error(), "403")
+}
@@ -1,18 +1,12 @@
 package storer
 
 import (
-	"bytes"
-	"context"
-	"crypto/sha256"
-	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
 	"math/big"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/service/s3"
-	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/jmhodges/clock"
 	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/protobuf/types/known/emptypb"
@@ -24,16 +18,9 @@ import (
 	blog "github.com/letsencrypt/boulder/log"
 )
 
-// s3Putter matches the subset of the s3.Client interface which we use, to allow
-// simpler mocking in tests.
-type s3Putter interface {
-	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
-}
-
 type crlStorer struct {
 	cspb.UnimplementedCRLStorerServer
-	s3Client         s3Putter
-	s3Bucket         string
+	backend          Backend
 	issuers          map[issuance.IssuerNameID]*issuance.Certificate
 	uploadCount      *prometheus.CounterVec
 	sizeHistogram    *prometheus.HistogramVec
@@ -44,8 +31,7 @@ type crlStorer struct {
 
 func New(
 	issuers []*issuance.Certificate,
-	s3Client s3Putter,
-	s3Bucket string,
+	backend Backend,
 	stats prometheus.Registerer,
 	log blog.Logger,
 	clk clock.Clock,
@@ -77,8 +63,7 @@ func New(
 
 	return &crlStorer{
 		issuers:          issuersByNameID,
-		s3Client:         s3Client,
-		s3Bucket:         s3Bucket,
+		backend:          backend,
 		uploadCount:      uploadCount,
 		sizeHistogram:    sizeHistogram,
 		latencyHistogram: latencyHistogram,
@@ -94,6 +79,7 @@ func (cs *crlStorer) UploadCRL(stream cspb.CRLStorer_UploadCRLServer) error {
 	var issuer *issuance.Certificate
 	var shardIdx int64
 	var crlNumber *big.Int
+	var delta bool
 	crlBytes := make([]byte, 0)
 
 	for {
@@ -115,6 +101,7 @@ func (cs *crlStorer) UploadCRL(stream cspb.CRLStorer_UploadCRLServer) error {
 			}
 
 			shardIdx = payload.Metadata.ShardIdx
+			delta = payload.Metadata.Delta
 			crlNumber = crl.Number(time.Unix(0, payload.Metadata.Number))
 
 			var ok bool
@@ -146,6 +133,10 @@ func (cs *crlStorer) UploadCRL(stream cspb.CRLStorer_UploadCRLServer) error {
 		return errors.New("got mismatched CRL Number")
 	}
 
+	if crl.IsDelta() != delta {
+		return fmt.Errorf("got mismatched Delta CRL Indicator for %s: expected delta=%t", crlId, delta)
+	}
+
 	err = crl.CheckSignatureFrom(issuer.Certificate)
 	if err != nil {
 		return fmt.Errorf("validating signature for %s: %w", crlId, err)
@@ -153,19 +144,13 @@ func (cs *crlStorer) UploadCRL(stream cspb.CRLStorer_UploadCRLServer) error {
 
 	start := cs.clk.Now()
 
+	// Delta CRLs live alongside, but never overwrite, the complete CRL shard
+	// that they update.
 	filename := fmt.Sprintf("%d/%d.crl", issuer.NameID(), shardIdx)
-	checksum := sha256.Sum256(crlBytes)
-	checksumb64 := base64.StdEncoding.EncodeToString(checksum[:])
-	crlContentType := "application/pkix-crl"
-	_, err = cs.s3Client.PutObject(stream.Context(), &s3.PutObjectInput{
-		Bucket:            &cs.s3Bucket,
-		Key:               &filename,
-		Body:              bytes.NewReader(crlBytes),
-		ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
-		ChecksumSHA256:    &checksumb64,
-		ContentType:       &crlContentType,
-		Metadata:          map[string]string{"crlNumber": crlNumber.String()},
-	})
+	if delta {
+		filename = fmt.Sprintf("%d/%d-delta.crl", issuer.NameID(), shardIdx)
+	}
+	err = cs.backend.Put(stream.Context(), filename, crlNumber, crlBytes)
 
 	latency := cs.clk.Now().Sub(start)
 	cs.latencyHistogram.WithLabelValues(issuer.Subject.CommonName).Observe(latency.Seconds())
@@ -173,13 +158,13 @@ func (cs *crlStorer) UploadCRL(stream cspb.CRLStorer_UploadCRLServer) error {
 	if err != nil {
 		cs.uploadCount.WithLabelValues(issuer.Subject.CommonName, "failed").Inc()
 		cs.log.AuditErrf("CRL upload failed: id=[%s] err=[%s]", crlId, err)
-		return fmt.Errorf("uploading to S3: %w", err)
+		return fmt.Errorf("storing %s: %w", crlId, err)
 	}
 
 	cs.uploadCount.WithLabelValues(issuer.Subject.CommonName, "success").Inc()
 	cs.log.AuditInfof(
-		"CRL uploaded: id=[%s] issuerCN=[%s] thisUpdate=[%s] nextUpdate=[%s] numEntries=[%d]",
-		crlId, issuer.Subject.CommonName, crl.ThisUpdate, crl.NextUpdate, len(crl.RevokedCertificates),
+		"CRL uploaded: id=[%s] issuerCN=[%s] thisUpdate=[%s] nextUpdate=[%s] delta=[%t] numEntries=[%d]",
+		crlId, issuer.Subject.CommonName, crl.ThisUpdate, crl.NextUpdate, delta, len(crl.RevokedCertificates),
 	)
 
 	return stream.SendAndClose(&emptypb.Empty{})
@@ -6,7 +6,10 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/x509/pkix"
+	"encoding/asn1"
 	"errors"
+	"fmt"
 	"io"
 	"math/big"
 	"testing"
@@ -59,7 +62,7 @@ func setupTestUploadCRL(t *testing.T) (*crlStorer, *issuance.Issuer) {
 
 	storer, err := New(
 		[]*issuance.Certificate{r3, e1},
-		nil, "le-crl.s3.us-west.amazonaws.com",
+		NewS3Backend(nil, "le-crl.s3.us-west.amazonaws.com"),
 		metrics.NoopRegisterer, blog.NewMock(), clock.NewFake(),
 	)
 	test.AssertNotError(t, err, "creating test crl-storer")
@@ -267,6 +270,7 @@ func TestUploadCRLMismatchedNumbers(t *testing.T) {
 
 type fakeS3Putter struct {
 	expectBytes []byte
+	expectKey   string
 }
 
 func (p *fakeS3Putter) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
@@ -277,6 +281,9 @@ func (p *fakeS3Putter) PutObject(ctx context.Context, params *s3.PutObjectInput,
 	if !bytes.Equal(p.expectBytes, recvBytes) {
 		return nil, errors.New("received bytes did not match expectation")
 	}
+	if p.expectKey != "" && p.expectKey != *params.Key {
+		return nil, fmt.Errorf("received key %q did not match expectation %q", *params.Key, p.expectKey)
+	}
 	return &s3.PutObjectOutput{}, nil
 }
 
@@ -311,7 +318,7 @@ func TestUploadCRLSuccess(t *testing.T) {
 		iss.Signer,
 	)
 	test.AssertNotError(t, err, "creating test CRL")
-	storer.s3Client = &fakeS3Putter{expectBytes: crlBytes}
+	storer.backend = NewS3Backend(&fakeS3Putter{expectBytes: crlBytes}, "bucket")
 	ins <- &cspb.UploadCRLRequest{
 		Payload: &cspb.UploadCRLRequest_CrlChunk{
 			CrlChunk: crlBytes,
@@ -322,6 +329,82 @@ func TestUploadCRLSuccess(t *testing.T) {
 	test.AssertNotError(t, err, "uploading valid CRL should work")
 }
 
+// Test that Delta CRLs are checked against the metadata and uploaded under
+// their own key.
+func TestUploadCRLDelta(t *testing.T) {
+	storer, iss := setupTestUploadCRL(t)
+	errs := make(chan error, 1)
+
+	baseNum, err := asn1.Marshal(big.NewInt(1))
+	test.AssertNotError(t, err, "marshalling base CRL number")
+	crlBytes, err := crl_x509.CreateRevocationList(
+		rand.Reader,
+		&crl_x509.RevocationList{
+			ThisUpdate: time.Now(),
+			NextUpdate: time.Now().Add(time.Hour),
+			Number:     big.NewInt(2),
+			ExtraExtensions: []pkix.Extension{
+				{Id: crl_x509.OIDExtensionDeltaCRLIndicator, Critical: true, Value: baseNum},
+			},
+		},
+		iss.Cert.Certificate,
+		iss.Signer,
+	)
+	test.AssertNotError(t, err, "creating test CRL")
+
+	// A Delta CRL uploaded as a complete CRL must be rejected.
+	ins := make(chan *cspb.UploadCRLRequest)
+	go func() {
+		errs <- storer.UploadCRL(&fakeUploadCRLServerStream{input: ins})
+	}()
+	ins <- &cspb.UploadCRLRequest{
+		Payload: &cspb.UploadCRLRequest_Metadata{
+			Metadata: &cspb.CRLMetadata{
+				IssuerNameID: int64(iss.Cert.NameID()),
+				Number:       2,
+				ShardIdx:     3,
+			},
+		},
+	}
+	ins <- &cspb.UploadCRLRequest{
+		Payload: &cspb.UploadCRLRequest_CrlChunk{
+			CrlChunk: crlBytes,
+		},
+	}
+	close(ins)
+	err = <-errs
+	test.AssertError(t, err, "can't upload Delta CRL as complete CRL")
+	test.AssertContains(t, err.Error(), "mismatched Delta CRL Indicator")
+
+	// A Delta CRL uploaded as such goes to the delta key.
+	storer.backend = NewS3Backend(&fakeS3Putter{
+		expectBytes: crlBytes,
+		expectKey:   fmt.Sprintf("%d/3-delta.crl", iss.Cert.NameID()),
+	}, "bucket")
+	ins = make(chan *cspb.UploadCRLRequest)
+	go func() {
+		errs <- storer.UploadCRL(&fakeUploadCRLServerStream{input: ins})
+	}()
+	ins <- &cspb.UploadCRLRequest{
+		Payload: &cspb.UploadCRLRequest_Metadata{
+			Metadata: &cspb.CRLMetadata{
+				IssuerNameID: int64(iss.Cert.NameID()),
+				Number:       2,
+				ShardIdx:     3,
+				Delta:        true,
+			},
+		},
+	}
+	ins <- &cspb.UploadCRLRequest{
+		Payload: &cspb.UploadCRLRequest_CrlChunk{
+			CrlChunk: crlBytes,
+		},
+	}
+	close(ins)
+	err = <-errs
+	test.AssertNotError(t, err, "uploading valid Delta CRL should work")
+}
+
 type brokenS3Putter struct{}
 
 func (p *brokenS3Putter) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
@@ -359,7 +442,7 @@ func TestUploadCRLBrokenS3(t *testing.T) {
 		iss.Signer,
 	)
 	test.AssertNotError(t, err, "creating test CRL")
-	storer.s3Client = &brokenS3Putter{}
+	storer.backend = NewS3Backend(&brokenS3Putter{}, "bucket")
 	ins <- &cspb.UploadCRLRequest{
 		Payload: &cspb.UploadCRLRequest_CrlChunk{
 			CrlChunk: crlBytes,
@@ -0,0 +1,106 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v3.20.1
+// source: updater.proto
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// CRLUpdaterClient is the client API for CRLUpdater service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CRLUpdaterClient interface {
+	EnqueueRevocation(ctx context.Context, in *EnqueueRevocationRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type cRLUpdaterClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCRLUpdaterClient(cc grpc.ClientConnInterface) CRLUpdaterClient {
+	return &cRLUpdaterClient{cc}
+}
+
+func (c *cRLUpdaterClient) EnqueueRevocation(ctx context.Context, in *EnqueueRevocationRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/updater.CRLUpdater/EnqueueRevocation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CRLUpdaterServer is the server API for CRLUpdater service.
+// All implementations must embed UnimplementedCRLUpdaterServer
+// for forward compatibility
+type CRLUpdaterServer interface {
+	EnqueueRevocation(context.Context, *EnqueueRevocationRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedCRLUpdaterServer()
+}
+
+// UnimplementedCRLUpdaterServer must be embedded to have forward compatible implementations.
+type UnimplementedCRLUpdaterServer struct {
+}
+
+func (UnimplementedCRLUpdaterServer) EnqueueRevocation(context.Context, *EnqueueRevocationRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EnqueueRevocation not implemented")
+}
+func (UnimplementedCRLUpdaterServer) mustEmbedUnimplementedCRLUpdaterServer() {}
+
+// UnsafeCRLUpdaterServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CRLUpdaterServer will
+// result in compilation errors.
+type UnsafeCRLUpdaterServer interface {
+	mustEmbedUnimplementedCRLUpdaterServer()
+}
+
+func RegisterCRLUpdaterServer(s grpc.ServiceRegistrar, srv CRLUpdaterServer) {
+	s.RegisterService(&CRLUpdater_ServiceDesc, srv)
+}
+
+func _CRLUpdater_EnqueueRevocation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EnqueueRevocationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CRLUpdaterServer).EnqueueRevocation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/updater.CRLUpdater/EnqueueRevocation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CRLUpdaterServer).EnqueueRevocation(ctx, req.(*EnqueueRevocationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CRLUpdater_ServiceDesc is the grpc.ServiceDesc for CRLUpdater service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CRLUpdater_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "updater.CRLUpdater",
+	HandlerType: (*CRLUpdaterServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "EnqueueRevocation",
+			Handler:    _CRLUpdater_EnqueueRevocation_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "updater.proto",
+}
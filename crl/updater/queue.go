@@ -0,0 +1,168 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/crl"
+	cupb "github.com/letsencrypt/boulder/crl/updater/proto"
+	"github.com/letsencrypt/boulder/features"
+	"github.com/letsencrypt/boulder/issuance"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+)
+
+var errIncompleteRequest = errors.New("incomplete gRPC request message")
+
+// queuedShard records when a shard was first and most recently affected by a
+// revocation since it was last generated.
+type queuedShard struct {
+	first time.Time
+	last  time.Time
+}
+
+// EnqueueRevocation implements the CRLUpdater gRPC service. It is called by the
+// RA whenever a certificate is revoked, or its revocation reason is changed, and
+// queues the CRL shard(s) which contain that certificate for regeneration. The
+// RA doesn't know how certificates are mapped to shards, so this looks up the
+// certificate's notAfter date and maps it in the same way as tickIssuer.
+func (cu *crlUpdater) EnqueueRevocation(ctx context.Context, req *cupb.EnqueueRevocationRequest) (*emptypb.Empty, error) {
+	if req == nil || req.IssuerNameID == 0 || req.Serial == "" {
+		return nil, errIncompleteRequest
+	}
+	if cu.debouncePeriod == 0 {
+		return nil, errors.New("revocation events are not enabled")
+	}
+
+	issuerNameID := issuance.IssuerNameID(req.IssuerNameID)
+	if _, ok := cu.issuers[issuerNameID]; !ok {
+		return nil, fmt.Errorf("got unrecognized IssuerNameID: %d", req.IssuerNameID)
+	}
+
+	status, err := cu.sa.GetCertificateStatus(ctx, &sapb.Serial{Serial: req.Serial})
+	if err != nil {
+		return nil, fmt.Errorf("getting status of serial %q: %w", req.Serial, err)
+	}
+
+	c, err := cu.getChunkAtTime(time.Unix(0, status.NotAfter))
+	if err != nil {
+		return nil, err
+	}
+	shards := []int{c.idx}
+
+	// Certificates with a CRL distribution point were assigned a shard at
+	// issuance, based on their serial. We don't know whether this certificate has
+	// one, so regenerate that shard too, just in case.
+	if features.Enabled(features.StoreCRLShards) {
+		serial, err := core.StringToSerial(req.Serial)
+		if err != nil {
+			return nil, err
+		}
+		stored := int(issuance.CRLShardForSerial(serial, cu.numShards))
+		if stored != c.idx {
+			shards = append(shards, stored)
+		}
+	}
+
+	now := cu.clk.Now()
+	for _, shardIdx := range shards {
+		cu.enqueue(shardKey{issuerNameID, shardIdx}, now)
+	}
+
+	cu.log.Infof(
+		"Queued CRL shards for revocation: issuer=[%d] serial=[%s] shards=[%v]",
+		issuerNameID, req.Serial, shards)
+	return &emptypb.Empty{}, nil
+}
+
+// enqueue records that the given shard was affected by a revocation at the
+// given time. Repeated revocations in the same shard are coalesced, and don't
+// delay its regeneration beyond one debouncePeriod after the first of them.
+func (cu *crlUpdater) enqueue(key shardKey, at time.Time) {
+	cu.queueMu.Lock()
+	defer cu.queueMu.Unlock()
+	q, ok := cu.queue[key]
+	if !ok {
+		q.first = at
+	}
+	if at.After(q.last) {
+		q.last = at
+	}
+	cu.queue[key] = q
+}
+
+// dequeue removes and returns all of the shards which were first queued at
+// least one debouncePeriod before the given time.
+func (cu *crlUpdater) dequeue(atTime time.Time) map[shardKey]queuedShard {
+	cu.queueMu.Lock()
+	defer cu.queueMu.Unlock()
+	due := make(map[shardKey]queuedShard)
+	for key, q := range cu.queue {
+		if !q.first.Add(cu.debouncePeriod).After(atTime) {
+			due[key] = q
+			delete(cu.queue, key)
+		}
+	}
+	return due
+}
+
+// runQueue runs TickQueue once immediately, and logs rather than returns any
+// error, so that the long-lived process can try again at the next tick.
+func (cu *crlUpdater) runQueue(ctx context.Context) {
+	atTime := cu.clk.Now()
+	err := cu.TickQueue(ctx, atTime)
+	if err != nil {
+		cu.log.AuditErrf(
+			"Regenerating queued CRLs failed: number=[%s] err=[%s]",
+			(*big.Int)(crl.Number(atTime)), err)
+	}
+}
+
+// TickQueue regenerates every shard which has been queued for at least one
+// debouncePeriod. Shards for which a complete CRL has already been published
+// since their most recent revocation, for instance by the regular Tick, are
+// skipped. Shards which fail are queued again, to be retried at the next tick.
+func (cu *crlUpdater) TickQueue(ctx context.Context, atTime time.Time) error {
+	due := cu.dequeue(atTime)
+	if len(due) == 0 {
+		return nil
+	}
+	cu.log.Debugf("Ticking queue at time %s", atTime)
+
+	shardMap, err := cu.getShardMappings(ctx, atTime)
+	if err != nil {
+		for key, q := range due {
+			cu.enqueue(key, q.last)
+		}
+		return fmt.Errorf("computing shardmap: %w", err)
+	}
+
+	var errShards []string
+	for key, q := range due {
+		base, ok := cu.getBase(key.issuerNameID, key.shardIdx)
+		if ok && !base.Before(q.last) {
+			continue
+		}
+
+		err := cu.tickShard(ctx, atTime, key.issuerNameID, key.shardIdx, shardMap[key.shardIdx])
+		if err != nil {
+			cu.log.AuditErrf(
+				"Regenerating queued CRL failed: id=[%s] err=[%s]",
+				crl.Id(key.issuerNameID, crl.Number(atTime), key.shardIdx), err)
+			cu.enqueue(key, q.last)
+			errShards = append(errShards, fmt.Sprintf("%d/%d", key.issuerNameID, key.shardIdx))
+		}
+	}
+
+	if len(errShards) != 0 {
+		sort.Strings(errShards)
+		return fmt.Errorf("%d shards failed: %v", len(errShards), errShards)
+	}
+	return nil
+}
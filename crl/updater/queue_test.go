@@ -0,0 +1,156 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"google.golang.org/grpc"
+
+	corepb "github.com/letsencrypt/boulder/core/proto"
+	cupb "github.com/letsencrypt/boulder/crl/updater/proto"
+	"github.com/letsencrypt/boulder/features"
+	"github.com/letsencrypt/boulder/issuance"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+	"github.com/letsencrypt/boulder/test"
+)
+
+// fakeStatusSAC is a fakeSAC which also returns a fixed notAfter date for calls
+// to GetCertificateStatus.
+type fakeStatusSAC struct {
+	fakeSAC
+	notAfter time.Time
+}
+
+func (f *fakeStatusSAC) GetCertificateStatus(_ context.Context, req *sapb.Serial, _ ...grpc.CallOption) (*corepb.CertificateStatus, error) {
+	return &corepb.CertificateStatus{Serial: req.Serial, NotAfter: f.notAfter.UnixNano()}, nil
+}
+
+func setupQueue(t *testing.T, debouncePeriod time.Duration) (*crlUpdater, *issuance.Certificate, clock.FakeClock) {
+	t.Helper()
+	e1, err := issuance.LoadCertificate("../../test/hierarchy/int-e1.cert.pem")
+	test.AssertNotError(t, err, "loading test issuer")
+
+	clk := clock.NewFake()
+	clk.Set(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC))
+	cu, err := NewUpdater(
+		[]*issuance.Certificate{e1},
+		4, 18*time.Hour, 24*time.Hour,
+		6*time.Hour, 1*time.Minute, 0, debouncePeriod, 1,
+		&fakeStatusSAC{
+			fakeSAC:  fakeSAC{maxNotAfter: clk.Now().Add(90 * 24 * time.Hour)},
+			notAfter: clk.Now().Add(30 * 24 * time.Hour),
+		},
+		&fakeCGC{gcc: fakeGCC{}},
+		&fakeCSC{ucc: fakeUCC{}},
+		metrics.NoopRegisterer, blog.NewMock(), clk,
+	)
+	test.AssertNotError(t, err, "building test crlUpdater")
+	return cu, e1, clk
+}
+
+func TestEnqueueRevocation(t *testing.T) {
+	cu, e1, clk := setupQueue(t, 0)
+	req := &cupb.EnqueueRevocationRequest{
+		IssuerNameID: int64(e1.NameID()),
+		Serial:       "000000000000000000000000000000000007",
+	}
+
+	_, err := cu.EnqueueRevocation(context.Background(), req)
+	test.AssertError(t, err, "events should be disabled without a debounce period")
+
+	_, err = NewUpdater(
+		[]*issuance.Certificate{e1},
+		4, 18*time.Hour, 24*time.Hour,
+		6*time.Hour, 1*time.Minute, 0, 6*time.Hour, 1,
+		&fakeSAC{}, &fakeCGC{}, &fakeCSC{},
+		metrics.NoopRegisterer, blog.NewMock(), clk,
+	)
+	test.AssertError(t, err, "debounce period as long as update period")
+	test.AssertContains(t, err.Error(), "debounce period must be less than period")
+
+	cu, e1, clk = setupQueue(t, time.Minute)
+
+	_, err = cu.EnqueueRevocation(context.Background(), &cupb.EnqueueRevocationRequest{Serial: req.Serial})
+	test.AssertErrorIs(t, err, errIncompleteRequest)
+
+	_, err = cu.EnqueueRevocation(context.Background(), &cupb.EnqueueRevocationRequest{IssuerNameID: 1, Serial: req.Serial})
+	test.AssertError(t, err, "unknown issuer")
+	test.AssertContains(t, err.Error(), "unrecognized IssuerNameID")
+
+	// The certificate is mapped to the shard containing its notAfter date.
+	notAfter := cu.sa.(*fakeStatusSAC).notAfter
+	c, err := cu.getChunkAtTime(notAfter)
+	test.AssertNotError(t, err, "getting chunk")
+	_, err = cu.EnqueueRevocation(context.Background(), req)
+	test.AssertNotError(t, err, "enqueueing revocation")
+	test.AssertEquals(t, len(cu.queue), 1)
+	q, ok := cu.queue[shardKey{e1.NameID(), c.idx}]
+	test.Assert(t, ok, "expected notAfter shard to be queued")
+	test.AssertEquals(t, q.first, clk.Now())
+
+	// With stored shards, the shard derived from its serial is queued too.
+	err = features.Set(map[string]bool{"StoreCRLShards": true})
+	test.AssertNotError(t, err, "setting feature")
+	defer features.Reset()
+	stored := (c.idx + 1) % 4
+	req.Serial = fmt.Sprintf("%036x", stored)
+	_, err = cu.EnqueueRevocation(context.Background(), req)
+	test.AssertNotError(t, err, "enqueueing revocation")
+	_, ok = cu.queue[shardKey{e1.NameID(), stored}]
+	test.Assert(t, ok, "expected serial shard to be queued")
+	test.AssertEquals(t, len(cu.queue), 2)
+}
+
+func TestTickQueue(t *testing.T) {
+	cu, e1, clk := setupQueue(t, time.Minute)
+	ca := cu.ca.(*fakeCGC)
+	key := shardKey{e1.NameID(), 2}
+
+	// Nothing is regenerated until the debounce period has passed, and then
+	// repeated revocations in the same shard are regenerated only once.
+	cu.enqueue(key, clk.Now())
+	clk.Add(30 * time.Second)
+	cu.enqueue(key, clk.Now())
+	last := clk.Now()
+	err := cu.TickQueue(context.Background(), clk.Now())
+	test.AssertNotError(t, err, "ticking queue")
+	test.AssertEquals(t, len(ca.gcc.sent), 0)
+
+	clk.Add(30 * time.Second)
+	err = cu.TickQueue(context.Background(), clk.Now())
+	test.AssertNotError(t, err, "ticking queue")
+	test.AssertEquals(t, len(ca.gcc.sent), 1)
+	meta := ca.gcc.sent[0].GetMetadata()
+	test.AssertEquals(t, meta.ShardIdx, int64(2))
+	test.AssertEquals(t, meta.ThisUpdate, clk.Now().UnixNano())
+	test.AssertEquals(t, len(cu.queue), 0)
+	base, ok := cu.getBase(e1.NameID(), 2)
+	test.Assert(t, ok, "regenerated shard should become the base")
+	test.Assert(t, base.After(last), "base should postdate the revocations")
+
+	// Shards which have been regenerated since their last revocation, e.g. by
+	// the regular cycle, are skipped.
+	ca.gcc.sent = nil
+	cu.enqueue(key, base.Add(-time.Second))
+	clk.Add(time.Minute)
+	err = cu.TickQueue(context.Background(), clk.Now())
+	test.AssertNotError(t, err, "ticking queue")
+	test.AssertEquals(t, len(ca.gcc.sent), 0)
+	test.AssertEquals(t, len(cu.queue), 0)
+
+	// Shards which fail to regenerate are queued again.
+	cu.cs = &fakeCSC{ucc: fakeUCC{recvErr: errors.New("oops")}}
+	cu.enqueue(key, clk.Now())
+	clk.Add(time.Minute)
+	err = cu.TickQueue(context.Background(), clk.Now())
+	test.AssertError(t, err, "storer error")
+	test.AssertContains(t, err.Error(), "1 shards failed")
+	_, ok = cu.queue[key]
+	test.Assert(t, ok, "failed shard should be queued again")
+}
@@ -10,6 +10,7 @@ import (
 	"math/big"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jmhodges/clock"
@@ -20,24 +21,39 @@ import (
 	"github.com/letsencrypt/boulder/core/proto"
 	"github.com/letsencrypt/boulder/crl"
 	cspb "github.com/letsencrypt/boulder/crl/storer/proto"
+	cupb "github.com/letsencrypt/boulder/crl/updater/proto"
+	"github.com/letsencrypt/boulder/features"
 	"github.com/letsencrypt/boulder/issuance"
 	blog "github.com/letsencrypt/boulder/log"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
 )
 
 type crlUpdater struct {
+	cupb.UnimplementedCRLUpdaterServer
 	issuers        map[issuance.IssuerNameID]*issuance.Certificate
 	numShards      int
 	shardWidth     time.Duration
 	lookbackPeriod time.Duration
 	updatePeriod   time.Duration
 	updateOffset   time.Duration
+	deltaPeriod    time.Duration
+	debouncePeriod time.Duration
 	maxParallelism int
 
 	sa sapb.StorageAuthorityReadOnlyClient
 	ca capb.CRLGeneratorClient
 	cs cspb.CRLStorerClient
 
+	// bases holds the most recently published complete CRL for each shard,
+	// against which Delta CRLs are computed.
+	bases   map[shardKey]baseCRL
+	basesMu sync.RWMutex
+
+	// queue holds the shards which have been affected by revocations since
+	// they were last generated, and which are awaiting regeneration.
+	queue   map[shardKey]queuedShard
+	queueMu sync.Mutex
+
 	tickHistogram  *prometheus.HistogramVec
 	updatedCounter *prometheus.CounterVec
 
@@ -45,6 +61,12 @@ type crlUpdater struct {
 	clk clock.Clock
 }
 
+// shardKey identifies a single shard of a single issuer's CRL.
+type shardKey struct {
+	issuerNameID issuance.IssuerNameID
+	shardIdx     int
+}
+
 func NewUpdater(
 	issuers []*issuance.Certificate,
 	numShards int,
@@ -52,6 +74,8 @@ func NewUpdater(
 	lookbackPeriod time.Duration,
 	updatePeriod time.Duration,
 	updateOffset time.Duration,
+	deltaPeriod time.Duration,
+	debouncePeriod time.Duration,
 	maxParallelism int,
 	sa sapb.StorageAuthorityReadOnlyClient,
 	ca capb.CRLGeneratorClient,
@@ -81,6 +105,14 @@ func NewUpdater(
 		return nil, fmt.Errorf("lookbackPeriod must be at least 2x updatePeriod: %s !< 2 * %s", lookbackPeriod, updatePeriod)
 	}
 
+	if deltaPeriod < 0 || (deltaPeriod > 0 && deltaPeriod >= updatePeriod) {
+		return nil, fmt.Errorf("delta period must be less than period: %s !< %s", deltaPeriod, updatePeriod)
+	}
+
+	if debouncePeriod < 0 || (debouncePeriod > 0 && debouncePeriod >= updatePeriod) {
+		return nil, fmt.Errorf("debounce period must be less than period: %s !< %s", debouncePeriod, updatePeriod)
+	}
+
 	if maxParallelism <= 0 {
 		maxParallelism = 1
 	}
@@ -99,16 +131,23 @@ func NewUpdater(
 	stats.MustRegister(updatedCounter)
 
 	return &crlUpdater{
+		cupb.UnimplementedCRLUpdaterServer{},
 		issuersByNameID,
 		numShards,
 		shardWidth,
 		lookbackPeriod,
 		updatePeriod,
 		updateOffset,
+		deltaPeriod,
+		debouncePeriod,
 		maxParallelism,
 		sa,
 		ca,
 		cs,
+		make(map[shardKey]baseCRL),
+		sync.RWMutex{},
+		make(map[shardKey]queuedShard),
+		sync.Mutex{},
 		tickHistogram,
 		updatedCounter,
 		log,
@@ -118,8 +157,21 @@ func NewUpdater(
 
 // Run causes the crlUpdater to enter its processing loop. It waits until the
 // next scheduled run time based on the current time and the updateOffset, then
-// begins running once every updatePeriod.
+// begins running once every updatePeriod. If Delta CRLs are enabled, it also
+// generates them once every deltaPeriod in between. If revocation events are
+// enabled, it regenerates the shards they affect once every debouncePeriod,
+// including while waiting for the first run. All of these are processed on the
+// same goroutine, so they never overlap.
 func (cu *crlUpdater) Run(ctx context.Context) error {
+	// The queue ticker is only created if revocation events are enabled;
+	// receiving from the nil channel otherwise blocks forever.
+	var queueC <-chan time.Time
+	if cu.debouncePeriod > 0 {
+		queueTicker := time.NewTicker(cu.debouncePeriod)
+		defer queueTicker.Stop()
+		queueC = queueTicker.C
+	}
+
 	// We don't want the times at which crlUpdater runs to be dependent on when
 	// the process starts. So wait until the appropriate time before kicking off
 	// the first run and the main ticker loop.
@@ -131,10 +183,42 @@ func (cu *crlUpdater) Run(ctx context.Context) error {
 		waitNanos = cu.updatePeriod.Nanoseconds() - currOffset + cu.updateOffset.Nanoseconds()
 	}
 	cu.log.Infof("Running, next tick in %ds", waitNanos*int64(time.Nanosecond)/int64(time.Second))
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case <-time.After(time.Duration(waitNanos)):
+	firstTick := time.After(time.Duration(waitNanos))
+
+	// The delta ticker is only created if deltas are enabled; receiving from the
+	// nil channel otherwise blocks forever.
+	var deltaC <-chan time.Time
+	if cu.deltaPeriod > 0 {
+		// The bases against which Delta CRLs are computed are only held in
+		// memory, so after a restart no deltas can be generated until complete
+		// CRLs have been published again, while those published before the
+		// restart still point at deltas which will soon expire. So publish
+		// complete CRLs immediately, rather than waiting for the first run.
+		cu.log.Infof("Delta CRLs enabled, generating base CRLs immediately")
+		atTime := cu.clk.Now()
+		err := cu.Tick(ctx, atTime)
+		if err != nil {
+			cu.log.AuditErrf(
+				"Generating base CRLs failed: number=[%s] err=[%s]",
+				(*big.Int)(crl.Number(atTime)), err)
+		}
+		deltaTicker := time.NewTicker(cu.deltaPeriod)
+		defer deltaTicker.Stop()
+		deltaC = deltaTicker.C
+	}
+
+waiting:
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-firstTick:
+			break waiting
+		case <-deltaC:
+			cu.runDeltas(ctx)
+		case <-queueC:
+			cu.runQueue(ctx)
+		}
 	}
 
 	// Tick once immediately, but create the ticker first so that it starts
@@ -160,6 +244,10 @@ func (cu *crlUpdater) Run(ctx context.Context) error {
 					"Generating CRLs failed: number=[%s] err=[%s]",
 					(*big.Int)(crl.Number(atTime)), err)
 			}
+		case <-deltaC:
+			cu.runDeltas(ctx)
+		case <-queueC:
+			cu.runQueue(ctx)
 		case <-ctx.Done():
 			ticker.Stop()
 			return ctx.Err()
@@ -202,11 +290,66 @@ func (cu *crlUpdater) Tick(ctx context.Context, atTime time.Time) (err error) {
 	return nil
 }
 
+// runDeltas generates Delta CRLs for every shard, logging rather than
+// returning any error so that the long-lived process can try again at the next
+// tick.
+func (cu *crlUpdater) runDeltas(ctx context.Context) {
+	atTime := cu.clk.Now()
+	err := cu.TickDeltas(ctx, atTime)
+	if err != nil {
+		cu.log.AuditErrf(
+			"Generating Delta CRLs failed: number=[%s] err=[%s]",
+			(*big.Int)(crl.Number(atTime)), err)
+	}
+}
+
+// TickDeltas runs the Delta CRL update process once immediately. Like Tick, it
+// processes each configured issuer serially, and returns all errors encountered
+// as a single combined error at the end.
+func (cu *crlUpdater) TickDeltas(ctx context.Context, atTime time.Time) (err error) {
+	defer func() {
+		// This func closes over the named return value `err`, so can reference it.
+		result := "success"
+		if err != nil {
+			result = "failed"
+		}
+		cu.tickHistogram.WithLabelValues("all (Delta)", result).Observe(cu.clk.Since(atTime).Seconds())
+	}()
+	cu.log.Debugf("Ticking deltas at time %s", atTime)
+
+	var errIssuers []string
+	for id := range cu.issuers {
+		err := cu.tickIssuerShards(ctx, atTime, id, " (Delta Overall)", cu.tickShardDelta)
+		if err != nil {
+			cu.log.AuditErrf(
+				"Generating Delta CRLs for issuer failed: number=[%d] issuer=[%s] err=[%s]",
+				(*big.Int)(crl.Number(atTime)), cu.issuers[id].Subject.CommonName, err)
+			errIssuers = append(errIssuers, cu.issuers[id].Subject.CommonName)
+		}
+	}
+
+	if len(errIssuers) != 0 {
+		return fmt.Errorf("%d issuers failed: %v", len(errIssuers), strings.Join(errIssuers, ", "))
+	}
+	return nil
+}
+
 // tickIssuer performs the full CRL issuance cycle for a single issuer cert. It
 // processes all of the shards of this issuer's CRL concurrently, and processes
 // all of them even if an early one encounters an error. All errors encountered
 // are returned as a single combined error at the end.
-func (cu *crlUpdater) tickIssuer(ctx context.Context, atTime time.Time, issuerNameID issuance.IssuerNameID) (err error) {
+func (cu *crlUpdater) tickIssuer(ctx context.Context, atTime time.Time, issuerNameID issuance.IssuerNameID) error {
+	return cu.tickIssuerShards(ctx, atTime, issuerNameID, " (Overall)", cu.tickShard)
+}
+
+// shardTicker processes a single shard of an issuer's CRL, given the chunks
+// which are mapped to it.
+type shardTicker func(ctx context.Context, atTime time.Time, issuerNameID issuance.IssuerNameID, shardIdx int, chunks []chunk) error
+
+// tickIssuerShards computes the current shard mappings and runs tickFunc on
+// each of the issuer's shards, using a pool of maxParallelism workers. The
+// label suffix distinguishes its overall latency metric.
+func (cu *crlUpdater) tickIssuerShards(ctx context.Context, atTime time.Time, issuerNameID issuance.IssuerNameID, label string, tickFunc shardTicker) (err error) {
 	start := cu.clk.Now()
 	defer func() {
 		// This func closes over the named return value `err`, so can reference it.
@@ -214,7 +357,7 @@ func (cu *crlUpdater) tickIssuer(ctx context.Context, atTime time.Time, issuerNa
 		if err != nil {
 			result = "failed"
 		}
-		cu.tickHistogram.WithLabelValues(cu.issuers[issuerNameID].Subject.CommonName+" (Overall)", result).Observe(cu.clk.Since(start).Seconds())
+		cu.tickHistogram.WithLabelValues(cu.issuers[issuerNameID].Subject.CommonName+label, result).Observe(cu.clk.Since(start).Seconds())
 	}()
 	cu.log.Debugf("Ticking issuer %d at time %s", issuerNameID, atTime)
 
@@ -236,7 +379,7 @@ func (cu *crlUpdater) tickIssuer(ctx context.Context, atTime time.Time, issuerNa
 			default:
 				out <- shardResult{
 					shardIdx: idx,
-					err:      cu.tickShard(ctx, atTime, issuerNameID, idx, shardMap[idx]),
+					err:      tickFunc(ctx, atTime, issuerNameID, idx, shardMap[idx]),
 				}
 			}
 		}
@@ -274,7 +417,8 @@ func (cu *crlUpdater) tickIssuer(ctx context.Context, atTime time.Time, issuerNa
 // tickShard processes a single shard. It computes the shard's boundaries, gets
 // the list of revoked certs in that shard from the SA, gets the CA to sign the
 // resulting CRL, and gets the crl-storer to upload it. It returns an error if
-// any of these operations fail.
+// any of these operations fail. On success, the CRL becomes the base against
+// which that shard's Delta CRLs are computed.
 func (cu *crlUpdater) tickShard(ctx context.Context, atTime time.Time, issuerNameID issuance.IssuerNameID, shardIdx int, chunks []chunk) (err error) {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -295,17 +439,120 @@ func (cu *crlUpdater) tickShard(ctx context.Context, atTime time.Time, issuerNam
 	cu.log.Infof(
 		"Generating CRL shard: id=[%s] numChunks=[%d]", crlID, len(chunks))
 
-	// Get the full list of CRL Entries for this shard from the SA.
+	crlEntries, err := cu.getShardEntries(ctx, atTime, issuerNameID, shardIdx, chunks)
+	if err != nil {
+		return err
+	}
+
+	crlLen, crlHash, err := cu.signAndStore(ctx, atTime, issuerNameID, shardIdx, time.Time{}, crlEntries)
+	if err != nil {
+		return err
+	}
+
+	cu.setBase(issuerNameID, shardIdx, atTime, crlEntries)
+
+	cu.log.Infof(
+		"Generated CRL shard: id=[%s] size=[%d] hash=[%x]",
+		crlID, crlLen, crlHash)
+	return nil
+}
+
+// tickShardDelta processes a single shard's Delta CRL. It gets the same list of
+// revoked certs as tickShard would, keeps only those which differ from the
+// shard's last published base CRL, gets the CA to sign the resulting Delta CRL, and
+// gets the crl-storer to upload it. Shards which have no base CRL yet, such as
+// immediately after startup, are skipped.
+func (cu *crlUpdater) tickShardDelta(ctx context.Context, atTime time.Time, issuerNameID issuance.IssuerNameID, shardIdx int, chunks []chunk) (err error) {
+	base, ok := cu.getBaseCRL(issuerNameID, shardIdx)
+	if !ok {
+		cu.log.Debugf("Skipping Delta CRL for shard without base: issuer=[%d] shard=[%d]", issuerNameID, shardIdx)
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	crlID := crl.Id(issuerNameID, crl.Number(atTime), shardIdx)
+
+	start := cu.clk.Now()
+	defer func() {
+		// This func closes over the named return value `err`, so can reference it.
+		result := "success"
+		if err != nil {
+			result = "failed"
+		}
+		cu.tickHistogram.WithLabelValues(cu.issuers[issuerNameID].Subject.CommonName+" (Delta)", result).Observe(cu.clk.Since(start).Seconds())
+		cu.updatedCounter.WithLabelValues(cu.issuers[issuerNameID].Subject.CommonName+" (Delta)", result).Inc()
+	}()
+
+	cu.log.Infof(
+		"Generating Delta CRL shard: id=[%s] base=[%s] numChunks=[%d]",
+		crlID, (*big.Int)(crl.Number(base.thisUpdate)), len(chunks))
+
+	allEntries, err := cu.getShardEntries(ctx, atTime, issuerNameID, shardIdx, chunks)
+	if err != nil {
+		return err
+	}
+
+	crlEntries := base.changes(allEntries)
+
+	crlLen, crlHash, err := cu.signAndStore(ctx, atTime, issuerNameID, shardIdx, base.thisUpdate, crlEntries)
+	if err != nil {
+		return err
+	}
+
+	cu.log.Infof(
+		"Generated Delta CRL shard: id=[%s] numEntries=[%d] size=[%d] hash=[%x]",
+		crlID, len(crlEntries), crlLen, crlHash)
+	return nil
+}
+
+// getShardEntries gets the full list of CRL Entries for a shard from the SA.
+// Certificates which were assigned this shard at issuance, and so name it in
+// their CRL distribution points, are placed in it regardless of their notAfter
+// date. Only those without a stored shard are placed by the chunk mapping.
+func (cu *crlUpdater) getShardEntries(ctx context.Context, atTime time.Time, issuerNameID issuance.IssuerNameID, shardIdx int, chunks []chunk) ([]*proto.CRLEntry, error) {
+	crlID := crl.Id(issuerNameID, crl.Number(atTime), shardIdx)
+
 	var crlEntries []*proto.CRLEntry
+	storedShards := features.Enabled(features.StoreCRLShards)
+	if storedShards {
+		saStream, err := cu.sa.GetRevokedCertsByShard(ctx, &sapb.GetRevokedCertsByShardRequest{
+			IssuerNameID:  int64(issuerNameID),
+			ShardIdx:      int64(shardIdx),
+			ExpiresAfter:  atTime.Add(-cu.lookbackPeriod).UnixNano(),
+			RevokedBefore: atTime.UnixNano(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("connecting to SA: %w", err)
+		}
+
+		for {
+			entry, err := saStream.Recv()
+			if err != nil {
+				if err == io.EOF {
+					break
+				}
+				return nil, fmt.Errorf("retrieving entry from SA: %w", err)
+			}
+			crlEntries = append(crlEntries, entry)
+		}
+
+		cu.log.Infof(
+			"Queried SA for stored CRL shard: id=[%s] numEntries=[%d]",
+			crlID, len(crlEntries))
+	}
+
 	for _, chunk := range chunks {
 		saStream, err := cu.sa.GetRevokedCerts(ctx, &sapb.GetRevokedCertsRequest{
 			IssuerNameID:  int64(issuerNameID),
 			ExpiresAfter:  chunk.start.UnixNano(),
 			ExpiresBefore: chunk.end.UnixNano(),
 			RevokedBefore: atTime.UnixNano(),
+			UnshardedOnly: storedShards,
 		})
 		if err != nil {
-			return fmt.Errorf("connecting to SA: %w", err)
+			return nil, fmt.Errorf("connecting to SA: %w", err)
 		}
 
 		for {
@@ -314,7 +561,7 @@ func (cu *crlUpdater) tickShard(ctx context.Context, atTime time.Time, issuerNam
 				if err == io.EOF {
 					break
 				}
-				return fmt.Errorf("retrieving entry from SA: %w", err)
+				return nil, fmt.Errorf("retrieving entry from SA: %w", err)
 			}
 			crlEntries = append(crlEntries, entry)
 		}
@@ -324,23 +571,45 @@ func (cu *crlUpdater) tickShard(ctx context.Context, atTime time.Time, issuerNam
 			crlID, chunk.start, chunk.end, len(crlEntries))
 	}
 
+	return crlEntries, nil
+}
+
+// signAndStore sends the given CRL Entries to the CA to be signed, and sends
+// the resulting CRL to the crl-storer to be uploaded. If base is non-zero, the
+// CRL is a Delta CRL against the complete CRL issued at that time. Otherwise,
+// it is a complete CRL, which points at its Delta CRL if deltas are enabled
+// and the shard already has a base, so that no CRL points at a delta which
+// this process isn't yet keeping up to date. It returns the length and hash of
+// the signed CRL.
+func (cu *crlUpdater) signAndStore(ctx context.Context, atTime time.Time, issuerNameID issuance.IssuerNameID, shardIdx int, base time.Time, crlEntries []*proto.CRLEntry) (int, []byte, error) {
+	var baseThisUpdate int64
+	var includeFreshest bool
+	if !base.IsZero() {
+		baseThisUpdate = base.UnixNano()
+	} else if cu.deltaPeriod > 0 {
+		_, includeFreshest = cu.getBase(issuerNameID, shardIdx)
+	}
+
 	// Send the full list of CRL Entries to the CA.
 	caStream, err := cu.ca.GenerateCRL(ctx)
 	if err != nil {
-		return fmt.Errorf("connecting to CA: %w", err)
+		return 0, nil, fmt.Errorf("connecting to CA: %w", err)
 	}
 
 	err = caStream.Send(&capb.GenerateCRLRequest{
 		Payload: &capb.GenerateCRLRequest_Metadata{
 			Metadata: &capb.CRLMetadata{
-				IssuerNameID: int64(issuerNameID),
-				ThisUpdate:   atTime.UnixNano(),
-				ShardIdx:     int64(shardIdx),
+				IssuerNameID:       int64(issuerNameID),
+				ThisUpdate:         atTime.UnixNano(),
+				ShardIdx:           int64(shardIdx),
+				BaseThisUpdate:     baseThisUpdate,
+				IncludeFreshestCRL: includeFreshest,
+				NumShards:          int64(cu.numShards),
 			},
 		},
 	})
 	if err != nil {
-		return fmt.Errorf("sending CA metadata: %w", err)
+		return 0, nil, fmt.Errorf("sending CA metadata: %w", err)
 	}
 
 	for _, entry := range crlEntries {
@@ -350,13 +619,13 @@ func (cu *crlUpdater) tickShard(ctx context.Context, atTime time.Time, issuerNam
 			},
 		})
 		if err != nil {
-			return fmt.Errorf("sending entry to CA: %w", err)
+			return 0, nil, fmt.Errorf("sending entry to CA: %w", err)
 		}
 	}
 
 	err = caStream.CloseSend()
 	if err != nil {
-		return fmt.Errorf("closing CA request stream: %w", err)
+		return 0, nil, fmt.Errorf("closing CA request stream: %w", err)
 	}
 
 	// Receive the full bytes of the signed CRL from the CA.
@@ -369,7 +638,7 @@ func (cu *crlUpdater) tickShard(ctx context.Context, atTime time.Time, issuerNam
 			if err == io.EOF {
 				break
 			}
-			return fmt.Errorf("receiving CRL bytes: %w", err)
+			return 0, nil, fmt.Errorf("receiving CRL bytes: %w", err)
 		}
 
 		crlLen += len(out.Chunk)
@@ -380,7 +649,7 @@ func (cu *crlUpdater) tickShard(ctx context.Context, atTime time.Time, issuerNam
 	// Send the full bytes of the signed CRL to the Storer.
 	csStream, err := cu.cs.UploadCRL(ctx)
 	if err != nil {
-		return fmt.Errorf("connecting to CRLStorer: %w", err)
+		return 0, nil, fmt.Errorf("connecting to CRLStorer: %w", err)
 	}
 
 	err = csStream.Send(&cspb.UploadCRLRequest{
@@ -389,11 +658,12 @@ func (cu *crlUpdater) tickShard(ctx context.Context, atTime time.Time, issuerNam
 				IssuerNameID: int64(issuerNameID),
 				Number:       atTime.UnixNano(),
 				ShardIdx:     int64(shardIdx),
+				Delta:        baseThisUpdate != 0,
 			},
 		},
 	})
 	if err != nil {
-		return fmt.Errorf("sending CRLStorer metadata: %w", err)
+		return 0, nil, fmt.Errorf("sending CRLStorer metadata: %w", err)
 	}
 
 	for _, chunk := range crlChunks {
@@ -403,19 +673,72 @@ func (cu *crlUpdater) tickShard(ctx context.Context, atTime time.Time, issuerNam
 			},
 		})
 		if err != nil {
-			return fmt.Errorf("uploading CRL bytes: %w", err)
+			return 0, nil, fmt.Errorf("uploading CRL bytes: %w", err)
 		}
 	}
 
 	_, err = csStream.CloseAndRecv()
 	if err != nil {
-		return fmt.Errorf("closing CRLStorer upload stream: %w", err)
+		return 0, nil, fmt.Errorf("closing CRLStorer upload stream: %w", err)
 	}
 
-	cu.log.Infof(
-		"Generated CRL shard: id=[%s] size=[%d] hash=[%x]",
-		crlID, crlLen, crlHash.Sum(nil))
-	return nil
+	return crlLen, crlHash.Sum(nil), nil
+}
+
+// baseCRL describes a complete CRL against which Delta CRLs are computed.
+type baseCRL struct {
+	thisUpdate time.Time
+	// reasons maps the serial of each entry in the CRL to its reason code.
+	reasons map[string]int32
+}
+
+// setBase records the most recently published complete CRL for the given
+// shard, against which its Delta CRLs will be computed.
+func (cu *crlUpdater) setBase(issuerNameID issuance.IssuerNameID, shardIdx int, thisUpdate time.Time, entries []*proto.CRLEntry) {
+	reasons := make(map[string]int32, len(entries))
+	for _, entry := range entries {
+		reasons[entry.Serial] = entry.Reason
+	}
+
+	cu.basesMu.Lock()
+	defer cu.basesMu.Unlock()
+	key := shardKey{issuerNameID, shardIdx}
+	if thisUpdate.After(cu.bases[key].thisUpdate) {
+		cu.bases[key] = baseCRL{thisUpdate, reasons}
+	}
+}
+
+// getBaseCRL returns the most recently published complete CRL for the given
+// shard, or false if none has been published by this process.
+func (cu *crlUpdater) getBaseCRL(issuerNameID issuance.IssuerNameID, shardIdx int) (baseCRL, bool) {
+	cu.basesMu.RLock()
+	defer cu.basesMu.RUnlock()
+	base, ok := cu.bases[shardKey{issuerNameID, shardIdx}]
+	return base, ok
+}
+
+// getBase returns the thisUpdate of the most recently published complete CRL
+// for the given shard, or false if none has been published by this process.
+func (cu *crlUpdater) getBase(issuerNameID issuance.IssuerNameID, shardIdx int) (time.Time, bool) {
+	base, ok := cu.getBaseCRL(issuerNameID, shardIdx)
+	return base.thisUpdate, ok
+}
+
+// changes returns those of the given entries of the base CRL's shard which
+// differ from it: those which it doesn't contain, and those whose reason has
+// changed since, such as certificates re-revoked for keyCompromise. RFC 5280,
+// Section 5.2.4 requires a Delta CRL to list every change since its base. This
+// is judged by the base's entries rather than by revocation date, since neither
+// reason changes nor backdated revocations after the base are revealed by it.
+func (b baseCRL) changes(entries []*proto.CRLEntry) []*proto.CRLEntry {
+	var changed []*proto.CRLEntry
+	for _, entry := range entries {
+		reason, ok := b.reasons[entry.Serial]
+		if !ok || reason != entry.Reason {
+			changed = append(changed, entry)
+		}
+	}
+	return changed
 }
 
 // anchorTime is used as a universal starting point against which other times
@@ -15,6 +15,7 @@ import (
 	capb "github.com/letsencrypt/boulder/ca/proto"
 	corepb "github.com/letsencrypt/boulder/core/proto"
 	cspb "github.com/letsencrypt/boulder/crl/storer/proto"
+	"github.com/letsencrypt/boulder/features"
 	"github.com/letsencrypt/boulder/issuance"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
@@ -72,9 +73,11 @@ type fakeGCC struct {
 	nextIdx int
 	sendErr error
 	recvErr error
+	sent    []*capb.GenerateCRLRequest
 }
 
-func (f *fakeGCC) Send(*capb.GenerateCRLRequest) error {
+func (f *fakeGCC) Send(req *capb.GenerateCRLRequest) error {
+	f.sent = append(f.sent, req)
 	return f.sendErr
 }
 
@@ -110,9 +113,11 @@ type fakeUCC struct {
 	grpc.ClientStream
 	sendErr error
 	recvErr error
+	sent    []*cspb.UploadCRLRequest
 }
 
-func (f *fakeUCC) Send(*cspb.UploadCRLRequest) error {
+func (f *fakeUCC) Send(req *cspb.UploadCRLRequest) error {
+	f.sent = append(f.sent, req)
 	return f.sendErr
 }
 
@@ -146,7 +151,7 @@ func TestTickShard(t *testing.T) {
 	cu, err := NewUpdater(
 		[]*issuance.Certificate{e1, r3},
 		2, 18*time.Hour, 24*time.Hour,
-		6*time.Hour, 1*time.Minute, 1,
+		6*time.Hour, 1*time.Minute, 0, 0, 1,
 		&fakeSAC{grcc: fakeGRCC{}, maxNotAfter: clk.Now().Add(90 * 24 * time.Hour)},
 		&fakeCGC{gcc: fakeGCC{}},
 		&fakeCSC{ucc: fakeUCC{}},
@@ -222,6 +227,185 @@ func TestTickShard(t *testing.T) {
 	cu.updatedCounter.Reset()
 }
 
+// fakeShardSAC is a fakeSAC which records the requests it receives, and which
+// returns a separate fakeGRCC for calls to GetRevokedCertsByShard.
+type fakeShardSAC struct {
+	fakeSAC
+	shardGRCC fakeGRCC
+	shardReqs []*sapb.GetRevokedCertsByShardRequest
+	chunkReqs []*sapb.GetRevokedCertsRequest
+}
+
+func (f *fakeShardSAC) GetRevokedCerts(ctx context.Context, req *sapb.GetRevokedCertsRequest, _ ...grpc.CallOption) (sapb.StorageAuthorityReadOnly_GetRevokedCertsClient, error) {
+	f.chunkReqs = append(f.chunkReqs, req)
+	return &f.grcc, nil
+}
+
+func (f *fakeShardSAC) GetRevokedCertsByShard(ctx context.Context, req *sapb.GetRevokedCertsByShardRequest, _ ...grpc.CallOption) (sapb.StorageAuthorityReadOnly_GetRevokedCertsByShardClient, error) {
+	f.shardReqs = append(f.shardReqs, req)
+	return &f.shardGRCC, nil
+}
+
+func TestTickShardStoredShards(t *testing.T) {
+	defer features.Reset()
+	e1, err := issuance.LoadCertificate("../../test/hierarchy/int-e1.cert.pem")
+	test.AssertNotError(t, err, "loading test issuer")
+
+	clk := clock.NewFake()
+	clk.Set(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC))
+	sa := &fakeShardSAC{fakeSAC: fakeSAC{maxNotAfter: clk.Now().Add(90 * 24 * time.Hour)}}
+	cu, err := NewUpdater(
+		[]*issuance.Certificate{e1},
+		2, 18*time.Hour, 24*time.Hour,
+		6*time.Hour, 1*time.Minute, 0, 0, 1,
+		sa,
+		&fakeCGC{gcc: fakeGCC{}},
+		&fakeCSC{ucc: fakeUCC{}},
+		metrics.NoopRegisterer, blog.NewMock(), clk,
+	)
+	test.AssertNotError(t, err, "building test crlUpdater")
+
+	testChunks := []chunk{
+		{clk.Now(), clk.Now().Add(18 * time.Hour), 1},
+	}
+
+	// Without the feature, entries are only placed by their notAfter date.
+	err = cu.tickShard(context.Background(), clk.Now(), e1.NameID(), 1, testChunks)
+	test.AssertNotError(t, err, "tickShard failed")
+	test.AssertEquals(t, len(sa.shardReqs), 0)
+	test.AssertEquals(t, len(sa.chunkReqs), 1)
+	test.Assert(t, !sa.chunkReqs[0].UnshardedOnly, "chunk request excluded stored shards")
+
+	// With it, entries are also placed by their stored shard, and the chunks
+	// only contain certificates without one.
+	err = features.Set(map[string]bool{"StoreCRLShards": true})
+	test.AssertNotError(t, err, "setting feature")
+	sa.chunkReqs = nil
+	err = cu.tickShard(context.Background(), clk.Now(), e1.NameID(), 1, testChunks)
+	test.AssertNotError(t, err, "tickShard failed")
+	test.AssertEquals(t, len(sa.shardReqs), 1)
+	test.AssertEquals(t, sa.shardReqs[0].IssuerNameID, int64(e1.NameID()))
+	test.AssertEquals(t, sa.shardReqs[0].ShardIdx, int64(1))
+	test.AssertEquals(t, sa.shardReqs[0].ExpiresAfter, clk.Now().Add(-24*time.Hour).UnixNano())
+	test.AssertEquals(t, len(sa.chunkReqs), 1)
+	test.Assert(t, sa.chunkReqs[0].UnshardedOnly, "chunk request included stored shards")
+
+	// Errors reading the stored shard should bubble up.
+	sentinelErr := errors.New("oops")
+	sa.shardGRCC = fakeGRCC{err: sentinelErr}
+	err = cu.tickShard(context.Background(), clk.Now(), e1.NameID(), 1, testChunks)
+	test.AssertErrorIs(t, err, sentinelErr)
+	test.AssertContains(t, err.Error(), "retrieving entry from SA")
+}
+
+func TestTickShardDelta(t *testing.T) {
+	e1, err := issuance.LoadCertificate("../../test/hierarchy/int-e1.cert.pem")
+	test.AssertNotError(t, err, "loading test issuer")
+
+	clk := clock.NewFake()
+	clk.Set(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC))
+	base := clk.Now()
+
+	_, err = NewUpdater(
+		[]*issuance.Certificate{e1},
+		2, 18*time.Hour, 24*time.Hour,
+		6*time.Hour, 1*time.Minute, 6*time.Hour, 0, 1,
+		&fakeSAC{}, &fakeCGC{}, &fakeCSC{},
+		metrics.NoopRegisterer, blog.NewMock(), clk,
+	)
+	test.AssertError(t, err, "delta period as long as update period")
+	test.AssertContains(t, err.Error(), "delta period must be less than period")
+
+	sa := &fakeSAC{
+		grcc: fakeGRCC{entries: []*corepb.CRLEntry{
+			{Serial: "0311b5d430823cfa25b0fc85d14c54ee35", Reason: 1, RevokedAt: base.Add(-time.Hour).UnixNano()},
+			{Serial: "038c3f6388afb7695dd4d6bbe3d264f1e4", Reason: 4, RevokedAt: base.Add(-time.Hour).UnixNano()},
+		}},
+		maxNotAfter: clk.Now().Add(90 * 24 * time.Hour),
+	}
+	ca := &fakeCGC{gcc: fakeGCC{}}
+	cs := &fakeCSC{ucc: fakeUCC{}}
+	cu, err := NewUpdater(
+		[]*issuance.Certificate{e1},
+		2, 18*time.Hour, 24*time.Hour,
+		6*time.Hour, 1*time.Minute, 1*time.Hour, 0, 1,
+		sa, ca, cs,
+		metrics.NoopRegisterer, blog.NewMock(), clk,
+	)
+	test.AssertNotError(t, err, "building test crlUpdater")
+
+	testChunks := []chunk{
+		{clk.Now(), clk.Now().Add(18 * time.Hour), 0},
+	}
+
+	// Without a base CRL, there is nothing for a delta to update.
+	err = cu.tickShardDelta(context.Background(), clk.Now(), e1.NameID(), 0, testChunks)
+	test.AssertNotError(t, err, "tickShardDelta without base")
+	test.AssertEquals(t, len(ca.gcc.sent), 0)
+
+	// A complete CRL becomes the base, but as no deltas have been generated
+	// since this process started, the first one doesn't point at them.
+	err = cu.tickShard(context.Background(), base, e1.NameID(), 0, testChunks)
+	test.AssertNotError(t, err, "tickShard failed")
+	meta := ca.gcc.sent[0].GetMetadata()
+	test.Assert(t, !meta.IncludeFreshestCRL, "complete CRL without a base should not include Freshest CRL")
+	test.AssertEquals(t, meta.BaseThisUpdate, int64(0))
+	test.AssertEquals(t, meta.NumShards, int64(2))
+	test.AssertEquals(t, len(ca.gcc.sent), 3)
+	test.Assert(t, !cs.ucc.sent[0].GetMetadata().Delta, "complete CRL stored as delta")
+	_, ok := cu.getBase(e1.NameID(), 1)
+	test.Assert(t, !ok, "other shard should have no base")
+
+	// The delta contains only the entries which changed since the base: new
+	// revocations, including those backdated to before it, and the
+	// re-revocation of a certificate for keyCompromise.
+	ca.gcc.sent = nil
+	cs.ucc.sent = nil
+	sa.grcc.entries = []*corepb.CRLEntry{
+		{Serial: "0311b5d430823cfa25b0fc85d14c54ee35", Reason: 1, RevokedAt: base.Add(-time.Hour).UnixNano()},
+		{Serial: "038c3f6388afb7695dd4d6bbe3d264f1e4", Reason: 1, RevokedAt: base.Add(-time.Hour).UnixNano()},
+		{Serial: "037d6a05a0f6a975380456ae605cee9889", Reason: 1, RevokedAt: base.Add(30 * time.Minute).UnixNano()},
+		{Serial: "0371b58f7c5bb0a0b5b3a0e5b0b4b8a0b7", Reason: 0, RevokedAt: base.Add(-time.Minute).UnixNano()},
+	}
+	sa.grcc.nextIdx = 0
+	clk.Add(time.Hour)
+	err = cu.tickShardDelta(context.Background(), clk.Now(), e1.NameID(), 0, testChunks)
+	test.AssertNotError(t, err, "tickShardDelta failed")
+	meta = ca.gcc.sent[0].GetMetadata()
+	test.AssertEquals(t, meta.BaseThisUpdate, base.UnixNano())
+	test.AssertEquals(t, meta.ThisUpdate, clk.Now().UnixNano())
+	test.AssertEquals(t, len(ca.gcc.sent), 4)
+	test.AssertEquals(t, ca.gcc.sent[1].GetEntry().Serial, "038c3f6388afb7695dd4d6bbe3d264f1e4")
+	test.AssertEquals(t, ca.gcc.sent[1].GetEntry().Reason, int32(1))
+	test.AssertEquals(t, ca.gcc.sent[2].GetEntry().Serial, "037d6a05a0f6a975380456ae605cee9889")
+	test.AssertEquals(t, ca.gcc.sent[3].GetEntry().Serial, "0371b58f7c5bb0a0b5b3a0e5b0b4b8a0b7")
+	test.Assert(t, cs.ucc.sent[0].GetMetadata().Delta, "delta CRL not stored as delta")
+	test.AssertMetricWithLabelsEquals(t, cu.updatedCounter, prometheus.Labels{
+		"issuer": "(TEST) Elegant Elephant E1 (Delta)", "result": "success",
+	}, 1)
+
+	// Once the shard has a base, complete CRLs point at its deltas.
+	ca.gcc.sent = nil
+	sa.grcc.nextIdx = 0
+	err = cu.tickShard(context.Background(), clk.Now(), e1.NameID(), 1, testChunks)
+	test.AssertNotError(t, err, "tickShard failed")
+	test.Assert(t, !ca.gcc.sent[0].GetMetadata().IncludeFreshestCRL, "complete CRL for shard without a base should not include Freshest CRL")
+	ca.gcc.sent = nil
+	sa.grcc.nextIdx = 0
+	err = cu.tickShard(context.Background(), clk.Now(), e1.NameID(), 0, testChunks)
+	test.AssertNotError(t, err, "tickShard failed")
+	test.Assert(t, ca.gcc.sent[0].GetMetadata().IncludeFreshestCRL, "complete CRL with a base should include Freshest CRL")
+	base = clk.Now()
+
+	// A failed complete CRL doesn't replace the base.
+	cu.cs = &fakeCSC{ucc: fakeUCC{recvErr: errors.New("oops")}}
+	err = cu.tickShard(context.Background(), clk.Now(), e1.NameID(), 0, testChunks)
+	test.AssertError(t, err, "storer error")
+	got, ok := cu.getBase(e1.NameID(), 0)
+	test.Assert(t, ok, "shard should still have a base")
+	test.AssertEquals(t, got, base)
+}
+
 func TestTickIssuer(t *testing.T) {
 	e1, err := issuance.LoadCertificate("../../test/hierarchy/int-e1.cert.pem")
 	test.AssertNotError(t, err, "loading test issuer")
@@ -234,7 +418,7 @@ func TestTickIssuer(t *testing.T) {
 	cu, err := NewUpdater(
 		[]*issuance.Certificate{e1, r3},
 		2, 18*time.Hour, 24*time.Hour,
-		6*time.Hour, 1*time.Minute, 1,
+		6*time.Hour, 1*time.Minute, 0, 0, 1,
 		&fakeSAC{grcc: fakeGRCC{err: errors.New("db no worky")}, maxNotAfter: clk.Now().Add(90 * 24 * time.Hour)},
 		&fakeCGC{gcc: fakeGCC{}},
 		&fakeCSC{ucc: fakeUCC{}},
@@ -270,7 +454,7 @@ func TestTick(t *testing.T) {
 	cu, err := NewUpdater(
 		[]*issuance.Certificate{e1, r3},
 		2, 18*time.Hour, 24*time.Hour,
-		6*time.Hour, 1*time.Minute, 1,
+		6*time.Hour, 1*time.Minute, 0, 0, 1,
 		&fakeSAC{grcc: fakeGRCC{err: errors.New("db no worky")}, maxNotAfter: clk.Now().Add(90 * 24 * time.Hour)},
 		&fakeCGC{gcc: fakeGCC{}},
 		&fakeCSC{ucc: fakeUCC{}},
@@ -21,6 +21,9 @@ const maxCNLength = 64
 // strong enough to use. Significantly the missing algorithms are:
 // * No algorithms using MD2, MD5, or SHA-1
 // * No DSA algorithms
+// The Ed25519 algorithm is only usable by keys which the key policy allows. The
+// ML-DSA algorithms are added when built with Go 1.27 or later (see mldsa.go),
+// and are likewise only usable by keys which the key policy allows.
 var goodSignatureAlgorithms = map[x509.SignatureAlgorithm]bool{
 	x509.SHA256WithRSA:   true,
 	x509.SHA384WithRSA:   true,
@@ -28,6 +31,7 @@ var goodSignatureAlgorithms = map[x509.SignatureAlgorithm]bool{
 	x509.ECDSAWithSHA256: true,
 	x509.ECDSAWithSHA384: true,
 	x509.ECDSAWithSHA512: true,
+	x509.PureEd25519:     true,
 }
 
 var (
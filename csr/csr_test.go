@@ -2,6 +2,7 @@ package csr
 
 import (
 	"context"
+	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -265,3 +266,22 @@ func TestDuplicateExtensionRejection(t *testing.T) {
 	_, err = x509.ParseCertificateRequest(csrBytes)
 	test.AssertError(t, err, "CSR with duplicate extension OID should fail to parse")
 }
+
+func TestVerifyCSREd25519(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	test.AssertNotError(t, err, "generating Ed25519 key")
+	csrBytes, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		DNSNames: []string{"example.com"},
+	}, priv)
+	test.AssertNotError(t, err, "creating test CSR")
+	csr, err := x509.ParseCertificateRequest(csrBytes)
+	test.AssertNotError(t, err, "parsing test CSR")
+
+	err = VerifyCSR(context.Background(), csr, 100, testingPolicy, &mockPA{})
+	test.AssertErrorIs(t, err, berrors.BadCSR)
+
+	policy := *testingPolicy
+	policy.AllowEd25519 = true
+	err = VerifyCSR(context.Background(), csr, 100, &policy, &mockPA{})
+	test.AssertNotError(t, err, "CSR should verify when Ed25519 keys are allowed")
+}
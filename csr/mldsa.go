@@ -0,0 +1,11 @@
+//go:build go1.27
+
+package csr
+
+import "crypto/x509"
+
+func init() {
+	goodSignatureAlgorithms[x509.MLDSA44] = true
+	goodSignatureAlgorithms[x509.MLDSA65] = true
+	goodSignatureAlgorithms[x509.MLDSA87] = true
+}
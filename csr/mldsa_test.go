@@ -0,0 +1,33 @@
+//go:build go1.27
+
+package csr
+
+import (
+	"context"
+	"crypto/mldsa"
+	"crypto/rand"
+	"crypto/x509"
+	"testing"
+
+	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestVerifyCSRMLDSA(t *testing.T) {
+	priv, err := mldsa.GenerateKey(mldsa.MLDSA65())
+	test.AssertNotError(t, err, "generating ML-DSA key")
+	csrBytes, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		DNSNames: []string{"example.com"},
+	}, priv)
+	test.AssertNotError(t, err, "creating test CSR")
+	csr, err := x509.ParseCertificateRequest(csrBytes)
+	test.AssertNotError(t, err, "parsing test CSR")
+
+	err = VerifyCSR(context.Background(), csr, 100, testingPolicy, &mockPA{})
+	test.AssertErrorIs(t, err, berrors.BadCSR)
+
+	policy := *testingPolicy
+	policy.AllowMLDSA = true
+	err = VerifyCSR(context.Background(), csr, 100, &policy, &mockPA{})
+	test.AssertNotError(t, err, "CSR should verify when ML-DSA keys are allowed")
+}
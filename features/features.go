@@ -75,6 +75,15 @@ const (
 	// According to the BRs Section 7.1.4.2.2(a), the commonName field is
 	// Deprecated, and its inclusion is discouraged but not (yet) prohibited.
 	RequireCommonName
+
+	// StoreCRLShards causes the SA to store the CRL shard which certificates
+	// were assigned at issuance, and the crl-updater to place revoked
+	// certificates in their stored shard rather than one derived from their
+	// notAfter date. It requires the certificateStatus.crlShard column, and
+	// must be enabled in the SA before the crl-updater. The CA requires it in
+	// order to include per-shard CRL distribution points in certificates, so
+	// it must be enabled in the SA and crl-updater before it is enabled there.
+	StoreCRLShards
 )
 
 // List of features and their default value, protected by fMu
@@ -95,6 +104,7 @@ var features = map[FeatureFlag]bool{
 	CertCheckerRequiresValidations: false,
 	AsyncFinalize:                  false,
 	RequireCommonName:              true,
+	StoreCRLShards:                 false,
 }
 
 var fMu = new(sync.RWMutex)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rsa"
 	"errors"
@@ -53,6 +54,13 @@ type Config struct {
 	// be trivially factored because the two factors are very close to each other.
 	// If this config value is empty (0), no factorization will be attempted.
 	FermatRounds int
+	// AllowEd25519 permits Ed25519 keys. They are not permitted for publicly
+	// trusted certificates, so this should only be set for private PKIs.
+	AllowEd25519 bool
+	// AllowMLDSA permits ML-DSA (FIPS 204) keys. Support is experimental,
+	// requires Boulder to be built with Go 1.27 or later, and is subject to the
+	// same caveats as AllowEd25519.
+	AllowMLDSA bool
 }
 
 // ErrBadKey represents an error with a key. It is distinct from the various
@@ -78,13 +86,16 @@ type KeyPolicy struct {
 	AllowRSA           bool // Whether RSA keys should be allowed.
 	AllowECDSANISTP256 bool // Whether ECDSA NISTP256 keys should be allowed.
 	AllowECDSANISTP384 bool // Whether ECDSA NISTP384 keys should be allowed.
+	AllowEd25519       bool // Whether Ed25519 keys should be allowed.
+	AllowMLDSA         bool // Whether ML-DSA keys should be allowed.
 	weakRSAList        *WeakRSAKeys
 	blockedList        *blockedKeys
 	fermatRounds       int
 	blockedCheck       BlockedKeyCheckFunc
 }
 
-// NewKeyPolicy returns a KeyPolicy that allows RSA, ECDSA256 and ECDSA384.
+// NewKeyPolicy returns a KeyPolicy that allows RSA, ECDSA256 and ECDSA384, and
+// Ed25519 and ML-DSA if the config says so.
 // weakKeyFile contains the path to a JSON file containing truncated modulus
 // hashes of known weak RSA keys. If this argument is empty RSA modulus hash
 // checking will be disabled. blockedKeyFile contains the path to a YAML file
@@ -96,8 +107,13 @@ func NewKeyPolicy(config *Config, bkc BlockedKeyCheckFunc) (KeyPolicy, error) {
 		AllowRSA:           true,
 		AllowECDSANISTP256: true,
 		AllowECDSANISTP384: true,
+		AllowEd25519:       config.AllowEd25519,
+		AllowMLDSA:         config.AllowMLDSA,
 		blockedCheck:       bkc,
 	}
+	if config.AllowMLDSA && !mldsaSupported {
+		return KeyPolicy{}, errors.New("ML-DSA keys require Boulder to be built with Go 1.27 or later")
+	}
 	if config.WeakKeyFile != "" {
 		keyList, err := LoadWeakRSASuffixes(config.WeakKeyFile)
 		if err != nil {
@@ -121,16 +137,20 @@ func NewKeyPolicy(config *Config, bkc BlockedKeyCheckFunc) (KeyPolicy, error) {
 
 // GoodKey returns true if the key is acceptable for both TLS use and account
 // key use (our requirements are the same for either one), according to basic
-// strength and algorithm checking. GoodKey only supports pointers: *rsa.PublicKey
-// and *ecdsa.PublicKey. It will reject non-pointer types.
+// strength and algorithm checking. GoodKey supports *rsa.PublicKey,
+// *ecdsa.PublicKey, ed25519.PublicKey and, when built with Go 1.27 or later,
+// *mldsa.PublicKey. It will reject other types, including non-pointer RSA and
+// ECDSA keys.
 // TODO: Support JSONWebKeys once go-jose migration is done.
 func (policy *KeyPolicy) GoodKey(ctx context.Context, key crypto.PublicKey) error {
 	// Early rejection of unacceptable key types to guard subsequent checks.
 	switch t := key.(type) {
-	case *rsa.PublicKey, *ecdsa.PublicKey:
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
 		break
 	default:
-		return badKey("unsupported key type %T", t)
+		if !isMLDSAKey(key) {
+			return badKey("unsupported key type %T", t)
+		}
 	}
 	// If there is a blocked list configured then check if the public key is one
 	// that has been administratively blocked.
@@ -158,11 +178,27 @@ func (policy *KeyPolicy) GoodKey(ctx context.Context, key crypto.PublicKey) erro
 		return policy.goodKeyRSA(t)
 	case *ecdsa.PublicKey:
 		return policy.goodKeyECDSA(t)
+	case ed25519.PublicKey:
+		return policy.goodKeyEd25519(t)
 	default:
+		if isMLDSAKey(key) {
+			return policy.goodKeyMLDSA(key)
+		}
 		return badKey("unsupported key type %T", key)
 	}
 }
 
+// goodKeyEd25519 determines if an Ed25519 key is acceptable.
+func (policy *KeyPolicy) goodKeyEd25519(key ed25519.PublicKey) error {
+	if !policy.AllowEd25519 {
+		return badKey("Ed25519 keys are not allowed")
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return badKey("Ed25519 key has wrong length %d", len(key))
+	}
+	return nil
+}
+
 // GoodKeyECDSA determines if an ECDSA pubkey meets our requirements
 func (policy *KeyPolicy) goodKeyECDSA(key *ecdsa.PublicKey) (err error) {
 	// Check the curve.
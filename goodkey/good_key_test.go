@@ -3,6 +3,7 @@ package goodkey
 import (
 	"context"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
@@ -351,3 +352,30 @@ func BenchmarkFermat10(b *testing.B)    { benchFermat(10, b) }
 func BenchmarkFermat100(b *testing.B)   { benchFermat(100, b) }
 func BenchmarkFermat1000(b *testing.B)  { benchFermat(1000, b) }
 func BenchmarkFermat10000(b *testing.B) { benchFermat(10000, b) }
+
+func TestEd25519Key(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	test.AssertNotError(t, err, "generating Ed25519 key")
+
+	err = testingPolicy.GoodKey(context.Background(), pub)
+	test.AssertError(t, err, "Ed25519 key accepted without AllowEd25519")
+	test.AssertEquals(t, err.Error(), "Ed25519 keys are not allowed")
+
+	policy := *testingPolicy
+	policy.AllowEd25519 = true
+	err = policy.GoodKey(context.Background(), pub)
+	test.AssertNotError(t, err, "Ed25519 key rejected with AllowEd25519")
+
+	err = policy.GoodKey(context.Background(), pub[:16])
+	test.AssertError(t, err, "short Ed25519 key accepted")
+}
+
+func TestNewKeyPolicyEd25519(t *testing.T) {
+	kp, err := NewKeyPolicy(&Config{}, nil)
+	test.AssertNotError(t, err, "NewKeyPolicy failed")
+	test.Assert(t, !kp.AllowEd25519, "Ed25519 keys allowed by default")
+
+	kp, err = NewKeyPolicy(&Config{AllowEd25519: true}, nil)
+	test.AssertNotError(t, err, "NewKeyPolicy failed")
+	test.Assert(t, kp.AllowEd25519, "Ed25519 keys not allowed by config")
+}
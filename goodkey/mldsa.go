@@ -0,0 +1,34 @@
+//go:build go1.27
+
+package goodkey
+
+import (
+	"crypto"
+	"crypto/mldsa"
+)
+
+// mldsaSupported is true when Boulder is built with a Go toolchain whose
+// standard library implements ML-DSA.
+const mldsaSupported = true
+
+// isMLDSAKey returns true if key is an ML-DSA public key.
+func isMLDSAKey(key crypto.PublicKey) bool {
+	_, ok := key.(*mldsa.PublicKey)
+	return ok
+}
+
+// goodKeyMLDSA determines if an ML-DSA key is acceptable. All three parameter
+// sets defined in FIPS 204 are, and the key's encoding was validated when it
+// was parsed.
+func (policy *KeyPolicy) goodKeyMLDSA(key crypto.PublicKey) error {
+	if !policy.AllowMLDSA {
+		return badKey("ML-DSA keys are not allowed")
+	}
+	params := key.(*mldsa.PublicKey).Parameters()
+	switch params {
+	case mldsa.MLDSA44(), mldsa.MLDSA65(), mldsa.MLDSA87():
+		return nil
+	default:
+		return badKey("unsupported ML-DSA parameter set %s", params)
+	}
+}
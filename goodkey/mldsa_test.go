@@ -0,0 +1,39 @@
+//go:build go1.27
+
+package goodkey
+
+import (
+	"context"
+	"crypto/mldsa"
+	"testing"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestMLDSAKey(t *testing.T) {
+	for _, params := range []mldsa.Parameters{mldsa.MLDSA44(), mldsa.MLDSA65(), mldsa.MLDSA87()} {
+		t.Run(params.String(), func(t *testing.T) {
+			priv, err := mldsa.GenerateKey(params)
+			test.AssertNotError(t, err, "generating ML-DSA key")
+
+			err = testingPolicy.GoodKey(context.Background(), priv.PublicKey())
+			test.AssertError(t, err, "ML-DSA key accepted without AllowMLDSA")
+			test.AssertEquals(t, err.Error(), "ML-DSA keys are not allowed")
+
+			policy := *testingPolicy
+			policy.AllowMLDSA = true
+			err = policy.GoodKey(context.Background(), priv.PublicKey())
+			test.AssertNotError(t, err, "ML-DSA key rejected with AllowMLDSA")
+		})
+	}
+}
+
+func TestNewKeyPolicyMLDSA(t *testing.T) {
+	kp, err := NewKeyPolicy(&Config{}, nil)
+	test.AssertNotError(t, err, "NewKeyPolicy failed")
+	test.Assert(t, !kp.AllowMLDSA, "ML-DSA keys allowed by default")
+
+	kp, err = NewKeyPolicy(&Config{AllowMLDSA: true}, nil)
+	test.AssertNotError(t, err, "NewKeyPolicy failed")
+	test.Assert(t, kp.AllowMLDSA, "ML-DSA keys not allowed by config")
+}
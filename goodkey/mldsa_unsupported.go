@@ -0,0 +1,17 @@
+//go:build !go1.27
+
+package goodkey
+
+import "crypto"
+
+// mldsaSupported is false because Go releases before 1.27 do not implement
+// ML-DSA, so no key can be an ML-DSA key.
+const mldsaSupported = false
+
+func isMLDSAKey(crypto.PublicKey) bool {
+	return false
+}
+
+func (policy *KeyPolicy) goodKeyMLDSA(key crypto.PublicKey) error {
+	return badKey("unsupported key type %T", key)
+}
@@ -106,6 +106,7 @@ func TestValidationRecord(t *testing.T) {
 		AddressUsed:       ip,
 		URL:               "url",
 		AddressesTried:    []net.IP{ip},
+		Perspectives:      []string{"eu-west", "us-east"},
 	}
 
 	pb, err := ValidationRecordToPB(vr)
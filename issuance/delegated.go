@@ -0,0 +1,92 @@
+package issuance
+
+import (
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/letsencrypt/boulder/core"
+	ocsplints "github.com/letsencrypt/boulder/linter/lints/ocsp"
+)
+
+// DelegatedResponder is a certificate and key with which OCSP responses can be
+// signed on behalf of the issuer of that certificate, sparing the issuer's own
+// key (which usually lives in a busy HSM) from doing so.
+type DelegatedResponder struct {
+	Cert   *x509.Certificate
+	Signer crypto.Signer
+}
+
+// LoadDelegatedResponder loads a delegated responder's certificate and key from
+// the locations specified, and checks that the certificate is a valid delegated
+// responder for the given issuer.
+func LoadDelegatedResponder(location IssuerLoc, issuer *Certificate) (*DelegatedResponder, error) {
+	cert, err := core.LoadCert(location.CertFile)
+	if err != nil {
+		return nil, err
+	}
+
+	err = VerifyDelegatedResponder(cert, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("delegated responder %s: %w", location.CertFile, err)
+	}
+
+	signer, err := loadSigner(location, cert.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if !core.KeyDigestEquals(signer.Public(), cert.PublicKey) {
+		return nil, fmt.Errorf("Delegated responder key did not match certificate %s", location.CertFile)
+	}
+	return &DelegatedResponder{Cert: cert, Signer: signer}, nil
+}
+
+// VerifyDelegatedResponder returns an error unless cert was signed by issuer
+// and is suitable only for signing OCSP responses: it must be an end-entity
+// certificate with the digitalSignature key usage, id-kp-OCSPSigning as its
+// only extended key usage, and the ocsp-nocheck extension. It does not check
+// the certificate's validity period.
+func VerifyDelegatedResponder(cert *x509.Certificate, issuer *Certificate) error {
+	err := cert.CheckSignatureFrom(issuer.Certificate)
+	if err != nil {
+		return fmt.Errorf("not signed by issuer %q: %w", issuer.Subject.CommonName, err)
+	}
+	if cert.IsCA {
+		return errors.New("certificate is a CA certificate")
+	}
+	if cert.KeyUsage&x509.KeyUsageDigitalSignature == 0 {
+		return errors.New("certificate does not have keyUsage digitalSignature")
+	}
+	if len(cert.ExtKeyUsage) != 1 || cert.ExtKeyUsage[0] != x509.ExtKeyUsageOCSPSigning || len(cert.UnknownExtKeyUsage) != 0 {
+		return errors.New("certificate's only extended key usage must be id-kp-OCSPSigning")
+	}
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(ocsplints.OIDOCSPNoCheck) {
+			return nil
+		}
+	}
+	return errors.New("certificate does not have the ocsp-nocheck extension")
+}
+
+// OCSPResponder returns the delegated responder which should sign an OCSP
+// response valid from thisUpdate until nextUpdate: of those whose certificates
+// are valid for that entire period, the one issued most recently. Adding a new
+// responder therefore rotates to it as soon as it becomes valid, and an old
+// one stops being used as soon as it would expire before a response it signs.
+// It returns nil if no responder is suitable, in which case the issuer should
+// sign the response itself.
+func (i *Issuer) OCSPResponder(thisUpdate, nextUpdate time.Time) *DelegatedResponder {
+	var best *DelegatedResponder
+	for _, r := range i.OCSPResponders {
+		if thisUpdate.Before(r.Cert.NotBefore) || nextUpdate.After(r.Cert.NotAfter) {
+			continue
+		}
+		if best == nil || r.Cert.NotBefore.After(best.Cert.NotBefore) {
+			best = r
+		}
+	}
+	return best
+}
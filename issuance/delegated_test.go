@@ -0,0 +1,127 @@
+package issuance
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ocsplints "github.com/letsencrypt/boulder/linter/lints/ocsp"
+	"github.com/letsencrypt/boulder/test"
+)
+
+// makeResponder returns a delegated responder for issuerCert, valid for the
+// given period, after applying modify to its template.
+func makeResponder(t *testing.T, notBefore, notAfter time.Time, modify func(*x509.Certificate)) *DelegatedResponder {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating responder key")
+	template := &x509.Certificate{
+		SerialNumber:    big.NewInt(notBefore.Unix()),
+		Subject:         pkix.Name{CommonName: "big ca ocsp"},
+		NotBefore:       notBefore,
+		NotAfter:        notAfter,
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+		ExtraExtensions: []pkix.Extension{{Id: ocsplints.OIDOCSPNoCheck, Value: []byte{5, 0}}},
+	}
+	if modify != nil {
+		modify(template)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuerCert.Certificate, key.Public(), issuerSigner)
+	test.AssertNotError(t, err, "creating responder certificate")
+	cert, err := x509.ParseCertificate(der)
+	test.AssertNotError(t, err, "parsing responder certificate")
+	return &DelegatedResponder{Cert: cert, Signer: key}
+}
+
+func TestVerifyDelegatedResponder(t *testing.T) {
+	now := time.Now()
+	valid := makeResponder(t, now, now.Add(time.Hour), nil)
+	test.AssertNotError(t, VerifyDelegatedResponder(valid.Cert, issuerCert), "valid responder rejected")
+
+	otherIssuer := &Certificate{Certificate: valid.Cert}
+	err := VerifyDelegatedResponder(valid.Cert, otherIssuer)
+	test.AssertError(t, err, "responder accepted for wrong issuer")
+
+	for name, modify := range map[string]func(*x509.Certificate){
+		"CA": func(c *x509.Certificate) {
+			c.BasicConstraintsValid = true
+			c.IsCA = true
+		},
+		"no digitalSignature": func(c *x509.Certificate) {
+			c.KeyUsage = x509.KeyUsageKeyEncipherment
+		},
+		"no OCSPSigning": func(c *x509.Certificate) {
+			c.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		},
+		"extra EKU": func(c *x509.Certificate) {
+			c.ExtKeyUsage = append(c.ExtKeyUsage, x509.ExtKeyUsageServerAuth)
+		},
+		"no ocsp-nocheck": func(c *x509.Certificate) {
+			c.ExtraExtensions = nil
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := makeResponder(t, now, now.Add(time.Hour), modify)
+			test.AssertError(t, VerifyDelegatedResponder(r.Cert, issuerCert), "invalid responder accepted")
+		})
+	}
+}
+
+func TestLoadDelegatedResponder(t *testing.T) {
+	now := time.Now()
+	r := makeResponder(t, now, now.Add(time.Hour), nil)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: r.Cert.Raw}), 0600)
+	test.AssertNotError(t, err, "writing certificate")
+	keyDER, err := x509.MarshalECPrivateKey(r.Signer.(*ecdsa.PrivateKey))
+	test.AssertNotError(t, err, "marshalling key")
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	test.AssertNotError(t, err, "writing key")
+
+	loaded, err := LoadDelegatedResponder(IssuerLoc{File: keyFile, CertFile: certFile}, issuerCert)
+	test.AssertNotError(t, err, "loading delegated responder")
+	test.AssertByteEquals(t, loaded.Cert.Raw, r.Cert.Raw)
+
+	// The issuer's own key doesn't match the responder's certificate.
+	issuerKeyDER, err := x509.MarshalECPrivateKey(issuerSigner)
+	test.AssertNotError(t, err, "marshalling issuer key")
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: issuerKeyDER}), 0600)
+	test.AssertNotError(t, err, "writing key")
+	_, err = LoadDelegatedResponder(IssuerLoc{File: keyFile, CertFile: certFile}, issuerCert)
+	test.AssertError(t, err, "loaded delegated responder with mismatched key")
+}
+
+func TestOCSPResponder(t *testing.T) {
+	now := time.Now().Truncate(time.Hour)
+	lifetime := 96 * time.Hour
+	day := 24 * time.Hour
+	old := makeResponder(t, now.Add(-30*day), now.Add(5*day), nil)
+	next := makeResponder(t, now.Add(day), now.Add(60*day), nil)
+
+	issuer := &Issuer{Cert: issuerCert}
+	test.Assert(t, issuer.OCSPResponder(now, now.Add(lifetime)) == nil, "issuer without responders returned one")
+
+	issuer.OCSPResponders = []*DelegatedResponder{old, next}
+	// Until its successor becomes valid, the old responder is used.
+	test.AssertEquals(t, issuer.OCSPResponder(now, now.Add(lifetime)), old)
+	// Then the successor is used as soon as it is.
+	test.AssertEquals(t, issuer.OCSPResponder(now.Add(day), now.Add(day+lifetime)), next)
+
+	// Without a successor, the old responder stops being used once responses
+	// would outlive it.
+	issuer.OCSPResponders = []*DelegatedResponder{old}
+	test.AssertEquals(t, issuer.OCSPResponder(now.Add(day), now.Add(day+lifetime)), old)
+	test.Assert(t, issuer.OCSPResponder(now.Add(2*day), now.Add(2*day+lifetime)) == nil, "responder used beyond its expiry")
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
@@ -29,9 +30,12 @@ import (
 
 	"github.com/letsencrypt/boulder/config"
 	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/features"
 	"github.com/letsencrypt/boulder/linter"
+	"github.com/letsencrypt/boulder/policy"
 	"github.com/letsencrypt/boulder/policyasn1"
 	"github.com/letsencrypt/boulder/privatekey"
+	"github.com/letsencrypt/boulder/strictyaml"
 	"github.com/letsencrypt/pkcs11key/v4"
 )
 
@@ -42,6 +46,33 @@ type ProfileConfig struct {
 	AllowSCTList    bool
 	AllowCommonName bool
 
+	// AllowEd25519Leaves permits issuers to be configured with
+	// UseForEd25519Leaves. Ed25519 subscriber keys are not permitted by the
+	// Baseline Requirements, so this is only suitable for private PKIs, and
+	// the "e_public_key_type_not_allowed" and
+	// "e_algorithm_identifier_improper_encoding" lints must be ignored, for
+	// instance in the issuer's IssuerConfig.Lints.
+	AllowEd25519Leaves bool
+	// AllowMLDSALeaves permits issuers to be configured with UseForMLDSALeaves.
+	// Support for ML-DSA (FIPS 204) subscriber keys is experimental, requires
+	// Boulder to be built with Go 1.27 or later, and is subject to the same
+	// caveats as AllowEd25519Leaves.
+	AllowMLDSALeaves bool
+
+	// IncludeCRLDistributionPoints causes each certificate to include a CRL
+	// Distribution Points extension naming the CRL shard which it was assigned
+	// at issuance. The URL is built from CRLDPBase in the same way as the
+	// shard's Issuing Distribution Point, so CRLDPBase must match the CA's
+	// crldpBase (which boulder-ca uses if it is unset) and CRLShards must
+	// match the crl-updater's numShards: boulder-ca refuses to sign CRLs for
+	// a crl-updater with a different number of shards. It requires the
+	// StoreCRLShards feature, which must also be enabled in the SA and the
+	// crl-updater, since otherwise revoked certificates are placed in the
+	// shard matching their notAfter date, rather than the one they name.
+	IncludeCRLDistributionPoints bool
+	CRLDPBase                    string `validate:"omitempty,url,startswith=http://,endsnotwith=/"`
+	CRLShards                    int    `validate:"min=0"`
+
 	Policies            []PolicyInformation `validate:"omitempty,dive"`
 	MaxValidityPeriod   config.Duration
 	MaxValidityBackdate config.Duration
@@ -59,16 +90,56 @@ type PolicyQualifier struct {
 	Value string `validate:"required"`
 }
 
+// IssuerState describes where an issuer is in its lifecycle.
+type IssuerState string
+
+const (
+	// StateActive issuers issue new certificates, and sign OCSP responses and
+	// CRLs for the certificates they have issued.
+	StateActive = IssuerState("active")
+	// StateRetiring issuers issue no new certificates, but keep signing OCSP
+	// responses and CRLs until the last certificate they issued has expired.
+	StateRetiring = IssuerState("retiring")
+	// StateArchived issuers are no longer used for anything, and are not loaded,
+	// except by the ocsp-responder to serve their responses from an archive.
+	StateArchived = IssuerState("archived")
+)
+
 // IssuerConfig describes the constraints on and URLs used by a single issuer.
 type IssuerConfig struct {
 	UseForRSALeaves   bool
 	UseForECDSALeaves bool
+	// UseForEd25519Leaves and UseForMLDSALeaves require the corresponding
+	// Allow setting in the ProfileConfig.
+	UseForEd25519Leaves bool
+	UseForMLDSALeaves   bool
+
+	// State is the lifecycle state of the issuer. If unset, the issuer is
+	// active.
+	State IssuerState `validate:"omitempty,oneof=active retiring archived"`
+	// Weight is the relative likelihood of this issuer being selected to issue
+	// a certificate among all of the active issuers for the same key type. If
+	// no issuer for a key type has a positive weight, the first one configured
+	// is used.
+	Weight int `validate:"min=0"`
 
 	IssuerURL string `validate:"required,url"`
 	OCSPURL   string `validate:"required,url"`
 	CRLURL    string `validate:"omitempty,url"`
 
+	// Lints, if set, replaces the CA's default lint configuration for
+	// certificates from this issuer, so that for instance a private PKI
+	// issuer can be linted differently from a WebPKI one.
+	Lints *linter.Config `validate:"omitempty"`
+
 	Location IssuerLoc
+
+	// OCSPResponders locates delegated OCSP responder certificates issued by
+	// this issuer, and their keys. If any are configured, OCSP responses are
+	// signed with one of them rather than with the issuer's own key; see
+	// Issuer.OCSPResponder for how it is chosen. Their SignerPool settings
+	// are ignored.
+	OCSPResponders []IssuerLoc `validate:"omitempty,dive"`
 }
 
 // IssuerLoc describes the on-disk location and parameters that an issuer
@@ -86,6 +157,9 @@ type IssuerLoc struct {
 	// Number of sessions to open with the HSM. For maximum performance,
 	// this should be equal to the number of cores in the HSM. Defaults to 1.
 	NumSessions int
+	// SignerPool optionally limits concurrent signing with the key, and marks
+	// the issuer unavailable if signing keeps failing. See SignerPoolConfig.
+	SignerPool *SignerPoolConfig `validate:"omitempty"`
 }
 
 // LoadIssuer loads a signer (private key) and certificate from the locations specified.
@@ -95,7 +169,7 @@ func LoadIssuer(location IssuerLoc) (*Certificate, crypto.Signer, error) {
 		return nil, nil, err
 	}
 
-	signer, err := loadSigner(location, issuerCert)
+	signer, err := loadSigner(location, issuerCert.PublicKey)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -114,7 +188,69 @@ func LoadCertificate(path string) (*Certificate, error) {
 	return NewCertificate(cert)
 }
 
-func loadSigner(location IssuerLoc, cert *Certificate) (crypto.Signer, error) {
+// LoadCertificates loads the issuer certificates at the given paths, for use
+// by services which sign nothing with them but must know which issuers are in
+// use. Paths which states marks as StateArchived are skipped; those absent
+// from states are active. It is an error for states to name a path which is
+// not in paths, as that is most likely a configuration mistake.
+func LoadCertificates(paths []string, states map[string]IssuerState) ([]*Certificate, error) {
+	known := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		known[path] = true
+	}
+	for path, state := range states {
+		if !known[path] {
+			return nil, fmt.Errorf("issuer state %q configured for unknown issuer certificate %q", state, path)
+		}
+		switch state {
+		case StateActive, StateRetiring, StateArchived:
+		default:
+			return nil, fmt.Errorf("unknown issuer state %q for issuer certificate %q", state, path)
+		}
+	}
+
+	certs := make([]*Certificate, 0, len(paths))
+	for _, path := range paths {
+		if states[path] == StateArchived {
+			continue
+		}
+		cert, err := LoadCertificate(path)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+// LoadIssuerStates reads a YAML file mapping issuer certificate paths to their
+// lifecycle states. The same file is meant to be shared by every service which
+// needs to know issuers' states, so that they cannot drift apart. It returns
+// the states of the issuers at the given paths, each of which must be listed,
+// so that a service never treats an issuer as active by omission.
+func LoadIssuerStates(filename string, paths []string) (map[string]IssuerState, error) {
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]IssuerState
+	err = strictyaml.Unmarshal(contents, &all)
+	if err != nil {
+		return nil, fmt.Errorf("parsing issuer states file %q: %w", filename, err)
+	}
+
+	states := make(map[string]IssuerState, len(paths))
+	for _, path := range paths {
+		state, ok := all[path]
+		if !ok {
+			return nil, fmt.Errorf("issuer states file %q has no state for issuer certificate %q", filename, path)
+		}
+		states[path] = state
+	}
+	return states, nil
+}
+
+func loadSigner(location IssuerLoc, pub crypto.PublicKey) (crypto.Signer, error) {
 	if location.File != "" {
 		signer, _, err := privatekey.Load(location.File)
 		if err != nil {
@@ -150,19 +286,26 @@ func loadSigner(location IssuerLoc, cert *Certificate) (crypto.Signer, error) {
 	}
 
 	return pkcs11key.NewPool(numSessions, pkcs11Config.Module,
-		pkcs11Config.TokenLabel, pkcs11Config.PIN, cert.PublicKey)
+		pkcs11Config.TokenLabel, pkcs11Config.PIN, pub)
 }
 
 // Profile is the validated structure created by reading in ProfileConfigs and IssuerConfigs
 type Profile struct {
-	useForRSALeaves   bool
-	useForECDSALeaves bool
+	useForRSALeaves     bool
+	useForECDSALeaves   bool
+	useForEd25519Leaves bool
+	useForMLDSALeaves   bool
+	state               IssuerState
+	weight              int
 
 	allowMustStaple bool
 	allowCTPoison   bool
 	allowSCTList    bool
 	allowCommonName bool
 
+	crlDPBase string
+	crlShards int
+
 	sigAlg    x509.SignatureAlgorithm
 	ocspURL   string
 	crlURL    string
@@ -201,18 +344,63 @@ func NewProfile(profileConfig ProfileConfig, issuerConfig IssuerConfig) (*Profil
 	if issuerConfig.OCSPURL == "" {
 		return nil, errors.New("OCSP URL is required")
 	}
+	state := issuerConfig.State
+	if state == "" {
+		state = StateActive
+	}
+	switch state {
+	case StateActive, StateRetiring, StateArchived:
+	default:
+		return nil, fmt.Errorf("unknown issuer state %q", state)
+	}
+	if issuerConfig.Weight < 0 {
+		return nil, errors.New("issuer weight must not be negative")
+	}
+	if issuerConfig.UseForEd25519Leaves && !profileConfig.AllowEd25519Leaves {
+		return nil, errors.New("Ed25519 leaves are not allowed by the profile")
+	}
+	if issuerConfig.UseForMLDSALeaves {
+		if !profileConfig.AllowMLDSALeaves {
+			return nil, errors.New("ML-DSA leaves are not allowed by the profile")
+		}
+		if !mldsaSupported {
+			return nil, errors.New("ML-DSA leaves require Boulder to be built with Go 1.27 or later")
+		}
+	}
+	if profileConfig.IncludeCRLDistributionPoints {
+		if profileConfig.CRLDPBase == "" {
+			return nil, errors.New("CRL distribution point base is required to include CRL distribution points")
+		}
+		if profileConfig.CRLShards < 1 {
+			return nil, errors.New("number of CRL shards must be positive to include CRL distribution points")
+		}
+		if issuerConfig.CRLURL != "" {
+			return nil, errors.New("CRL URL cannot be used with per-shard CRL distribution points")
+		}
+		if !features.Enabled(features.StoreCRLShards) {
+			return nil, errors.New("the StoreCRLShards feature is required to include CRL distribution points")
+		}
+	}
 	sp := &Profile{
-		useForRSALeaves:   issuerConfig.UseForRSALeaves,
-		useForECDSALeaves: issuerConfig.UseForECDSALeaves,
-		allowMustStaple:   profileConfig.AllowMustStaple,
-		allowCTPoison:     profileConfig.AllowCTPoison,
-		allowSCTList:      profileConfig.AllowSCTList,
-		allowCommonName:   profileConfig.AllowCommonName,
-		issuerURL:         issuerConfig.IssuerURL,
-		crlURL:            issuerConfig.CRLURL,
-		ocspURL:           issuerConfig.OCSPURL,
-		maxBackdate:       profileConfig.MaxValidityBackdate.Duration,
-		maxValidity:       profileConfig.MaxValidityPeriod.Duration,
+		useForRSALeaves:     issuerConfig.UseForRSALeaves,
+		useForECDSALeaves:   issuerConfig.UseForECDSALeaves,
+		useForEd25519Leaves: issuerConfig.UseForEd25519Leaves,
+		useForMLDSALeaves:   issuerConfig.UseForMLDSALeaves,
+		state:               state,
+		weight:              issuerConfig.Weight,
+		allowMustStaple:     profileConfig.AllowMustStaple,
+		allowCTPoison:       profileConfig.AllowCTPoison,
+		allowSCTList:        profileConfig.AllowSCTList,
+		allowCommonName:     profileConfig.AllowCommonName,
+		issuerURL:           issuerConfig.IssuerURL,
+		crlURL:              issuerConfig.CRLURL,
+		ocspURL:             issuerConfig.OCSPURL,
+		maxBackdate:         profileConfig.MaxValidityBackdate.Duration,
+		maxValidity:         profileConfig.MaxValidityPeriod.Duration,
+	}
+	if profileConfig.IncludeCRLDistributionPoints {
+		sp.crlDPBase = profileConfig.CRLDPBase
+		sp.crlShards = profileConfig.CRLShards
 	}
 	if len(profileConfig.Policies) > 0 {
 		var policies []policyasn1.PolicyInformation
@@ -247,9 +435,10 @@ func NewProfile(profileConfig ProfileConfig, issuerConfig IssuerConfig) (*Profil
 	return sp, nil
 }
 
-// requestValid verifies the passed IssuanceRequest against the profile. If the
+// requestValid verifies the passed IssuanceRequest against the profile and the
+// name constraints of the issuer certificate which will sign it. If the
 // request doesn't match the signing profile an error is returned.
-func (p *Profile) requestValid(clk clock.Clock, req *IssuanceRequest) error {
+func (p *Profile) requestValid(clk clock.Clock, issuer *Certificate, req *IssuanceRequest) error {
 	switch req.PublicKey.(type) {
 	case *rsa.PublicKey:
 		if !p.useForRSALeaves {
@@ -259,8 +448,17 @@ func (p *Profile) requestValid(clk clock.Clock, req *IssuanceRequest) error {
 		if !p.useForECDSALeaves {
 			return errors.New("cannot sign ECDSA public keys")
 		}
+	case ed25519.PublicKey:
+		if !p.useForEd25519Leaves {
+			return errors.New("cannot sign Ed25519 public keys")
+		}
 	default:
-		return errors.New("unsupported public key type")
+		if !isMLDSAKey(req.PublicKey) {
+			return errors.New("unsupported public key type")
+		}
+		if !p.useForMLDSALeaves {
+			return errors.New("cannot sign ML-DSA public keys")
+		}
 	}
 
 	if !p.allowMustStaple && req.IncludeMustStaple {
@@ -307,6 +505,13 @@ func (p *Profile) requestValid(clk clock.Clock, req *IssuanceRequest) error {
 		return errors.New("serial must be between 9 and 19 bytes")
 	}
 
+	// A certificate for names outside its issuer's name constraints would be
+	// rejected by relying parties, so refuse to sign it at all.
+	err := policy.NameConstraintsFromCert(issuer.Certificate).Permits(req.DNSNames)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -335,6 +540,39 @@ func (p *Profile) generateTemplate() *x509.Certificate {
 	return template
 }
 
+// CRLShardForSerial returns the index, out of numShards, of the CRL shard
+// assigned at issuance to the certificate with the given serial. The shard is
+// derived from the serial alone, so that a precertificate and its final
+// certificate are always assigned the same one, and so that the crl-updater can
+// find it again without the certificate.
+func CRLShardForSerial(serial *big.Int, numShards int) int64 {
+	return new(big.Int).Mod(serial, big.NewInt(int64(numShards))).Int64()
+}
+
+// crlShard returns the index of the CRL shard which will contain the
+// certificate with the given serial if it is revoked, and false if the profile
+// doesn't include CRL distribution points.
+func (p *Profile) crlShard(serial *big.Int) (int64, bool) {
+	if p.crlShards == 0 {
+		return 0, false
+	}
+	return CRLShardForSerial(serial, p.crlShards), true
+}
+
+// ShardedCRLURL returns the URL of the given shard of the given issuer's CRL.
+// It is used both for the CRL Distribution Point of certificates and for the
+// Issuing Distribution Point of the CRL shard itself, which must match.
+func ShardedCRLURL(base string, issuer IssuerNameID, shardIdx int64) string {
+	return fmt.Sprintf("%s/%d/%d.crl", base, issuer, shardIdx)
+}
+
+// ShardedDeltaCRLURL returns the URL of the Delta CRL for the given shard of the
+// given issuer's CRL. It is used for the Freshest CRL extension of the complete
+// CRL shard, so that relying parties can find the delta which updates it.
+func ShardedDeltaCRLURL(base string, issuer IssuerNameID, shardIdx int64) string {
+	return fmt.Sprintf("%s/%d/%d-delta.crl", base, issuer, shardIdx)
+}
+
 // IssuerID is a statistically-unique small ID computed from a hash over the
 // entirety of the issuer certificate.
 // DEPRECATED: This identifier is being phased out in favor of IssuerNameID.
@@ -456,6 +694,9 @@ type Issuer struct {
 	Profile *Profile
 	Linter  *linter.Linter
 	Clk     clock.Clock
+	// OCSPResponders are the delegated responders which may sign OCSP
+	// responses on this issuer's behalf.
+	OCSPResponders []*DelegatedResponder
 }
 
 // NewIssuer constructs an Issuer on the heap, verifying that the profile
@@ -477,7 +718,7 @@ func NewIssuer(cert *Certificate, signer crypto.Signer, profile *Profile, linter
 		return nil, errors.New("unsupported issuer key type")
 	}
 
-	if profile.useForRSALeaves || profile.useForECDSALeaves {
+	if len(profile.leafAlgs()) > 0 {
 		if cert.KeyUsage&x509.KeyUsageCertSign == 0 {
 			return nil, errors.New("end-entity signing cert does not have keyUsage certSign")
 		}
@@ -499,23 +740,84 @@ func NewIssuer(cert *Certificate, signer crypto.Signer, profile *Profile, linter
 
 // Algs provides the list of leaf certificate public key algorithms for which
 // this issuer is willing to issue. This is not necessarily the same as the
-// public key algorithm or signature algorithm in this issuer's own cert.
+// public key algorithm or signature algorithm in this issuer's own cert. Only
+// active issuers are willing to issue.
 func (i *Issuer) Algs() []x509.PublicKeyAlgorithm {
+	if i.State() != StateActive {
+		return nil
+	}
+	return i.Profile.leafAlgs()
+}
+
+// leafAlgs provides the list of leaf certificate public key algorithms which
+// the profile permits signing.
+func (p *Profile) leafAlgs() []x509.PublicKeyAlgorithm {
 	var algs []x509.PublicKeyAlgorithm
-	if i.Profile.useForRSALeaves {
+	if p.useForRSALeaves {
 		algs = append(algs, x509.RSA)
 	}
-	if i.Profile.useForECDSALeaves {
+	if p.useForECDSALeaves {
 		algs = append(algs, x509.ECDSA)
 	}
+	if p.useForEd25519Leaves {
+		algs = append(algs, x509.Ed25519)
+	}
+	if p.useForMLDSALeaves {
+		algs = append(algs, mldsaAlgorithm)
+	}
 	return algs
 }
 
+// Available returns false if the issuer's signer reports that it is currently
+// unable to sign, for instance because its HSM has stopped responding (see
+// PooledSigner). Other signers are always available.
+func (i *Issuer) Available() bool {
+	checker, ok := i.Signer.(interface{ Available() bool })
+	if !ok {
+		return true
+	}
+	return checker.Available()
+}
+
+// State provides the lifecycle state of the issuer.
+func (i *Issuer) State() IssuerState {
+	if i.Profile.state == "" {
+		return StateActive
+	}
+	return i.Profile.state
+}
+
+// Weight provides the relative likelihood of this issuer being selected to
+// issue among the active issuers for the same key type.
+func (i *Issuer) Weight() int {
+	return i.Profile.weight
+}
+
+// NameConstraints returns the DNS and IP address name constraints of the
+// issuer's certificate.
+func (i *Issuer) NameConstraints() policy.NameConstraints {
+	return policy.NameConstraintsFromCert(i.Cert.Certificate)
+}
+
 // Name provides the Common Name specified in the issuer's certificate.
 func (i *Issuer) Name() string {
 	return i.Cert.Subject.CommonName
 }
 
+// CRLShard returns the index of the CRL shard assigned to the certificate
+// with the given serial, and false if this issuer's certificates don't include
+// per-shard CRL distribution points.
+func (i *Issuer) CRLShard(serial *big.Int) (int64, bool) {
+	return i.Profile.crlShard(serial)
+}
+
+// CRLShards returns the number of CRL shards this issuer's certificates are
+// divided between, or zero if they don't include per-shard CRL distribution
+// points.
+func (i *Issuer) CRLShards() int {
+	return i.Profile.crlShards
+}
+
 // ID provides a stable ID for an issuer's certificate. This is used for
 // identifying which issuer issued a certificate in the certificateStatus table.
 func (i *Issuer) ID() IssuerID {
@@ -565,6 +867,11 @@ var mustStapleExt = pkix.Extension{
 	Value: []byte{0x30, 0x03, 0x02, 0x01, 0x05},
 }
 
+// generateSKID computes a subject key identifier for the given public key using
+// method (1) of RFC 5280, Section 4.2.1.2: the SHA-1 hash of the value of the
+// subjectPublicKey BIT STRING. This works for every key type which
+// x509.MarshalPKIXPublicKey can encode, including Ed25519 and ML-DSA keys,
+// whose encodings carry no algorithm parameters to be excluded.
 func generateSKID(pk crypto.PublicKey) ([]byte, error) {
 	pkBytes, err := x509.MarshalPKIXPublicKey(pk)
 	if err != nil {
@@ -596,6 +903,25 @@ type IssuanceRequest struct {
 	IncludeMustStaple bool
 	IncludeCTPoison   bool
 	SCTList           []ct.SignedCertificateTimestamp
+
+	// fromPrecert is set by RequestFromPrecert, and marks a request for the
+	// final certificate corresponding to an already-issued precertificate.
+	fromPrecert bool
+}
+
+// PreparedCertificate is a certificate which has been generated from an
+// IssuanceRequest and linted, but not yet signed with the issuer's key.
+type PreparedCertificate struct {
+	issuer    *Issuer
+	template  *x509.Certificate
+	publicKey crypto.PublicKey
+	tbs       []byte
+}
+
+// RawTBSCertificate returns the DER encoding of the TBSCertificate which will
+// be signed when the prepared certificate is issued.
+func (p *PreparedCertificate) RawTBSCertificate() []byte {
+	return p.tbs
 }
 
 // Issue generates a certificate from the provided issuance request and
@@ -604,8 +930,25 @@ type IssuanceRequest struct {
 // zlint. If the linting fails, an error is returned and the certificate
 // is not signed using the issuer's key.
 func (i *Issuer) Issue(req *IssuanceRequest) ([]byte, error) {
+	prepared, err := i.Prepare(req)
+	if err != nil {
+		return nil, err
+	}
+	return i.IssuePrepared(prepared)
+}
+
+// Prepare generates and lints a certificate from the provided issuance
+// request, so that its TBSCertificate can be inspected before it is signed with
+// IssuePrepared.
+func (i *Issuer) Prepare(req *IssuanceRequest) (*PreparedCertificate, error) {
+	// Issuers which are not active only sign the final certificates for
+	// precertificates they had already issued.
+	if i.State() != StateActive && !req.fromPrecert {
+		return nil, fmt.Errorf("issuer is %s, and cannot issue new certificates", i.State())
+	}
+
 	// check request is valid according to the issuance profile
-	err := i.Profile.requestValid(i.Clk, req)
+	err := i.Profile.requestValid(i.Clk, i.Cert, req)
 	if err != nil {
 		return nil, err
 	}
@@ -620,6 +963,9 @@ func (i *Issuer) Issue(req *IssuanceRequest) ([]byte, error) {
 		template.Subject.CommonName = req.CommonName
 	}
 	template.DNSNames = req.DNSNames
+	if shard, ok := i.Profile.crlShard(template.SerialNumber); ok {
+		template.CRLDistributionPoints = []string{ShardedCRLURL(i.Profile.crlDPBase, i.Cert.NameID(), shard)}
+	}
 	template.AuthorityKeyId = i.Cert.SubjectKeyId
 	skid, err := generateSKID(req.PublicKey)
 	if err != nil {
@@ -629,7 +975,10 @@ func (i *Issuer) Issue(req *IssuanceRequest) ([]byte, error) {
 	switch req.PublicKey.(type) {
 	case *rsa.PublicKey:
 		template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
-	case *ecdsa.PublicKey:
+	default:
+		// requestValid has limited the remaining key types to ECDSA, Ed25519
+		// and ML-DSA. Ed25519 and ML-DSA keys can only be used for signatures
+		// (see RFC 8410, Section 5, and the LAMPS ML-DSA certificate profile).
 		template.KeyUsage = x509.KeyUsageDigitalSignature
 	}
 
@@ -649,12 +998,43 @@ func (i *Issuer) Issue(req *IssuanceRequest) ([]byte, error) {
 
 	// check that the tbsCertificate is properly formed by signing it
 	// with a throwaway key and then linting it using zlint
-	err = i.Linter.Check(template, req.PublicKey)
+	lintCertBytes, err := i.Linter.Check(template, req.PublicKey)
 	if err != nil {
 		return nil, fmt.Errorf("tbsCertificate linting failed: %w", err)
 	}
+	lintCert, err := x509.ParseCertificate(lintCertBytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing lint certificate: %w", err)
+	}
 
-	return x509.CreateCertificate(rand.Reader, template, i.Cert.Certificate, req.PublicKey, i.Signer)
+	return &PreparedCertificate{
+		issuer:    i,
+		template:  template,
+		publicKey: req.PublicKey,
+		tbs:       lintCert.RawTBSCertificate,
+	}, nil
+}
+
+// IssuePrepared signs a certificate previously generated by Prepare, and
+// returns its DER encoding. It returns an error if the certificate was
+// prepared by a different issuer, or if the signed certificate's
+// TBSCertificate differs from the prepared one.
+func (i *Issuer) IssuePrepared(prepared *PreparedCertificate) ([]byte, error) {
+	if prepared.issuer != i {
+		return nil, errors.New("certificate was prepared by a different issuer")
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, prepared.template, i.Cert.Certificate, prepared.publicKey, i.Signer)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		return nil, fmt.Errorf("parsing issued certificate: %w", err)
+	}
+	if !bytes.Equal(cert.RawTBSCertificate, prepared.tbs) {
+		return nil, errors.New("issued tbsCertificate differs from the linted tbsCertificate")
+	}
+	return certDER, nil
 }
 
 func ContainsMustStaple(extensions []pkix.Extension) bool {
@@ -691,6 +1071,7 @@ func RequestFromPrecert(precert *x509.Certificate, scts []ct.SignedCertificateTi
 		DNSNames:          precert.DNSNames,
 		IncludeMustStaple: ContainsMustStaple(precert.Extensions),
 		SCTList:           scts,
+		fromPrecert:       true,
 	}, nil
 }
 
@@ -15,6 +15,7 @@ import (
 	"fmt"
 	"math/big"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -26,6 +27,8 @@ import (
 	"github.com/letsencrypt/boulder/config"
 	"github.com/letsencrypt/boulder/core"
 	"github.com/letsencrypt/boulder/ctpolicy/loglist"
+	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/features"
 	"github.com/letsencrypt/boulder/linter"
 	"github.com/letsencrypt/boulder/policyasn1"
 	"github.com/letsencrypt/boulder/test"
@@ -99,6 +102,7 @@ func TestNewProfilePolicies(t *testing.T) {
 	test.AssertDeepEquals(t, *profile, Profile{
 		useForRSALeaves:   true,
 		useForECDSALeaves: true,
+		state:             StateActive,
 		allowMustStaple:   true,
 		allowCTPoison:     true,
 		allowSCTList:      true,
@@ -164,6 +168,116 @@ func TestNewProfileUnknownQualifierType(t *testing.T) {
 	test.AssertEquals(t, err.Error(), "unknown qualifier type: asd")
 }
 
+func TestNewProfileState(t *testing.T) {
+	issuerConfig := defaultIssuerConfig()
+	issuerConfig.State = "sunsetting"
+	_, err := NewProfile(defaultProfileConfig(), issuerConfig)
+	test.AssertError(t, err, "NewProfile didn't fail with unknown state")
+	test.AssertEquals(t, err.Error(), `unknown issuer state "sunsetting"`)
+
+	issuerConfig.State = ""
+	issuerConfig.Weight = -1
+	_, err = NewProfile(defaultProfileConfig(), issuerConfig)
+	test.AssertError(t, err, "NewProfile didn't fail with negative weight")
+
+	issuerConfig.Weight = 3
+	profile, err := NewProfile(defaultProfileConfig(), issuerConfig)
+	test.AssertNotError(t, err, "NewProfile failed")
+	issuer, err := NewIssuer(issuerCert, issuerSigner, profile, &linter.Linter{}, clock.NewFake())
+	test.AssertNotError(t, err, "NewIssuer failed")
+	test.AssertEquals(t, issuer.State(), StateActive)
+	test.AssertEquals(t, issuer.Weight(), 3)
+	test.AssertDeepEquals(t, issuer.Algs(), []x509.PublicKeyAlgorithm{x509.RSA, x509.ECDSA})
+
+	// Retiring issuers are not willing to issue for any key type.
+	issuerConfig.State = StateRetiring
+	profile, err = NewProfile(defaultProfileConfig(), issuerConfig)
+	test.AssertNotError(t, err, "NewProfile failed")
+	issuer, err = NewIssuer(issuerCert, issuerSigner, profile, &linter.Linter{}, clock.NewFake())
+	test.AssertNotError(t, err, "NewIssuer failed")
+	test.AssertEquals(t, issuer.State(), StateRetiring)
+	test.AssertEquals(t, len(issuer.Algs()), 0)
+}
+
+func TestNewProfileCRLDistributionPoints(t *testing.T) {
+	profileConfig := defaultProfileConfig()
+	profileConfig.IncludeCRLDistributionPoints = true
+	_, err := NewProfile(profileConfig, defaultIssuerConfig())
+	test.AssertError(t, err, "NewProfile didn't fail with no CRLDP base")
+	test.AssertContains(t, err.Error(), "CRL distribution point base is required")
+
+	profileConfig.CRLDPBase = "http://c.example.com"
+	_, err = NewProfile(profileConfig, defaultIssuerConfig())
+	test.AssertError(t, err, "NewProfile didn't fail with no CRL shards")
+	test.AssertContains(t, err.Error(), "number of CRL shards must be positive")
+
+	profileConfig.CRLShards = 10
+	issuerConfig := defaultIssuerConfig()
+	issuerConfig.CRLURL = "http://c.example.com/static.crl"
+	_, err = NewProfile(profileConfig, issuerConfig)
+	test.AssertError(t, err, "NewProfile didn't fail with a static CRL URL")
+
+	_, err = NewProfile(profileConfig, defaultIssuerConfig())
+	test.AssertError(t, err, "NewProfile didn't fail without stored CRL shards")
+	test.AssertContains(t, err.Error(), "StoreCRLShards feature is required")
+
+	err = features.Set(map[string]bool{"StoreCRLShards": true})
+	test.AssertNotError(t, err, "setting features")
+	defer features.Reset()
+	profile, err := NewProfile(profileConfig, defaultIssuerConfig())
+	test.AssertNotError(t, err, "NewProfile failed")
+	shard, ok := profile.crlShard(big.NewInt(1234))
+	test.Assert(t, ok, "profile didn't assign a CRL shard")
+	test.AssertEquals(t, shard, int64(4))
+
+	_, ok = defaultProfile().crlShard(big.NewInt(1234))
+	test.Assert(t, !ok, "profile without CRLDPs assigned a CRL shard")
+}
+
+func TestLoadCertificates(t *testing.T) {
+	paths := []string{"../test/test-ca.pem", "../test/test-ca2.pem"}
+	certs, err := LoadCertificates(paths, nil)
+	test.AssertNotError(t, err, "LoadCertificates failed")
+	test.AssertEquals(t, len(certs), 2)
+
+	certs, err = LoadCertificates(paths, map[string]IssuerState{
+		"../test/test-ca.pem":  StateRetiring,
+		"../test/test-ca2.pem": StateArchived,
+	})
+	test.AssertNotError(t, err, "LoadCertificates failed")
+	test.AssertEquals(t, len(certs), 1)
+	test.AssertEquals(t, certs[0].Subject.CommonName, "happy hacker fake CA")
+
+	_, err = LoadCertificates(paths, map[string]IssuerState{"../test/test-ca3.pem": StateArchived})
+	test.AssertError(t, err, "LoadCertificates accepted state for unknown path")
+
+	_, err = LoadCertificates(paths, map[string]IssuerState{"../test/test-ca.pem": "sunsetting"})
+	test.AssertError(t, err, "LoadCertificates accepted unknown state")
+}
+
+func TestLoadIssuerStates(t *testing.T) {
+	statesFile := filepath.Join(t.TempDir(), "issuer-states.yaml")
+	err := os.WriteFile(statesFile, []byte(
+		"../test/test-ca.pem: retiring\n../test/test-ca2.pem: archived\n../test/test-ca3.pem: active\n"), 0600)
+	test.AssertNotError(t, err, "writing issuer states file")
+
+	paths := []string{"../test/test-ca.pem", "../test/test-ca2.pem"}
+	states, err := LoadIssuerStates(statesFile, paths)
+	test.AssertNotError(t, err, "LoadIssuerStates failed")
+	test.AssertDeepEquals(t, states, map[string]IssuerState{
+		"../test/test-ca.pem":  StateRetiring,
+		"../test/test-ca2.pem": StateArchived,
+	})
+
+	_, err = LoadIssuerStates(statesFile, append(paths, "../test/test-ca4.pem"))
+	test.AssertError(t, err, "LoadIssuerStates accepted an issuer missing from the file")
+
+	err = os.WriteFile(statesFile, []byte("../test/test-ca.pem: [active]\n"), 0600)
+	test.AssertNotError(t, err, "writing issuer states file")
+	_, err = LoadIssuerStates(statesFile, paths)
+	test.AssertError(t, err, "LoadIssuerStates accepted a malformed file")
+}
+
 func TestRequestValid(t *testing.T) {
 	fc := clock.NewFake()
 	fc.Add(time.Hour * 24)
@@ -179,6 +293,12 @@ func TestRequestValid(t *testing.T) {
 			request:       &IssuanceRequest{PublicKey: &dsa.PublicKey{}},
 			expectedError: "unsupported public key type",
 		},
+		{
+			name:          "cannot sign ed25519",
+			profile:       &Profile{},
+			request:       &IssuanceRequest{PublicKey: ed25519.PublicKey{}},
+			expectedError: "cannot sign Ed25519 public keys",
+		},
 		{
 			name:          "cannot sign rsa",
 			profile:       &Profile{},
@@ -359,7 +479,7 @@ func TestRequestValid(t *testing.T) {
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			err := tc.profile.requestValid(fc, tc.request)
+			err := tc.profile.requestValid(fc, issuerCert, tc.request)
 			if err != nil {
 				if tc.expectedError == "" {
 					t.Errorf("failed with unexpected error: %s", err)
@@ -575,6 +695,114 @@ func TestIssue(t *testing.T) {
 	}
 }
 
+func TestIssueNameConstraints(t *testing.T) {
+	fc := clock.NewFake()
+	fc.Set(time.Now())
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(124),
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		Subject: pkix.Name{
+			CommonName: "constrained ca",
+		},
+		KeyUsage:                    x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		PermittedDNSDomains:         []string{"example.com"},
+		ExcludedDNSDomains:          []string{"bad.example.com"},
+		PermittedDNSDomainsCritical: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, issuerSigner.Public(), issuerSigner)
+	test.AssertNotError(t, err, "failed to generate constrained issuer")
+	constrainedCert, err := x509.ParseCertificate(der)
+	test.AssertNotError(t, err, "failed to parse constrained issuer")
+
+	linter, err := linter.New(constrainedCert, issuerSigner, []string{
+		"w_ct_sct_policy_count_unsatisfied",
+		"e_scts_from_same_operator",
+	})
+	test.AssertNotError(t, err, "failed to create linter")
+	signer, err := NewIssuer(&Certificate{Certificate: constrainedCert}, issuerSigner, defaultProfile(), linter, fc)
+	test.AssertNotError(t, err, "NewIssuer failed")
+	test.Assert(t, !signer.NameConstraints().IsEmpty(), "issuer should report its name constraints")
+
+	pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "failed to generate test key")
+	issue := func(names ...string) error {
+		_, err := signer.Issue(&IssuanceRequest{
+			PublicKey: pk.Public(),
+			Serial:    []byte{1, 2, 3, 4, 5, 6, 7, 8, 9},
+			DNSNames:  names,
+			NotBefore: fc.Now(),
+			NotAfter:  fc.Now().Add(time.Hour - time.Second),
+		})
+		return err
+	}
+
+	err = issue("example.com", "www.example.com")
+	test.AssertNotError(t, err, "Issue failed for names within constraints")
+
+	for _, names := range [][]string{
+		{"www.example.com", "example.net"},
+		{"bad.example.com"},
+		{"*.example.com"},
+	} {
+		err = issue(names...)
+		test.AssertError(t, err, fmt.Sprintf("Issue succeeded for %v", names))
+		test.AssertErrorIs(t, err, berrors.RejectedIdentifier)
+	}
+}
+
+func TestIssueEd25519(t *testing.T) {
+	fc := clock.NewFake()
+	fc.Set(time.Now())
+
+	profileConfig := defaultProfileConfig()
+	issuerConfig := defaultIssuerConfig()
+	issuerConfig.UseForEd25519Leaves = true
+	_, err := NewProfile(profileConfig, issuerConfig)
+	test.AssertError(t, err, "NewProfile allowed Ed25519 leaves without the profile setting")
+
+	profileConfig.AllowEd25519Leaves = true
+	profile, err := NewProfile(profileConfig, issuerConfig)
+	test.AssertNotError(t, err, "NewProfile failed")
+
+	linter, err := linter.New(
+		issuerCert.Certificate,
+		issuerSigner,
+		[]string{
+			"w_ct_sct_policy_count_unsatisfied",
+			"e_scts_from_same_operator",
+			"n_subject_common_name_included",
+			"e_public_key_type_not_allowed",
+			"e_algorithm_identifier_improper_encoding",
+		},
+	)
+	test.AssertNotError(t, err, "failed to create linter")
+	signer, err := NewIssuer(issuerCert, issuerSigner, profile, linter, fc)
+	test.AssertNotError(t, err, "NewIssuer failed")
+	test.AssertDeepEquals(t, signer.Algs(), []x509.PublicKeyAlgorithm{x509.RSA, x509.ECDSA, x509.Ed25519})
+
+	pk, _, err := ed25519.GenerateKey(rand.Reader)
+	test.AssertNotError(t, err, "failed to generate Ed25519 key")
+	certBytes, err := signer.Issue(&IssuanceRequest{
+		PublicKey: pk,
+		Serial:    []byte{1, 2, 3, 4, 5, 6, 7, 8, 9},
+		DNSNames:  []string{"example.com"},
+		NotBefore: fc.Now(),
+		NotAfter:  fc.Now().Add(time.Hour - time.Second),
+	})
+	test.AssertNotError(t, err, "Issue failed")
+	cert, err := x509.ParseCertificate(certBytes)
+	test.AssertNotError(t, err, "failed to parse certificate")
+	err = cert.CheckSignatureFrom(issuerCert.Certificate)
+	test.AssertNotError(t, err, "signature validation failed")
+	test.AssertDeepEquals(t, cert.PublicKey, pk)
+	test.AssertEquals(t, cert.KeyUsage, x509.KeyUsageDigitalSignature)
+	skid, err := generateSKID(pk)
+	test.AssertNotError(t, err, "failed to generate SKID")
+	test.AssertByteEquals(t, cert.SubjectKeyId, skid)
+	test.AssertEquals(t, len(skid), 20)
+}
+
 func TestIssueRSA(t *testing.T) {
 	fc := clock.NewFake()
 	fc.Set(time.Now())
@@ -609,6 +837,50 @@ func TestIssueRSA(t *testing.T) {
 	test.AssertEquals(t, cert.KeyUsage, x509.KeyUsageDigitalSignature|x509.KeyUsageKeyEncipherment)
 }
 
+func TestIssueCRLDistributionPoints(t *testing.T) {
+	fc := clock.NewFake()
+	fc.Set(time.Now())
+	linter, err := linter.New(
+		issuerCert.Certificate,
+		issuerSigner,
+		[]string{
+			"w_ct_sct_policy_count_unsatisfied",
+			"e_scts_from_same_operator",
+		},
+	)
+	test.AssertNotError(t, err, "failed to create linter")
+	err = features.Set(map[string]bool{"StoreCRLShards": true})
+	test.AssertNotError(t, err, "setting features")
+	defer features.Reset()
+	profileConfig := defaultProfileConfig()
+	profileConfig.IncludeCRLDistributionPoints = true
+	profileConfig.CRLDPBase = "http://c.example.com"
+	profileConfig.CRLShards = 100
+	profile, err := NewProfile(profileConfig, defaultIssuerConfig())
+	test.AssertNotError(t, err, "NewProfile failed")
+	signer, err := NewIssuer(issuerCert, issuerSigner, profile, linter, fc)
+	test.AssertNotError(t, err, "NewIssuer failed")
+	pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "failed to generate test key")
+	certBytes, err := signer.Issue(&IssuanceRequest{
+		PublicKey: pk.Public(),
+		Serial:    []byte{1, 2, 3, 4, 5, 6, 7, 8, 9},
+		DNSNames:  []string{"example.com"},
+		NotBefore: fc.Now(),
+		NotAfter:  fc.Now().Add(time.Hour - time.Second),
+	})
+	test.AssertNotError(t, err, "Issue failed")
+	cert, err := x509.ParseCertificate(certBytes)
+	test.AssertNotError(t, err, "failed to parse certificate")
+
+	shard, ok := signer.CRLShard(cert.SerialNumber)
+	test.Assert(t, ok, "issuer didn't assign a CRL shard")
+	test.AssertEquals(t, shard, new(big.Int).Mod(cert.SerialNumber, big.NewInt(100)).Int64())
+	test.AssertDeepEquals(t, cert.CRLDistributionPoints, []string{
+		fmt.Sprintf("http://c.example.com/%d/%d.crl", issuerCert.NameID(), shard),
+	})
+}
+
 func TestIssueCommonName(t *testing.T) {
 	fc := clock.NewFake()
 	fc.Set(time.Now())
@@ -689,6 +961,65 @@ func TestIssueCTPoison(t *testing.T) {
 	test.AssertDeepEquals(t, cert.Extensions[8], ctPoisonExt)
 }
 
+func TestIssueRetiring(t *testing.T) {
+	fc := clock.NewFake()
+	fc.Set(time.Now())
+	linter, err := linter.New(
+		issuerCert.Certificate,
+		issuerSigner,
+		[]string{
+			"w_ct_sct_policy_count_unsatisfied",
+			"e_scts_from_same_operator",
+		},
+	)
+	test.AssertNotError(t, err, "failed to create linter")
+	active, err := NewIssuer(issuerCert, issuerSigner, defaultProfile(), linter, fc)
+	test.AssertNotError(t, err, "NewIssuer failed")
+	issuerConfig := defaultIssuerConfig()
+	issuerConfig.State = StateRetiring
+	profile, err := NewProfile(defaultProfileConfig(), issuerConfig)
+	test.AssertNotError(t, err, "NewProfile failed")
+	retiring, err := NewIssuer(issuerCert, issuerSigner, profile, linter, fc)
+	test.AssertNotError(t, err, "NewIssuer failed")
+
+	pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "failed to generate test key")
+	req := &IssuanceRequest{
+		PublicKey:       pk.Public(),
+		Serial:          []byte{1, 2, 3, 4, 5, 6, 7, 8, 9},
+		DNSNames:        []string{"example.com"},
+		IncludeCTPoison: true,
+		NotBefore:       fc.Now(),
+		NotAfter:        fc.Now().Add(time.Hour - time.Second),
+	}
+
+	// A retiring issuer refuses to issue new precertificates...
+	_, err = retiring.Issue(req)
+	test.AssertError(t, err, "retiring issuer issued a new precertificate")
+	test.AssertContains(t, err.Error(), "retiring")
+
+	// ...or new certificates without a precertificate...
+	_, err = retiring.Issue(&IssuanceRequest{
+		PublicKey: pk.Public(),
+		Serial:    []byte{1, 2, 3, 4, 5, 6, 7, 8, 10},
+		DNSNames:  []string{"example.com"},
+		NotBefore: fc.Now(),
+		NotAfter:  fc.Now().Add(time.Hour - time.Second),
+	})
+	test.AssertError(t, err, "retiring issuer issued a new certificate")
+
+	// ...but still signs the final certificate for a precertificate it issued
+	// while it was active.
+	precertDER, err := active.Issue(req)
+	test.AssertNotError(t, err, "Issue failed")
+	precert, err := x509.ParseCertificate(precertDER)
+	test.AssertNotError(t, err, "failed to parse precertificate")
+	finalReq, err := RequestFromPrecert(precert, nil)
+	test.AssertNotError(t, err, "RequestFromPrecert failed")
+	_, err = retiring.Issue(finalReq)
+	test.AssertNotError(t, err, "retiring issuer refused to issue a final certificate")
+}
+
 func TestIssueSCTList(t *testing.T) {
 	fc := clock.NewFake()
 	fc.Set(time.Now())
@@ -0,0 +1,22 @@
+//go:build go1.27
+
+package issuance
+
+import (
+	"crypto"
+	"crypto/mldsa"
+	"crypto/x509"
+)
+
+// mldsaSupported is true when Boulder is built with a Go toolchain whose
+// standard library can issue certificates for ML-DSA keys.
+const mldsaSupported = true
+
+// mldsaAlgorithm is the public key algorithm of ML-DSA leaf certificates.
+const mldsaAlgorithm = x509.MLDSA
+
+// isMLDSAKey returns true if key is an ML-DSA public key.
+func isMLDSAKey(key crypto.PublicKey) bool {
+	_, ok := key.(*mldsa.PublicKey)
+	return ok
+}
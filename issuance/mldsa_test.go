@@ -0,0 +1,68 @@
+//go:build go1.27
+
+package issuance
+
+import (
+	"crypto/mldsa"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/linter"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestIssueMLDSA(t *testing.T) {
+	fc := clock.NewFake()
+	fc.Set(time.Now())
+
+	profileConfig := defaultProfileConfig()
+	issuerConfig := defaultIssuerConfig()
+	issuerConfig.UseForMLDSALeaves = true
+	_, err := NewProfile(profileConfig, issuerConfig)
+	test.AssertError(t, err, "NewProfile allowed ML-DSA leaves without the profile setting")
+
+	profileConfig.AllowMLDSALeaves = true
+	profile, err := NewProfile(profileConfig, issuerConfig)
+	test.AssertNotError(t, err, "NewProfile failed")
+
+	linter, err := linter.New(
+		issuerCert.Certificate,
+		issuerSigner,
+		[]string{
+			"w_ct_sct_policy_count_unsatisfied",
+			"e_scts_from_same_operator",
+			"n_subject_common_name_included",
+			"e_public_key_type_not_allowed",
+			"e_algorithm_identifier_improper_encoding",
+		},
+	)
+	test.AssertNotError(t, err, "failed to create linter")
+	signer, err := NewIssuer(issuerCert, issuerSigner, profile, linter, fc)
+	test.AssertNotError(t, err, "NewIssuer failed")
+	test.AssertDeepEquals(t, signer.Algs(), []x509.PublicKeyAlgorithm{x509.RSA, x509.ECDSA, x509.MLDSA})
+
+	priv, err := mldsa.GenerateKey(mldsa.MLDSA44())
+	test.AssertNotError(t, err, "failed to generate ML-DSA key")
+	pk := priv.PublicKey()
+	certBytes, err := signer.Issue(&IssuanceRequest{
+		PublicKey: pk,
+		Serial:    []byte{1, 2, 3, 4, 5, 6, 7, 8, 9},
+		DNSNames:  []string{"example.com"},
+		NotBefore: fc.Now(),
+		NotAfter:  fc.Now().Add(time.Hour - time.Second),
+	})
+	test.AssertNotError(t, err, "Issue failed")
+	cert, err := x509.ParseCertificate(certBytes)
+	test.AssertNotError(t, err, "failed to parse certificate")
+	err = cert.CheckSignatureFrom(issuerCert.Certificate)
+	test.AssertNotError(t, err, "signature validation failed")
+	test.AssertDeepEquals(t, cert.PublicKey, pk)
+	test.AssertEquals(t, cert.KeyUsage, x509.KeyUsageDigitalSignature)
+	skid, err := generateSKID(pk)
+	test.AssertNotError(t, err, "failed to generate SKID")
+	test.AssertByteEquals(t, cert.SubjectKeyId, skid)
+	test.AssertEquals(t, len(skid), 20)
+}
@@ -0,0 +1,18 @@
+//go:build !go1.27
+
+package issuance
+
+import (
+	"crypto"
+	"crypto/x509"
+)
+
+// mldsaSupported is false because Go releases before 1.27 cannot issue
+// certificates for ML-DSA keys, so NewProfile refuses UseForMLDSALeaves.
+const mldsaSupported = false
+
+const mldsaAlgorithm = x509.UnknownPublicKeyAlgorithm
+
+func isMLDSAKey(crypto.PublicKey) bool {
+	return false
+}
@@ -0,0 +1,126 @@
+package issuance
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// tbsParts holds the top-level fields of a DER-encoded TBSCertificate, with
+// its extensions split out.
+type tbsParts struct {
+	fields     [][]byte
+	extensions []tbsExtension
+}
+
+type tbsExtension struct {
+	id  asn1.ObjectIdentifier
+	der []byte
+}
+
+var extensionsTag = cryptobyte_asn1.Tag(3).Constructed().ContextSpecific()
+
+func parseTBS(der []byte) (*tbsParts, error) {
+	input := cryptobyte.String(der)
+	var tbs cryptobyte.String
+	if !input.ReadASN1(&tbs, cryptobyte_asn1.SEQUENCE) || !input.Empty() {
+		return nil, errors.New("malformed tbsCertificate")
+	}
+	var parts tbsParts
+	for !tbs.Empty() {
+		var field cryptobyte.String
+		var tag cryptobyte_asn1.Tag
+		if !tbs.ReadAnyASN1Element(&field, &tag) {
+			return nil, errors.New("malformed tbsCertificate field")
+		}
+		if tag != extensionsTag {
+			parts.fields = append(parts.fields, field)
+			continue
+		}
+		var exts cryptobyte.String
+		if !field.ReadASN1(&exts, extensionsTag) || !exts.ReadASN1(&exts, cryptobyte_asn1.SEQUENCE) {
+			return nil, errors.New("malformed extensions")
+		}
+		for !exts.Empty() {
+			var ext, body cryptobyte.String
+			var id asn1.ObjectIdentifier
+			if !exts.ReadASN1Element(&ext, cryptobyte_asn1.SEQUENCE) {
+				return nil, errors.New("malformed extension")
+			}
+			body = ext
+			if !body.ReadASN1(&body, cryptobyte_asn1.SEQUENCE) || !body.ReadASN1ObjectIdentifier(&id) {
+				return nil, errors.New("malformed extension")
+			}
+			parts.extensions = append(parts.extensions, tbsExtension{id, ext})
+		}
+	}
+	return &parts, nil
+}
+
+// CheckPrecertCorrespondence returns an error unless finalTBS, the DER-encoded
+// TBSCertificate of a final certificate, is byte-for-byte identical to
+// precertTBS, that of its precertificate, apart from the CT poison extension,
+// which the precertificate must have and the final certificate must not, and
+// the SCT list extension. This ensures that the final certificate is the one
+// which was submitted to CT logs.
+func CheckPrecertCorrespondence(precertTBS, finalTBS []byte) error {
+	precert, err := parseTBS(precertTBS)
+	if err != nil {
+		return fmt.Errorf("parsing precertificate: %w", err)
+	}
+	final, err := parseTBS(finalTBS)
+	if err != nil {
+		return fmt.Errorf("parsing final certificate: %w", err)
+	}
+
+	if len(precert.fields) != len(final.fields) {
+		return fmt.Errorf("precertificate has %d tbsCertificate fields, final certificate has %d",
+			len(precert.fields), len(final.fields))
+	}
+	for i := range precert.fields {
+		if !bytes.Equal(precert.fields[i], final.fields[i]) {
+			return fmt.Errorf("tbsCertificate field %d differs", i)
+		}
+	}
+
+	precertExts, poisoned := withoutCTExtensions(precert.extensions)
+	if !poisoned {
+		return errors.New("precertificate doesn't contain the CT poison extension")
+	}
+	finalExts, poisoned := withoutCTExtensions(final.extensions)
+	if poisoned {
+		return errors.New("final certificate contains the CT poison extension")
+	}
+	for i := 0; i < len(precertExts) || i < len(finalExts); i++ {
+		switch {
+		case i >= len(finalExts):
+			return fmt.Errorf("extension %s is missing from final certificate", precertExts[i].id)
+		case i >= len(precertExts):
+			return fmt.Errorf("extension %s is missing from precertificate", finalExts[i].id)
+		case !bytes.Equal(precertExts[i].der, finalExts[i].der):
+			return fmt.Errorf("extension %s differs from precertificate's %s", finalExts[i].id, precertExts[i].id)
+		}
+	}
+	return nil
+}
+
+// withoutCTExtensions returns the given extensions less any CT poison or SCT
+// list extensions, and whether a CT poison extension was present.
+func withoutCTExtensions(exts []tbsExtension) ([]tbsExtension, bool) {
+	var res []tbsExtension
+	var poisoned bool
+	for _, ext := range exts {
+		switch {
+		case ext.id.Equal(ctPoisonExt.Id):
+			poisoned = true
+		case ext.id.Equal(sctListOID):
+		default:
+			res = append(res, ext)
+		}
+	}
+	return res, poisoned
+}
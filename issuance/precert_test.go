@@ -0,0 +1,155 @@
+package issuance
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"strings"
+	"testing"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/linter"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestCheckPrecertCorrespondence(t *testing.T) {
+	fc := clock.NewFake()
+	fc.Set(time.Now())
+	linter, err := linter.New(
+		issuerCert.Certificate,
+		issuerSigner,
+		[]string{
+			"w_ct_sct_policy_count_unsatisfied",
+			"e_scts_from_same_operator",
+		},
+	)
+	test.AssertNotError(t, err, "failed to create linter")
+	signer, err := NewIssuer(issuerCert, issuerSigner, defaultProfile(), linter, fc)
+	test.AssertNotError(t, err, "NewIssuer failed")
+	pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "failed to generate test key")
+
+	precertDER, err := signer.Issue(&IssuanceRequest{
+		PublicKey:       pk.Public(),
+		Serial:          []byte{1, 2, 3, 4, 5, 6, 7, 8, 9},
+		DNSNames:        []string{"example.com"},
+		NotBefore:       fc.Now(),
+		NotAfter:        fc.Now().Add(time.Hour - time.Second),
+		IncludeCTPoison: true,
+	})
+	test.AssertNotError(t, err, "Issue failed")
+	precert, err := x509.ParseCertificate(precertDER)
+	test.AssertNotError(t, err, "failed to parse precertificate")
+
+	prepare := func(modify func(*IssuanceRequest)) []byte {
+		t.Helper()
+		req, err := RequestFromPrecert(precert, []ct.SignedCertificateTimestamp{{SCTVersion: ct.V1}})
+		test.AssertNotError(t, err, "RequestFromPrecert failed")
+		modify(req)
+		prepared, err := signer.Prepare(req)
+		test.AssertNotError(t, err, "Prepare failed")
+		return prepared.RawTBSCertificate()
+	}
+
+	testCases := []struct {
+		name        string
+		precertTBS  []byte
+		finalTBS    []byte
+		expectedErr string
+	}{
+		{
+			name:       "matching",
+			precertTBS: precert.RawTBSCertificate,
+			finalTBS:   prepare(func(*IssuanceRequest) {}),
+		},
+		{
+			name:        "different serial",
+			precertTBS:  precert.RawTBSCertificate,
+			finalTBS:    prepare(func(req *IssuanceRequest) { req.Serial = []byte{9, 8, 7, 6, 5, 4, 3, 2, 1} }),
+			expectedErr: "tbsCertificate field 1 differs",
+		},
+		{
+			name:        "different names",
+			precertTBS:  precert.RawTBSCertificate,
+			finalTBS:    prepare(func(req *IssuanceRequest) { req.DNSNames = []string{"example.net"} }),
+			expectedErr: "extension 2.5.29.17 differs",
+		},
+		{
+			name:        "extra extension",
+			precertTBS:  precert.RawTBSCertificate,
+			finalTBS:    prepare(func(req *IssuanceRequest) { req.IncludeMustStaple = true }),
+			expectedErr: "extension 1.3.6.1.5.5.7.1.24 is missing from precertificate",
+		},
+		{
+			name:        "unpoisoned precertificate",
+			precertTBS:  prepare(func(*IssuanceRequest) {}),
+			finalTBS:    prepare(func(*IssuanceRequest) {}),
+			expectedErr: "precertificate doesn't contain the CT poison extension",
+		},
+		{
+			name:        "poisoned final certificate",
+			precertTBS:  precert.RawTBSCertificate,
+			finalTBS:    precert.RawTBSCertificate,
+			expectedErr: "final certificate contains the CT poison extension",
+		},
+		{
+			name:        "malformed",
+			precertTBS:  precert.RawTBSCertificate,
+			finalTBS:    precert.Raw[:20],
+			expectedErr: "parsing final certificate",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckPrecertCorrespondence(tc.precertTBS, tc.finalTBS)
+			if tc.expectedErr == "" {
+				test.AssertNotError(t, err, "CheckPrecertCorrespondence failed")
+			} else {
+				test.AssertError(t, err, "CheckPrecertCorrespondence succeeded")
+				test.Assert(t, strings.Contains(err.Error(), tc.expectedErr), err.Error())
+			}
+		})
+	}
+}
+
+func TestIssuePrepared(t *testing.T) {
+	fc := clock.NewFake()
+	fc.Set(time.Now())
+	linter, err := linter.New(
+		issuerCert.Certificate,
+		issuerSigner,
+		[]string{
+			"w_ct_sct_policy_count_unsatisfied",
+			"e_scts_from_same_operator",
+		},
+	)
+	test.AssertNotError(t, err, "failed to create linter")
+	signer, err := NewIssuer(issuerCert, issuerSigner, defaultProfile(), linter, fc)
+	test.AssertNotError(t, err, "NewIssuer failed")
+	pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "failed to generate test key")
+
+	prepared, err := signer.Prepare(&IssuanceRequest{
+		PublicKey: pk.Public(),
+		Serial:    []byte{1, 2, 3, 4, 5, 6, 7, 8, 9},
+		DNSNames:  []string{"example.com"},
+		NotBefore: fc.Now(),
+		NotAfter:  fc.Now().Add(time.Hour - time.Second),
+	})
+	test.AssertNotError(t, err, "Prepare failed")
+	certDER, err := signer.IssuePrepared(prepared)
+	test.AssertNotError(t, err, "IssuePrepared failed")
+	cert, err := x509.ParseCertificate(certDER)
+	test.AssertNotError(t, err, "failed to parse certificate")
+	// The lint certificate's tbsCertificate is exactly the one which was signed.
+	test.AssertByteEquals(t, cert.RawTBSCertificate, prepared.RawTBSCertificate())
+
+	other, err := NewIssuer(issuerCert, issuerSigner, defaultProfile(), linter, fc)
+	test.AssertNotError(t, err, "NewIssuer failed")
+	_, err = other.IssuePrepared(prepared)
+	test.AssertError(t, err, "IssuePrepared accepted a certificate prepared by another issuer")
+}
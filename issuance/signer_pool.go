@@ -0,0 +1,343 @@
+package issuance
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/letsencrypt/boulder/config"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/semaphore"
+)
+
+// SignerPoolConfig controls concurrent use of an issuer's signing key, which
+// is typically a pool of sessions with an HSM (see IssuerLoc.NumSessions).
+type SignerPoolConfig struct {
+	// MaxConcurrency is the maximum number of signing operations which may be
+	// in flight at once. It defaults to the issuer's NumSessions.
+	MaxConcurrency int `validate:"min=0"`
+	// MinConcurrency is the lowest the adaptive concurrency limit may fall.
+	// It defaults to 1.
+	MinConcurrency int `validate:"min=0"`
+	// MaxWaiters is the number of signing operations which may queue for a
+	// free slot; beyond that, signing fails immediately. Zero means no limit.
+	MaxWaiters int `validate:"min=0"`
+	// TargetLatency is the signing latency the pool aims for. Each operation
+	// which takes longer reduces the concurrency limit by one, down to
+	// MinConcurrency, and each which is quicker raises it by one, up to
+	// MaxConcurrency. Zero disables adaptation.
+	TargetLatency config.Duration `validate:"-"`
+	// Timeout bounds the time spent waiting for a free slot and signing. An
+	// operation which times out counts as a failure. It defaults to 5s.
+	Timeout config.Duration `validate:"-"`
+	// FailureThreshold is the number of consecutive failed operations after
+	// which the signer is marked unavailable, until a health check succeeds.
+	// It defaults to 3.
+	FailureThreshold int `validate:"min=0"`
+	// HealthCheckInterval is how often a test signature is made to check
+	// that the key is still usable. It defaults to 10s.
+	HealthCheckInterval config.Duration `validate:"-"`
+}
+
+// SignerPoolMetrics are the metrics shared by every PooledSigner.
+type SignerPoolMetrics struct {
+	latency    *prometheus.HistogramVec
+	queueDepth *prometheus.GaugeVec
+	limit      *prometheus.GaugeVec
+	available  *prometheus.GaugeVec
+}
+
+// NewSignerPoolMetrics creates and registers the metrics used by
+// PooledSigners.
+func NewSignerPoolMetrics(stats prometheus.Registerer) *SignerPoolMetrics {
+	latency := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "issuer_signing_latency_seconds",
+		Help:    "Time taken to sign with an issuer key, labelled by issuer and result",
+		Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"issuer", "result"})
+	stats.MustRegister(latency)
+	queueDepth := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "issuer_signing_queue_depth",
+		Help: "Number of signing operations waiting for a free slot, labelled by issuer",
+	}, []string{"issuer"})
+	stats.MustRegister(queueDepth)
+	limit := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "issuer_signing_concurrency_limit",
+		Help: "Current adaptive limit on concurrent signing operations, labelled by issuer",
+	}, []string{"issuer"})
+	stats.MustRegister(limit)
+	available := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "issuer_signer_available",
+		Help: "Whether an issuer's signing key is available (1) or failing (0), labelled by issuer",
+	}, []string{"issuer"})
+	stats.MustRegister(available)
+	return &SignerPoolMetrics{latency, queueDepth, limit, available}
+}
+
+// errSignerTimeout is returned when a signing operation times out.
+var errSignerTimeout = errors.New("timed out waiting for signer")
+
+// PooledSigner wraps an issuer's crypto.Signer, limiting the number of
+// concurrent signing operations with a semaphore whose effective size adapts
+// to the observed signing latency, and tracking whether the key is available.
+type PooledSigner struct {
+	crypto.Signer
+
+	name string
+	sem  *semaphore.Weighted
+
+	minLimit      int
+	maxLimit      int
+	targetLatency time.Duration
+	timeout       time.Duration
+	threshold     int
+	interval      time.Duration
+
+	sync.Mutex
+	// reserved is the number of semaphore slots held back from use to shrink
+	// the concurrency limit below maxLimit.
+	reserved    int
+	failures    int
+	unavailable bool
+
+	stop    chan struct{}
+	stopped sync.WaitGroup
+
+	metrics *SignerPoolMetrics
+	clk     clock.Clock
+	log     blog.Logger
+}
+
+// NewPooledSigner wraps signer, which belongs to the named issuer and has
+// numSessions sessions with its HSM, according to the given config.
+func NewPooledSigner(name string, signer crypto.Signer, numSessions int, cfg SignerPoolConfig, metrics *SignerPoolMetrics, clk clock.Clock, log blog.Logger) (*PooledSigner, error) {
+	if numSessions < 1 {
+		numSessions = 1
+	}
+	maxLimit := cfg.MaxConcurrency
+	if maxLimit == 0 {
+		maxLimit = numSessions
+	}
+	minLimit := cfg.MinConcurrency
+	if minLimit == 0 {
+		minLimit = 1
+	}
+	if minLimit > maxLimit {
+		return nil, fmt.Errorf("minimum signing concurrency %d exceeds maximum %d", minLimit, maxLimit)
+	}
+	timeout := cfg.Timeout.Duration
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+	threshold := cfg.FailureThreshold
+	if threshold == 0 {
+		threshold = 3
+	}
+	interval := cfg.HealthCheckInterval.Duration
+	if interval == 0 {
+		interval = 10 * time.Second
+	}
+	ps := &PooledSigner{
+		Signer:        signer,
+		name:          name,
+		sem:           semaphore.NewWeighted(int64(maxLimit), cfg.MaxWaiters),
+		minLimit:      minLimit,
+		maxLimit:      maxLimit,
+		targetLatency: cfg.TargetLatency.Duration,
+		timeout:       timeout,
+		threshold:     threshold,
+		interval:      interval,
+		metrics:       metrics,
+		clk:           clk,
+		log:           log,
+	}
+	metrics.limit.WithLabelValues(name).Set(float64(maxLimit))
+	metrics.available.WithLabelValues(name).Set(1)
+	return ps, nil
+}
+
+// Sign waits for a free slot, then signs with the wrapped signer. If the
+// wrapped signer doesn't return within the configured timeout, Sign returns an
+// error, while the slot remains in use until the wrapped signer does return.
+func (ps *PooledSigner) Sign(random io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ps.timeout)
+	defer cancel()
+
+	start := ps.clk.Now()
+	ps.metrics.queueDepth.WithLabelValues(ps.name).Set(float64(ps.sem.NumWaiters() + 1))
+	err := ps.sem.Acquire(ctx, 1)
+	ps.metrics.queueDepth.WithLabelValues(ps.name).Set(float64(ps.sem.NumWaiters()))
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = errSignerTimeout
+		}
+		ps.metrics.latency.WithLabelValues(ps.name, "queue_failed").Observe(ps.clk.Since(start).Seconds())
+		return nil, fmt.Errorf("signing with %q: %w", ps.name, err)
+	}
+
+	type result struct {
+		sig []byte
+		err error
+	}
+	done := make(chan result, 1)
+	op := &signOp{}
+	go func() {
+		sig, err := ps.Signer.Sign(random, digest, opts)
+		latency := ps.clk.Since(start)
+		ps.finish(op, latency, err)
+		done <- result{sig, err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.err != nil {
+			return nil, fmt.Errorf("signing with %q: %w", ps.name, res.err)
+		}
+		return res.sig, nil
+	case <-ctx.Done():
+		if !ps.account(op) {
+			// The signature completed, and was accounted for, just as the
+			// timeout fired.
+			res := <-done
+			if res.err != nil {
+				return nil, fmt.Errorf("signing with %q: %w", ps.name, res.err)
+			}
+			return res.sig, nil
+		}
+		ps.recordFailure()
+		ps.metrics.latency.WithLabelValues(ps.name, "timeout").Observe(ps.clk.Since(start).Seconds())
+		return nil, fmt.Errorf("signing with %q: %w", ps.name, errSignerTimeout)
+	}
+}
+
+// signOp tracks whether the outcome of a single signing operation has been
+// recorded, so that an operation which times out and later completes only
+// counts once towards the signer's availability.
+type signOp struct {
+	// accounted is protected by the PooledSigner's mutex.
+	accounted bool
+}
+
+// account marks the outcome of op as recorded, returning false if it already
+// was.
+func (ps *PooledSigner) account(op *signOp) bool {
+	ps.Lock()
+	defer ps.Unlock()
+	if op.accounted {
+		return false
+	}
+	op.accounted = true
+	return true
+}
+
+// finish releases the slot used by an operation which took the given time and
+// returned the given error, adjusting the concurrency limit and, unless the
+// operation already timed out, availability.
+func (ps *PooledSigner) finish(op *signOp, latency time.Duration, err error) {
+	if ps.account(op) {
+		result := "success"
+		if err != nil {
+			result = "failed"
+			ps.recordFailure()
+		} else {
+			ps.recordSuccess()
+		}
+		ps.metrics.latency.WithLabelValues(ps.name, result).Observe(latency.Seconds())
+	}
+
+	ps.Lock()
+	defer ps.Unlock()
+	if ps.targetLatency == 0 {
+		ps.sem.Release(1)
+		return
+	}
+	slow := err != nil || latency > ps.targetLatency
+	if slow && ps.maxLimit-ps.reserved > ps.minLimit {
+		// Shrink the limit by keeping this operation's slot.
+		ps.reserved++
+	} else if !slow && ps.reserved > 0 {
+		// Grow the limit by returning a reserved slot along with this one.
+		ps.reserved--
+		ps.sem.Release(2)
+	} else {
+		ps.sem.Release(1)
+	}
+	ps.metrics.limit.WithLabelValues(ps.name).Set(float64(ps.maxLimit - ps.reserved))
+}
+
+func (ps *PooledSigner) recordFailure() {
+	ps.Lock()
+	defer ps.Unlock()
+	ps.failures++
+	if ps.failures >= ps.threshold && !ps.unavailable {
+		ps.unavailable = true
+		ps.metrics.available.WithLabelValues(ps.name).Set(0)
+		ps.log.AuditErrf("Signer for issuer %q marked unavailable after %d consecutive failures", ps.name, ps.failures)
+	}
+}
+
+func (ps *PooledSigner) recordSuccess() {
+	ps.Lock()
+	defer ps.Unlock()
+	ps.failures = 0
+	if ps.unavailable {
+		ps.unavailable = false
+		ps.metrics.available.WithLabelValues(ps.name).Set(1)
+		ps.log.AuditInfof("Signer for issuer %q available again", ps.name)
+	}
+}
+
+// Available returns false if the signer has failed FailureThreshold times in a
+// row, and no health check or signing operation has since succeeded.
+func (ps *PooledSigner) Available() bool {
+	ps.Lock()
+	defer ps.Unlock()
+	return !ps.unavailable
+}
+
+// checkHealth makes a test signature, updating the signer's availability.
+func (ps *PooledSigner) checkHealth() {
+	digest := sha256.Sum256([]byte("boulder issuer health check"))
+	_, err := ps.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		ps.log.Warningf("Health check for issuer %q failed: %s", ps.name, err)
+	}
+}
+
+// Start begins checking the signer's health every HealthCheckInterval, until
+// Stop is called.
+func (ps *PooledSigner) Start() {
+	ps.stop = make(chan struct{})
+	ps.stopped.Add(1)
+	go func() {
+		defer ps.stopped.Done()
+		ticker := time.NewTicker(ps.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ps.stop:
+				return
+			case <-ticker.C:
+				ps.checkHealth()
+			}
+		}
+	}()
+}
+
+// Stop stops the health checks begun by Start.
+func (ps *PooledSigner) Stop() {
+	if ps.stop == nil {
+		return
+	}
+	close(ps.stop)
+	ps.stopped.Wait()
+}
@@ -0,0 +1,231 @@
+package issuance
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/config"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/test"
+)
+
+// testSigner wraps issuerSigner, optionally blocking until released, advancing
+// a fake clock, or failing.
+type testSigner struct {
+	crypto.Signer
+	mu       sync.Mutex
+	block    chan struct{}
+	fc       clock.FakeClock
+	advance  time.Duration
+	fail     atomic.Bool
+	inFlight atomic.Int32
+	maxSeen  atomic.Int32
+}
+
+func (ts *testSigner) Sign(random io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	n := ts.inFlight.Add(1)
+	defer ts.inFlight.Add(-1)
+	for {
+		seen := ts.maxSeen.Load()
+		if n <= seen || ts.maxSeen.CompareAndSwap(seen, n) {
+			break
+		}
+	}
+	ts.mu.Lock()
+	block := ts.block
+	ts.mu.Unlock()
+	if block != nil {
+		<-block
+	}
+	if ts.advance != 0 {
+		ts.fc.Add(ts.advance)
+	}
+	if ts.fail.Load() {
+		return nil, errors.New("HSM on fire")
+	}
+	return ts.Signer.Sign(random, digest, opts)
+}
+
+func (ts *testSigner) setBlock(block chan struct{}) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.block = block
+}
+
+func testDigest() []byte {
+	digest := sha256.Sum256([]byte("test"))
+	return digest[:]
+}
+
+func TestPooledSignerConcurrency(t *testing.T) {
+	ts := &testSigner{Signer: issuerSigner, block: make(chan struct{})}
+	ps, err := NewPooledSigner("test", ts, 2, SignerPoolConfig{}, NewSignerPoolMetrics(metrics.NoopRegisterer), clock.NewFake(), blog.NewMock())
+	test.AssertNotError(t, err, "NewPooledSigner failed")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := ps.Sign(rand.Reader, testDigest(), crypto.SHA256)
+			test.AssertNotError(t, err, "Sign failed")
+		}()
+	}
+	for ps.sem.NumWaiters() < 3 {
+		time.Sleep(time.Millisecond)
+	}
+	close(ts.block)
+	wg.Wait()
+	// The limit defaults to the number of HSM sessions.
+	test.AssertEquals(t, ts.maxSeen.Load(), int32(2))
+}
+
+func TestPooledSignerMaxWaiters(t *testing.T) {
+	ts := &testSigner{Signer: issuerSigner, block: make(chan struct{})}
+	ps, err := NewPooledSigner("test", ts, 1, SignerPoolConfig{MaxWaiters: 1}, NewSignerPoolMetrics(metrics.NoopRegisterer), clock.NewFake(), blog.NewMock())
+	test.AssertNotError(t, err, "NewPooledSigner failed")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = ps.Sign(rand.Reader, testDigest(), crypto.SHA256)
+		}()
+	}
+	for ps.sem.NumWaiters() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	_, err = ps.Sign(rand.Reader, testDigest(), crypto.SHA256)
+	test.AssertError(t, err, "Sign succeeded with a full queue")
+	close(ts.block)
+	wg.Wait()
+}
+
+func TestPooledSignerAdaptiveLimit(t *testing.T) {
+	fc := clock.NewFake()
+	ts := &testSigner{Signer: issuerSigner, fc: fc, advance: 200 * time.Millisecond}
+	ps, err := NewPooledSigner("test", ts, 4, SignerPoolConfig{
+		MinConcurrency: 2,
+		TargetLatency:  config.Duration{Duration: 100 * time.Millisecond},
+	}, NewSignerPoolMetrics(metrics.NoopRegisterer), fc, blog.NewMock())
+	test.AssertNotError(t, err, "NewPooledSigner failed")
+
+	limit := func() int {
+		ps.Lock()
+		defer ps.Unlock()
+		return ps.maxLimit - ps.reserved
+	}
+
+	// Slow signatures shrink the limit, but not below the minimum.
+	for i := 0; i < 5; i++ {
+		_, err = ps.Sign(rand.Reader, testDigest(), crypto.SHA256)
+		test.AssertNotError(t, err, "Sign failed")
+	}
+	test.AssertEquals(t, limit(), 2)
+	test.Assert(t, ps.sem.TryAcquire(2), "semaphore should have two free slots")
+	test.Assert(t, !ps.sem.TryAcquire(1), "semaphore should have only two free slots")
+	ps.sem.Release(2)
+
+	// Quick signatures grow it again, but not beyond the maximum.
+	ts.advance = 10 * time.Millisecond
+	for i := 0; i < 5; i++ {
+		_, err = ps.Sign(rand.Reader, testDigest(), crypto.SHA256)
+		test.AssertNotError(t, err, "Sign failed")
+	}
+	test.AssertEquals(t, limit(), 4)
+	test.Assert(t, ps.sem.TryAcquire(4), "semaphore should have four free slots")
+	ps.sem.Release(4)
+}
+
+func TestPooledSignerAvailability(t *testing.T) {
+	ts := &testSigner{Signer: issuerSigner}
+	log := blog.NewMock()
+	ps, err := NewPooledSigner("test", ts, 1, SignerPoolConfig{
+		FailureThreshold: 2,
+		Timeout:          config.Duration{Duration: 20 * time.Millisecond},
+	}, NewSignerPoolMetrics(metrics.NoopRegisterer), clock.NewFake(), log)
+	test.AssertNotError(t, err, "NewPooledSigner failed")
+	issuer := &Issuer{Cert: issuerCert, Signer: ps}
+	test.Assert(t, issuer.Available(), "issuer should start available")
+
+	ts.fail.Store(true)
+	_, err = ps.Sign(rand.Reader, testDigest(), crypto.SHA256)
+	test.AssertError(t, err, "Sign should have failed")
+	test.Assert(t, issuer.Available(), "one failure should not mark the issuer unavailable")
+
+	// A hung HSM times out, which also counts as a failure.
+	ts.fail.Store(false)
+	ts.setBlock(make(chan struct{}))
+	_, err = ps.Sign(rand.Reader, testDigest(), crypto.SHA256)
+	test.AssertErrorIs(t, err, errSignerTimeout)
+	test.Assert(t, !issuer.Available(), "two failures should mark the issuer unavailable")
+	test.AssertEquals(t, len(log.GetAllMatching("marked unavailable")), 1)
+
+	// Once the HSM responds again, a health check restores availability.
+	close(ts.block)
+	ts.setBlock(nil)
+	ps.checkHealth()
+	test.Assert(t, issuer.Available(), "a successful health check should mark the issuer available")
+	test.AssertEquals(t, len(log.GetAllMatching("available again")), 1)
+}
+
+func TestPooledSignerTimeoutCountedOnce(t *testing.T) {
+	ts := &testSigner{Signer: issuerSigner}
+	ps, err := NewPooledSigner("test", ts, 1, SignerPoolConfig{
+		FailureThreshold: 3,
+		Timeout:          config.Duration{Duration: 20 * time.Millisecond},
+	}, NewSignerPoolMetrics(metrics.NoopRegisterer), clock.NewFake(), blog.NewMock())
+	test.AssertNotError(t, err, "NewPooledSigner failed")
+
+	failures := func() int {
+		ps.Lock()
+		defer ps.Unlock()
+		return ps.failures
+	}
+	// timeOut makes a signing operation which times out, then lets the
+	// wrapped signer return and waits for its slot to be released.
+	timeOut := func() {
+		block := make(chan struct{})
+		ts.setBlock(block)
+		_, err := ps.Sign(rand.Reader, testDigest(), crypto.SHA256)
+		test.AssertErrorIs(t, err, errSignerTimeout)
+		ts.setBlock(nil)
+		close(block)
+		for !ps.sem.TryAcquire(1) {
+			time.Sleep(time.Millisecond)
+		}
+		ps.sem.Release(1)
+	}
+
+	// A signature which fails after timing out is only counted once.
+	ts.fail.Store(true)
+	timeOut()
+	test.AssertEquals(t, failures(), 1)
+
+	// A signature which succeeds after timing out doesn't reset the count.
+	ts.fail.Store(false)
+	timeOut()
+	test.AssertEquals(t, failures(), 2)
+}
+
+func TestIssuerAvailableUnpooled(t *testing.T) {
+	issuer := &Issuer{Cert: issuerCert, Signer: issuerSigner}
+	test.Assert(t, issuer.Available(), "issuers without a pooled signer are always available")
+}
+
+func TestNewPooledSignerLimits(t *testing.T) {
+	_, err := NewPooledSigner("test", issuerSigner, 1, SignerPoolConfig{MaxConcurrency: 2, MinConcurrency: 3},
+		NewSignerPoolMetrics(metrics.NoopRegisterer), clock.NewFake(), blog.NewMock())
+	test.AssertError(t, err, "NewPooledSigner accepted a minimum above the maximum")
+}
@@ -1,20 +1,27 @@
 package linter
 
 import (
+	"bytes"
 	"crypto"
 	"crypto/ecdsa"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 
 	zlintx509 "github.com/zmap/zcrypto/x509"
 	"github.com/zmap/zlint/v3"
 	"github.com/zmap/zlint/v3/lint"
+	"golang.org/x/crypto/ocsp"
 
 	"github.com/letsencrypt/boulder/crl/crl_x509"
 	crllints "github.com/letsencrypt/boulder/linter/lints/crl"
+	ocsplints "github.com/letsencrypt/boulder/linter/lints/ocsp"
 
 	_ "github.com/letsencrypt/boulder/linter/lints/all"
 	_ "github.com/letsencrypt/boulder/linter/lints/intermediate"
@@ -33,7 +40,50 @@ func Check(tbs *x509.Certificate, subjectPubKey crypto.PublicKey, realIssuer *x5
 	if err != nil {
 		return err
 	}
-	return linter.Check(tbs, subjectPubKey)
+	_, err = linter.Check(tbs, subjectPubKey)
+	return err
+}
+
+// CheckCertificate runs the same default set of lints as Check, less those
+// named in skipLints, against an already-signed DER-encoded certificate. It
+// returns an error if any lint fails.
+func CheckCertificate(certDER []byte, skipLints []string) error {
+	reg, err := makeRegistry(nil, skipLints)
+	if err != nil {
+		return err
+	}
+	cert, err := zlintx509.ParseCertificate(certDER)
+	if err != nil {
+		return fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	return ProcessResultSet(zlint.LintCertificateEx(cert, reg))
+}
+
+// Config selects which lints a Linter runs against certificates, and which of
+// their results cause linting to fail. Custom lints are written in Go and
+// registered with zlint's global registry (see linter/lints), under a
+// LintSource of their own which can then be named in Sources.
+type Config struct {
+	// Sources restricts linting to lints from the named sources, for example
+	// "CABF_BR", "RFC5280", "Mozilla", "ChromeCT", or one of the Let's Encrypt
+	// CPS sources declared in linter/lints. If empty, lints from every source
+	// except "CABF_EV" and "ETSI_ESI" are run.
+	Sources []string
+	// IgnoredLints lists the names of lints which are not run.
+	IgnoredLints []string
+	// FailureLevel is the least severe lint result which fails linting: one
+	// of "notice" (the default), "warn", or "error".
+	FailureLevel string `validate:"omitempty,oneof=notice warn error"`
+	// WarningsAsErrors lists the names of lints whose notices and warnings
+	// fail linting regardless of FailureLevel.
+	WarningsAsErrors []string
+}
+
+var failureLevels = map[string]lint.LintStatus{
+	"":       lint.Notice,
+	"notice": lint.Notice,
+	"warn":   lint.Warn,
+	"error":  lint.Error,
 }
 
 // Linter is capable of linting a to-be-signed (TBS) certificate. It does so by
@@ -44,6 +94,26 @@ type Linter struct {
 	issuer   *x509.Certificate
 	signer   crypto.Signer
 	registry lint.Registry
+	// failLevel is the least severe lint result which fails linting, and
+	// strict is the set of lints whose notices and warnings also do.
+	failLevel lint.LintStatus
+	strict    map[string]bool
+	// responders caches the lint counterparts of the issuer's delegated OCSP
+	// responders, since creating them is expensive.
+	responders *lintResponders
+}
+
+// lintResponders maps the DER of real delegated OCSP responder certificates to
+// lint responders, which have the same subject and extensions but are signed by
+// the lint issuer and have a throwaway key of their own.
+type lintResponders struct {
+	sync.Mutex
+	byRaw map[string]*lintResponder
+}
+
+type lintResponder struct {
+	cert   *x509.Certificate
+	signer crypto.Signer
 }
 
 // New constructs a Linter. It uses the provided real certificate and signer
@@ -52,31 +122,80 @@ type Linter struct {
 // to skip to filter the zlint global registry to only those lints which should
 // be run.
 func New(realIssuer *x509.Certificate, realSigner crypto.Signer, skipLints []string) (*Linter, error) {
-	lintSigner, err := makeSigner(realSigner)
+	return NewWithConfig(realIssuer, realSigner, Config{IgnoredLints: skipLints})
+}
+
+// NewWithConfig is like New, but selects lints and interprets their results
+// according to the given Config.
+func NewWithConfig(realIssuer *x509.Certificate, realSigner crypto.Signer, config Config) (*Linter, error) {
+	failLevel, ok := failureLevels[config.FailureLevel]
+	if !ok {
+		return nil, fmt.Errorf("unknown lint failure level %q", config.FailureLevel)
+	}
+	reg, err := makeRegistry(config.Sources, config.IgnoredLints)
 	if err != nil {
 		return nil, err
 	}
-	lintIssuer, err := makeIssuer(realIssuer, lintSigner)
+	strict := make(map[string]bool, len(config.WarningsAsErrors))
+	for _, name := range config.WarningsAsErrors {
+		if reg.ByName(name) == nil {
+			return nil, fmt.Errorf("lint %q in WarningsAsErrors is not run", name)
+		}
+		strict[name] = true
+	}
+	lintSigner, err := makeSigner(realSigner)
 	if err != nil {
 		return nil, err
 	}
-	reg, err := makeRegistry(skipLints)
+	lintIssuer, err := makeIssuer(realIssuer, lintSigner)
 	if err != nil {
 		return nil, err
 	}
-	return &Linter{lintIssuer, lintSigner, reg}, nil
+	return &Linter{
+		issuer:     lintIssuer,
+		signer:     lintSigner,
+		registry:   reg,
+		failLevel:  failLevel,
+		strict:     strict,
+		responders: &lintResponders{byRaw: make(map[string]*lintResponder)},
+	}, nil
 }
 
 // Check signs the given TBS certificate using the Linter's fake issuer cert and
 // private key, then runs the resulting certificate through all non-filtered
-// lints. It returns an error if any lint fails.
-func (l Linter) Check(tbs *x509.Certificate, subjectPubKey crypto.PublicKey) error {
-	cert, err := makeLintCert(tbs, subjectPubKey, l.issuer, l.signer)
+// lints. It returns an error if any lint fails. On success, it also returns the
+// DER encoding of the lint certificate. Because the fake issuer has the same
+// subject and key type as the real one, the lint certificate's TBSCertificate
+// is byte-for-byte identical to that of the certificate the real issuer would
+// produce from the same template.
+func (l Linter) Check(tbs *x509.Certificate, subjectPubKey crypto.PublicKey) ([]byte, error) {
+	lintCertBytes, cert, err := makeLintCert(tbs, subjectPubKey, l.issuer, l.signer)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	lintRes := zlint.LintCertificateEx(cert, l.registry)
-	return ProcessResultSet(lintRes)
+	err = l.processResults(lintRes)
+	if err != nil {
+		return nil, err
+	}
+	return lintCertBytes, nil
+}
+
+// processResults is like ProcessResultSet, but only fails for results at or
+// above the Linter's failure level, or for any non-passing result from its
+// strict lints.
+func (l Linter) processResults(lintRes *zlint.ResultSet) error {
+	var failedLints []string
+	for lintName, result := range lintRes.Results {
+		if result.Status >= l.failLevel || (result.Status > lint.Pass && l.strict[lintName]) {
+			failedLints = append(failedLints, fmt.Sprintf("%s (%s)", lintName, result.Details))
+		}
+	}
+	if len(failedLints) > 0 {
+		sort.Strings(failedLints)
+		return fmt.Errorf("failed lints: %s", strings.Join(failedLints, ", "))
+	}
+	return nil
 }
 
 // CheckCRL signs the given RevocationList template using the Linter's fake
@@ -91,10 +210,67 @@ func (l Linter) CheckCRL(tbs *crl_x509.RevocationList) error {
 	return ProcessResultSet(lintRes)
 }
 
+// CheckOCSP signs the given OCSP response template as the given responder,
+// then runs the resulting response through our suite of OCSP checks. It returns
+// an error if any check fails. If responder is nil, the response is signed by
+// the Linter's fake issuer cert and private key, as the real issuer would sign
+// it. Otherwise responder is the real issuer's delegated OCSP responder, and
+// the response is signed by a fake responder with the same subject and
+// extensions, which the fake issuer has issued. If the template embeds the
+// responder's certificate, the fake responder's is embedded instead.
+func (l Linter) CheckOCSP(tbs ocsp.Response, responder *x509.Certificate) error {
+	responderCert := l.issuer
+	signer := l.signer
+	if responder != nil {
+		r, err := l.lintResponder(responder)
+		if err != nil {
+			return err
+		}
+		responderCert = r.cert
+		signer = r.signer
+	}
+	if tbs.Certificate != nil {
+		if responder == nil || !bytes.Equal(tbs.Certificate.Raw, responder.Raw) {
+			return errors.New("OCSP response template embeds a certificate other than its responder's")
+		}
+		tbs.Certificate = responderCert
+	}
+	resp, err := makeLintOCSP(tbs, l.issuer, responderCert, signer)
+	if err != nil {
+		return err
+	}
+	lintRes := ocsplints.LintOCSP(resp, l.issuer)
+	return ProcessResultSet(lintRes)
+}
+
+// lintResponder returns the lint counterpart of a real delegated OCSP
+// responder, creating it if necessary.
+func (l Linter) lintResponder(realResponder *x509.Certificate) (*lintResponder, error) {
+	if l.responders == nil {
+		return makeLintResponder(realResponder, l.issuer, l.signer)
+	}
+	l.responders.Lock()
+	defer l.responders.Unlock()
+	r, ok := l.responders.byRaw[string(realResponder.Raw)]
+	if ok {
+		return r, nil
+	}
+	r, err := makeLintResponder(realResponder, l.issuer, l.signer)
+	if err != nil {
+		return nil, err
+	}
+	l.responders.byRaw[string(realResponder.Raw)] = r
+	return r, nil
+}
+
 func makeSigner(realSigner crypto.Signer) (crypto.Signer, error) {
+	return makeSignerForKey(realSigner.Public())
+}
+
+func makeSignerForKey(realKey crypto.PublicKey) (crypto.Signer, error) {
 	var lintSigner crypto.Signer
 	var err error
-	switch k := realSigner.Public().(type) {
+	switch k := realKey.(type) {
 	case *rsa.PublicKey:
 		lintSigner, err = rsa.GenerateKey(rand.Reader, k.Size()*8)
 		if err != nil {
@@ -143,12 +319,15 @@ func makeIssuer(realIssuer *x509.Certificate, lintSigner crypto.Signer) (*x509.C
 		PermittedIPRanges:           realIssuer.PermittedIPRanges,
 		PermittedURIDomains:         realIssuer.PermittedURIDomains,
 		PolicyIdentifiers:           realIssuer.PolicyIdentifiers,
-		SerialNumber:                realIssuer.SerialNumber,
-		SignatureAlgorithm:          realIssuer.SignatureAlgorithm,
-		Subject:                     realIssuer.Subject,
-		SubjectKeyId:                realIssuer.SubjectKeyId,
-		URIs:                        realIssuer.URIs,
-		UnknownExtKeyUsage:          realIssuer.UnknownExtKeyUsage,
+		// Carry over the exact encoding of the real issuer's subject, so that
+		// the issuer field of lint certificates matches that of real ones.
+		RawSubject:         realIssuer.RawSubject,
+		SerialNumber:       realIssuer.SerialNumber,
+		SignatureAlgorithm: realIssuer.SignatureAlgorithm,
+		Subject:            realIssuer.Subject,
+		SubjectKeyId:       realIssuer.SubjectKeyId,
+		URIs:               realIssuer.URIs,
+		UnknownExtKeyUsage: realIssuer.UnknownExtKeyUsage,
 	}
 	lintIssuerBytes, err := x509.CreateCertificate(rand.Reader, lintIssuerTBS, lintIssuerTBS, lintSigner.Public(), lintSigner)
 	if err != nil {
@@ -161,8 +340,8 @@ func makeIssuer(realIssuer *x509.Certificate, lintSigner crypto.Signer) (*x509.C
 	return lintIssuer, nil
 }
 
-func makeRegistry(skipLints []string) (lint.Registry, error) {
-	reg, err := lint.GlobalRegistry().Filter(lint.FilterOptions{
+func makeRegistry(sources []string, skipLints []string) (lint.Registry, error) {
+	opts := lint.FilterOptions{
 		ExcludeNames: skipLints,
 		ExcludeSources: []lint.LintSource{
 			// Excluded because Boulder does not issue EV certs.
@@ -171,23 +350,38 @@ func makeRegistry(skipLints []string) (lint.Registry, error) {
 			// ETSI EN 319 412-5 qcStatements extension.
 			lint.EtsiEsi,
 		},
-	})
+	}
+	if len(sources) > 0 {
+		known := make(map[lint.LintSource]bool)
+		for _, source := range lint.GlobalRegistry().Sources() {
+			known[source] = true
+		}
+		opts.ExcludeSources = nil
+		for _, name := range sources {
+			source := lint.LintSource(name)
+			if !known[source] {
+				return nil, fmt.Errorf("unknown lint source %q", name)
+			}
+			opts.IncludeSources = append(opts.IncludeSources, source)
+		}
+	}
+	reg, err := lint.GlobalRegistry().Filter(opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create lint registry: %w", err)
 	}
 	return reg, nil
 }
 
-func makeLintCert(tbs *x509.Certificate, subjectPubKey crypto.PublicKey, issuer *x509.Certificate, signer crypto.Signer) (*zlintx509.Certificate, error) {
+func makeLintCert(tbs *x509.Certificate, subjectPubKey crypto.PublicKey, issuer *x509.Certificate, signer crypto.Signer) ([]byte, *zlintx509.Certificate, error) {
 	lintCertBytes, err := x509.CreateCertificate(rand.Reader, tbs, issuer, subjectPubKey, signer)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create lint certificate: %w", err)
+		return nil, nil, fmt.Errorf("failed to create lint certificate: %w", err)
 	}
 	lintCert, err := zlintx509.ParseCertificate(lintCertBytes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse lint certificate: %w", err)
+		return nil, nil, fmt.Errorf("failed to parse lint certificate: %w", err)
 	}
-	return lintCert, nil
+	return lintCertBytes, lintCert, nil
 }
 
 func ProcessResultSet(lintRes *zlint.ResultSet) error {
@@ -214,3 +408,62 @@ func makeLintCRL(tbs *crl_x509.RevocationList, issuer *x509.Certificate, signer
 	}
 	return lintCRL, nil
 }
+
+// makeLintResponder creates a lint delegated OCSP responder certificate, issued
+// by the lint issuer, which is as identical to the real responder as we can get
+// without sharing a public key.
+func makeLintResponder(realResponder *x509.Certificate, lintIssuer *x509.Certificate, lintIssuerSigner crypto.Signer) (*lintResponder, error) {
+	lintSigner, err := makeSignerForKey(realResponder.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	var extraExtensions []pkix.Extension
+	for _, ext := range realResponder.Extensions {
+		if ext.Id.Equal(ocsplints.OIDOCSPNoCheck) {
+			extraExtensions = append(extraExtensions, ext)
+		}
+	}
+	tbs := &x509.Certificate{
+		BasicConstraintsValid: realResponder.BasicConstraintsValid,
+		ExtKeyUsage:           realResponder.ExtKeyUsage,
+		ExtraExtensions:       extraExtensions,
+		IsCA:                  realResponder.IsCA,
+		KeyUsage:              realResponder.KeyUsage,
+		NotAfter:              realResponder.NotAfter,
+		NotBefore:             realResponder.NotBefore,
+		RawSubject:            realResponder.RawSubject,
+		SerialNumber:          realResponder.SerialNumber,
+		SignatureAlgorithm:    realResponder.SignatureAlgorithm,
+		Subject:               realResponder.Subject,
+		SubjectKeyId:          realResponder.SubjectKeyId,
+		UnknownExtKeyUsage:    realResponder.UnknownExtKeyUsage,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tbs, lintIssuer, lintSigner.Public(), lintIssuerSigner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lint OCSP responder: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse lint OCSP responder: %w", err)
+	}
+	return &lintResponder{cert: cert, signer: lintSigner}, nil
+}
+
+func makeLintOCSP(tbs ocsp.Response, issuer, responder *x509.Certificate, signer crypto.Signer) (*ocsp.Response, error) {
+	lintRespBytes, err := ocsp.CreateResponse(issuer, responder, tbs, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lint OCSP response: %w", err)
+	}
+	// A response signed by a delegated responder can only be verified if it
+	// embeds the responder's certificate. If it doesn't, leave that for the
+	// lints to report.
+	verifier := issuer
+	if responder != issuer && tbs.Certificate == nil {
+		verifier = nil
+	}
+	lintResp, err := ocsp.ParseResponse(lintRespBytes, verifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse lint OCSP response: %w", err)
+	}
+	return lintResp, nil
+}
@@ -4,10 +4,19 @@ import (
 	"crypto/ecdsa"
 	"crypto/ed25519"
 	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
 	"math/big"
+	"strings"
 	"testing"
+	"time"
 
+	"golang.org/x/crypto/ocsp"
+
+	ocsplints "github.com/letsencrypt/boulder/linter/lints/ocsp"
 	"github.com/letsencrypt/boulder/test"
 )
 
@@ -46,3 +55,214 @@ func TestMakeSigner_Unsupported(t *testing.T) {
 func TestMakeIssuer(t *testing.T) {
 
 }
+
+func TestCheckOCSP(t *testing.T) {
+	realSigner, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating key")
+	realIssuer := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "lint issuer"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	l, err := New(realIssuer, realSigner, nil)
+	test.AssertNotError(t, err, "creating linter")
+
+	now := time.Now().Truncate(time.Hour)
+	tbs := ocsp.Response{
+		Status:       ocsp.Good,
+		SerialNumber: big.NewInt(1234),
+		ThisUpdate:   now,
+		NextUpdate:   now.Add(96*time.Hour - time.Second),
+	}
+	test.AssertNotError(t, l.CheckOCSP(tbs, nil), "good OCSP response failed lints")
+
+	tbs.NextUpdate = now.Add(time.Hour)
+	err = l.CheckOCSP(tbs, nil)
+	test.AssertError(t, err, "short-lived OCSP response passed lints")
+	test.AssertContains(t, err.Error(), "hasAcceptableValidity")
+
+	tbs.NextUpdate = now.Add(96*time.Hour - time.Second)
+	tbs.ExtraExtensions = []pkix.Extension{{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 2}, Value: []byte{0x04, 0x01, 0x00}}}
+	err = l.CheckOCSP(tbs, nil)
+	test.AssertError(t, err, "OCSP response with nonce passed lints")
+	test.AssertContains(t, err.Error(), "hasNoNonce")
+}
+
+func TestCheckOCSPDelegated(t *testing.T) {
+	realSigner, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating key")
+	issuerTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "lint issuer"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	issuerDER, err := x509.CreateCertificate(rand.Reader, issuerTemplate, issuerTemplate, realSigner.Public(), realSigner)
+	test.AssertNotError(t, err, "creating issuer")
+	realIssuer, err := x509.ParseCertificate(issuerDER)
+	test.AssertNotError(t, err, "parsing issuer")
+	l, err := New(realIssuer, realSigner, nil)
+	test.AssertNotError(t, err, "creating linter")
+
+	makeResponder := func(extraExtensions []pkix.Extension) *x509.Certificate {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		test.AssertNotError(t, err, "generating responder key")
+		der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber:    big.NewInt(2),
+			Subject:         pkix.Name{CommonName: "lint issuer ocsp"},
+			NotBefore:       time.Now().Add(-time.Hour),
+			NotAfter:        time.Now().Add(30 * 24 * time.Hour),
+			KeyUsage:        x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+			ExtraExtensions: extraExtensions,
+		}, realIssuer, key.Public(), realSigner)
+		test.AssertNotError(t, err, "creating responder")
+		cert, err := x509.ParseCertificate(der)
+		test.AssertNotError(t, err, "parsing responder")
+		return cert
+	}
+	noCheck := []pkix.Extension{{Id: ocsplints.OIDOCSPNoCheck, Value: []byte{0x05, 0x00}}}
+	responder := makeResponder(noCheck)
+
+	now := time.Now().Truncate(time.Hour)
+	tbs := ocsp.Response{
+		Status:       ocsp.Good,
+		SerialNumber: big.NewInt(1234),
+		ThisUpdate:   now,
+		NextUpdate:   now.Add(96*time.Hour - time.Second),
+		Certificate:  responder,
+	}
+	test.AssertNotError(t, l.CheckOCSP(tbs, responder), "good delegated OCSP response failed lints")
+
+	// A delegated response which doesn't embed its responder's certificate
+	// names a responder other than the issuer.
+	tbs.Certificate = nil
+	err = l.CheckOCSP(tbs, responder)
+	test.AssertError(t, err, "delegated OCSP response without its responder's certificate passed lints")
+	test.AssertContains(t, err.Error(), "hasByNameResponderID")
+
+	// The embedded certificate must be the responder's.
+	tbs.Certificate = makeResponder(noCheck)
+	err = l.CheckOCSP(tbs, responder)
+	test.AssertError(t, err, "OCSP response embedding another certificate passed lints")
+	tbs.Certificate = responder
+	err = l.CheckOCSP(tbs, nil)
+	test.AssertError(t, err, "issuer-signed OCSP response embedding a certificate passed lints")
+
+	// The responder's certificate is linted too.
+	badResponder := makeResponder(nil)
+	tbs.Certificate = badResponder
+	err = l.CheckOCSP(tbs, badResponder)
+	test.AssertError(t, err, "OCSP response from responder without ocsp-nocheck passed lints")
+	test.AssertContains(t, err.Error(), "hasValidResponderCert")
+
+	tbs.Certificate = responder
+	tbs.NextUpdate = responder.NotAfter.Add(time.Hour)
+	tbs.ThisUpdate = tbs.NextUpdate.Add(-96 * time.Hour)
+	err = l.CheckOCSP(tbs, responder)
+	test.AssertError(t, err, "OCSP response outliving its responder passed lints")
+	test.AssertContains(t, err.Error(), "hasValidResponderCert")
+}
+
+func TestNewWithConfig(t *testing.T) {
+	realSigner, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating key")
+	realIssuer := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "lint issuer"},
+		SubjectKeyId:          []byte{1, 2, 3, 4},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating key")
+	// This certificate has a Common Name and no SCTs, which trigger notices,
+	// and no Subject Key Identifier, which triggers a warning.
+	tbs := &x509.Certificate{
+		SerialNumber:          big.NewInt(1234),
+		Subject:               pkix.Name{CommonName: "example.com"},
+		DNSNames:              []string{"example.com"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(24*time.Hour - time.Second),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		OCSPServer:            []string{"http://ocsp.example.com"},
+		IssuingCertificateURL: []string{"http://issuer.example.com"},
+		PolicyIdentifiers:     []asn1.ObjectIdentifier{{2, 23, 140, 1, 2, 1}},
+	}
+
+	testCases := []struct {
+		name       string
+		config     Config
+		failedLint []string
+	}{
+		{
+			name:       "default",
+			failedLint: []string{"n_subject_common_name_included", "w_ct_sct_policy_count_unsatisfied", "w_ext_subject_key_identifier_missing_sub_cert"},
+		},
+		{
+			name:       "fail on warnings",
+			config:     Config{FailureLevel: "warn"},
+			failedLint: []string{"w_ext_subject_key_identifier_missing_sub_cert"},
+		},
+		{
+			name:   "fail on errors",
+			config: Config{FailureLevel: "error"},
+		},
+		{
+			name: "fail on errors and some warnings",
+			config: Config{
+				FailureLevel:     "error",
+				WarningsAsErrors: []string{"w_ct_sct_policy_count_unsatisfied", "w_ext_subject_key_identifier_missing_sub_cert"},
+			},
+			failedLint: []string{"w_ct_sct_policy_count_unsatisfied", "w_ext_subject_key_identifier_missing_sub_cert"},
+		},
+		{
+			name:       "RFC 5280 only",
+			config:     Config{Sources: []string{"RFC5280"}},
+			failedLint: []string{"w_ext_subject_key_identifier_missing_sub_cert"},
+		},
+		{
+			name:       "ignored lint",
+			config:     Config{IgnoredLints: []string{"n_subject_common_name_included"}},
+			failedLint: []string{"w_ct_sct_policy_count_unsatisfied", "w_ext_subject_key_identifier_missing_sub_cert"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l, err := NewWithConfig(realIssuer, realSigner, tc.config)
+			test.AssertNotError(t, err, "creating linter")
+			_, err = l.Check(tbs, leafKey.Public())
+			if len(tc.failedLint) == 0 {
+				test.AssertNotError(t, err, "linting failed")
+				return
+			}
+			test.AssertError(t, err, "linting succeeded")
+			test.AssertEquals(t, strings.Count(err.Error(), "("), len(tc.failedLint))
+			for _, name := range tc.failedLint {
+				test.AssertContains(t, err.Error(), name)
+			}
+		})
+	}
+
+	_, err = NewWithConfig(realIssuer, realSigner, Config{Sources: []string{"Chrome"}})
+	test.AssertError(t, err, "NewWithConfig accepted an unknown lint source")
+	_, err = NewWithConfig(realIssuer, realSigner, Config{FailureLevel: "fatal"})
+	test.AssertError(t, err, "NewWithConfig accepted an unknown failure level")
+	_, err = NewWithConfig(realIssuer, realSigner, Config{
+		IgnoredLints:     []string{"w_ct_sct_policy_count_unsatisfied"},
+		WarningsAsErrors: []string{"w_ct_sct_policy_count_unsatisfied"},
+	})
+	test.AssertError(t, err, "NewWithConfig accepted an ignored lint in WarningsAsErrors")
+}
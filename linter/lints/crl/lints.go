@@ -5,6 +5,7 @@ import (
 	"encoding/asn1"
 	"errors"
 	"fmt"
+	"math/big"
 	"net/url"
 	"time"
 
@@ -36,9 +37,9 @@ func init() {
 		"noEmptyRevokedCertificatesList": noEmptyRevokedCertificatesList,
 		"hasAKI":                         hasAKI,
 		"hasNumber":                      hasNumber,
-		"isNotDelta":                     isNotDelta,
+		"checkDelta":                     checkDelta,
 		"checkIDP":                       checkIDP,
-		"hasNoFreshest":                  hasNoFreshest,
+		"checkFreshest":                  checkFreshest,
 		"hasNoAIA":                       hasNoAIA,
 		"noZeroReasonCodes":              noZeroReasonCodes,
 		"hasNoCertIssuers":               hasNoCertIssuers,
@@ -206,15 +207,36 @@ func hasNumber(crl *crl_x509.RevocationList) *lint.LintResult {
 	return &lint.LintResult{Status: lint.Pass}
 }
 
-// isNotDelta checks that the CRL is not a Delta CRL. (RFC 5280, Section 5.2.4).
-// There's no requirement against this, but Delta CRLs come with extra
-// requirements we don't want to deal with.
-func isNotDelta(crl *crl_x509.RevocationList) *lint.LintResult {
-	deltaCRLIndicatorOID := asn1.ObjectIdentifier{2, 5, 29, 27} // id-ce-deltaCRLIndicator
-	if getExtWithOID(crl.Extensions, deltaCRLIndicatorOID) != nil {
+// checkDelta checks the Delta CRL Indicator extension, if the CRL is a Delta
+// CRL (RFC 5280, Section 5.2.4):
+// When a conforming CRL issuer generates a delta CRL, the delta CRL MUST
+// include a critical delta CRL indicator extension.
+// The BaseCRLNumber it contains identifies the complete CRL which the delta
+// CRL updates, which must therefore precede it in the shared CRL numbering
+// sequence.
+func checkDelta(crl *crl_x509.RevocationList) *lint.LintResult {
+	ext := getExtWithOID(crl.Extensions, crl_x509.OIDExtensionDeltaCRLIndicator)
+	if ext == nil {
+		return &lint.LintResult{Status: lint.Pass}
+	}
+	if !ext.Critical {
 		return &lint.LintResult{
-			Status:  lint.Notice,
-			Details: "CRL is a Delta CRL",
+			Status:  lint.Error,
+			Details: "Delta CRL Indicator MUST be critical",
+		}
+	}
+	base := new(big.Int)
+	val := cryptobyte.String(ext.Value)
+	if !val.ReadASN1Integer(base) || !val.Empty() {
+		return &lint.LintResult{
+			Status:  lint.Error,
+			Details: "Failed to read Delta CRL Indicator BaseCRLNumber",
+		}
+	}
+	if crl.Number == nil || base.Cmp(crl.Number) >= 0 {
+		return &lint.LintResult{
+			Status:  lint.Error,
+			Details: "Delta CRL's BaseCRLNumber must be less than its CRL Number",
 		}
 	}
 	return &lint.LintResult{Status: lint.Pass}
@@ -341,16 +363,63 @@ func checkIDP(crl *crl_x509.RevocationList) *lint.LintResult {
 	return &lint.LintResult{Status: lint.Pass}
 }
 
-// hasNoFreshest checks that the CRL is does not have a Freshest CRL extension
-// (RFC 5280, Section 5.2.6). There's no requirement against this, but Freshest
-// CRL extensions (and the Delta CRLs they imply) come with extra requirements
-// we don't want to deal with.
-func hasNoFreshest(crl *crl_x509.RevocationList) *lint.LintResult {
-	freshestOID := asn1.ObjectIdentifier{2, 5, 29, 46} // id-ce-freshestCRL
-	if getExtWithOID(crl.Extensions, freshestOID) != nil {
+// checkFreshest checks the Freshest CRL extension, which points complete CRLs
+// at their Delta CRL, if present (RFC 5280, Section 5.2.6):
+// The extension MUST be marked as non-critical by conforming CRL issuers.
+// This extension MUST NOT appear in delta CRLs.
+// Like the IDP, it should contain a single http distributionPointName.
+func checkFreshest(crl *crl_x509.RevocationList) *lint.LintResult {
+	ext := getExtWithOID(crl.Extensions, crl_x509.OIDExtensionFreshestCRL)
+	if ext == nil {
+		return &lint.LintResult{Status: lint.Pass}
+	}
+	if ext.Critical {
 		return &lint.LintResult{
-			Status:  lint.Notice,
-			Details: "CRL has a Freshest CRL url",
+			Status:  lint.Error,
+			Details: "Freshest CRL MUST NOT be critical",
+		}
+	}
+	if crl.IsDelta() {
+		return &lint.LintResult{
+			Status:  lint.Error,
+			Details: "Freshest CRL MUST NOT appear in Delta CRLs",
+		}
+	}
+
+	// Step inside the CRLDistributionPoints sequence, its single
+	// DistributionPoint, and its DistributionPointName and FullName, to
+	// read the singular GeneralName element.
+	val := cryptobyte.String(ext.Value)
+	var dps, dp, dpName, fullName cryptobyte.String
+	if !val.ReadASN1(&dps, cryptobyte_asn1.SEQUENCE) ||
+		!dps.ReadASN1(&dp, cryptobyte_asn1.SEQUENCE) ||
+		!dp.ReadASN1(&dpName, cryptobyte_asn1.Tag(0).ContextSpecific().Constructed()) ||
+		!dpName.ReadASN1(&fullName, cryptobyte_asn1.Tag(0).ContextSpecific().Constructed()) {
+		return &lint.LintResult{
+			Status:  lint.Warn,
+			Details: "Failed to read Freshest CRL distributionPoint fullName",
+		}
+	}
+
+	var uriBytes []byte
+	if !fullName.ReadASN1Bytes(&uriBytes, cryptobyte_asn1.Tag(6).ContextSpecific()) {
+		return &lint.LintResult{
+			Status:  lint.Warn,
+			Details: "Failed to read Freshest CRL URI",
+		}
+	}
+	uri, err := url.Parse(string(uriBytes))
+	if err != nil || uri.Scheme != "http" {
+		return &lint.LintResult{
+			Status:  lint.Error,
+			Details: "Freshest CRL URI MUST use http scheme",
+		}
+	}
+
+	if !fullName.Empty() || !dps.Empty() {
+		return &lint.LintResult{
+			Status:  lint.Warn,
+			Details: "Freshest CRL should contain only one distributionPoint",
 		}
 	}
 	return &lint.LintResult{Status: lint.Pass}
@@ -1,13 +1,18 @@
 package crl
 
 import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
 	"encoding/pem"
+	"math/big"
 	"os"
 	"testing"
 
 	"github.com/letsencrypt/boulder/crl/crl_x509"
 	"github.com/letsencrypt/boulder/test"
 	"github.com/zmap/zlint/v3/lint"
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
 )
 
 func loadPEMCRL(t *testing.T, filename string) *crl_x509.RevocationList {
@@ -96,15 +101,36 @@ func TestHashNumber(t *testing.T) {
 	test.AssertContains(t, res.Details, "MUST NOT be longer than 20 octets")
 }
 
-func TestIsNotDelta(t *testing.T) {
+func TestCheckDelta(t *testing.T) {
 	crl := loadPEMCRL(t, "testdata/good.pem")
-	res := isNotDelta(crl)
+	res := checkDelta(crl)
 	test.AssertEquals(t, res.Status, lint.Pass)
 
 	crl = loadPEMCRL(t, "testdata/delta.pem")
-	res = isNotDelta(crl)
-	test.AssertEquals(t, res.Status, lint.Notice)
-	test.AssertContains(t, res.Details, "Delta")
+	res = checkDelta(crl)
+	test.AssertEquals(t, res.Status, lint.Error)
+	test.AssertContains(t, res.Details, "MUST be critical")
+
+	baseNum, err := asn1.Marshal(big.NewInt(5))
+	test.AssertNotError(t, err, "marshalling base CRL number")
+	crl = &crl_x509.RevocationList{
+		Number: big.NewInt(6),
+		Extensions: []pkix.Extension{
+			{Id: crl_x509.OIDExtensionDeltaCRLIndicator, Critical: true, Value: baseNum},
+		},
+	}
+	res = checkDelta(crl)
+	test.AssertEquals(t, res.Status, lint.Pass)
+
+	crl.Number = big.NewInt(5)
+	res = checkDelta(crl)
+	test.AssertEquals(t, res.Status, lint.Error)
+	test.AssertContains(t, res.Details, "less than its CRL Number")
+
+	crl.Extensions[0].Value = []byte{0x04, 0x00}
+	res = checkDelta(crl)
+	test.AssertEquals(t, res.Status, lint.Error)
+	test.AssertContains(t, res.Details, "Failed to read")
 }
 
 func TestCheckIDP(t *testing.T) {
@@ -138,15 +164,77 @@ func TestCheckIDP(t *testing.T) {
 	test.AssertContains(t, res.Details, "should not contain fields other than")
 }
 
-func TestHasNoFreshest(t *testing.T) {
+func TestCheckFreshest(t *testing.T) {
 	crl := loadPEMCRL(t, "testdata/good.pem")
-	res := hasNoFreshest(crl)
+	res := checkFreshest(crl)
 	test.AssertEquals(t, res.Status, lint.Pass)
 
 	crl = loadPEMCRL(t, "testdata/freshest.pem")
-	res = hasNoFreshest(crl)
-	test.AssertEquals(t, res.Status, lint.Notice)
-	test.AssertContains(t, res.Details, "Freshest")
+	res = checkFreshest(crl)
+	test.AssertEquals(t, res.Status, lint.Warn)
+	test.AssertContains(t, res.Details, "Failed to read")
+
+	freshest := pkix.Extension{
+		Id:    crl_x509.OIDExtensionFreshestCRL,
+		Value: makeFreshestValue(t, "http://c.boulder.test/66283756913588288/0-delta.crl"),
+	}
+	crl = &crl_x509.RevocationList{Extensions: []pkix.Extension{freshest}}
+	res = checkFreshest(crl)
+	test.AssertEquals(t, res.Status, lint.Pass)
+
+	crl.Extensions[0].Value = makeFreshestValue(t, "ldap://c.boulder.test/0-delta.crl")
+	res = checkFreshest(crl)
+	test.AssertEquals(t, res.Status, lint.Error)
+	test.AssertContains(t, res.Details, "http scheme")
+
+	freshest.Critical = true
+	crl = &crl_x509.RevocationList{Extensions: []pkix.Extension{freshest}}
+	res = checkFreshest(crl)
+	test.AssertEquals(t, res.Status, lint.Error)
+	test.AssertContains(t, res.Details, "MUST NOT be critical")
+
+	baseNum, err := asn1.Marshal(big.NewInt(5))
+	test.AssertNotError(t, err, "marshalling base CRL number")
+	freshest.Critical = false
+	crl = &crl_x509.RevocationList{
+		Extensions: []pkix.Extension{
+			{Id: crl_x509.OIDExtensionDeltaCRLIndicator, Critical: true, Value: baseNum},
+			freshest,
+		},
+	}
+	res = checkFreshest(crl)
+	test.AssertEquals(t, res.Status, lint.Error)
+	test.AssertContains(t, res.Details, "MUST NOT appear in Delta CRLs")
+
+	crl = &crl_x509.RevocationList{
+		Extensions: []pkix.Extension{
+			{Id: crl_x509.OIDExtensionFreshestCRL, Value: []byte{0x30, 0x00}},
+		},
+	}
+	res = checkFreshest(crl)
+	test.AssertEquals(t, res.Status, lint.Warn)
+	test.AssertContains(t, res.Details, "Failed to read")
+}
+
+// makeFreshestValue returns the DER encoding of a CRLDistributionPoints
+// sequence containing a single fullName URI.
+func makeFreshestValue(t *testing.T, uri string) []byte {
+	t.Helper()
+	var b cryptobyte.Builder
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			b.AddASN1(cryptobyte_asn1.Tag(0).ContextSpecific().Constructed(), func(b *cryptobyte.Builder) {
+				b.AddASN1(cryptobyte_asn1.Tag(0).ContextSpecific().Constructed(), func(b *cryptobyte.Builder) {
+					b.AddASN1(cryptobyte_asn1.Tag(6).ContextSpecific(), func(b *cryptobyte.Builder) {
+						b.AddBytes([]byte(uri))
+					})
+				})
+			})
+		})
+	})
+	der, err := b.Bytes()
+	test.AssertNotError(t, err, "building Freshest CRL value")
+	return der
 }
 
 func TestHasNoAIA(t *testing.T) {
@@ -0,0 +1,236 @@
+package ocsp
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"time"
+
+	"github.com/zmap/zlint/v3"
+	"github.com/zmap/zlint/v3/lint"
+	"golang.org/x/crypto/ocsp"
+)
+
+type ocspLint func(resp *ocsp.Response, issuer *x509.Certificate) *lint.LintResult
+
+// registry is the collection of all known OCSP lints. It is populated by this
+// file's init(), and should not be touched by anything else on pain of races.
+var registry map[string]ocspLint
+
+func init() {
+	// NOTE TO DEVS: you MUST add your new lint function to this list or it
+	// WILL NOT be run.
+	registry = map[string]ocspLint{
+		"hasNextUpdate":         hasNextUpdate,
+		"hasAcceptableValidity": hasAcceptableValidity,
+		"hasByNameResponderID":  hasByNameResponderID,
+		"hasValidResponderCert": hasValidResponderCert,
+		"hasNoNonce":            hasNoNonce,
+	}
+}
+
+// oidOCSPNonce is the OID of the OCSP nonce extension, RFC 8954 Section 2.1.
+var oidOCSPNonce = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 2}
+
+// OIDOCSPNoCheck is the OID of the id-pkix-ocsp-nocheck extension, RFC 6960
+// Section 4.2.2.2.1, which delegated OCSP responder certificates carry.
+var OIDOCSPNoCheck = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 5}
+
+// LintOCSP examines the given lint OCSP response, which must have been signed
+// by the given issuer or by a delegated responder whose certificate it embeds,
+// runs it through all of our checks, and returns a list of all failures.
+func LintOCSP(lintResp *ocsp.Response, issuer *x509.Certificate) *zlint.ResultSet {
+	rset := zlint.ResultSet{
+		Version:   0,
+		Timestamp: time.Now().UnixNano(),
+		Results:   make(map[string]*lint.LintResult),
+	}
+
+	for name, callable := range registry {
+		res := callable(lintResp, issuer)
+		switch res.Status {
+		case lint.Notice:
+			rset.NoticesPresent = true
+		case lint.Warn:
+			rset.WarningsPresent = true
+		case lint.Error:
+			rset.ErrorsPresent = true
+		case lint.Fatal:
+			rset.FatalsPresent = true
+		}
+		rset.Results[name] = res
+	}
+
+	return &rset
+}
+
+// hasNextUpdate checks Baseline Requirements, Section 4.9.9:
+// OCSP responses MUST conform to RFC 6960 and/or RFC 5019.
+// RFC 5019 Section 2.2.4 requires that responses include nextUpdate, and
+// without it a response's validity interval (see hasAcceptableValidity) is
+// unbounded.
+func hasNextUpdate(resp *ocsp.Response, _ *x509.Certificate) *lint.LintResult {
+	if resp.NextUpdate.IsZero() {
+		return &lint.LintResult{
+			Status:  lint.Error,
+			Details: "OCSP responses MUST include the nextUpdate field",
+		}
+	}
+	return &lint.LintResult{Status: lint.Pass}
+}
+
+// hasAcceptableValidity checks Baseline Requirements, Section 4.9.10:
+// OCSP responses MUST have a validity interval greater than or equal to eight
+// hours and less than or equal to ten days. Per Section 1.6.1, the validity
+// interval is inclusive of both thisUpdate and nextUpdate, i.e. one second
+// longer than their difference.
+func hasAcceptableValidity(resp *ocsp.Response, _ *x509.Certificate) *lint.LintResult {
+	if resp.NextUpdate.IsZero() {
+		// Reported by hasNextUpdate.
+		return &lint.LintResult{Status: lint.NA}
+	}
+	validity := resp.NextUpdate.Sub(resp.ThisUpdate) + time.Second
+	if validity <= time.Second {
+		return &lint.LintResult{
+			Status:  lint.Error,
+			Details: "OCSP response has NextUpdate at or before ThisUpdate",
+		}
+	} else if validity < 8*time.Hour {
+		return &lint.LintResult{
+			Status:  lint.Error,
+			Details: "OCSP response has validity interval less than eight hours",
+		}
+	} else if validity > 10*24*time.Hour {
+		return &lint.LintResult{
+			Status:  lint.Error,
+			Details: "OCSP response has validity interval greater than ten days",
+		}
+	}
+	return &lint.LintResult{Status: lint.Pass}
+}
+
+// hasByNameResponderID checks that the ResponderID (RFC 6960, Section 4.2.1)
+// uses the byName form and names the responder which signed the response: the
+// delegated responder whose certificate is embedded in the response, if there
+// is one, and otherwise the issuer. Responders and clients rely on matching the
+// responder name against that certificate.
+func hasByNameResponderID(resp *ocsp.Response, issuer *x509.Certificate) *lint.LintResult {
+	if len(resp.ResponderKeyHash) != 0 || len(resp.RawResponderName) == 0 {
+		return &lint.LintResult{
+			Status:  lint.Error,
+			Details: "OCSP response must identify its responder byName",
+		}
+	}
+	if resp.Certificate != nil {
+		if !bytes.Equal(resp.RawResponderName, resp.Certificate.RawSubject) {
+			return &lint.LintResult{
+				Status:  lint.Error,
+				Details: "OCSP response's responder name does not match its embedded responder certificate's subject",
+			}
+		}
+	} else if !bytes.Equal(resp.RawResponderName, issuer.RawSubject) {
+		return &lint.LintResult{
+			Status:  lint.Error,
+			Details: "OCSP response's responder name does not match its issuer's subject",
+		}
+	}
+	return &lint.LintResult{Status: lint.Pass}
+}
+
+// hasValidResponderCert checks that a delegated responder certificate embedded
+// in the response meets Baseline Requirements, Section 4.9.9, and RFC 6960,
+// Section 4.2.2.2: it must be issued by the issuer, must not be a CA, must
+// include the id-kp-OCSPSigning extended key usage and the id-pkix-ocsp-nocheck
+// extension, and must be valid for the whole of the response's validity
+// interval.
+func hasValidResponderCert(resp *ocsp.Response, issuer *x509.Certificate) *lint.LintResult {
+	cert := resp.Certificate
+	if cert == nil {
+		return &lint.LintResult{Status: lint.NA}
+	}
+	if err := cert.CheckSignatureFrom(issuer); err != nil {
+		return &lint.LintResult{
+			Status:  lint.Error,
+			Details: "OCSP responder certificate is not issued by the response's issuer",
+		}
+	}
+	if cert.IsCA {
+		return &lint.LintResult{
+			Status:  lint.Error,
+			Details: "OCSP responder certificate must not be a CA",
+		}
+	}
+	hasOCSPSigning := false
+	for _, eku := range cert.ExtKeyUsage {
+		if eku == x509.ExtKeyUsageOCSPSigning {
+			hasOCSPSigning = true
+		}
+	}
+	if !hasOCSPSigning {
+		return &lint.LintResult{
+			Status:  lint.Error,
+			Details: "OCSP responder certificate must include the id-kp-OCSPSigning extended key usage",
+		}
+	}
+	hasNoCheck := false
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(OIDOCSPNoCheck) {
+			hasNoCheck = true
+		}
+	}
+	if !hasNoCheck {
+		return &lint.LintResult{
+			Status:  lint.Error,
+			Details: "OCSP responder certificate must include the id-pkix-ocsp-nocheck extension",
+		}
+	}
+	if cert.NotBefore.After(resp.ThisUpdate) || cert.NotAfter.Before(resp.NextUpdate) {
+		return &lint.LintResult{
+			Status:  lint.Error,
+			Details: "OCSP responder certificate is not valid for the response's whole validity interval",
+		}
+	}
+	return &lint.LintResult{Status: lint.Pass}
+}
+
+// responseData is the ResponseData structure of RFC 6960, Section 4.2.1. The
+// ocsp package parses it, but does not expose its responseExtensions.
+type responseData struct {
+	Raw                asn1.RawContent
+	Version            int `asn1:"optional,default:0,explicit,tag:0"`
+	RawResponderID     asn1.RawValue
+	ProducedAt         time.Time `asn1:"generalized"`
+	Responses          asn1.RawValue
+	ResponseExtensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+// hasNoNonce checks that the response does not carry a nonce extension. Boulder
+// signs responses ahead of time and serves them to any client, so it never
+// echoes a request's nonce (RFC 8954, Section 2.1; RFC 5019, Section 2.2.1).
+// A nonce belongs in the responseExtensions, but is also rejected from the
+// singleExtensions.
+func hasNoNonce(resp *ocsp.Response, _ *x509.Certificate) *lint.LintResult {
+	var extensions []pkix.Extension
+	extensions = append(extensions, resp.Extensions...)
+	if len(resp.TBSResponseData) != 0 {
+		var tbs responseData
+		rest, err := asn1.Unmarshal(resp.TBSResponseData, &tbs)
+		if err != nil || len(rest) != 0 {
+			return &lint.LintResult{
+				Status:  lint.Fatal,
+				Details: "failed to parse OCSP response data",
+			}
+		}
+		extensions = append(extensions, tbs.ResponseExtensions...)
+	}
+	for _, ext := range extensions {
+		if ext.Id.Equal(oidOCSPNonce) {
+			return &lint.LintResult{
+				Status:  lint.Error,
+				Details: "OCSP response must not include a nonce extension",
+			}
+		}
+	}
+	return &lint.LintResult{Status: lint.Pass}
+}
@@ -0,0 +1,228 @@
+package ocsp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/zmap/zlint/v3/lint"
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func goodResponse() (*ocsp.Response, *x509.Certificate) {
+	issuer := &x509.Certificate{RawSubject: []byte("issuer subject")}
+	thisUpdate := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &ocsp.Response{
+		Status:           ocsp.Good,
+		ThisUpdate:       thisUpdate,
+		NextUpdate:       thisUpdate.Add(96*time.Hour - time.Second),
+		RawResponderName: issuer.RawSubject,
+	}, issuer
+}
+
+func TestLintOCSP(t *testing.T) {
+	resp, issuer := goodResponse()
+	res := LintOCSP(resp, issuer)
+	test.Assert(t, !res.ErrorsPresent, "good response failed lints")
+	test.AssertEquals(t, len(res.Results), len(registry))
+
+	resp.NextUpdate = time.Time{}
+	res = LintOCSP(resp, issuer)
+	test.Assert(t, res.ErrorsPresent, "response without nextUpdate passed lints")
+	test.AssertEquals(t, res.Results["hasNextUpdate"].Status, lint.Error)
+}
+
+func TestHasNextUpdate(t *testing.T) {
+	resp, issuer := goodResponse()
+	test.AssertEquals(t, hasNextUpdate(resp, issuer).Status, lint.Pass)
+
+	resp.NextUpdate = time.Time{}
+	res := hasNextUpdate(resp, issuer)
+	test.AssertEquals(t, res.Status, lint.Error)
+	test.AssertContains(t, res.Details, "MUST include the nextUpdate")
+}
+
+func TestHasAcceptableValidity(t *testing.T) {
+	resp, issuer := goodResponse()
+	test.AssertEquals(t, hasAcceptableValidity(resp, issuer).Status, lint.Pass)
+
+	testCases := []struct {
+		name     string
+		validity time.Duration
+		status   lint.LintStatus
+		details  string
+	}{
+		{"eight hours", 8*time.Hour - time.Second, lint.Pass, ""},
+		{"ten days", 10*24*time.Hour - time.Second, lint.Pass, ""},
+		{"under eight hours", 8*time.Hour - 2*time.Second, lint.Error, "less than eight hours"},
+		{"over ten days", 10 * 24 * time.Hour, lint.Error, "greater than ten days"},
+		{"backwards", -time.Hour, lint.Error, "at or before ThisUpdate"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp.NextUpdate = resp.ThisUpdate.Add(tc.validity)
+			res := hasAcceptableValidity(resp, issuer)
+			test.AssertEquals(t, res.Status, tc.status)
+			test.AssertContains(t, res.Details, tc.details)
+		})
+	}
+
+	resp.NextUpdate = time.Time{}
+	test.AssertEquals(t, hasAcceptableValidity(resp, issuer).Status, lint.NA)
+}
+
+func TestHasByNameResponderID(t *testing.T) {
+	resp, issuer := goodResponse()
+	test.AssertEquals(t, hasByNameResponderID(resp, issuer).Status, lint.Pass)
+
+	resp.RawResponderName = []byte("someone else")
+	res := hasByNameResponderID(resp, issuer)
+	test.AssertEquals(t, res.Status, lint.Error)
+	test.AssertContains(t, res.Details, "does not match")
+
+	resp.RawResponderName = nil
+	resp.ResponderKeyHash = make([]byte, 20)
+	res = hasByNameResponderID(resp, issuer)
+	test.AssertEquals(t, res.Status, lint.Error)
+	test.AssertContains(t, res.Details, "byName")
+}
+
+func TestHasNoNonce(t *testing.T) {
+	resp, issuer := goodResponse()
+	test.AssertEquals(t, hasNoNonce(resp, issuer).Status, lint.Pass)
+
+	resp.Extensions = []pkix.Extension{{Id: oidOCSPNonce, Value: []byte{0x04, 0x01, 0x00}}}
+	res := hasNoNonce(resp, issuer)
+	test.AssertEquals(t, res.Status, lint.Error)
+	test.AssertContains(t, res.Details, "nonce")
+}
+
+func TestHasNoNonceResponseExtensions(t *testing.T) {
+	resp, issuer := goodResponse()
+	nonce := pkix.Extension{Id: oidOCSPNonce, Value: []byte{0x04, 0x01, 0x00}}
+
+	// The ocsp package can't create responses with responseExtensions, so
+	// marshal the ResponseData by hand.
+	tbs := responseData{
+		RawResponderID: asn1.RawValue{Class: 2, Tag: 1, IsCompound: true, Bytes: issuer.RawSubject},
+		ProducedAt:     resp.ThisUpdate,
+		Responses:      asn1.RawValue{Tag: asn1.TagSequence, IsCompound: true},
+	}
+	der, err := asn1.Marshal(tbs)
+	test.AssertNotError(t, err, "marshalling response data")
+	resp.TBSResponseData = der
+	test.AssertEquals(t, hasNoNonce(resp, issuer).Status, lint.Pass)
+
+	tbs.ResponseExtensions = []pkix.Extension{nonce}
+	der, err = asn1.Marshal(tbs)
+	test.AssertNotError(t, err, "marshalling response data")
+	resp.TBSResponseData = der
+	res := hasNoNonce(resp, issuer)
+	test.AssertEquals(t, res.Status, lint.Error)
+	test.AssertContains(t, res.Details, "nonce")
+
+	resp.TBSResponseData = []byte("not response data")
+	test.AssertEquals(t, hasNoNonce(resp, issuer).Status, lint.Fatal)
+}
+
+// makeResponderCert returns an issuer and a delegated OCSP responder
+// certificate which it issued, after applying modify to the responder's
+// template.
+func makeResponderCert(t *testing.T, modify func(*x509.Certificate)) (*x509.Certificate, *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating key")
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	issuerTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "issuer"},
+		NotBefore:             now.Add(-24 * time.Hour),
+		NotAfter:              now.Add(365 * 24 * time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, issuerTemplate, issuerTemplate, key.Public(), key)
+	test.AssertNotError(t, err, "creating issuer")
+	issuer, err := x509.ParseCertificate(der)
+	test.AssertNotError(t, err, "parsing issuer")
+
+	responderTemplate := &x509.Certificate{
+		SerialNumber:    big.NewInt(2),
+		Subject:         pkix.Name{CommonName: "issuer ocsp"},
+		NotBefore:       now.Add(-24 * time.Hour),
+		NotAfter:        now.Add(30 * 24 * time.Hour),
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+		ExtraExtensions: []pkix.Extension{{Id: OIDOCSPNoCheck, Value: []byte{0x05, 0x00}}},
+	}
+	if modify != nil {
+		modify(responderTemplate)
+	}
+	der, err = x509.CreateCertificate(rand.Reader, responderTemplate, issuer, key.Public(), key)
+	test.AssertNotError(t, err, "creating responder")
+	responder, err := x509.ParseCertificate(der)
+	test.AssertNotError(t, err, "parsing responder")
+	return issuer, responder
+}
+
+func TestHasByNameResponderIDDelegated(t *testing.T) {
+	resp, _ := goodResponse()
+	issuer, responder := makeResponderCert(t, nil)
+
+	// Without the responder's certificate, the responder must be the issuer.
+	resp.RawResponderName = responder.RawSubject
+	res := hasByNameResponderID(resp, issuer)
+	test.AssertEquals(t, res.Status, lint.Error)
+	test.AssertContains(t, res.Details, "issuer's subject")
+
+	resp.Certificate = responder
+	test.AssertEquals(t, hasByNameResponderID(resp, issuer).Status, lint.Pass)
+
+	resp.RawResponderName = issuer.RawSubject
+	res = hasByNameResponderID(resp, issuer)
+	test.AssertEquals(t, res.Status, lint.Error)
+	test.AssertContains(t, res.Details, "embedded responder certificate")
+}
+
+func TestHasValidResponderCert(t *testing.T) {
+	resp, _ := goodResponse()
+	issuer, responder := makeResponderCert(t, nil)
+	test.AssertEquals(t, hasValidResponderCert(resp, issuer).Status, lint.NA)
+
+	resp.Certificate = responder
+	test.AssertEquals(t, hasValidResponderCert(resp, issuer).Status, lint.Pass)
+
+	otherIssuer, _ := makeResponderCert(t, nil)
+	res := hasValidResponderCert(resp, otherIssuer)
+	test.AssertEquals(t, res.Status, lint.Error)
+	test.AssertContains(t, res.Details, "not issued by")
+
+	testCases := []struct {
+		name    string
+		modify  func(*x509.Certificate)
+		details string
+	}{
+		{"CA", func(c *x509.Certificate) { c.BasicConstraintsValid, c.IsCA = true, true }, "must not be a CA"},
+		{"no OCSPSigning", func(c *x509.Certificate) { c.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth} }, "id-kp-OCSPSigning"},
+		{"no ocsp-nocheck", func(c *x509.Certificate) { c.ExtraExtensions = nil }, "id-pkix-ocsp-nocheck"},
+		{"expires too soon", func(c *x509.Certificate) { c.NotAfter = resp.NextUpdate.Add(-time.Hour) }, "whole validity interval"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			issuer, responder := makeResponderCert(t, tc.modify)
+			resp.Certificate = responder
+			res := hasValidResponderCert(resp, issuer)
+			test.AssertEquals(t, res.Status, lint.Error)
+			test.AssertContains(t, res.Details, tc.details)
+		})
+	}
+}
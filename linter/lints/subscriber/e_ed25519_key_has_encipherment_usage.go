@@ -0,0 +1,56 @@
+package subscriber
+
+import (
+	"time"
+
+	"github.com/zmap/zcrypto/encoding/asn1"
+	"github.com/zmap/zcrypto/x509"
+	"github.com/zmap/zlint/v3/lint"
+	"github.com/zmap/zlint/v3/util"
+
+	"github.com/letsencrypt/boulder/linter/lints"
+)
+
+type ed25519KeyHasEnciphermentUsage struct{}
+
+func init() {
+	lint.RegisterLint(&lint.Lint{
+		Name:          "e_ed25519_key_has_encipherment_usage",
+		Description:   "Subscriber Certificates for Ed25519 keys must assert digitalSignature and must not assert any encipherment or key agreement key usage",
+		Citation:      "RFC 8410: 5",
+		Source:        lints.LetsEncryptCPSSubscriber,
+		EffectiveDate: time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC),
+		Lint:          NewEd25519KeyHasEnciphermentUsage,
+	})
+}
+
+func NewEd25519KeyHasEnciphermentUsage() lint.LintInterface {
+	return &ed25519KeyHasEnciphermentUsage{}
+}
+
+var oidKeyEd25519 = asn1.ObjectIdentifier{1, 3, 101, 112}
+
+func (l *ed25519KeyHasEnciphermentUsage) CheckApplies(c *x509.Certificate) bool {
+	if !util.IsSubscriberCert(c) || !util.IsExtInCert(c, util.KeyUsageOID) {
+		return false
+	}
+	return c.PublicKeyAlgorithmOID.Equal(oidKeyEd25519)
+}
+
+func (l *ed25519KeyHasEnciphermentUsage) Execute(c *x509.Certificate) *lint.LintResult {
+	if c.KeyUsage&x509.KeyUsageDigitalSignature == 0 {
+		return &lint.LintResult{
+			Status:  lint.Error,
+			Details: "Ed25519 key does not assert the digitalSignature key usage",
+		}
+	}
+	forbidden := x509.KeyUsageKeyEncipherment | x509.KeyUsageDataEncipherment | x509.KeyUsageKeyAgreement |
+		x509.KeyUsageEncipherOnly | x509.KeyUsageDecipherOnly
+	if c.KeyUsage&forbidden != 0 {
+		return &lint.LintResult{
+			Status:  lint.Error,
+			Details: "Ed25519 key asserts an encipherment or key agreement key usage",
+		}
+	}
+	return &lint.LintResult{Status: lint.Pass}
+}
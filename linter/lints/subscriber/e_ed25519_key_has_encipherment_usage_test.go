@@ -0,0 +1,80 @@
+package subscriber
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	zx509 "github.com/zmap/zcrypto/x509"
+	"github.com/zmap/zlint/v3/lint"
+
+	"github.com/letsencrypt/boulder/linter/lints"
+	"github.com/letsencrypt/boulder/test"
+)
+
+// subscriberCert returns a subscriber certificate for pub, asserting the given
+// key usage, issued by a throwaway ECDSA CA.
+func subscriberCert(t *testing.T, pub crypto.PublicKey, ku x509.KeyUsage) *zx509.Certificate {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating CA key")
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		DNSNames:              []string{"example.com"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		KeyUsage:              ku,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca, pub, caKey)
+	test.AssertNotError(t, err, "creating subscriber certificate")
+	cert, err := zx509.ParseCertificate(der)
+	test.AssertNotError(t, err, "parsing subscriber certificate")
+	return cert
+}
+
+func TestEd25519KeyHasEnciphermentUsage(t *testing.T) {
+	l := lint.GlobalRegistry().ByName("e_ed25519_key_has_encipherment_usage")
+	test.AssertNotNil(t, l, "lint is not registered")
+	test.AssertEquals(t, l.Source, lints.LetsEncryptCPSSubscriber)
+
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	test.AssertNotError(t, err, "generating Ed25519 key")
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating ECDSA key")
+
+	testCases := []struct {
+		name   string
+		pub    crypto.PublicKey
+		ku     x509.KeyUsage
+		status lint.LintStatus
+	}{
+		{"Ed25519 digitalSignature", edPub, x509.KeyUsageDigitalSignature, lint.Pass},
+		{"Ed25519 keyEncipherment", edPub, x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment, lint.Error},
+		{"Ed25519 without digitalSignature", edPub, x509.KeyUsageContentCommitment, lint.Error},
+		{"ECDSA keyEncipherment", ecKey.Public(), x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment, lint.NA},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cert := subscriberCert(t, tc.pub, tc.ku)
+			res := l.Execute(cert, lint.NewEmptyConfig())
+			test.AssertEquals(t, res.Status, tc.status)
+		})
+	}
+}
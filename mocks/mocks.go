@@ -273,6 +273,16 @@ func (sa *StorageAuthority) GetRevokedCerts(ctx context.Context, _ *sapb.GetRevo
 	return nil, nil
 }
 
+// GetRevokedCertsByShard is a mock
+func (sa *StorageAuthorityReadOnly) GetRevokedCertsByShard(ctx context.Context, _ *sapb.GetRevokedCertsByShardRequest, _ ...grpc.CallOption) (sapb.StorageAuthorityReadOnly_GetRevokedCertsByShardClient, error) {
+	return nil, nil
+}
+
+// GetRevokedCertsByShard is a mock
+func (sa *StorageAuthority) GetRevokedCertsByShard(ctx context.Context, _ *sapb.GetRevokedCertsByShardRequest, _ ...grpc.CallOption) (sapb.StorageAuthority_GetRevokedCertsByShardClient, error) {
+	return nil, nil
+}
+
 // GetMaxExpiration is a mock
 func (sa *StorageAuthorityReadOnly) GetMaxExpiration(_ context.Context, req *emptypb.Empty, _ ...grpc.CallOption) (*timestamppb.Timestamp, error) {
 	return nil, nil
@@ -0,0 +1,66 @@
+package presigner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/letsencrypt/boulder/db"
+	"github.com/letsencrypt/boulder/sa"
+)
+
+// statusLister lists the rows of certificateStatus for certificates which
+// have not yet expired, in order of their IDs.
+type statusLister interface {
+	// startingID returns an ID below that of every row whose notAfter is at
+	// or after the given time.
+	startingID(ctx context.Context, notAfter time.Time) (int64, error)
+	// list returns up to limit rows whose IDs follow afterID and whose
+	// notAfter is at or after the given time.
+	list(ctx context.Context, afterID int64, notAfter time.Time, limit int) ([]*sa.CertStatusMetadata, error)
+}
+
+// dbLister is a statusLister which reads from the database.
+type dbLister struct {
+	dbMap db.MappedExecutor
+}
+
+func (l *dbLister) startingID(ctx context.Context, notAfter time.Time) (int64, error) {
+	// Scanning by primary key is much more efficient than by notAfter, but most
+	// of the table's rows have long since expired, so skip past those first.
+	minID, err := l.dbMap.WithContext(ctx).SelectNullInt(
+		"SELECT MIN(id) FROM certificateStatus WHERE notAfter >= ?",
+		notAfter,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("selecting minimum ID: %w", err)
+	}
+	if !minID.Valid {
+		// There are no unexpired certificates; any ID will do.
+		return 0, nil
+	}
+	return minID.Int64 - 1, nil
+}
+
+func (l *dbLister) list(ctx context.Context, afterID int64, notAfter time.Time, limit int) ([]*sa.CertStatusMetadata, error) {
+	selector, err := db.NewMappedSelector[sa.CertStatusMetadata](l.dbMap)
+	if err != nil {
+		return nil, fmt.Errorf("initializing db map: %w", err)
+	}
+
+	rows, err := selector.Query(ctx, "WHERE id > ? AND notAfter >= ? ORDER BY id LIMIT ?", afterID, notAfter, limit)
+	if err != nil {
+		return nil, fmt.Errorf("reading db: %w", err)
+	}
+	defer rows.Close()
+
+	var statuses []*sa.CertStatusMetadata
+	for rows.Next() {
+		status, err := rows.Get()
+		if err != nil {
+			return nil, fmt.Errorf("reading row: %w", err)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, rows.Err()
+}
@@ -0,0 +1,402 @@
+// Package presigner implements a background service which keeps the OCSP
+// responses cached in Redis fresh, so that the ocsp-responder rarely has to
+// sign a response live while a client waits for it.
+//
+// The presigner repeatedly walks the unexpired rows of certificateStatus. For
+// each certificate it looks up the response cached in Redis, and if there is
+// none, or it is older than RefreshAge, it schedules it for re-signing. As the
+// walk continues, it asks the RA to sign fresh responses for the scheduled
+// certificates, in order of their cached responses' expiry and taking turns
+// between issuers, and stores them in Redis. At most MaxQueued certificates are
+// held awaiting signing at once, and the walk pauses while that many are.
+// RefreshAge should be comfortably less than the ocsp-responder's
+// LiveSigningPeriod, so that responses are replaced before the responder would
+// consider them stale.
+package presigner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/crypto/ocsp"
+	"google.golang.org/grpc"
+
+	capb "github.com/letsencrypt/boulder/ca/proto"
+	"github.com/letsencrypt/boulder/db"
+	berrors "github.com/letsencrypt/boulder/errors"
+	blog "github.com/letsencrypt/boulder/log"
+	rapb "github.com/letsencrypt/boulder/ra/proto"
+	"github.com/letsencrypt/boulder/rocsp"
+	"github.com/letsencrypt/boulder/sa"
+)
+
+// ocspGenerator matches the subset of the RA's gRPC client which we use.
+type ocspGenerator interface {
+	GenerateOCSP(ctx context.Context, in *rapb.GenerateOCSPRequest, opts ...grpc.CallOption) (*capb.OCSPResponse, error)
+}
+
+// rocspClient matches the subset of rocsp.RWClient which we use.
+type rocspClient interface {
+	GetResponse(ctx context.Context, serial string) ([]byte, error)
+	StoreResponse(ctx context.Context, resp *ocsp.Response) error
+}
+
+// staleResponse is a certificate whose cached OCSP response needs replacing.
+// Its expiry is the NextUpdate of the cached response, or the zero time if
+// there is no usable cached response at all.
+type staleResponse struct {
+	serial   string
+	issuerID int64
+	expiry   time.Time
+}
+
+// Presigner walks certificateStatus and re-signs the OCSP responses cached in
+// Redis before they go stale.
+type Presigner struct {
+	statuses statusLister
+	redis    rocspClient
+	ra       ocspGenerator
+
+	// refreshAge is the age past which a cached response is re-signed.
+	refreshAge time.Duration
+	// passInterval is the minimum time between the starts of two passes over
+	// certificateStatus.
+	passInterval time.Duration
+	// batchSize is the number of certificateStatus rows read at once.
+	batchSize int
+	// lookupFrequency and signFrequency limit the rates at which cached
+	// responses are read from Redis and fresh responses are requested from the
+	// RA, respectively.
+	lookupFrequency time.Duration
+	signFrequency   time.Duration
+	// parallelSigns is the maximum number of concurrent GenerateOCSP calls.
+	parallelSigns int
+	// maxQueued is the maximum number of stale responses held awaiting
+	// signing. Once it is reached, the walk over certificateStatus pauses
+	// until responses have been signed.
+	maxQueued int
+
+	responseAges *prometheus.HistogramVec
+	lookups      *prometheus.CounterVec
+	signs        *prometheus.CounterVec
+	passes       *prometheus.CounterVec
+
+	clk clock.Clock
+	log blog.Logger
+}
+
+// New returns a Presigner which reads certificateStatus through dbMap.
+// lookupsPerSecond and signsPerSecond must be positive. If maxQueued is not
+// positive, at most 100,000 stale responses are held awaiting signing.
+func New(
+	dbMap db.MappedExecutor,
+	redis rocspClient,
+	ra ocspGenerator,
+	refreshAge time.Duration,
+	passInterval time.Duration,
+	batchSize int,
+	lookupsPerSecond int,
+	signsPerSecond int,
+	parallelSigns int,
+	maxQueued int,
+	stats prometheus.Registerer,
+	clk clock.Clock,
+	log blog.Logger,
+) (*Presigner, error) {
+	return newPresigner(&dbLister{dbMap}, redis, ra, refreshAge, passInterval, batchSize,
+		lookupsPerSecond, signsPerSecond, parallelSigns, maxQueued, stats, clk, log)
+}
+
+func newPresigner(
+	statuses statusLister,
+	redis rocspClient,
+	ra ocspGenerator,
+	refreshAge time.Duration,
+	passInterval time.Duration,
+	batchSize int,
+	lookupsPerSecond int,
+	signsPerSecond int,
+	parallelSigns int,
+	maxQueued int,
+	stats prometheus.Registerer,
+	clk clock.Clock,
+	log blog.Logger,
+) (*Presigner, error) {
+	if refreshAge <= 0 {
+		return nil, errors.New("refresh age must be positive")
+	}
+	if batchSize <= 0 {
+		return nil, errors.New("batch size must be positive")
+	}
+	if lookupsPerSecond <= 0 || signsPerSecond <= 0 {
+		return nil, errors.New("lookup and signing rates must be positive")
+	}
+	if parallelSigns <= 0 {
+		parallelSigns = 1
+	}
+	if maxQueued <= 0 {
+		maxQueued = 100000
+	}
+
+	// Set up 12-hour-wide buckets, measured in seconds.
+	buckets := make([]float64, 14)
+	for i := range buckets {
+		buckets[i] = 43200 * float64(i)
+	}
+	responseAges := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "ocsp_presigner_response_ages",
+		Help:    "How old the OCSP responses cached in Redis were when the presigner looked them up, by issuer ID",
+		Buckets: buckets,
+	}, []string{"issuer"})
+	stats.MustRegister(responseAges)
+
+	lookups := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ocsp_presigner_lookups",
+		Help: "Count of cached OCSP response lookups by the presigner, by result: fresh, stale, not_found, parse_error or lookup_error",
+	}, []string{"result"})
+	stats.MustRegister(lookups)
+
+	signs := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ocsp_presigner_signs",
+		Help: "Count of OCSP responses signed and stored by the presigner, by issuer ID and result",
+	}, []string{"issuer", "result"})
+	stats.MustRegister(signs)
+
+	passes := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ocsp_presigner_passes",
+		Help: "Count of complete passes over certificateStatus by the presigner, by result",
+	}, []string{"result"})
+	stats.MustRegister(passes)
+
+	return &Presigner{
+		statuses:        statuses,
+		redis:           redis,
+		ra:              ra,
+		refreshAge:      refreshAge,
+		passInterval:    passInterval,
+		batchSize:       batchSize,
+		lookupFrequency: time.Second / time.Duration(lookupsPerSecond),
+		signFrequency:   time.Second / time.Duration(signsPerSecond),
+		parallelSigns:   parallelSigns,
+		maxQueued:       maxQueued,
+		responseAges:    responseAges,
+		lookups:         lookups,
+		signs:           signs,
+		passes:          passes,
+		clk:             clk,
+		log:             log,
+	}, nil
+}
+
+// Run makes passes over certificateStatus until the context is canceled,
+// starting a new pass at most once every passInterval.
+func (p *Presigner) Run(ctx context.Context) {
+	for {
+		start := p.clk.Now()
+		err := p.Pass(ctx)
+		if err != nil {
+			p.passes.WithLabelValues("failed").Inc()
+			p.log.Errf("OCSP presigning pass failed: %s", err)
+		} else {
+			p.passes.WithLabelValues("success").Inc()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.clk.After(p.passInterval - p.clk.Since(start)):
+		}
+	}
+}
+
+// Pass walks every unexpired row of certificateStatus once, in batches,
+// finding the certificates whose cached responses need replacing. Meanwhile it
+// re-signs those found so far, soonest-expiring first and taking turns between
+// issuers, so that the responses closest to expiry are replaced first however
+// far through the walk they were found. At most maxQueued responses are held
+// awaiting signing, and the walk pauses while that many are.
+func (p *Presigner) Pass(ctx context.Context) error {
+	now := p.clk.Now()
+	sched := newScheduler(p.maxQueued)
+	signed := make(chan int, 1)
+	go func() {
+		numSigned := p.signScheduled(ctx, sched)
+		// If signing stopped early, don't leave the walk waiting for room.
+		sched.abort()
+		signed <- numSigned
+	}()
+
+	scanned, err := p.walk(ctx, now, sched)
+	sched.close()
+	numSigned := <-signed
+	if err != nil {
+		return err
+	}
+
+	p.log.Infof("OCSP presigning pass complete: scanned=[%d] signed=[%d] took=[%s]",
+		scanned, numSigned, p.clk.Since(now))
+	return nil
+}
+
+// walk reads the unexpired rows of certificateStatus in batches, adding the
+// certificates whose cached responses need replacing to the schedule, and
+// waiting for room in it when it is full. It returns the number of rows read.
+func (p *Presigner) walk(ctx context.Context, now time.Time, sched *scheduler) (int, error) {
+	afterID, err := p.statuses.startingID(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("getting starting ID: %w", err)
+	}
+
+	var scanned int
+	for {
+		rows, err := p.statuses.list(ctx, afterID, now, p.batchSize)
+		if err != nil {
+			return scanned, fmt.Errorf("listing statuses after ID %d: %w", afterID, err)
+		}
+		if len(rows) == 0 {
+			return scanned, nil
+		}
+		afterID = rows[len(rows)-1].ID
+		scanned += len(rows)
+
+		stale, err := p.findStale(ctx, rows)
+		if err != nil {
+			return scanned, err
+		}
+		if !sched.push(stale...) {
+			return scanned, fmt.Errorf("signing stopped: %w", ctx.Err())
+		}
+	}
+}
+
+// findStale looks up the cached response for each of the given certificates,
+// no faster than lookupFrequency, and returns those whose responses are
+// missing, unparseable or older than refreshAge.
+func (p *Presigner) findStale(ctx context.Context, rows []*sa.CertStatusMetadata) ([]staleResponse, error) {
+	ticker := time.NewTicker(p.lookupFrequency)
+	defer ticker.Stop()
+
+	var stale []staleResponse
+	for _, row := range rows {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+
+		respBytes, err := p.redis.GetResponse(ctx, row.Serial)
+		if err != nil {
+			if errors.Is(err, rocsp.ErrRedisNotFound) {
+				p.lookups.WithLabelValues("not_found").Inc()
+				stale = append(stale, staleResponse{serial: row.Serial, issuerID: row.IssuerID})
+			} else {
+				// Don't pile more load onto an unhealthy Redis by signing a
+				// response we may not be able to store.
+				p.lookups.WithLabelValues("lookup_error").Inc()
+			}
+			continue
+		}
+
+		resp, err := ocsp.ParseResponse(respBytes, nil)
+		if err != nil {
+			p.lookups.WithLabelValues("parse_error").Inc()
+			stale = append(stale, staleResponse{serial: row.Serial, issuerID: row.IssuerID})
+			continue
+		}
+
+		age := p.clk.Since(resp.ThisUpdate)
+		p.responseAges.WithLabelValues(strconv.FormatInt(row.IssuerID, 10)).Observe(age.Seconds())
+		if age < p.refreshAge {
+			p.lookups.WithLabelValues("fresh").Inc()
+			continue
+		}
+		p.lookups.WithLabelValues("stale").Inc()
+		stale = append(stale, staleResponse{serial: row.Serial, issuerID: row.IssuerID, expiry: resp.NextUpdate})
+	}
+	return stale, nil
+}
+
+// signScheduled re-signs and stores the responses in the schedule, in the
+// order it gives them out, until it is closed and empty. It starts no more
+// than one signing per signFrequency and runs at most parallelSigns at once,
+// and returns how many succeeded.
+func (p *Presigner) signScheduled(ctx context.Context, sched *scheduler) int {
+	work := make(chan staleResponse)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var succeeded int
+	for i := 0; i < p.parallelSigns; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for s := range work {
+				if p.signAndStore(ctx, s) {
+					mu.Lock()
+					succeeded++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(p.signFrequency)
+	defer ticker.Stop()
+loop:
+	for {
+		// Wait for the rate limit before choosing a response, so that the
+		// choice takes into account everything found in the meantime.
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-ticker.C:
+		}
+		s, ok := sched.pop()
+		if !ok {
+			break
+		}
+		work <- s
+	}
+	close(work)
+	wg.Wait()
+	return succeeded
+}
+
+// signAndStore asks the RA for a fresh response for the given certificate and
+// stores it in Redis, returning true if both succeeded.
+func (p *Presigner) signAndStore(ctx context.Context, s staleResponse) bool {
+	issuer := strconv.FormatInt(s.issuerID, 10)
+	result, err := p.ra.GenerateOCSP(ctx, &rapb.GenerateOCSPRequest{Serial: s.serial})
+	if err != nil {
+		if errors.Is(err, berrors.NotFound) {
+			// The certificate has expired since we listed it.
+			p.signs.WithLabelValues(issuer, "certificate_not_found").Inc()
+			return false
+		}
+		p.signs.WithLabelValues(issuer, "signing_error").Inc()
+		p.log.Warningf("Presigning OCSP response for serial %s: %s", s.serial, err)
+		return false
+	}
+
+	resp, err := ocsp.ParseResponse(result.Response, nil)
+	if err != nil {
+		p.signs.WithLabelValues(issuer, "parse_error").Inc()
+		p.log.Warningf("Parsing presigned OCSP response for serial %s: %s", s.serial, err)
+		return false
+	}
+
+	err = p.redis.StoreResponse(ctx, resp)
+	if err != nil {
+		p.signs.WithLabelValues(issuer, "store_error").Inc()
+		p.log.Warningf("Storing presigned OCSP response for serial %s: %s", s.serial, err)
+		return false
+	}
+
+	p.signs.WithLabelValues(issuer, "success").Inc()
+	return true
+}
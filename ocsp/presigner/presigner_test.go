@@ -0,0 +1,289 @@
+package presigner
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/crypto/ocsp"
+	"google.golang.org/grpc"
+
+	capb "github.com/letsencrypt/boulder/ca/proto"
+	"github.com/letsencrypt/boulder/core"
+	berrors "github.com/letsencrypt/boulder/errors"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	ocsp_test "github.com/letsencrypt/boulder/ocsp/test"
+	rapb "github.com/letsencrypt/boulder/ra/proto"
+	"github.com/letsencrypt/boulder/rocsp"
+	"github.com/letsencrypt/boulder/sa"
+	"github.com/letsencrypt/boulder/test"
+)
+
+// fakeLister is a statusLister backed by a slice of rows in ID order.
+type fakeLister struct {
+	rows []*sa.CertStatusMetadata
+}
+
+func (f *fakeLister) startingID(_ context.Context, notAfter time.Time) (int64, error) {
+	for _, row := range f.rows {
+		if !row.NotAfter.Before(notAfter) {
+			return row.ID - 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (f *fakeLister) list(_ context.Context, afterID int64, notAfter time.Time, limit int) ([]*sa.CertStatusMetadata, error) {
+	var res []*sa.CertStatusMetadata
+	for _, row := range f.rows {
+		if row.ID > afterID && !row.NotAfter.Before(notAfter) && len(res) < limit {
+			res = append(res, row)
+		}
+	}
+	return res, nil
+}
+
+// fakeRedis is a rocspClient backed by a map of serials to responses.
+type fakeRedis struct {
+	sync.Mutex
+	responses map[string][]byte
+	getErr    error
+}
+
+func (f *fakeRedis) GetResponse(_ context.Context, serial string) ([]byte, error) {
+	f.Lock()
+	defer f.Unlock()
+	if f.getErr != nil {
+		return nil, f.getErr
+	}
+	resp, ok := f.responses[serial]
+	if !ok {
+		return nil, rocsp.ErrRedisNotFound
+	}
+	return resp, nil
+}
+
+func (f *fakeRedis) StoreResponse(_ context.Context, resp *ocsp.Response) error {
+	f.Lock()
+	defer f.Unlock()
+	f.responses[core.SerialToString(resp.SerialNumber)] = resp.Raw
+	return nil
+}
+
+// fakeRA signs a fresh response as of the given clock's time for every serial
+// except those listed as expired, and records the order of its requests.
+type fakeRA struct {
+	sync.Mutex
+	clk       clock.Clock
+	expired   map[string]bool
+	requested []string
+}
+
+func (f *fakeRA) GenerateOCSP(_ context.Context, req *rapb.GenerateOCSPRequest, _ ...grpc.CallOption) (*capb.OCSPResponse, error) {
+	f.Lock()
+	f.requested = append(f.requested, req.Serial)
+	f.Unlock()
+	if f.expired[req.Serial] {
+		return nil, berrors.NotFoundError("certificate is expired")
+	}
+	serial, err := core.StringToSerial(req.Serial)
+	if err != nil {
+		return nil, err
+	}
+	return signedResponse(serial, f.clk.Now())
+}
+
+func signedResponse(serial *big.Int, thisUpdate time.Time) (*capb.OCSPResponse, error) {
+	resp, _, err := ocsp_test.FakeResponse(ocsp.Response{
+		SerialNumber: serial,
+		ThisUpdate:   thisUpdate,
+		NextUpdate:   thisUpdate.Add(96 * time.Hour),
+		Status:       ocsp.Good,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &capb.OCSPResponse{Response: resp.Raw}, nil
+}
+
+func TestSchedule(t *testing.T) {
+	now := time.Now()
+	stale := []staleResponse{
+		{serial: "a1", issuerID: 1, expiry: now.Add(3 * time.Hour)},
+		{serial: "a2", issuerID: 1, expiry: now.Add(time.Hour)},
+		{serial: "a3", issuerID: 1, expiry: now.Add(2 * time.Hour)},
+		{serial: "a4", issuerID: 1},
+		{serial: "b1", issuerID: 2, expiry: now.Add(5 * time.Hour)},
+		{serial: "c1", issuerID: 3, expiry: now.Add(4 * time.Hour)},
+		{serial: "c2", issuerID: 3},
+	}
+
+	sched := newScheduler(10)
+	sched.push(stale...)
+	sched.close()
+	// Issuers take turns, each starting with its missing and soonest-expiring
+	// responses.
+	test.AssertDeepEquals(t, drain(sched), []string{"a4", "b1", "c2", "a2", "c1", "a3", "a1"})
+}
+
+func TestScheduleAcrossPushes(t *testing.T) {
+	now := time.Now()
+	sched := newScheduler(10)
+	sched.push(
+		staleResponse{serial: "a1", issuerID: 1, expiry: now.Add(3 * time.Hour)},
+		staleResponse{serial: "a2", issuerID: 1, expiry: now.Add(2 * time.Hour)},
+		staleResponse{serial: "c1", issuerID: 3, expiry: now.Add(time.Hour)},
+	)
+	s, ok := sched.pop()
+	test.Assert(t, ok, "schedule should not be empty")
+	test.AssertEquals(t, s.serial, "a2")
+
+	// Responses found later in the walk are signed ahead of those found
+	// earlier if they expire sooner, and a newly seen issuer gets its turn.
+	sched.push(
+		staleResponse{serial: "a3", issuerID: 1, expiry: now.Add(time.Hour)},
+		staleResponse{serial: "b1", issuerID: 2, expiry: now.Add(4 * time.Hour)},
+	)
+	sched.close()
+	test.AssertDeepEquals(t, drain(sched), []string{"b1", "c1", "a3", "a1"})
+}
+
+func TestSchedulePopBlocks(t *testing.T) {
+	sched := newScheduler(10)
+	popped := make(chan string)
+	go func() {
+		for {
+			s, ok := sched.pop()
+			if !ok {
+				close(popped)
+				return
+			}
+			popped <- s.serial
+		}
+	}()
+
+	sched.push(staleResponse{serial: "a1", issuerID: 1})
+	test.AssertEquals(t, <-popped, "a1")
+	sched.close()
+	_, ok := <-popped
+	test.Assert(t, !ok, "pop should stop once the schedule is closed and empty")
+}
+
+func TestSchedulePushBlocks(t *testing.T) {
+	sched := newScheduler(1)
+	test.Assert(t, sched.push(staleResponse{serial: "a1", issuerID: 1}), "pushing into empty schedule")
+
+	pushed := make(chan bool)
+	go func() {
+		pushed <- sched.push(staleResponse{serial: "a2", issuerID: 1})
+	}()
+	select {
+	case <-pushed:
+		t.Fatal("push should block while the schedule is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+	s, ok := sched.pop()
+	test.Assert(t, ok, "popping from full schedule")
+	test.AssertEquals(t, s.serial, "a1")
+	test.Assert(t, <-pushed, "push should succeed once there is room")
+
+	go func() {
+		pushed <- sched.push(staleResponse{serial: "a3", issuerID: 1})
+	}()
+	sched.abort()
+	test.Assert(t, !<-pushed, "push should give up once the schedule is aborted")
+}
+
+// drain pops responses from the schedule until it is closed and empty,
+// returning their serials in order.
+func drain(sched *scheduler) []string {
+	var serials []string
+	for {
+		s, ok := sched.pop()
+		if !ok {
+			return serials
+		}
+		serials = append(serials, s.serial)
+	}
+}
+
+func TestPass(t *testing.T) {
+	clk := clock.NewFake()
+	clk.Set(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	now := clk.Now()
+
+	serial := func(n int64) string { return core.SerialToString(big.NewInt(n)) }
+	lister := &fakeLister{rows: []*sa.CertStatusMetadata{
+		// Already expired, so never looked at.
+		{ID: 1, Serial: serial(1), IssuerID: 1, NotAfter: now.Add(-time.Hour)},
+		// Fresh.
+		{ID: 2, Serial: serial(2), IssuerID: 1, NotAfter: now.Add(time.Hour)},
+		// Stale.
+		{ID: 3, Serial: serial(3), IssuerID: 1, NotAfter: now.Add(time.Hour)},
+		// Missing.
+		{ID: 4, Serial: serial(4), IssuerID: 2, NotAfter: now.Add(time.Hour)},
+		// Missing, and the RA considers it expired by the time it's signed.
+		{ID: 5, Serial: serial(5), IssuerID: 2, NotAfter: now.Add(time.Hour)},
+	}}
+
+	redis := &fakeRedis{responses: make(map[string][]byte)}
+	for n, age := range map[int64]time.Duration{2: time.Hour, 3: 50 * time.Hour} {
+		resp, err := signedResponse(big.NewInt(n), now.Add(-age))
+		test.AssertNotError(t, err, "signing cached response")
+		redis.responses[serial(n)] = resp.Response
+	}
+	ra := &fakeRA{clk: clk, expired: map[string]bool{serial(5): true}}
+
+	p, err := newPresigner(lister, redis, ra, 48*time.Hour, time.Hour, 2, 10000, 10000, 2, 1,
+		metrics.NoopRegisterer, clk, blog.NewMock())
+	test.AssertNotError(t, err, "creating presigner")
+
+	err = p.Pass(context.Background())
+	test.AssertNotError(t, err, "running pass")
+
+	test.AssertEquals(t, len(ra.requested), 3)
+	for _, n := range []int64{3, 4} {
+		resp, err := ocsp.ParseResponse(redis.responses[serial(n)], nil)
+		test.AssertNotError(t, err, "parsing stored response")
+		test.AssertEquals(t, resp.ThisUpdate, now)
+	}
+	_, ok := redis.responses[serial(5)]
+	test.Assert(t, !ok, "response for expired certificate should not be stored")
+
+	test.AssertMetricWithLabelsEquals(t, p.lookups, prometheus.Labels{"result": "fresh"}, 1)
+	test.AssertMetricWithLabelsEquals(t, p.lookups, prometheus.Labels{"result": "stale"}, 1)
+	test.AssertMetricWithLabelsEquals(t, p.lookups, prometheus.Labels{"result": "not_found"}, 2)
+	test.AssertMetricWithLabelsEquals(t, p.signs, prometheus.Labels{"issuer": "1", "result": "success"}, 1)
+	test.AssertMetricWithLabelsEquals(t, p.signs, prometheus.Labels{"issuer": "2", "result": "success"}, 1)
+	test.AssertMetricWithLabelsEquals(t, p.signs, prometheus.Labels{"issuer": "2", "result": "certificate_not_found"}, 1)
+
+	// A second pass finds everything fresh.
+	ra.requested = nil
+	err = p.Pass(context.Background())
+	test.AssertNotError(t, err, "running second pass")
+	test.AssertEquals(t, len(ra.requested), 1)
+	test.AssertEquals(t, ra.requested[0], serial(5))
+
+	// Nothing is signed while Redis is unavailable.
+	ra.requested = nil
+	redis.getErr = errors.New("oops")
+	err = p.Pass(context.Background())
+	test.AssertNotError(t, err, "running pass with Redis errors")
+	test.AssertEquals(t, len(ra.requested), 0)
+	test.AssertMetricWithLabelsEquals(t, p.lookups, prometheus.Labels{"result": "lookup_error"}, 4)
+}
+
+func TestNewPresignerValidation(t *testing.T) {
+	_, err := newPresigner(&fakeLister{}, nil, nil, 0, time.Hour, 1, 1, 1, 1, 0,
+		metrics.NoopRegisterer, clock.NewFake(), blog.NewMock())
+	test.AssertError(t, err, "zero refresh age")
+	_, err = newPresigner(&fakeLister{}, nil, nil, time.Hour, time.Hour, 1, 0, 1, 1, 0,
+		metrics.NoopRegisterer, clock.NewFake(), blog.NewMock())
+	test.AssertError(t, err, "zero lookup rate")
+}
@@ -0,0 +1,141 @@
+package presigner
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+)
+
+// expiryQueue is a min-heap of one issuer's stale responses, ordered by the
+// expiry of their cached responses, soonest (or missing) first, and then by
+// the order in which they were found.
+type expiryQueue []queuedResponse
+
+type queuedResponse struct {
+	staleResponse
+	seq int
+}
+
+func (q expiryQueue) Len() int { return len(q) }
+
+func (q expiryQueue) Less(i, j int) bool {
+	if !q[i].expiry.Equal(q[j].expiry) {
+		return q[i].expiry.Before(q[j].expiry)
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q expiryQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *expiryQueue) Push(x any) { *q = append(*q, x.(queuedResponse)) }
+
+func (q *expiryQueue) Pop() any {
+	old := *q
+	last := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return last
+}
+
+// scheduler holds the stale responses found so far in a pass, until they are
+// signed. Whenever a response is taken for signing, the issuers take turns, and
+// each gives up its soonest-expiring response, so that a backlog for one
+// issuer doesn't delay the others and the responses closest to expiry are
+// signed first, however far apart in certificateStatus they were found. It
+// holds at most limit responses, so that a pass over a cold Redis, in which
+// every response is stale, doesn't hold every unexpired certificate in memory;
+// once it is full, responses are only added as others are taken for signing.
+type scheduler struct {
+	sync.Mutex
+	cond *sync.Cond
+
+	queues map[int64]*expiryQueue
+	// issuers is the sorted list of issuer IDs with a queue, and lastIssuer
+	// the issuer which most recently gave up a response.
+	issuers    []int64
+	lastIssuer int64
+	seq        int
+	// size is the number of responses across all queues.
+	size    int
+	limit   int
+	closed  bool
+	aborted bool
+}
+
+func newScheduler(limit int) *scheduler {
+	s := &scheduler{queues: make(map[int64]*expiryQueue), limit: limit}
+	s.cond = sync.NewCond(&s.Mutex)
+	return s
+}
+
+// push adds stale responses to the schedule, blocking while it is full. It
+// returns false if the schedule is aborted before they have all been added.
+func (s *scheduler) push(stale ...staleResponse) bool {
+	s.Lock()
+	defer s.Unlock()
+	for _, r := range stale {
+		for s.size >= s.limit && !s.aborted {
+			s.cond.Wait()
+		}
+		if s.aborted {
+			return false
+		}
+		q, ok := s.queues[r.issuerID]
+		if !ok {
+			q = &expiryQueue{}
+			s.queues[r.issuerID] = q
+			s.issuers = append(s.issuers, r.issuerID)
+			sort.Slice(s.issuers, func(i, j int) bool { return s.issuers[i] < s.issuers[j] })
+		}
+		heap.Push(q, queuedResponse{r, s.seq})
+		s.seq++
+		s.size++
+		s.cond.Broadcast()
+	}
+	return true
+}
+
+// close indicates that no more responses will be pushed.
+func (s *scheduler) close() {
+	s.Lock()
+	defer s.Unlock()
+	s.closed = true
+	s.cond.Broadcast()
+}
+
+// abort indicates that no more responses will be taken for signing, so that
+// pushes waiting for room in the schedule, and any later ones, give up.
+func (s *scheduler) abort() {
+	s.Lock()
+	defer s.Unlock()
+	s.aborted = true
+	s.cond.Broadcast()
+}
+
+// pop returns the next response to sign: the soonest-expiring response of the
+// issuer following the one which last gave up a response. It blocks until a
+// response is available, and returns false once the schedule is closed and
+// empty.
+func (s *scheduler) pop() (staleResponse, bool) {
+	s.Lock()
+	defer s.Unlock()
+	for {
+		// Start with the first issuer after the last one served, wrapping
+		// around to the start of the list.
+		start := sort.Search(len(s.issuers), func(i int) bool { return s.issuers[i] > s.lastIssuer })
+		for i := 0; i < len(s.issuers); i++ {
+			issuer := s.issuers[(start+i)%len(s.issuers)]
+			q := s.queues[issuer]
+			if q.Len() == 0 {
+				continue
+			}
+			s.lastIssuer = issuer
+			s.size--
+			s.cond.Broadcast()
+			return heap.Pop(q).(queuedResponse).staleResponse, true
+		}
+		if s.closed {
+			return staleResponse{}, false
+		}
+		s.cond.Wait()
+	}
+}
@@ -0,0 +1,76 @@
+package responder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/letsencrypt/boulder/core"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/crypto/ocsp"
+)
+
+// archiveSource looks up pre-generated OCSP responses stored as individual
+// DER files in a directory, each named for the serial it covers: for instance
+// "<dir>/03deadbeef...der". Unlike inMemorySource, responses are read on
+// demand, so an archive can hold every response of a retired issuer without
+// being loaded into memory.
+type archiveSource struct {
+	dir     string
+	counter *prometheus.CounterVec
+}
+
+// NewArchiveSource returns an archiveSource which reads responses from the
+// given directory.
+func NewArchiveSource(dir string, stats prometheus.Registerer) (*archiveSource, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("OCSP response archive %q is not a directory", dir)
+	}
+
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ocsp_archive_responses",
+		Help: "Count of OCSP requests/responses by action taken by the archiveSource",
+	}, []string{"result"})
+	stats.MustRegister(counter)
+
+	return &archiveSource{
+		dir:     dir,
+		counter: counter,
+	}, nil
+}
+
+// Response implements the Source interface. It reads the response for the
+// requested serial from the archive, without regard to what issuer the request
+// is asking for.
+func (src *archiveSource) Response(_ context.Context, req *ocsp.Request) (*Response, error) {
+	serialString := core.SerialToString(req.SerialNumber)
+	der, err := os.ReadFile(filepath.Join(src.dir, serialString+".der"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			src.counter.WithLabelValues("not_found").Inc()
+			return nil, ErrNotFound
+		}
+		src.counter.WithLabelValues("read_error").Inc()
+		return nil, fmt.Errorf("reading archived response for %s: %w", serialString, err)
+	}
+
+	resp, err := ocsp.ParseResponse(der, nil)
+	if err != nil {
+		src.counter.WithLabelValues("parse_error").Inc()
+		return nil, fmt.Errorf("parsing archived response for %s: %w", serialString, err)
+	}
+	if resp.SerialNumber.Cmp(req.SerialNumber) != 0 {
+		src.counter.WithLabelValues("serial_mismatch").Inc()
+		return nil, fmt.Errorf("archived response for %s is for serial %s", serialString, core.SerialToString(resp.SerialNumber))
+	}
+
+	src.counter.WithLabelValues("success").Inc()
+	return &Response{Response: resp, Raw: der}, nil
+}
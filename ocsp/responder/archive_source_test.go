@@ -0,0 +1,56 @@
+package responder
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/test"
+	"golang.org/x/crypto/ocsp"
+)
+
+func TestArchiveSource(t *testing.T) {
+	_, err := NewArchiveSource("./testdata/ocsp.resp", metrics.NoopRegisterer)
+	test.AssertError(t, err, "created archive source from a file")
+
+	reqBytes, err := os.ReadFile("./testdata/ocsp.req")
+	test.AssertNotError(t, err, "failed to read OCSP request")
+	req, err := ocsp.ParseRequest(reqBytes)
+	test.AssertNotError(t, err, "failed to parse OCSP request")
+	respBytes, err := os.ReadFile("./testdata/ocsp.resp")
+	test.AssertNotError(t, err, "failed to read OCSP response")
+
+	dir := t.TempDir()
+	src, err := NewArchiveSource(dir, metrics.NoopRegisterer)
+	test.AssertNotError(t, err, "creating archive source")
+
+	_, err = src.Response(context.Background(), req)
+	test.AssertErrorIs(t, err, ErrNotFound)
+	test.AssertMetricWithLabelsEquals(t, src.counter, map[string]string{"result": "not_found"}, 1)
+
+	serial := core.SerialToString(req.SerialNumber)
+	err = os.WriteFile(filepath.Join(dir, serial+".der"), respBytes, 0600)
+	test.AssertNotError(t, err, "writing archived response")
+	resp, err := src.Response(context.Background(), req)
+	test.AssertNotError(t, err, "getting archived response")
+	test.AssertByteEquals(t, resp.Raw, respBytes)
+	test.AssertMetricWithLabelsEquals(t, src.counter, map[string]string{"result": "success"}, 1)
+
+	// A response stored under the wrong serial is not served.
+	other := &ocsp.Request{SerialNumber: big.NewInt(1)}
+	err = os.WriteFile(filepath.Join(dir, core.SerialToString(other.SerialNumber)+".der"), respBytes, 0600)
+	test.AssertNotError(t, err, "writing archived response")
+	_, err = src.Response(context.Background(), other)
+	test.AssertError(t, err, "served archived response for the wrong serial")
+	test.AssertMetricWithLabelsEquals(t, src.counter, map[string]string{"result": "serial_mismatch"}, 1)
+
+	err = os.WriteFile(filepath.Join(dir, serial+".der"), []byte("not a response"), 0600)
+	test.AssertNotError(t, err, "writing archived response")
+	_, err = src.Response(context.Background(), req)
+	test.AssertError(t, err, "served unparseable archived response")
+	test.AssertMetricWithLabelsEquals(t, src.counter, map[string]string{"result": "parse_error"}, 1)
+}
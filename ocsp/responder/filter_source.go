@@ -26,6 +26,7 @@ type filterSource struct {
 	wrapped        Source
 	hashAlgorithm  crypto.Hash
 	issuers        map[issuance.IssuerNameID]responderID
+	issuerCerts    map[issuance.IssuerNameID]*issuance.Certificate
 	serialPrefixes []string
 	counter        *prometheus.CounterVec
 	log            blog.Logger
@@ -41,6 +42,7 @@ func NewFilterSource(issuerCerts []*issuance.Certificate, serialPrefixes []strin
 	}
 
 	issuersByNameId := make(map[issuance.IssuerNameID]responderID)
+	issuerCertsByNameId := make(map[issuance.IssuerNameID]*issuance.Certificate)
 	for _, issuerCert := range issuerCerts {
 		keyHash := issuerCert.KeyHash()
 		nameHash := issuerCert.NameHash()
@@ -49,6 +51,7 @@ func NewFilterSource(issuerCerts []*issuance.Certificate, serialPrefixes []strin
 			nameHash: nameHash[:],
 		}
 		issuersByNameId[issuerCert.NameID()] = rid
+		issuerCertsByNameId[issuerCert.NameID()] = issuerCert
 	}
 
 	counter := prometheus.NewCounterVec(prometheus.CounterOpts{
@@ -61,6 +64,7 @@ func NewFilterSource(issuerCerts []*issuance.Certificate, serialPrefixes []strin
 		wrapped:        wrapped,
 		hashAlgorithm:  crypto.SHA1,
 		issuers:        issuersByNameId,
+		issuerCerts:    issuerCertsByNameId,
 		serialPrefixes: serialPrefixes,
 		counter:        counter,
 		log:            log,
@@ -137,14 +141,17 @@ func (src *filterSource) checkRequest(req *ocsp.Request) (issuance.IssuerNameID,
 }
 
 // checkResponse returns nil if the ocsp response was generated by the same
-// issuer as was identified in the request, or an error otherwise. This filters
-// out, for example, responses which are for a serial that we issued, but from a
-// different issuer than that contained in the request.
+// issuer as was identified in the request, or by one of its delegated
+// responders, or an error otherwise. This filters out, for example, responses
+// which are for a serial that we issued, but from a different issuer than that
+// contained in the request.
 func (src *filterSource) checkResponse(reqIssuerID issuance.IssuerNameID, resp *Response) error {
 	respIssuerID := issuance.GetOCSPIssuerNameID(resp.Response)
 	if reqIssuerID != respIssuerID {
-		// This would be allowed if we used delegated responders, but we don't.
-		return fmt.Errorf("responder name does not match requested issuer name")
+		err := src.checkDelegatedResponder(reqIssuerID, resp)
+		if err != nil {
+			return err
+		}
 	}
 
 	err := src.checkNextUpdate(resp)
@@ -161,3 +168,25 @@ func (src *filterSource) checkResponse(reqIssuerID issuance.IssuerNameID, resp *
 
 	return nil
 }
+
+// checkDelegatedResponder returns nil if the ocsp response was signed by a
+// delegated responder of the issuer identified in the request. The responder's
+// certificate must be embedded in the response, whose signature was checked
+// against it when the response was parsed, and must be a delegated responder
+// certificate issued by that issuer which is valid for as long as the response.
+func (src *filterSource) checkDelegatedResponder(reqIssuerID issuance.IssuerNameID, resp *Response) error {
+	if resp.Certificate == nil {
+		return errors.New("responder name does not match requested issuer name")
+	}
+	if !bytes.Equal(resp.RawResponderName, resp.Certificate.RawSubject) {
+		return errors.New("responder name does not match embedded responder certificate")
+	}
+	err := issuance.VerifyDelegatedResponder(resp.Certificate, src.issuerCerts[reqIssuerID])
+	if err != nil {
+		return fmt.Errorf("invalid delegated responder: %w", err)
+	}
+	if resp.Certificate.NotAfter.Before(resp.NextUpdate) {
+		return errors.New("delegated responder certificate expires before response")
+	}
+	return nil
+}
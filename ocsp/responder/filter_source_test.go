@@ -3,7 +3,13 @@ package responder
 import (
 	"context"
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/hex"
+	"math/big"
 	"os"
 	"testing"
 	"time"
@@ -11,6 +17,7 @@ import (
 	"github.com/jmhodges/clock"
 	"github.com/letsencrypt/boulder/core"
 	"github.com/letsencrypt/boulder/issuance"
+	ocsplints "github.com/letsencrypt/boulder/linter/lints/ocsp"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/test"
@@ -136,3 +143,87 @@ func TestCheckResponse(t *testing.T) {
 	_, err = f.Response(context.Background(), req)
 	test.AssertError(t, err, "expected error")
 }
+
+func TestCheckResponseDelegated(t *testing.T) {
+	now := time.Now()
+
+	// newCA returns a self-signed issuer certificate and its key.
+	newCA := func(name string) (*issuance.Certificate, *ecdsa.PrivateKey) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		test.AssertNotError(t, err, "generating issuer key")
+		template := &x509.Certificate{
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: name},
+			NotBefore:             now.Add(-time.Hour),
+			NotAfter:              now.Add(365 * 24 * time.Hour),
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+		test.AssertNotError(t, err, "creating issuer certificate")
+		cert, err := x509.ParseCertificate(der)
+		test.AssertNotError(t, err, "parsing issuer certificate")
+		issuer, err := issuance.NewCertificate(cert)
+		test.AssertNotError(t, err, "wrapping issuer certificate")
+		return issuer, key
+	}
+	issuer, issuerKey := newCA("issuer")
+	other, otherKey := newCA("other issuer")
+
+	// respond returns a response signed by a delegated responder, valid until
+	// notAfter and issued by the given CA, as if for the given issuer.
+	respond := func(notAfter time.Time, ca *issuance.Certificate, caKey *ecdsa.PrivateKey) *Response {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		test.AssertNotError(t, err, "generating responder key")
+		der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber:    big.NewInt(2),
+			Subject:         pkix.Name{CommonName: "issuer ocsp"},
+			NotBefore:       now.Add(-time.Hour),
+			NotAfter:        notAfter,
+			KeyUsage:        x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+			ExtraExtensions: []pkix.Extension{{Id: ocsplints.OIDOCSPNoCheck, Value: []byte{5, 0}}},
+		}, ca.Certificate, key.Public(), caKey)
+		test.AssertNotError(t, err, "creating responder certificate")
+		responder, err := x509.ParseCertificate(der)
+		test.AssertNotError(t, err, "parsing responder certificate")
+
+		respBytes, err := ocsp.CreateResponse(issuer.Certificate, responder, ocsp.Response{
+			SerialNumber: big.NewInt(3),
+			Status:       ocsp.Good,
+			ThisUpdate:   now,
+			NextUpdate:   now.Add(96 * time.Hour),
+			Certificate:  responder,
+		}, key)
+		test.AssertNotError(t, err, "creating OCSP response")
+		resp, err := ocsp.ParseResponse(respBytes, nil)
+		test.AssertNotError(t, err, "parsing OCSP response")
+		return &Response{resp, respBytes}
+	}
+
+	nameHash := issuer.NameHash()
+	keyHash := issuer.KeyHash()
+	req := &ocsp.Request{
+		HashAlgorithm:  crypto.SHA1,
+		IssuerNameHash: nameHash[:],
+		IssuerKeyHash:  keyHash[:],
+		SerialNumber:   big.NewInt(3),
+	}
+
+	source := &echoSource{respond(now.Add(30*24*time.Hour), issuer, issuerKey)}
+	f, err := NewFilterSource([]*issuance.Certificate{issuer, other}, nil, source, metrics.NoopRegisterer, blog.NewMock(), clock.New())
+	test.AssertNotError(t, err, "errored when creating good filter")
+	_, err = f.Response(context.Background(), req)
+	test.AssertNotError(t, err, "rejected response from delegated responder")
+
+	// A responder issued by a different issuer is not accepted.
+	source.resp = respond(now.Add(30*24*time.Hour), other, otherKey)
+	_, err = f.Response(context.Background(), req)
+	test.AssertError(t, err, "accepted response from another issuer's delegated responder")
+
+	// Nor is one which expires before the response does.
+	source.resp = respond(now.Add(time.Hour), issuer, issuerKey)
+	_, err = f.Response(context.Background(), req)
+	test.AssertError(t, err, "accepted response outliving its delegated responder")
+}
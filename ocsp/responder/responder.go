@@ -43,6 +43,7 @@ import (
 	"math/rand"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/jmhodges/clock"
@@ -76,6 +77,7 @@ type Responder struct {
 	Source        Source
 	timeout       time.Duration
 	responseTypes *prometheus.CounterVec
+	conditionals  *prometheus.CounterVec
 	responseAges  prometheus.Histogram
 	requestSizes  prometheus.Histogram
 	sampleRate    int
@@ -115,10 +117,20 @@ func NewResponder(source Source, timeout time.Duration, stats prometheus.Registe
 	)
 	stats.MustRegister(responseTypes)
 
+	conditionals := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ocsp_conditional_requests",
+			Help: "Number of conditional GET requests for OCSP responses, by the header which was evaluated and whether the response was not_modified or modified",
+		},
+		[]string{"header", "result"},
+	)
+	stats.MustRegister(conditionals)
+
 	return &Responder{
 		Source:        source,
 		timeout:       timeout,
 		responseTypes: responseTypes,
+		conditionals:  conditionals,
 		responseAges:  responseAges,
 		requestSizes:  requestSizes,
 		clk:           clock.New(),
@@ -174,6 +186,10 @@ func (rs Responder) sampledError(format string, a ...interface{}) {
 //	ETag: the SHA256 hash of the response, and
 //	Content-Type: application/ocsp-response.
 //
+// GET requests whose If-None-Match header matches the ETag, or whose
+// If-Modified-Since header is no earlier than response.ThisUpdate, receive a
+// 304 Not Modified with the same headers and no body.
+//
 // Note: The caller must use http.StripPrefix to strip any path components
 // (including '/') on GET requests.
 // Do not use this responder in conjunction with http.NewServeMux, because the
@@ -322,8 +338,8 @@ func (rs Responder) ServeHTTP(response http.ResponseWriter, request *http.Reques
 	}
 
 	// Write OCSP response
-	response.Header().Add("Last-Modified", ocspResponse.ThisUpdate.Format(time.RFC1123))
-	response.Header().Add("Expires", ocspResponse.NextUpdate.Format(time.RFC1123))
+	response.Header().Add("Last-Modified", ocspResponse.ThisUpdate.UTC().Format(http.TimeFormat))
+	response.Header().Add("Expires", ocspResponse.NextUpdate.UTC().Format(http.TimeFormat))
 	now := rs.clk.Now()
 	var maxAge int
 	if now.Before(ocspResponse.NextUpdate) {
@@ -341,7 +357,8 @@ func (rs Responder) ServeHTTP(response http.ResponseWriter, request *http.Reques
 		),
 	)
 	responseHash := sha256.Sum256(ocspResponse.Raw)
-	response.Header().Add("ETag", fmt.Sprintf("\"%X\"", responseHash))
+	etag := fmt.Sprintf("\"%X\"", responseHash)
+	response.Header().Add("ETag", etag)
 
 	serialString := core.SerialToString(ocspResponse.SerialNumber)
 	if len(serialString) > 2 {
@@ -354,14 +371,58 @@ func (rs Responder) ServeHTTP(response http.ResponseWriter, request *http.Reques
 	// RFC 7232 says that a 304 response must contain the above
 	// headers if they would also be sent for a 200 for the same
 	// request, so we have to wait until here to do this
-	if etag := request.Header.Get("If-None-Match"); etag != "" {
-		if etag == fmt.Sprintf("\"%X\"", responseHash) {
-			response.WriteHeader(http.StatusNotModified)
-			return
-		}
+	if request.Method == http.MethodGet && rs.notModified(request, etag, ocspResponse.ThisUpdate) {
+		response.WriteHeader(http.StatusNotModified)
+		return
 	}
 	response.WriteHeader(http.StatusOK)
 	response.Write(ocspResponse.Raw)
 	rs.responseAges.Observe(rs.clk.Now().Sub(ocspResponse.ThisUpdate).Seconds())
 	rs.responseTypes.With(prometheus.Labels{"type": responseTypeToString[ocsp.Success]}).Inc()
 }
+
+// notModified evaluates the conditional headers of a GET request against the
+// ETag and Last-Modified time of the response, following RFC 9110 Section
+// 13.2.2: If-Modified-Since is only considered when If-None-Match is absent.
+// It returns true if the client's cached copy is current, and records the
+// outcome of every conditional request.
+func (rs Responder) notModified(request *http.Request, etag string, lastModified time.Time) bool {
+	record := func(header string, notModified bool) bool {
+		result := "modified"
+		if notModified {
+			result = "not_modified"
+		}
+		rs.conditionals.With(prometheus.Labels{"header": header, "result": result}).Inc()
+		return notModified
+	}
+
+	if inm := request.Header.Values("If-None-Match"); len(inm) != 0 {
+		return record("If-None-Match", etagMatches(strings.Join(inm, ","), etag))
+	}
+
+	if ims := request.Header.Get("If-Modified-Since"); ims != "" {
+		since, err := http.ParseTime(ims)
+		if err != nil {
+			// An invalid date must be ignored.
+			return false
+		}
+		// HTTP dates have a resolution of one second, so a response generated
+		// during the second given by the client must be considered unmodified.
+		return record("If-Modified-Since", !lastModified.Truncate(time.Second).After(since))
+	}
+
+	return false
+}
+
+// etagMatches returns true if the given If-None-Match header value lists the
+// given ETag, or is "*". If-None-Match uses weak comparison, so a "W/" prefix on
+// a listed entity tag is ignored.
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
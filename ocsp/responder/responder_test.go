@@ -32,6 +32,7 @@ package responder
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/hex"
 	"fmt"
 	"net/http"
@@ -225,6 +226,17 @@ func TestCacheHeaders(t *testing.T) {
 			},
 			[]string{"type"},
 		),
+		conditionals: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "ocspConditionals-test",
+			},
+			[]string{"header", "result"},
+		),
+		requestSizes: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Name: "ocspRequestSizes-test",
+			},
+		),
 		responseAges: prometheus.NewHistogram(
 			prometheus.HistogramOpts{
 				Name:    "ocspAges-test",
@@ -249,8 +261,8 @@ func TestCacheHeaders(t *testing.T) {
 		header string
 		value  string
 	}{
-		{"Last-Modified", "Tue, 20 Oct 2015 00:00:00 UTC"},
-		{"Expires", "Sun, 20 Oct 2030 00:00:00 UTC"},
+		{"Last-Modified", "Tue, 20 Oct 2015 00:00:00 GMT"},
+		{"Expires", "Sun, 20 Oct 2030 00:00:00 GMT"},
 		{"Cache-Control", "max-age=471398400, public, no-transform, must-revalidate"},
 		{"Etag", "\"8169FB0843B081A76E9F6F13FD70C8411597BEACF8B182136FFDD19FBD26140A\""},
 	}
@@ -283,6 +295,55 @@ func TestCacheHeaders(t *testing.T) {
 	if rw.Code != http.StatusNotModified {
 		t.Fatalf("Got wrong status code: expected %d, got %d", http.StatusNotModified, rw.Code)
 	}
+
+	etag := "\"8169FB0843B081A76E9F6F13FD70C8411597BEACF8B182136FFDD19FBD26140A\""
+	conditionalCases := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		code    int
+	}{
+		{"weak etag", "GET", map[string]string{"If-None-Match": "W/" + etag}, http.StatusNotModified},
+		{"etag list", "GET", map[string]string{"If-None-Match": "\"abc\", " + etag}, http.StatusNotModified},
+		{"any etag", "GET", map[string]string{"If-None-Match": "*"}, http.StatusNotModified},
+		{"other etag", "GET", map[string]string{"If-None-Match": "\"abc\""}, http.StatusOK},
+		{"modified since", "GET", map[string]string{"If-Modified-Since": "Mon, 19 Oct 2015 23:59:59 GMT"}, http.StatusOK},
+		{"not modified since", "GET", map[string]string{"If-Modified-Since": "Tue, 20 Oct 2015 00:00:00 GMT"}, http.StatusNotModified},
+		{"invalid date", "GET", map[string]string{"If-Modified-Since": "yesterday"}, http.StatusOK},
+		{"etag takes precedence", "GET", map[string]string{
+			"If-None-Match":     "\"abc\"",
+			"If-Modified-Since": "Tue, 20 Oct 2015 00:00:00 GMT",
+		}, http.StatusOK},
+		{"post is unconditional", "POST", map[string]string{"If-None-Match": etag}, http.StatusOK},
+	}
+	reqBytes, err := base64.StdEncoding.DecodeString("MEMwQTA/MD0wOzAJBgUrDgMCGgUABBSwLsMRhyg1dJUwnXWk++D57lvgagQU6aQ/7p6l5vLV13lgPJOmLiSOl6oCAhJN")
+	test.AssertNotError(t, err, "decoding request")
+	for _, tc := range conditionalCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/MEMwQTA/MD0wOzAJBgUrDgMCGgUABBSwLsMRhyg1dJUwnXWk++D57lvgagQU6aQ/7p6l5vLV13lgPJOmLiSOl6oCAhJN", nil)
+			if tc.method == "POST" {
+				req = httptest.NewRequest("POST", "/", bytes.NewReader(reqBytes))
+			} else {
+				// The responder expects any prefix to have been stripped.
+				req.URL.Path = strings.TrimPrefix(req.URL.Path, "/")
+			}
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			rw := httptest.NewRecorder()
+			responder.ServeHTTP(rw, req)
+			test.AssertEquals(t, rw.Code, tc.code)
+			test.AssertEquals(t, rw.Header().Get("ETag"), etag)
+			if tc.code == http.StatusNotModified {
+				test.AssertEquals(t, rw.Body.Len(), 0)
+			}
+		})
+	}
+
+	test.AssertMetricWithLabelsEquals(t, responder.conditionals, prometheus.Labels{"header": "If-None-Match", "result": "not_modified"}, 4)
+	test.AssertMetricWithLabelsEquals(t, responder.conditionals, prometheus.Labels{"header": "If-None-Match", "result": "modified"}, 2)
+	test.AssertMetricWithLabelsEquals(t, responder.conditionals, prometheus.Labels{"header": "If-Modified-Since", "result": "not_modified"}, 1)
+	test.AssertMetricWithLabelsEquals(t, responder.conditionals, prometheus.Labels{"header": "If-Modified-Since", "result": "modified"}, 1)
 }
 
 func TestNewSourceFromFile(t *testing.T) {
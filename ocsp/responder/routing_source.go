@@ -0,0 +1,88 @@
+package responder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/letsencrypt/boulder/issuance"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/crypto/ocsp"
+)
+
+// issuerHashes identifies an issuer as OCSP requests do: by the SHA-1 hashes
+// of its name and of its key.
+type issuerHashes struct {
+	nameHash [20]byte
+	keyHash  [20]byte
+}
+
+type route struct {
+	source Source
+	label  string
+}
+
+// routingSource passes each request on to the Source configured for the issuer
+// identified in the request, so that for instance legacy issuers can be served
+// from a static file while current issuers are served from Redis.
+type routingSource struct {
+	routes   map[issuerHashes]route
+	fallback Source
+	counter  *prometheus.CounterVec
+}
+
+// NewRoutingSource returns a routingSource which serves requests for each of
+// the given issuers from the corresponding Source, and requests for any other
+// issuer from the fallback Source. The fallback may be nil if every issuer has
+// a route. It does not check that responses are from the requested issuer, so
+// it should be wrapped in a filterSource covering every issuer.
+func NewRoutingSource(routes map[*issuance.Certificate]Source, fallback Source, stats prometheus.Registerer) (*routingSource, error) {
+	if len(routes) == 0 && fallback == nil {
+		return nil, errors.New("routing source must have at least one route or a fallback")
+	}
+
+	byHashes := make(map[issuerHashes]route, len(routes))
+	for issuer, source := range routes {
+		if source == nil {
+			return nil, fmt.Errorf("no source for issuer %q", issuer.Subject.CommonName)
+		}
+		byHashes[issuerHashes{issuer.NameHash(), issuer.KeyHash()}] = route{
+			source: source,
+			label:  issuer.Subject.CommonName,
+		}
+	}
+
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ocsp_routed_requests",
+		Help: "Count of OCSP requests by the issuer whose source they were routed to, or \"fallback\"",
+	}, []string{"issuer"})
+	stats.MustRegister(counter)
+
+	return &routingSource{
+		routes:   byHashes,
+		fallback: fallback,
+		counter:  counter,
+	}, nil
+}
+
+// Response implements the Source interface. It looks up the route for the
+// request's issuer hashes, and returns whatever that route's Source does.
+func (src *routingSource) Response(ctx context.Context, req *ocsp.Request) (*Response, error) {
+	var key issuerHashes
+	if len(req.IssuerNameHash) == len(key.nameHash) && len(req.IssuerKeyHash) == len(key.keyHash) {
+		copy(key.nameHash[:], req.IssuerNameHash)
+		copy(key.keyHash[:], req.IssuerKeyHash)
+		r, ok := src.routes[key]
+		if ok {
+			src.counter.WithLabelValues(r.label).Inc()
+			return r.source.Response(ctx, req)
+		}
+	}
+
+	if src.fallback == nil {
+		src.counter.WithLabelValues("unrouted").Inc()
+		return nil, ErrNotFound
+	}
+	src.counter.WithLabelValues("fallback").Inc()
+	return src.fallback.Response(ctx, req)
+}
@@ -0,0 +1,50 @@
+package responder
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/letsencrypt/boulder/issuance"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/test"
+	"golang.org/x/crypto/ocsp"
+)
+
+func TestRoutingSource(t *testing.T) {
+	_, err := NewRoutingSource(nil, nil, metrics.NoopRegisterer)
+	test.AssertError(t, err, "created routing source without routes or fallback")
+
+	issuer, err := issuance.LoadCertificate("./testdata/test-ca.der.pem")
+	test.AssertNotError(t, err, "failed to load issuer cert")
+	_, err = NewRoutingSource(map[*issuance.Certificate]Source{issuer: nil}, nil, metrics.NoopRegisterer)
+	test.AssertError(t, err, "created routing source with a nil route")
+
+	reqBytes, err := os.ReadFile("./testdata/ocsp.req")
+	test.AssertNotError(t, err, "failed to read OCSP request")
+	req, err := ocsp.ParseRequest(reqBytes)
+	test.AssertNotError(t, err, "failed to parse OCSP request")
+
+	routed := &echoSource{&Response{Raw: []byte("routed")}}
+	fallback := &echoSource{&Response{Raw: []byte("fallback")}}
+	src, err := NewRoutingSource(map[*issuance.Certificate]Source{issuer: routed}, fallback, metrics.NoopRegisterer)
+	test.AssertNotError(t, err, "creating routing source")
+
+	resp, err := src.Response(context.Background(), req)
+	test.AssertNotError(t, err, "getting routed response")
+	test.AssertEquals(t, string(resp.Raw), "routed")
+	test.AssertMetricWithLabelsEquals(t, src.counter, map[string]string{"issuer": issuer.Subject.CommonName}, 1)
+
+	// Requests for other issuers go to the fallback, if there is one.
+	req.IssuerKeyHash[0]++
+	resp, err = src.Response(context.Background(), req)
+	test.AssertNotError(t, err, "getting fallback response")
+	test.AssertEquals(t, string(resp.Raw), "fallback")
+	test.AssertMetricWithLabelsEquals(t, src.counter, map[string]string{"issuer": "fallback"}, 1)
+
+	src, err = NewRoutingSource(map[*issuance.Certificate]Source{issuer: routed}, nil, metrics.NoopRegisterer)
+	test.AssertNotError(t, err, "creating routing source")
+	_, err = src.Response(context.Background(), req)
+	test.AssertErrorIs(t, err, ErrNotFound)
+	test.AssertMetricWithLabelsEquals(t, src.counter, map[string]string{"issuer": "unrouted"}, 1)
+}
@@ -1,6 +1,7 @@
 package pkcs11helpers
 
 import (
+	"bytes"
 	"crypto"
 	"crypto/ecdsa"
 	"crypto/elliptic"
@@ -23,6 +24,8 @@ type PKCtx interface {
 	FindObjectsInit(sh pkcs11.SessionHandle, temp []*pkcs11.Attribute) error
 	FindObjects(sh pkcs11.SessionHandle, max int) ([]pkcs11.ObjectHandle, bool, error)
 	FindObjectsFinal(sh pkcs11.SessionHandle) error
+	GetSessionInfo(sh pkcs11.SessionHandle) (pkcs11.SessionInfo, error)
+	GetTokenInfo(slotID uint) (pkcs11.TokenInfo, error)
 }
 
 // Session represents a session with a given PKCS#11 module. It is not safe for
@@ -38,7 +41,9 @@ func Initialize(module string, slot uint, pin string) (*Session, error) {
 		return nil, errors.New("failed to load module")
 	}
 	err := ctx.Initialize()
-	if err != nil {
+	// A module may already have been initialized by an earlier call, when a
+	// single process uses keys in more than one slot.
+	if err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED)) {
 		return nil, fmt.Errorf("couldn't initialize context: %s", err)
 	}
 
@@ -48,7 +53,7 @@ func Initialize(module string, slot uint, pin string) (*Session, error) {
 	}
 
 	err = ctx.Login(session, pkcs11.CKU_USER, pin)
-	if err != nil {
+	if err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)) {
 		return nil, fmt.Errorf("couldn't login: %s", err)
 	}
 
@@ -226,6 +231,28 @@ func (s *Session) GetECDSAPublicKey(object pkcs11.ObjectHandle) (*ecdsa.PublicKe
 	return pubKey, nil
 }
 
+// GetPublicKey retrieves the RSA or ECDSA public key stored in the given
+// public key object.
+func (s *Session) GetPublicKey(object pkcs11.ObjectHandle) (crypto.PublicKey, error) {
+	attrs, err := s.Module.GetAttributeValue(s.Session, object, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, nil),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to retrieve key type: %s", err)
+	}
+	if len(attrs) != 1 || attrs[0].Type != pkcs11.CKA_KEY_TYPE {
+		return nil, errors.New("invalid result from GetAttributeValue")
+	}
+	switch {
+	case bytes.Equal(attrs[0].Value, pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_RSA).Value):
+		return s.GetRSAPublicKey(object)
+	case bytes.Equal(attrs[0].Value, pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_EC).Value):
+		return s.GetECDSAPublicKey(object)
+	default:
+		return nil, fmt.Errorf("unsupported key type %x", attrs[0].Value)
+	}
+}
+
 type keyType int
 
 const (
@@ -298,6 +325,46 @@ func (s *Session) FindObject(tmpl []*pkcs11.Attribute) (pkcs11.ObjectHandle, err
 	return handles[0], nil
 }
 
+// FindObjects looks up the handles of all PKCS#11 objects matching the provided
+// template. An empty template matches every object visible to the session.
+func (s *Session) FindObjects(tmpl []*pkcs11.Attribute) ([]pkcs11.ObjectHandle, error) {
+	err := s.Module.FindObjectsInit(s.Session, tmpl)
+	if err != nil {
+		return nil, err
+	}
+	var handles []pkcs11.ObjectHandle
+	for {
+		batch, _, err := s.Module.FindObjects(s.Session, 100)
+		if err != nil {
+			_ = s.Module.FindObjectsFinal(s.Session)
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		handles = append(handles, batch...)
+	}
+	err = s.Module.FindObjectsFinal(s.Session)
+	if err != nil {
+		return nil, err
+	}
+	return handles, nil
+}
+
+// TokenInfo returns the slot the session was opened on and information about
+// the token in that slot.
+func (s *Session) TokenInfo() (uint, pkcs11.TokenInfo, error) {
+	sessionInfo, err := s.Module.GetSessionInfo(s.Session)
+	if err != nil {
+		return 0, pkcs11.TokenInfo{}, fmt.Errorf("getting session info: %s", err)
+	}
+	tokenInfo, err := s.Module.GetTokenInfo(sessionInfo.SlotID)
+	if err != nil {
+		return 0, pkcs11.TokenInfo{}, fmt.Errorf("getting token info: %s", err)
+	}
+	return sessionInfo.SlotID, tokenInfo, nil
+}
+
 // x509Signer is a convenience wrapper used for converting between the
 // PKCS#11 ECDSA signature format and the RFC 5480 one which is required
 // for X.509 certificates
@@ -386,6 +453,8 @@ type MockCtx struct {
 	FindObjectsInitFunc   func(sh pkcs11.SessionHandle, temp []*pkcs11.Attribute) error
 	FindObjectsFunc       func(sh pkcs11.SessionHandle, max int) ([]pkcs11.ObjectHandle, bool, error)
 	FindObjectsFinalFunc  func(sh pkcs11.SessionHandle) error
+	GetSessionInfoFunc    func(sh pkcs11.SessionHandle) (pkcs11.SessionInfo, error)
+	GetTokenInfoFunc      func(slotID uint) (pkcs11.TokenInfo, error)
 }
 
 func (mc MockCtx) GenerateKeyPair(s pkcs11.SessionHandle, m []*pkcs11.Mechanism, a1 []*pkcs11.Attribute, a2 []*pkcs11.Attribute) (pkcs11.ObjectHandle, pkcs11.ObjectHandle, error) {
@@ -419,3 +488,11 @@ func (mc MockCtx) FindObjects(sh pkcs11.SessionHandle, max int) ([]pkcs11.Object
 func (mc MockCtx) FindObjectsFinal(sh pkcs11.SessionHandle) error {
 	return mc.FindObjectsFinalFunc(sh)
 }
+
+func (mc MockCtx) GetSessionInfo(sh pkcs11.SessionHandle) (pkcs11.SessionInfo, error) {
+	return mc.GetSessionInfoFunc(sh)
+}
+
+func (mc MockCtx) GetTokenInfo(slotID uint) (pkcs11.TokenInfo, error) {
+	return mc.GetTokenInfoFunc(slotID)
+}
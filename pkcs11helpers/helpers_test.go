@@ -418,3 +418,26 @@ func TestGetKeySucceeds(t *testing.T) {
 	_, err := s.NewSigner("label", pubKey)
 	test.AssertNotError(t, err, "newSigner failed when everything worked properly")
 }
+
+func TestTokenInfo(t *testing.T) {
+	ctx := &MockCtx{}
+	s := &Session{ctx, 0}
+
+	ctx.GetSessionInfoFunc = func(pkcs11.SessionHandle) (pkcs11.SessionInfo, error) {
+		return pkcs11.SessionInfo{}, errors.New("broken")
+	}
+	_, _, err := s.TokenInfo()
+	test.AssertError(t, err, "TokenInfo didn't fail on GetSessionInfo error")
+
+	ctx.GetSessionInfoFunc = func(pkcs11.SessionHandle) (pkcs11.SessionInfo, error) {
+		return pkcs11.SessionInfo{SlotID: 7}, nil
+	}
+	ctx.GetTokenInfoFunc = func(slotID uint) (pkcs11.TokenInfo, error) {
+		return pkcs11.TokenInfo{Label: "token", SerialNumber: "1234"}, nil
+	}
+	slot, info, err := s.TokenInfo()
+	test.AssertNotError(t, err, "TokenInfo failed")
+	test.AssertEquals(t, slot, uint(7))
+	test.AssertEquals(t, info.Label, "token")
+	test.AssertEquals(t, info.SerialNumber, "1234")
+}
@@ -0,0 +1,121 @@
+package policy
+
+import (
+	"crypto/x509"
+	"net"
+	"strings"
+
+	berrors "github.com/letsencrypt/boulder/errors"
+)
+
+// NameConstraints holds the DNS and IP address subtrees of an issuer
+// certificate's name constraints extension (RFC 5280, Section 4.2.1.10). The
+// zero value places no constraints on any name.
+type NameConstraints struct {
+	PermittedDNSDomains []string
+	ExcludedDNSDomains  []string
+	PermittedIPRanges   []*net.IPNet
+	ExcludedIPRanges    []*net.IPNet
+}
+
+// NameConstraintsFromCert returns the DNS and IP address name constraints of
+// the given issuer certificate.
+func NameConstraintsFromCert(cert *x509.Certificate) NameConstraints {
+	return NameConstraints{
+		PermittedDNSDomains: cert.PermittedDNSDomains,
+		ExcludedDNSDomains:  cert.ExcludedDNSDomains,
+		PermittedIPRanges:   cert.PermittedIPRanges,
+		ExcludedIPRanges:    cert.ExcludedIPRanges,
+	}
+}
+
+// IsEmpty returns true if nc places no constraints on any name.
+func (nc NameConstraints) IsEmpty() bool {
+	return len(nc.PermittedDNSDomains) == 0 && len(nc.ExcludedDNSDomains) == 0 &&
+		len(nc.PermittedIPRanges) == 0 && len(nc.ExcludedIPRanges) == 0
+}
+
+// Permits returns a RejectedIdentifierError for the first of the given names
+// which falls outside nc's permitted subtrees or inside its excluded subtrees,
+// or nil if all of them are within the constraints. Names which parse as IP
+// addresses are checked against the IP subtrees, all others against the DNS
+// subtrees.
+func (nc NameConstraints) Permits(names []string) error {
+	for _, name := range names {
+		err := nc.permits(name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (nc NameConstraints) permits(name string) error {
+	ip := net.ParseIP(name)
+	if ip != nil {
+		if len(nc.PermittedIPRanges) > 0 && !ipInRanges(ip, nc.PermittedIPRanges) {
+			return berrors.RejectedIdentifierError("%q is outside the issuer's permitted name constraints", name)
+		}
+		if ipInRanges(ip, nc.ExcludedIPRanges) {
+			return berrors.RejectedIdentifierError("%q is within the issuer's excluded name constraints", name)
+		}
+		return nil
+	}
+
+	name = strings.ToLower(name)
+	if len(nc.PermittedDNSDomains) > 0 {
+		permitted := false
+		for _, constraint := range nc.PermittedDNSDomains {
+			if dnsConstraintMatches(name, constraint) {
+				permitted = true
+				break
+			}
+		}
+		if !permitted {
+			return berrors.RejectedIdentifierError("%q is outside the issuer's permitted name constraints", name)
+		}
+	}
+	for _, constraint := range nc.ExcludedDNSDomains {
+		if dnsConstraintMatches(name, constraint) || wildcardCovers(name, constraint) {
+			return berrors.RejectedIdentifierError("%q is within the issuer's excluded name constraints", name)
+		}
+	}
+	return nil
+}
+
+// dnsConstraintMatches returns true if the lowercase DNS name is within the
+// subtree described by constraint, per RFC 5280, Section 4.2.1.10: a
+// constraint matches itself and any name formed by adding labels to its left,
+// while a constraint with a leading period matches only the latter.
+func dnsConstraintMatches(name, constraint string) bool {
+	constraint = strings.ToLower(constraint)
+	if constraint == "" {
+		return true
+	}
+	if strings.HasPrefix(constraint, ".") {
+		return strings.HasSuffix(name, constraint)
+	}
+	return name == constraint || strings.HasSuffix(name, "."+constraint)
+}
+
+// wildcardCovers returns true if name is a wildcard which would match a name
+// inside the subtree described by constraint. A relying party only compares
+// the literal "*.example.com" against an excluded subtree such as
+// "bad.example.com", but issuing it would still cover the excluded name.
+func wildcardCovers(name, constraint string) bool {
+	if !strings.HasPrefix(name, "*.") {
+		return false
+	}
+	base := strings.TrimPrefix(name, "*")
+	constraint = strings.TrimPrefix(strings.ToLower(constraint), ".")
+	return strings.HasSuffix(constraint, base)
+}
+
+func ipInRanges(ip net.IP, ranges []*net.IPNet) bool {
+	for _, r := range ranges {
+		if r.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package policy
+
+import (
+	"net"
+	"testing"
+
+	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func mustParseCIDR(t *testing.T, s string) *net.IPNet {
+	t.Helper()
+	_, ipNet, err := net.ParseCIDR(s)
+	test.AssertNotError(t, err, "parsing CIDR")
+	return ipNet
+}
+
+func TestNameConstraintsPermits(t *testing.T) {
+	nc := NameConstraints{
+		PermittedDNSDomains: []string{"example.com", ".example.org"},
+		ExcludedDNSDomains:  []string{"bad.example.com"},
+		PermittedIPRanges:   []*net.IPNet{mustParseCIDR(t, "10.0.0.0/8")},
+		ExcludedIPRanges:    []*net.IPNet{mustParseCIDR(t, "10.1.0.0/16")},
+	}
+	test.Assert(t, !nc.IsEmpty(), "constraints should not be empty")
+	test.Assert(t, NameConstraints{}.IsEmpty(), "zero value should be empty")
+
+	testCases := []struct {
+		name    string
+		allowed bool
+	}{
+		{"example.com", true},
+		{"WWW.Example.com", true},
+		{"*.www.example.com", true},
+		{"notexample.com", false},
+		{"example.net", false},
+		// A leading period permits only subdomains.
+		{"example.org", false},
+		{"www.example.org", true},
+		{"bad.example.com", false},
+		{"www.bad.example.com", false},
+		// A wildcard would cover the excluded subtree.
+		{"*.example.com", false},
+		{"10.2.3.4", true},
+		{"10.1.2.3", false},
+		{"192.168.1.1", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := nc.Permits([]string{tc.name})
+			if tc.allowed {
+				test.AssertNotError(t, err, "name should be permitted")
+			} else {
+				test.AssertError(t, err, "name should not be permitted")
+				test.AssertErrorIs(t, err, berrors.RejectedIdentifier)
+			}
+		})
+	}
+
+	err := NameConstraints{}.Permits([]string{"example.net", "*.example.com", "127.0.0.1"})
+	test.AssertNotError(t, err, "empty constraints should permit everything")
+
+	err = nc.Permits([]string{"www.example.com", "example.net"})
+	test.AssertError(t, err, "one unpermitted name should fail the set")
+	test.AssertContains(t, err.Error(), `"example.net"`)
+}
@@ -37,6 +37,11 @@ type AuthorityImpl struct {
 	enabledChallenges map[core.AcmeChallenge]bool
 	pseudoRNG         *rand.Rand
 	rngMu             sync.Mutex
+
+	// issuerConstraints holds the name constraints of every issuer which may
+	// be used for issuance. If it is empty, or any issuer is unconstrained, no
+	// identifiers are rejected because of name constraints.
+	issuerConstraints []NameConstraints
 }
 
 // New constructs a Policy Authority.
@@ -52,6 +57,14 @@ func New(challengeTypes map[core.AcmeChallenge]bool, log blog.Logger) (*Authorit
 	return &pa, nil
 }
 
+// SetIssuerNameConstraints configures the PA with the name constraints of
+// every issuer which may be used for issuance, so that identifiers which no
+// issuer could include can be rejected before validation. It must be called
+// before the PA is used.
+func (pa *AuthorityImpl) SetIssuerNameConstraints(constraints []NameConstraints) {
+	pa.issuerConstraints = constraints
+}
+
 // blockedNamesPolicy is a struct holding lists of blocked domain names. One for
 // exact blocks and one for blocks including all subdomains.
 type blockedNamesPolicy struct {
@@ -393,12 +406,18 @@ func (pa *AuthorityImpl) willingToIssue(id identifier.ACMEIdentifier) error {
 //     blocklist entry for "foo.example.com" should prevent issuance for
 //     "*.example.com")
 //
+// Finally, if every issuer is name constrained, it checks that a single
+// issuer's constraints permit all of the identifiers.
+//
 // If any of the identifiers are not valid then an error with suberrors specific
 // to the rejected identifiers will be returned.
 func (pa *AuthorityImpl) WillingToIssueWildcards(idents []identifier.ACMEIdentifier) error {
 	var subErrors []berrors.SubBoulderError
 	for _, ident := range idents {
 		err := pa.willingToIssueWildcard(ident)
+		if err == nil {
+			err = pa.checkIssuerConstraints(ident)
+		}
 		if err != nil {
 			var bErr *berrors.BoulderError
 			if errors.As(err, &bErr) {
@@ -437,7 +456,57 @@ func (pa *AuthorityImpl) WillingToIssueWildcards(idents []identifier.ACMEIdentif
 			Detail: detail,
 		}).WithSubErrors(subErrors)
 	}
-	return nil
+	return pa.checkSingleIssuerPermits(idents)
+}
+
+// unconstrained returns true if any issuer is free of name constraints, or if
+// no issuer constraints are configured.
+func (pa *AuthorityImpl) unconstrained() bool {
+	if len(pa.issuerConstraints) == 0 {
+		return true
+	}
+	for _, nc := range pa.issuerConstraints {
+		if nc.IsEmpty() {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIssuerConstraints returns an error if no issuer's name constraints
+// permit the given identifier.
+func (pa *AuthorityImpl) checkIssuerConstraints(ident identifier.ACMEIdentifier) error {
+	if pa.unconstrained() {
+		return nil
+	}
+	var err error
+	for _, nc := range pa.issuerConstraints {
+		err = nc.Permits([]string{ident.Value})
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// checkSingleIssuerPermits returns an error if each of the identifiers is
+// permitted by some issuer's name constraints, but no single issuer permits
+// all of them, since every certificate comes from exactly one issuer.
+func (pa *AuthorityImpl) checkSingleIssuerPermits(idents []identifier.ACMEIdentifier) error {
+	if pa.unconstrained() || len(idents) < 2 {
+		return nil
+	}
+	names := make([]string, len(idents))
+	for i, ident := range idents {
+		names[i] = ident.Value
+	}
+	for _, nc := range pa.issuerConstraints {
+		if nc.Permits(names) == nil {
+			return nil
+		}
+	}
+	return berrors.RejectedIdentifierError(
+		"Cannot issue for these identifiers together: no single issuer's name constraints permit all of them")
 }
 
 // willingToIssueWildcard vets a single identifier. It is used by
@@ -471,3 +471,66 @@ func TestValidEmailError(t *testing.T) {
 	err = ValidEmail("example@-foobar.com")
 	test.AssertEquals(t, err.Error(), "contact email \"example@-foobar.com\" has invalid domain : Domain name contains an invalid character")
 }
+
+func TestWillingToIssueWildcardsIssuerConstraints(t *testing.T) {
+	pa := paImpl(t)
+
+	policyBytes, err := yaml.Marshal(blockedNamesPolicy{
+		HighRiskBlockedNames: []string{"letsdecrypt.org"},
+		ExactBlockedNames:    []string{"letsdecrypt.org"},
+	})
+	test.AssertNotError(t, err, "Couldn't serialize blocked names policy")
+	f, _ := os.CreateTemp("", "test-constraints-blocklist.*.yaml")
+	defer os.Remove(f.Name())
+	err = os.WriteFile(f.Name(), policyBytes, 0640)
+	test.AssertNotError(t, err, "Couldn't write serialized policy to file")
+	err = pa.SetHostnamePolicyFile(f.Name())
+	test.AssertNotError(t, err, "Couldn't load policy contents from file")
+
+	// Without constraints nothing is rejected on their account.
+	err = pa.WillingToIssueWildcards([]identifier.ACMEIdentifier{
+		identifier.DNSIdentifier("example.net"),
+	})
+	test.AssertNotError(t, err, "unconstrained PA rejected a name")
+
+	pa.SetIssuerNameConstraints([]NameConstraints{
+		{PermittedDNSDomains: []string{"example.com"}, ExcludedDNSDomains: []string{"bad.example.com"}},
+		{PermittedDNSDomains: []string{"example.org"}},
+	})
+
+	err = pa.WillingToIssueWildcards([]identifier.ACMEIdentifier{
+		identifier.DNSIdentifier("www.example.com"),
+	})
+	test.AssertNotError(t, err, "name within constraints rejected")
+
+	err = pa.WillingToIssueWildcards([]identifier.ACMEIdentifier{
+		identifier.DNSIdentifier("bad.example.com"),
+	})
+	test.AssertErrorIs(t, err, berrors.RejectedIdentifier)
+
+	err = pa.WillingToIssueWildcards([]identifier.ACMEIdentifier{
+		identifier.DNSIdentifier("www.example.com"),
+		identifier.DNSIdentifier("example.net"),
+	})
+	test.AssertErrorIs(t, err, berrors.RejectedIdentifier)
+	test.AssertContains(t, err.Error(), "example.net")
+
+	// Each name is permitted by some issuer, but no one issuer permits both.
+	err = pa.WillingToIssueWildcards([]identifier.ACMEIdentifier{
+		identifier.DNSIdentifier("www.example.com"),
+		identifier.DNSIdentifier("www.example.org"),
+	})
+	test.AssertErrorIs(t, err, berrors.RejectedIdentifier)
+	test.AssertContains(t, err.Error(), "no single issuer")
+
+	// An unconstrained issuer can include anything.
+	pa.SetIssuerNameConstraints([]NameConstraints{
+		{PermittedDNSDomains: []string{"example.com"}},
+		{},
+	})
+	err = pa.WillingToIssueWildcards([]identifier.ACMEIdentifier{
+		identifier.DNSIdentifier("www.example.com"),
+		identifier.DNSIdentifier("example.net"),
+	})
+	test.AssertNotError(t, err, "name rejected despite an unconstrained issuer")
+}
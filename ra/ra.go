@@ -31,6 +31,7 @@ import (
 	capb "github.com/letsencrypt/boulder/ca/proto"
 	"github.com/letsencrypt/boulder/core"
 	corepb "github.com/letsencrypt/boulder/core/proto"
+	cupb "github.com/letsencrypt/boulder/crl/updater/proto"
 	csrlib "github.com/letsencrypt/boulder/csr"
 	"github.com/letsencrypt/boulder/ctpolicy"
 	berrors "github.com/letsencrypt/boulder/errors"
@@ -85,6 +86,9 @@ type RegistrationAuthorityImpl struct {
 	PA        core.PolicyAuthority
 	publisher pubpb.PublisherClient
 	caa       caaChecker
+	// CRLUpdater is optional: if nil, revoked certificates only appear in CRLs
+	// at the crl-updater's next regular update.
+	CRLUpdater cupb.CRLUpdaterClient
 
 	clk       clock.Clock
 	log       blog.Logger
@@ -1861,6 +1865,7 @@ func (ra *RegistrationAuthorityImpl) revokeCertificate(ctx context.Context, seri
 	}
 
 	ra.revocationReasonCounter.WithLabelValues(revocation.ReasonToString[reason]).Inc()
+	ra.enqueueCRLRegeneration(ctx, serialString, issuerID)
 	return nil
 }
 
@@ -1916,9 +1921,41 @@ func (ra *RegistrationAuthorityImpl) updateRevocationForKeyCompromise(ctx contex
 	}
 
 	ra.revocationReasonCounter.WithLabelValues(revocation.ReasonToString[ocsp.KeyCompromise]).Inc()
+	ra.enqueueCRLRegeneration(ctx, serialString, issuerID)
 	return nil
 }
 
+// enqueueCRLRegeneration asks the crl-updater, if one is configured, to
+// promptly regenerate the CRL shard containing the given newly-revoked
+// certificate. It only logs errors, rather than returning them, because the
+// revocation itself has already succeeded and the certificate will appear in
+// the CRL at the crl-updater's next regular update regardless.
+// TODO(#5152) make the issuerID argument an issuance.IssuerNameID
+func (ra *RegistrationAuthorityImpl) enqueueCRLRegeneration(ctx context.Context, serial string, issuerID int64) {
+	if ra.CRLUpdater == nil {
+		return
+	}
+
+	issuer, ok := ra.issuersByNameID[issuance.IssuerNameID(issuerID)]
+	if !ok {
+		// TODO(#5152): Remove this fallback (which only gets used when revoking by
+		// serial, so the issuer ID had to be read from the db).
+		issuer, ok = ra.issuersByID[issuance.IssuerID(issuerID)]
+		if !ok {
+			ra.log.Warningf("Unable to identify issuer to regenerate CRL for serial %q", serial)
+			return
+		}
+	}
+
+	_, err := ra.CRLUpdater.EnqueueRevocation(ctx, &cupb.EnqueueRevocationRequest{
+		IssuerNameID: int64(issuer.NameID()),
+		Serial:       serial,
+	})
+	if err != nil {
+		ra.log.Warningf("Failed to enqueue CRL regeneration for serial %q: %s", serial, err)
+	}
+}
+
 // purgeOCSPCache makes a request to akamai-purger to purge the cache entries
 // for the given certificate.
 // TODO(#5152) make the issuerID argument an issuance.IssuerNameID
@@ -41,6 +41,7 @@ import (
 	"github.com/letsencrypt/boulder/config"
 	"github.com/letsencrypt/boulder/core"
 	corepb "github.com/letsencrypt/boulder/core/proto"
+	cupb "github.com/letsencrypt/boulder/crl/updater/proto"
 	"github.com/letsencrypt/boulder/ctpolicy"
 	"github.com/letsencrypt/boulder/ctpolicy/loglist"
 	berrors "github.com/letsencrypt/boulder/errors"
@@ -3670,6 +3671,15 @@ func (mp *mockPurger) Purge(context.Context, *akamaipb.PurgeRequest, ...grpc.Cal
 	return &emptypb.Empty{}, nil
 }
 
+type mockCRLUpdater struct {
+	enqueued []*cupb.EnqueueRevocationRequest
+}
+
+func (mcu *mockCRLUpdater) EnqueueRevocation(_ context.Context, req *cupb.EnqueueRevocationRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+	mcu.enqueued = append(mcu.enqueued, req)
+	return &emptypb.Empty{}, nil
+}
+
 type mockSAGenerateOCSP struct {
 	mocks.StorageAuthority
 	expiration time.Time
@@ -3722,6 +3732,8 @@ func TestRevokeCertByApplicant_Subscriber(t *testing.T) {
 		ic.ID(): ic,
 	}
 	ra.SA = newMockSARevocation(cert, clk)
+	mockCU := &mockCRLUpdater{}
+	ra.CRLUpdater = mockCU
 
 	// Revoking without a regID should fail.
 	_, err = ra.RevokeCertByApplicant(context.Background(), &rapb.RevokeCertByApplicantRequest{
@@ -3748,6 +3760,9 @@ func TestRevokeCertByApplicant_Subscriber(t *testing.T) {
 		RegID: 1,
 	})
 	test.AssertNotError(t, err, "should have succeeded")
+	test.AssertEquals(t, len(mockCU.enqueued), 1)
+	test.AssertEquals(t, mockCU.enqueued[0].Serial, core.SerialToString(cert.SerialNumber))
+	test.AssertEquals(t, mockCU.enqueued[0].IssuerNameID, int64(ic.NameID()))
 
 	// Revoking an already-revoked serial should fail.
 	_, err = ra.RevokeCertByApplicant(context.Background(), &rapb.RevokeCertByApplicantRequest{
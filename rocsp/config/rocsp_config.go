@@ -2,6 +2,7 @@ package rocsp_config
 
 import (
 	"bytes"
+	"crypto/tls"
 	"crypto/x509/pkix"
 	"encoding/asn1"
 	"errors"
@@ -29,11 +30,19 @@ type RedisConfig struct {
 	Username string `validate:"required"`
 	// ShardAddrs is a map of shard names to IP address:port pairs. The go-redis
 	// `Ring` client will shard reads and writes across the provided Redis
-	// Servers based on a consistent hashing algorithm.
-	ShardAddrs map[string]string `validate:"min=1,dive,hostname_port"`
+	// Servers based on a consistent hashing algorithm. Exactly one of
+	// ShardAddrs and ClusterAddrs must be set.
+	ShardAddrs map[string]string `validate:"required_without=ClusterAddrs,excluded_with=ClusterAddrs,dive,hostname_port"`
+	// ClusterAddrs is a seed list of IP address:port pairs of Redis Cluster
+	// nodes. If set, the go-redis `ClusterClient` discovers the rest of the
+	// cluster from them, routes each key to the node serving its hash slot,
+	// and follows slots as they move between nodes or fail over to replicas.
+	ClusterAddrs []string `validate:"required_without=ShardAddrs,dive,hostname_port"`
 	// Timeout is a per-request timeout applied to all Redis requests.
 	Timeout config.Duration `validate:"-"`
 
+	// The following four settings only apply when ClusterAddrs is set.
+
 	// Enables read-only commands on replicas.
 	ReadOnly bool
 	// Allows routing read-only commands to the closest primary or replica.
@@ -42,6 +51,10 @@ type RedisConfig struct {
 	// Allows routing read-only commands to a random primary or replica.
 	// It automatically enables ReadOnly.
 	RouteRandomly bool
+	// Maximum number of times a command is retried against another node
+	// after a network error or MOVED/ASK redirect, such as while a failed
+	// primary is replaced by a replica. Default is 3; -1 disables retries.
+	MaxRedirects int `validate:"min=-1"`
 
 	// PoolFIFO uses FIFO mode for each node connection pool GET/PUT (default LIFO).
 	PoolFIFO bool
@@ -107,6 +120,11 @@ func MakeClient(c *RedisConfig, clk clock.Clock, stats prometheus.Registerer) (*
 		return nil, fmt.Errorf("loading TLS config: %w", err)
 	}
 
+	if len(c.ClusterAddrs) > 0 {
+		rdb := redis.NewClusterClient(c.clusterOptions(password, tlsConfig))
+		return rocsp.NewClusterWritingClient(rdb, c.Timeout.Duration, clk, stats), nil
+	}
+
 	rdb := redis.NewRing(&redis.RingOptions{
 		Addrs:     c.ShardAddrs,
 		Username:  c.Username,
@@ -132,8 +150,8 @@ func MakeClient(c *RedisConfig, clk clock.Clock, stats prometheus.Registerer) (*
 
 // MakeReadClient produces a read-only ROCSP client from a config.
 func MakeReadClient(c *RedisConfig, clk clock.Clock, stats prometheus.Registerer) (*rocsp.ROClient, error) {
-	if len(c.ShardAddrs) == 0 {
-		return nil, errors.New("redis config's 'shardAddrs' field was empty")
+	if len(c.ShardAddrs) == 0 && len(c.ClusterAddrs) == 0 {
+		return nil, errors.New("redis config's 'shardAddrs' and 'clusterAddrs' fields were both empty")
 	}
 
 	password, err := c.PasswordConfig.Pass()
@@ -146,6 +164,11 @@ func MakeReadClient(c *RedisConfig, clk clock.Clock, stats prometheus.Registerer
 		return nil, fmt.Errorf("loading TLS config: %w", err)
 	}
 
+	if len(c.ClusterAddrs) > 0 {
+		rdb := redis.NewClusterClient(c.clusterOptions(password, tlsConfig))
+		return rocsp.NewClusterReadingClient(rdb, c.Timeout.Duration, clk, stats), nil
+	}
+
 	rdb := redis.NewRing(&redis.RingOptions{
 		Addrs:     c.ShardAddrs,
 		Username:  c.Username,
@@ -170,6 +193,38 @@ func MakeReadClient(c *RedisConfig, clk clock.Clock, stats prometheus.Registerer
 	return rocsp.NewReadingClient(rdb, c.Timeout.Duration, clk, stats), nil
 }
 
+// clusterOptions returns the options for a Redis Cluster client described by
+// the config, using the given password and TLS configuration.
+func (c *RedisConfig) clusterOptions(password string, tlsConfig *tls.Config) *redis.ClusterOptions {
+	return &redis.ClusterOptions{
+		Addrs:     c.ClusterAddrs,
+		Username:  c.Username,
+		Password:  password,
+		TLSConfig: tlsConfig,
+
+		MaxRedirects:   c.MaxRedirects,
+		ReadOnly:       c.ReadOnly,
+		RouteByLatency: c.RouteByLatency,
+		RouteRandomly:  c.RouteRandomly,
+
+		PoolFIFO: c.PoolFIFO,
+
+		MaxRetries:      c.MaxRetries,
+		MinRetryBackoff: c.MinRetryBackoff.Duration,
+		MaxRetryBackoff: c.MaxRetryBackoff.Duration,
+		DialTimeout:     c.DialTimeout.Duration,
+		ReadTimeout:     c.ReadTimeout.Duration,
+		WriteTimeout:    c.WriteTimeout.Duration,
+
+		PoolSize:           c.PoolSize,
+		MinIdleConns:       c.MinIdleConns,
+		MaxConnAge:         c.MaxConnAge.Duration,
+		PoolTimeout:        c.PoolTimeout.Duration,
+		IdleTimeout:        c.IdleTimeout.Duration,
+		IdleCheckFrequency: c.IdleCheckFrequency.Duration,
+	}
+}
+
 // A ShortIDIssuer combines an issuance.Certificate with some fields necessary
 // to process OCSP responses: the subject name and the shortID.
 type ShortIDIssuer struct {
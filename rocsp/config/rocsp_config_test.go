@@ -0,0 +1,50 @@
+package rocsp_config
+
+import (
+	"testing"
+
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestClusterOptions(t *testing.T) {
+	c := RedisConfig{
+		Username:      "ocsp-responder",
+		ClusterAddrs:  []string{"10.33.33.4:4218", "10.33.33.5:4218"},
+		RouteRandomly: true,
+		MaxRedirects:  5,
+		PoolSize:      100,
+	}
+	opts := c.clusterOptions("hunter2", nil)
+	test.AssertDeepEquals(t, opts.Addrs, c.ClusterAddrs)
+	test.AssertEquals(t, opts.Username, "ocsp-responder")
+	test.AssertEquals(t, opts.Password, "hunter2")
+	test.Assert(t, opts.RouteRandomly, "RouteRandomly not set")
+	test.AssertEquals(t, opts.MaxRedirects, 5)
+	test.AssertEquals(t, opts.PoolSize, 100)
+}
+
+func TestMakeReadClient(t *testing.T) {
+	caCertFile := "../../test/redis-tls/minica.pem"
+	certFile := "../../test/redis-tls/boulder/cert.pem"
+	keyFile := "../../test/redis-tls/boulder/key.pem"
+	c := RedisConfig{
+		Username: "ocsp-responder",
+		TLS: cmd.TLSConfig{
+			CACertFile: &caCertFile,
+			CertFile:   &certFile,
+			KeyFile:    &keyFile,
+		},
+	}
+	_, err := MakeReadClient(&c, clock.NewFake(), metrics.NoopRegisterer)
+	test.AssertError(t, err, "made client without any addresses")
+
+	// Cluster clients connect lazily, so no cluster is needed to make one.
+	c.ClusterAddrs = []string{"10.33.33.4:4218"}
+	client, err := MakeReadClient(&c, clock.NewFake(), metrics.NoopRegisterer)
+	test.AssertNotError(t, err, "making cluster client")
+	test.AssertNotNil(t, client, "cluster client was nil")
+}
@@ -5,11 +5,13 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-// An interface satisfied by *redis.ClusterClient and also by a mock in our tests.
+// An interface satisfied by *redis.Ring, *redis.ClusterClient, and also by a
+// mock in our tests.
 type poolStatGetter interface {
 	PoolStats() *redis.PoolStats
 }
 
+var _ poolStatGetter = (*redis.Ring)(nil)
 var _ poolStatGetter = (*redis.ClusterClient)(nil)
 
 type metricsCollector struct {
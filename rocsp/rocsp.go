@@ -18,16 +18,21 @@ var ErrRedisNotFound = errors.New("redis key not found")
 
 // ROClient represents a read-only Redis client.
 type ROClient struct {
-	rdb        *redis.Ring
-	timeout    time.Duration
-	clk        clock.Clock
-	getLatency *prometheus.HistogramVec
+	rdb redis.UniversalClient
+	// forEachPrimary calls a function for a client of each node holding
+	// primary copies of keys: every shard of a Ring, or every master of a
+	// Cluster.
+	forEachPrimary func(context.Context, func(context.Context, *redis.Client) error) error
+	timeout        time.Duration
+	clk            clock.Clock
+	getLatency     *prometheus.HistogramVec
 }
 
-// NewReadingClient creates a read-only client. The timeout applies to all
-// requests, though a shorter timeout can be applied on a per-request basis
-// using context.Context. rdb must be non-nil and calls to rdb.Options().Addrs
-// must return at least one entry.
+// NewReadingClient creates a read-only client backed by a Ring, which shards
+// keys across independent Redis servers. The timeout applies to all requests,
+// though a shorter timeout can be applied on a per-request basis using
+// context.Context. rdb must be non-nil and calls to rdb.Options().Addrs must
+// return at least one entry.
 func NewReadingClient(rdb *redis.Ring, timeout time.Duration, clk clock.Clock, stats prometheus.Registerer) *ROClient {
 	if len(rdb.Options().Addrs) == 0 {
 		return nil
@@ -36,9 +41,35 @@ func NewReadingClient(rdb *redis.Ring, timeout time.Duration, clk clock.Clock, s
 	for addr := range rdb.Options().Addrs {
 		addrs = append(addrs, addr)
 	}
+	return newReadingClient(rdb, rdb.ForEachShard, addrs, rdb.Options().Username, timeout, clk, stats)
+}
+
+// NewClusterReadingClient creates a read-only client backed by a Redis
+// Cluster. Unlike a Ring, a Cluster routes each key to the node serving its
+// hash slot, follows slots as they move between nodes, and fails over to a
+// replica if a primary is lost. If rdb was configured with ReadOnly,
+// RouteByLatency, or RouteRandomly, reads may also be served by replicas.
+// rdb must be non-nil and calls to rdb.Options().Addrs must return at least
+// one entry.
+func NewClusterReadingClient(rdb *redis.ClusterClient, timeout time.Duration, clk clock.Clock, stats prometheus.Registerer) *ROClient {
+	if len(rdb.Options().Addrs) == 0 {
+		return nil
+	}
+	return newReadingClient(rdb, rdb.ForEachMaster, rdb.Options().Addrs, rdb.Options().Username, timeout, clk, stats)
+}
+
+func newReadingClient(
+	rdb redis.UniversalClient,
+	forEachPrimary func(context.Context, func(context.Context, *redis.Client) error) error,
+	addrs []string,
+	username string,
+	timeout time.Duration,
+	clk clock.Clock,
+	stats prometheus.Registerer,
+) *ROClient {
 	labels := prometheus.Labels{
 		"addresses": strings.Join(addrs, ", "),
-		"user":      rdb.Options().Username,
+		"user":      username,
 	}
 	stats.MustRegister(newMetricsCollector(rdb, labels))
 	getLatency := prometheus.NewHistogramVec(
@@ -53,10 +84,11 @@ func NewReadingClient(rdb *redis.Ring, timeout time.Duration, clk clock.Clock, s
 	stats.MustRegister(getLatency)
 
 	return &ROClient{
-		rdb:        rdb,
-		timeout:    timeout,
-		clk:        clk,
-		getLatency: getLatency,
+		rdb:            rdb,
+		forEachPrimary: forEachPrimary,
+		timeout:        timeout,
+		clk:            clk,
+		getLatency:     getLatency,
 	}
 }
 
@@ -72,8 +104,19 @@ type RWClient struct {
 	storeResponseLatency *prometheus.HistogramVec
 }
 
-// NewWritingClient creates a RWClient.
+// NewWritingClient creates a RWClient backed by a Ring.
 func NewWritingClient(rdb *redis.Ring, timeout time.Duration, clk clock.Clock, stats prometheus.Registerer) *RWClient {
+	return newWritingClient(NewReadingClient(rdb, timeout, clk, stats), stats)
+}
+
+// NewClusterWritingClient creates a RWClient backed by a Redis Cluster. Writes
+// always go to the primary serving each key's hash slot. See
+// NewClusterReadingClient.
+func NewClusterWritingClient(rdb *redis.ClusterClient, timeout time.Duration, clk clock.Clock, stats prometheus.Registerer) *RWClient {
+	return newWritingClient(NewClusterReadingClient(rdb, timeout, clk, stats), stats)
+}
+
+func newWritingClient(ro *ROClient, stats prometheus.Registerer) *RWClient {
 	storeResponseLatency := prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name: "rocsp_store_response_latency",
@@ -82,7 +125,7 @@ func NewWritingClient(rdb *redis.Ring, timeout time.Duration, clk clock.Clock, s
 		[]string{"result"},
 	)
 	stats.MustRegister(storeResponseLatency)
-	return &RWClient{NewReadingClient(rdb, timeout, clk, stats), storeResponseLatency}
+	return &RWClient{ro, storeResponseLatency}
 }
 
 // StoreResponse parses the given bytes as an OCSP response, and stores it
@@ -157,13 +200,16 @@ type ScanResponsesResult struct {
 // ScanResponses scans Redis for all OCSP responses where the serial number matches the provided pattern.
 // It returns immediately and emits results and errors on `<-chan ScanResponsesResult`. It closes the
 // channel when it is done or hits an error.
+//
+// serialPattern is a Redis glob matched against keys exactly as they are
+// stored, which is as bare serial numbers, so "*" matches every response. It
+// is not wrapped in a hash tag such as "r{...}", which no stored key matches.
 func (c *ROClient) ScanResponses(ctx context.Context, serialPattern string) <-chan ScanResponsesResult {
-	pattern := fmt.Sprintf("r{%s}", serialPattern)
 	results := make(chan ScanResponsesResult)
 	go func() {
 		defer close(results)
-		err := c.rdb.ForEachShard(ctx, func(ctx context.Context, rdb *redis.Client) error {
-			iter := rdb.Scan(ctx, 0, pattern, 0).Iterator()
+		err := c.forEachPrimary(ctx, func(ctx context.Context, rdb *redis.Client) error {
+			iter := rdb.Scan(ctx, 0, serialPattern, 0).Iterator()
 			for iter.Next(ctx) {
 				key := iter.Val()
 				val, err := c.rdb.Get(ctx, key).Result()
@@ -69,3 +69,47 @@ func TestSetAndGet(t *testing.T) {
 		t.Errorf("response written and response retrieved were not equal")
 	}
 }
+
+func TestScanResponses(t *testing.T) {
+	client, _ := makeClient()
+
+	respBytes, err := os.ReadFile("testdata/ocsp.response")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := ocsp.ParseResponse(respBytes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.StoreResponse(context.Background(), response)
+	if err != nil {
+		t.Fatalf("storing response: %s", err)
+	}
+
+	// scan collects the responses matching the pattern, by serial.
+	scan := func(pattern string) map[string][]byte {
+		found := make(map[string][]byte)
+		for r := range client.ScanResponses(context.Background(), pattern) {
+			if r.Err != nil {
+				t.Fatalf("scanning responses for %q: %s", pattern, r.Err)
+			}
+			found[r.Serial] = r.Body
+		}
+		return found
+	}
+
+	// The pattern is matched against the bare serials responses are stored
+	// under.
+	serial := "ffaa13f9c34be80b8e2532b83afe063b59a6"
+	for _, pattern := range []string{"*", serial, "ffaa13f9*"} {
+		body, ok := scan(pattern)[serial]
+		if !ok {
+			t.Errorf("scanning for %q did not find %s", pattern, serial)
+		} else if !bytes.Equal(body, respBytes) {
+			t.Errorf("scanning for %q found a different response for %s", pattern, serial)
+		}
+	}
+	if _, ok := scan(fmt.Sprintf("r{%s}", serial))[serial]; ok {
+		t.Errorf("scanning for a hash-tagged pattern found %s", serial)
+	}
+}
@@ -0,0 +1,12 @@
+
+-- +migrate Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+ALTER TABLE `certificateStatus` ADD COLUMN `crlShard` int(11) DEFAULT NULL;
+ALTER TABLE `certificateStatus` ADD KEY `issuerID_crlShard_status_idx` (`issuerID`,`crlShard`,`status`);
+
+-- +migrate Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+ALTER TABLE `certificateStatus` DROP KEY `issuerID_crlShard_status_idx`;
+ALTER TABLE `certificateStatus` DROP COLUMN `crlShard`;
@@ -124,6 +124,19 @@ func (ssa *SQLStorageAuthority) AddPrecertificate(ctx context.Context, req *sapb
 			return nil, err
 		}
 
+		// The CRL shard isn't part of core.CertificateStatus, since the column
+		// only exists in the next schema, so it is set separately.
+		if features.Enabled(features.StoreCRLShards) && len(parsed.CRLDistributionPoints) != 0 {
+			_, err = txWithCtx.Exec(
+				"UPDATE certificateStatus SET crlShard = ? WHERE serial = ?",
+				req.CrlShard,
+				serialHex,
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		// NOTE(@cpu): When we collect up names to check if an FQDN set exists (e.g.
 		// that it is a renewal) we use just the DNSNames from the certificate and
 		// ignore the Subject Common Name (if any). This is a safe assumption because
@@ -46,6 +46,7 @@ type StorageAuthorityReadOnlyClient interface {
 	GetRegistrationByKey(ctx context.Context, in *JSONWebKey, opts ...grpc.CallOption) (*proto.Registration, error)
 	GetRevocationStatus(ctx context.Context, in *Serial, opts ...grpc.CallOption) (*RevocationStatus, error)
 	GetRevokedCerts(ctx context.Context, in *GetRevokedCertsRequest, opts ...grpc.CallOption) (StorageAuthorityReadOnly_GetRevokedCertsClient, error)
+	GetRevokedCertsByShard(ctx context.Context, in *GetRevokedCertsByShardRequest, opts ...grpc.CallOption) (StorageAuthorityReadOnly_GetRevokedCertsByShardClient, error)
 	GetSerialMetadata(ctx context.Context, in *Serial, opts ...grpc.CallOption) (*SerialMetadata, error)
 	GetValidAuthorizations2(ctx context.Context, in *GetValidAuthorizationsRequest, opts ...grpc.CallOption) (*Authorizations, error)
 	GetValidOrderAuthorizations2(ctx context.Context, in *GetValidOrderAuthorizationsRequest, opts ...grpc.CallOption) (*Authorizations, error)
@@ -275,6 +276,38 @@ func (x *storageAuthorityReadOnlyGetRevokedCertsClient) Recv() (*proto.CRLEntry,
 	return m, nil
 }
 
+func (c *storageAuthorityReadOnlyClient) GetRevokedCertsByShard(ctx context.Context, in *GetRevokedCertsByShardRequest, opts ...grpc.CallOption) (StorageAuthorityReadOnly_GetRevokedCertsByShardClient, error) {
+	stream, err := c.cc.NewStream(ctx, &StorageAuthorityReadOnly_ServiceDesc.Streams[1], "/sa.StorageAuthorityReadOnly/GetRevokedCertsByShard", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &storageAuthorityReadOnlyGetRevokedCertsByShardClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type StorageAuthorityReadOnly_GetRevokedCertsByShardClient interface {
+	Recv() (*proto.CRLEntry, error)
+	grpc.ClientStream
+}
+
+type storageAuthorityReadOnlyGetRevokedCertsByShardClient struct {
+	grpc.ClientStream
+}
+
+func (x *storageAuthorityReadOnlyGetRevokedCertsByShardClient) Recv() (*proto.CRLEntry, error) {
+	m := new(proto.CRLEntry)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *storageAuthorityReadOnlyClient) GetSerialMetadata(ctx context.Context, in *Serial, opts ...grpc.CallOption) (*SerialMetadata, error) {
 	out := new(SerialMetadata)
 	err := c.cc.Invoke(ctx, "/sa.StorageAuthorityReadOnly/GetSerialMetadata", in, out, opts...)
@@ -330,7 +363,7 @@ func (c *storageAuthorityReadOnlyClient) PreviousCertificateExists(ctx context.C
 }
 
 func (c *storageAuthorityReadOnlyClient) SerialsForIncident(ctx context.Context, in *SerialsForIncidentRequest, opts ...grpc.CallOption) (StorageAuthorityReadOnly_SerialsForIncidentClient, error) {
-	stream, err := c.cc.NewStream(ctx, &StorageAuthorityReadOnly_ServiceDesc.Streams[1], "/sa.StorageAuthorityReadOnly/SerialsForIncident", opts...)
+	stream, err := c.cc.NewStream(ctx, &StorageAuthorityReadOnly_ServiceDesc.Streams[2], "/sa.StorageAuthorityReadOnly/SerialsForIncident", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -386,6 +419,7 @@ type StorageAuthorityReadOnlyServer interface {
 	GetRegistrationByKey(context.Context, *JSONWebKey) (*proto.Registration, error)
 	GetRevocationStatus(context.Context, *Serial) (*RevocationStatus, error)
 	GetRevokedCerts(*GetRevokedCertsRequest, StorageAuthorityReadOnly_GetRevokedCertsServer) error
+	GetRevokedCertsByShard(*GetRevokedCertsByShardRequest, StorageAuthorityReadOnly_GetRevokedCertsByShardServer) error
 	GetSerialMetadata(context.Context, *Serial) (*SerialMetadata, error)
 	GetValidAuthorizations2(context.Context, *GetValidAuthorizationsRequest) (*Authorizations, error)
 	GetValidOrderAuthorizations2(context.Context, *GetValidOrderAuthorizationsRequest) (*Authorizations, error)
@@ -463,6 +497,9 @@ func (UnimplementedStorageAuthorityReadOnlyServer) GetRevocationStatus(context.C
 func (UnimplementedStorageAuthorityReadOnlyServer) GetRevokedCerts(*GetRevokedCertsRequest, StorageAuthorityReadOnly_GetRevokedCertsServer) error {
 	return status.Errorf(codes.Unimplemented, "method GetRevokedCerts not implemented")
 }
+func (UnimplementedStorageAuthorityReadOnlyServer) GetRevokedCertsByShard(*GetRevokedCertsByShardRequest, StorageAuthorityReadOnly_GetRevokedCertsByShardServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetRevokedCertsByShard not implemented")
+}
 func (UnimplementedStorageAuthorityReadOnlyServer) GetSerialMetadata(context.Context, *Serial) (*SerialMetadata, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetSerialMetadata not implemented")
 }
@@ -879,6 +916,27 @@ func (x *storageAuthorityReadOnlyGetRevokedCertsServer) Send(m *proto.CRLEntry)
 	return x.ServerStream.SendMsg(m)
 }
 
+func _StorageAuthorityReadOnly_GetRevokedCertsByShard_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetRevokedCertsByShardRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StorageAuthorityReadOnlyServer).GetRevokedCertsByShard(m, &storageAuthorityReadOnlyGetRevokedCertsByShardServer{stream})
+}
+
+type StorageAuthorityReadOnly_GetRevokedCertsByShardServer interface {
+	Send(*proto.CRLEntry) error
+	grpc.ServerStream
+}
+
+type storageAuthorityReadOnlyGetRevokedCertsByShardServer struct {
+	grpc.ServerStream
+}
+
+func (x *storageAuthorityReadOnlyGetRevokedCertsByShardServer) Send(m *proto.CRLEntry) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _StorageAuthorityReadOnly_GetSerialMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Serial)
 	if err := dec(in); err != nil {
@@ -1126,6 +1184,11 @@ var StorageAuthorityReadOnly_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _StorageAuthorityReadOnly_GetRevokedCerts_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "GetRevokedCertsByShard",
+			Handler:       _StorageAuthorityReadOnly_GetRevokedCertsByShard_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "SerialsForIncident",
 			Handler:       _StorageAuthorityReadOnly_SerialsForIncident_Handler,
@@ -1161,6 +1224,7 @@ type StorageAuthorityClient interface {
 	GetRegistrationByKey(ctx context.Context, in *JSONWebKey, opts ...grpc.CallOption) (*proto.Registration, error)
 	GetRevocationStatus(ctx context.Context, in *Serial, opts ...grpc.CallOption) (*RevocationStatus, error)
 	GetRevokedCerts(ctx context.Context, in *GetRevokedCertsRequest, opts ...grpc.CallOption) (StorageAuthority_GetRevokedCertsClient, error)
+	GetRevokedCertsByShard(ctx context.Context, in *GetRevokedCertsByShardRequest, opts ...grpc.CallOption) (StorageAuthority_GetRevokedCertsByShardClient, error)
 	GetSerialMetadata(ctx context.Context, in *Serial, opts ...grpc.CallOption) (*SerialMetadata, error)
 	GetValidAuthorizations2(ctx context.Context, in *GetValidAuthorizationsRequest, opts ...grpc.CallOption) (*Authorizations, error)
 	GetValidOrderAuthorizations2(ctx context.Context, in *GetValidOrderAuthorizationsRequest, opts ...grpc.CallOption) (*Authorizations, error)
@@ -1406,6 +1470,38 @@ func (x *storageAuthorityGetRevokedCertsClient) Recv() (*proto.CRLEntry, error)
 	return m, nil
 }
 
+func (c *storageAuthorityClient) GetRevokedCertsByShard(ctx context.Context, in *GetRevokedCertsByShardRequest, opts ...grpc.CallOption) (StorageAuthority_GetRevokedCertsByShardClient, error) {
+	stream, err := c.cc.NewStream(ctx, &StorageAuthority_ServiceDesc.Streams[1], "/sa.StorageAuthority/GetRevokedCertsByShard", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &storageAuthorityGetRevokedCertsByShardClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type StorageAuthority_GetRevokedCertsByShardClient interface {
+	Recv() (*proto.CRLEntry, error)
+	grpc.ClientStream
+}
+
+type storageAuthorityGetRevokedCertsByShardClient struct {
+	grpc.ClientStream
+}
+
+func (x *storageAuthorityGetRevokedCertsByShardClient) Recv() (*proto.CRLEntry, error) {
+	m := new(proto.CRLEntry)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *storageAuthorityClient) GetSerialMetadata(ctx context.Context, in *Serial, opts ...grpc.CallOption) (*SerialMetadata, error) {
 	out := new(SerialMetadata)
 	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/GetSerialMetadata", in, out, opts...)
@@ -1461,7 +1557,7 @@ func (c *storageAuthorityClient) PreviousCertificateExists(ctx context.Context,
 }
 
 func (c *storageAuthorityClient) SerialsForIncident(ctx context.Context, in *SerialsForIncidentRequest, opts ...grpc.CallOption) (StorageAuthority_SerialsForIncidentClient, error) {
-	stream, err := c.cc.NewStream(ctx, &StorageAuthority_ServiceDesc.Streams[1], "/sa.StorageAuthority/SerialsForIncident", opts...)
+	stream, err := c.cc.NewStream(ctx, &StorageAuthority_ServiceDesc.Streams[2], "/sa.StorageAuthority/SerialsForIncident", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -1653,6 +1749,7 @@ type StorageAuthorityServer interface {
 	GetRegistrationByKey(context.Context, *JSONWebKey) (*proto.Registration, error)
 	GetRevocationStatus(context.Context, *Serial) (*RevocationStatus, error)
 	GetRevokedCerts(*GetRevokedCertsRequest, StorageAuthority_GetRevokedCertsServer) error
+	GetRevokedCertsByShard(*GetRevokedCertsByShardRequest, StorageAuthority_GetRevokedCertsByShardServer) error
 	GetSerialMetadata(context.Context, *Serial) (*SerialMetadata, error)
 	GetValidAuthorizations2(context.Context, *GetValidAuthorizationsRequest) (*Authorizations, error)
 	GetValidOrderAuthorizations2(context.Context, *GetValidOrderAuthorizationsRequest) (*Authorizations, error)
@@ -1746,6 +1843,9 @@ func (UnimplementedStorageAuthorityServer) GetRevocationStatus(context.Context,
 func (UnimplementedStorageAuthorityServer) GetRevokedCerts(*GetRevokedCertsRequest, StorageAuthority_GetRevokedCertsServer) error {
 	return status.Errorf(codes.Unimplemented, "method GetRevokedCerts not implemented")
 }
+func (UnimplementedStorageAuthorityServer) GetRevokedCertsByShard(*GetRevokedCertsByShardRequest, StorageAuthority_GetRevokedCertsByShardServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetRevokedCertsByShard not implemented")
+}
 func (UnimplementedStorageAuthorityServer) GetSerialMetadata(context.Context, *Serial) (*SerialMetadata, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetSerialMetadata not implemented")
 }
@@ -2206,6 +2306,27 @@ func (x *storageAuthorityGetRevokedCertsServer) Send(m *proto.CRLEntry) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _StorageAuthority_GetRevokedCertsByShard_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetRevokedCertsByShardRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StorageAuthorityServer).GetRevokedCertsByShard(m, &storageAuthorityGetRevokedCertsByShardServer{stream})
+}
+
+type StorageAuthority_GetRevokedCertsByShardServer interface {
+	Send(*proto.CRLEntry) error
+	grpc.ServerStream
+}
+
+type storageAuthorityGetRevokedCertsByShardServer struct {
+	grpc.ServerStream
+}
+
+func (x *storageAuthorityGetRevokedCertsByShardServer) Send(m *proto.CRLEntry) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _StorageAuthority_GetSerialMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Serial)
 	if err := dec(in); err != nil {
@@ -2783,6 +2904,11 @@ var StorageAuthority_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _StorageAuthority_GetRevokedCerts_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "GetRevokedCertsByShard",
+			Handler:       _StorageAuthority_GetRevokedCertsByShard_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "SerialsForIncident",
 			Handler:       _StorageAuthority_SerialsForIncident_Handler,
@@ -2918,6 +2918,91 @@ func TestGetRevokedCerts(t *testing.T) {
 	test.AssertEquals(t, count, 0)
 }
 
+func TestGetRevokedCertsByShard(t *testing.T) {
+	if !strings.Contains(os.Getenv("BOULDER_CONFIG_DIR"), "test/config-next") {
+		t.Skip("the certificateStatus.crlShard column is only in the next schema")
+	}
+	sa, fc, cleanUp := initSA(t)
+	defer cleanUp()
+	err := features.Set(map[string]bool{features.StoreCRLShards.String(): true})
+	test.AssertNotError(t, err, "setting feature")
+	defer features.Reset()
+
+	// Add two certificates, only one of which names a CRL shard, and revoke
+	// them both.
+	reg := createWorkingRegistration(t, sa)
+	testKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "error generating test key")
+	for i, crldp := range []string{"", "http://c.boulder.test/1/3.crl"} {
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(int64(4000 + i)),
+			DNSNames:     []string{fmt.Sprintf("%d.not-example.com", i)},
+			NotBefore:    fc.Now(),
+			NotAfter:     fc.Now().Add(90 * 24 * time.Hour),
+		}
+		if crldp != "" {
+			template.CRLDistributionPoints = []string{crldp}
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, testKey.Public(), testKey)
+		test.AssertNotError(t, err, "failed to create test cert")
+		_, err = sa.AddPrecertificate(ctx, &sapb.AddCertificateRequest{
+			Der:          der,
+			RegID:        reg.Id,
+			Issued:       fc.Now().UnixNano(),
+			IssuerNameID: 1,
+			CrlShard:     3,
+		})
+		test.AssertNotError(t, err, "failed to add test cert")
+		_, err = sa.RevokeCertificate(ctx, &sapb.RevokeCertificateRequest{
+			Serial:   core.SerialToString(template.SerialNumber),
+			Date:     fc.Now().UnixNano(),
+			Reason:   1,
+			Response: []byte{1, 2, 3},
+		})
+		test.AssertNotError(t, err, "failed to revoke test cert")
+	}
+
+	collect := func(get func(mockGetRevokedCertsServerStream) error) ([]string, error) {
+		stream := make(chan *corepb.CRLEntry)
+		var err error
+		go func() {
+			err = get(mockGetRevokedCertsServerStream{output: stream})
+			close(stream)
+		}()
+		var serials []string
+		for entry := range stream {
+			serials = append(serials, entry.Serial)
+		}
+		return serials, err
+	}
+
+	// Only the certificate naming a shard is found in it.
+	serials, err := collect(func(stream mockGetRevokedCertsServerStream) error {
+		return sa.GetRevokedCertsByShard(&sapb.GetRevokedCertsByShardRequest{
+			IssuerNameID:  1,
+			ShardIdx:      3,
+			ExpiresAfter:  fc.Now().UnixNano(),
+			RevokedBefore: fc.Now().Add(time.Hour).UnixNano(),
+		}, stream)
+	})
+	test.AssertNotError(t, err, "GetRevokedCertsByShard failed")
+	test.AssertDeepEquals(t, serials, []string{core.SerialToString(big.NewInt(4001))})
+
+	// And only the other one is found by expiry when asking for unsharded
+	// certificates.
+	serials, err = collect(func(stream mockGetRevokedCertsServerStream) error {
+		return sa.GetRevokedCerts(&sapb.GetRevokedCertsRequest{
+			IssuerNameID:  1,
+			ExpiresAfter:  fc.Now().UnixNano(),
+			ExpiresBefore: fc.Now().Add(91 * 24 * time.Hour).UnixNano(),
+			RevokedBefore: fc.Now().Add(time.Hour).UnixNano(),
+			UnshardedOnly: true,
+		}, stream)
+	})
+	test.AssertNotError(t, err, "GetRevokedCerts failed")
+	test.AssertDeepEquals(t, serials, []string{core.SerialToString(big.NewInt(4000))})
+}
+
 func TestGetMaxExpiration(t *testing.T) {
 	sa, _, cleanUp := initSA(t)
 	defer cleanUp()
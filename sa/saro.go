@@ -1176,8 +1176,6 @@ func (ssa *SQLStorageAuthority) SerialsForIncident(req *sapb.SerialsForIncidentR
 // notAfter date are included), but the ending timestamp is exclusive (certs
 // with exactly that notAfter date are *not* included).
 func (ssa *SQLStorageAuthorityRO) GetRevokedCerts(req *sapb.GetRevokedCertsRequest, stream sapb.StorageAuthorityReadOnly_GetRevokedCertsServer) error {
-	atTime := time.Unix(0, req.RevokedBefore)
-
 	clauses := `
 		WHERE notAfter >= ?
 		AND notAfter < ?
@@ -1189,7 +1187,53 @@ func (ssa *SQLStorageAuthorityRO) GetRevokedCerts(req *sapb.GetRevokedCertsReque
 		req.IssuerNameID,
 		core.OCSPStatusRevoked,
 	}
+	if req.UnshardedOnly {
+		clauses += `
+		AND crlShard IS NULL`
+	}
+
+	return ssa.streamCRLEntries(clauses, params, time.Unix(0, req.RevokedBefore), stream)
+}
+
+// GetRevokedCertsByShard gets a request specifying an issuer and a CRL shard,
+// and writes to the output stream the set of all revoked certificates from
+// that issuer which were assigned that shard at issuance, and which expire at
+// or after the given time.
+func (ssa *SQLStorageAuthorityRO) GetRevokedCertsByShard(req *sapb.GetRevokedCertsByShardRequest, stream sapb.StorageAuthorityReadOnly_GetRevokedCertsByShardServer) error {
+	if req.IssuerNameID == 0 || req.ExpiresAfter == 0 || req.RevokedBefore == 0 {
+		return errIncompleteRequest
+	}
+
+	clauses := `
+		WHERE issuerID = ?
+		AND crlShard = ?
+		AND status = ?
+		AND notAfter >= ?`
+	params := []interface{}{
+		req.IssuerNameID,
+		req.ShardIdx,
+		core.OCSPStatusRevoked,
+		time.Unix(0, req.ExpiresAfter),
+	}
+
+	return ssa.streamCRLEntries(clauses, params, time.Unix(0, req.RevokedBefore), stream)
+}
+
+func (ssa *SQLStorageAuthority) GetRevokedCertsByShard(req *sapb.GetRevokedCertsByShardRequest, stream sapb.StorageAuthority_GetRevokedCertsByShardServer) error {
+	return ssa.SQLStorageAuthorityRO.GetRevokedCertsByShard(req, stream)
+}
+
+// crlEntryStream is the part of the GetRevokedCerts and GetRevokedCertsByShard
+// server streams used by streamCRLEntries.
+type crlEntryStream interface {
+	Context() context.Context
+	Send(*corepb.CRLEntry) error
+}
 
+// streamCRLEntries selects the certificateStatus rows matching the given
+// clauses and writes a CRL entry to the stream for each of them which was
+// revoked before atTime.
+func (ssa *SQLStorageAuthorityRO) streamCRLEntries(clauses string, params []interface{}, atTime time.Time, stream crlEntryStream) error {
 	selector, err := db.NewMappedSelector[crlEntryModel](ssa.dbReadOnlyMap)
 	if err != nil {
 		return fmt.Errorf("initializing db map: %w", err)
@@ -224,4 +224,12 @@ func TestMaxWaiters(t *testing.T) {
 	if err != semaphore.ErrMaxWaiters {
 		t.Errorf("expected error when maxWaiters was reached, but got %#v", err)
 	}
+
+	// Rejecting a waiter must not leave the semaphore locked.
+	if sem.NumWaiters() != 10 {
+		t.Errorf("expected 10 waiters after rejecting one, got %d", sem.NumWaiters())
+	}
+	if sem.TryAcquire(1) {
+		t.Error("TryAcquire succeeded on a full semaphore")
+	}
 }
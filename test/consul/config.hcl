@@ -116,6 +116,22 @@ services {
   tags    = ["tcp"] // Required for SRV RR support in gRPC DNS resolution.
 }
 
+services {
+  id      = "crl-updater-a"
+  name    = "crl-updater"
+  address = "10.77.77.77"
+  port    = 9110
+  tags    = ["tcp"] // Required for SRV RR support in gRPC DNS resolution.
+}
+
+services {
+  id      = "crl-updater-b"
+  name    = "crl-updater"
+  address = "10.88.88.88"
+  port    = 9110
+  tags    = ["tcp"] // Required for SRV RR support in gRPC DNS resolution.
+}
+
 services {
   id      = "dns-a"
   name    = "dns"
@@ -92,15 +92,19 @@ SERVICES = (
     Service('boulder-ra-1',
         8002, 'ra1.service.consul:9094',
         ('./bin/boulder', 'boulder-ra', '--config', os.path.join(config_dir, 'ra.json'), '--addr', 'ra1.service.consul:9094', '--debug-addr', ':8002'),
-        ('boulder-sa-1', 'boulder-sa-2', 'boulder-ca-a', 'boulder-ca-b', 'boulder-va-1', 'boulder-va-2', 'akamai-purger', 'boulder-publisher-1', 'boulder-publisher-2')),
+        ('boulder-sa-1', 'boulder-sa-2', 'boulder-ca-a', 'boulder-ca-b', 'boulder-va-1', 'boulder-va-2', 'akamai-purger', 'boulder-publisher-1', 'boulder-publisher-2', 'crl-updater')),
     Service('boulder-ra-2',
         8102, 'ra2.service.consul:9094',
         ('./bin/boulder', 'boulder-ra', '--config', os.path.join(config_dir, 'ra.json'), '--addr', 'ra2.service.consul:9094', '--debug-addr', ':8102'),
-        ('boulder-sa-1', 'boulder-sa-2', 'boulder-ca-a', 'boulder-ca-b', 'boulder-va-1', 'boulder-va-2', 'akamai-purger', 'boulder-publisher-1', 'boulder-publisher-2')),
+        ('boulder-sa-1', 'boulder-sa-2', 'boulder-ca-a', 'boulder-ca-b', 'boulder-va-1', 'boulder-va-2', 'akamai-purger', 'boulder-publisher-1', 'boulder-publisher-2', 'crl-updater')),
     Service('bad-key-revoker',
         8020, None,
         ('./bin/boulder', 'bad-key-revoker', '--config', os.path.join(config_dir, 'bad-key-revoker.json')),
         ('boulder-ra-1', 'boulder-ra-2', 'mail-test-srv')),
+    Service('ocsp-presigner',
+        8018, None,
+        ('./bin/boulder', 'ocsp-presigner', '--config', os.path.join(config_dir, 'ocsp-presigner.json')),
+        ('boulder-ra-1', 'boulder-ra-2')),
     Service('nonce-service-taro',
         8111, 'nonce1.service.consul:9101',
         ('./bin/boulder', 'nonce-service', '--config', os.path.join(config_dir, 'nonce-a.json'), '--addr', '10.77.77.77:9101', '--debug-addr', ':8111',),
@@ -0,0 +1,165 @@
+package va
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/groupcache/lru"
+	"github.com/jmhodges/clock"
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/letsencrypt/boulder/bdns"
+)
+
+// MaxCAACacheAge is the longest a CAA RRset may be served from the cache,
+// regardless of its TTL. CABF BRs section 3.2.2.8 permit a CA to rely on a CAA
+// lookup for the greater of the record's TTL or 8 hours, so reusing a cached
+// RRset for at most this long, and never beyond its TTL, is always compliant.
+const MaxCAACacheAge = 8 * time.Hour
+
+// caaCache is a bdns.Client which answers LookupCAA from a bounded, in-memory
+// cache of CAA RRsets, falling back to an underlying bdns.Client on a miss.
+// Entries live for the smallest TTL among their records, capped at maxAge.
+// Empty RRsets carry no TTL of their own and live for emptyTTL. Errors are
+// never cached. All other lookups are passed directly to the underlying
+// client. It is safe for concurrent use.
+type caaCache struct {
+	bdns.Client
+
+	// Note: This must be a regular mutex, not an RWMutex, because cache.Get()
+	// actually mutates the lru.Cache (by updating the last-used info).
+	sync.Mutex
+	cache    *lru.Cache
+	maxAge   time.Duration
+	emptyTTL time.Duration
+	clk      clock.Clock
+
+	requests *prometheus.CounterVec
+	entries  prometheus.Gauge
+}
+
+var _ bdns.Client = &caaCache{}
+
+// NewCAACache wraps the provided bdns.Client with a CAA RRset cache holding at
+// most maxEntries names. Both maxAge and emptyTTL must be no greater than
+// MaxCAACacheAge.
+func NewCAACache(
+	under bdns.Client,
+	maxEntries int,
+	maxAge time.Duration,
+	emptyTTL time.Duration,
+	clk clock.Clock,
+	stats prometheus.Registerer,
+) (bdns.Client, error) {
+	if maxEntries <= 0 {
+		return nil, fmt.Errorf("CAA cache size must be positive, got %d", maxEntries)
+	}
+	if maxAge <= 0 || maxAge > MaxCAACacheAge {
+		return nil, fmt.Errorf("CAA cache max age must be in (0, %s], got %s", MaxCAACacheAge, maxAge)
+	}
+	if emptyTTL < 0 || emptyTTL > maxAge {
+		return nil, fmt.Errorf("CAA cache empty TTL must be in [0, %s], got %s", maxAge, emptyTTL)
+	}
+
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "caa_cache_requests",
+		Help: "A counter of CAA cache lookups labelled by status (hit, miss, expired)",
+	}, []string{"status"})
+	entries := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "caa_cache_entries",
+		Help: "The number of CAA RRsets currently held in the cache",
+	})
+	stats.MustRegister(requests, entries)
+
+	return &caaCache{
+		Client:   under,
+		cache:    lru.New(maxEntries),
+		maxAge:   maxAge,
+		emptyTTL: emptyTTL,
+		clk:      clk,
+		requests: requests,
+		entries:  entries,
+	}, nil
+}
+
+type caaEntry struct {
+	records  []*dns.CAA
+	response string
+	expires  time.Time
+}
+
+// LookupCAA returns the CAA RRset for hostname from the cache if a live entry
+// is present, and otherwise queries the underlying client and caches the
+// result.
+func (cc *caaCache) LookupCAA(ctx context.Context, hostname string) ([]*dns.CAA, string, error) {
+	key := strings.ToLower(dns.Fqdn(hostname))
+
+	cc.Lock()
+	val, ok := cc.cache.Get(key)
+	cc.Unlock()
+	if !ok {
+		cc.requests.WithLabelValues("miss").Inc()
+		return cc.queryAndStore(ctx, key, hostname)
+	}
+	entry := val.(caaEntry)
+	if !cc.clk.Now().Before(entry.expires) {
+		// We have to actively remove expired entries, because otherwise each
+		// retrieval counts as a "use" and they won't exit the cache on their
+		// own.
+		cc.Lock()
+		cc.cache.Remove(key)
+		cc.entries.Set(float64(cc.cache.Len()))
+		cc.Unlock()
+		cc.requests.WithLabelValues("expired").Inc()
+		return cc.queryAndStore(ctx, key, hostname)
+	}
+	cc.requests.WithLabelValues("hit").Inc()
+	return copyCAAs(entry.records), entry.response, nil
+}
+
+func (cc *caaCache) queryAndStore(ctx context.Context, key, hostname string) ([]*dns.CAA, string, error) {
+	records, response, err := cc.Client.LookupCAA(ctx, hostname)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ttl := cc.emptyTTL
+	if len(records) > 0 {
+		ttl = cc.maxAge
+		for _, caa := range records {
+			recordTTL := time.Duration(caa.Hdr.Ttl) * time.Second
+			if recordTTL < ttl {
+				ttl = recordTTL
+			}
+		}
+	}
+	if ttl <= 0 {
+		return records, response, nil
+	}
+
+	// Make sure we have our own copy that no one has a pointer to.
+	cc.Lock()
+	cc.cache.Add(key, caaEntry{
+		records:  copyCAAs(records),
+		response: response,
+		expires:  cc.clk.Now().Add(ttl),
+	})
+	cc.entries.Set(float64(cc.cache.Len()))
+	cc.Unlock()
+	return records, response, nil
+}
+
+func copyCAAs(records []*dns.CAA) []*dns.CAA {
+	if records == nil {
+		return nil
+	}
+	copied := make([]*dns.CAA, len(records))
+	for i, caa := range records {
+		copied[i] = dns.Copy(caa).(*dns.CAA)
+	}
+	return copied
+}
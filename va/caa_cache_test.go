@@ -0,0 +1,145 @@
+package va
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/letsencrypt/boulder/bdns"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/test"
+)
+
+// recordingCAAResolver counts CAA lookups per name and answers with a single
+// issue record with the given TTL for "records.com", an empty RRset for
+// "empty.com", and an error for "servfail.com".
+type recordingCAAResolver struct {
+	bdns.MockClient
+	ttl      uint32
+	requests map[string]int
+}
+
+func (r *recordingCAAResolver) LookupCAA(_ context.Context, domain string) ([]*dns.CAA, string, error) {
+	r.requests[domain]++
+	switch domain {
+	case "records.com":
+		return []*dns.CAA{{
+			Hdr:   dns.RR_Header{Name: "records.com.", Rrtype: dns.TypeCAA, Class: dns.ClassINET, Ttl: r.ttl},
+			Tag:   "issue",
+			Value: "letsencrypt.org",
+		}}, "response", nil
+	case "servfail.com":
+		return nil, "", errors.New("SERVFAIL")
+	}
+	return nil, "", nil
+}
+
+func setupCAACache(t *testing.T, ttl uint32) (*caaCache, *recordingCAAResolver, clock.FakeClock) {
+	t.Helper()
+	resolver := &recordingCAAResolver{ttl: ttl, requests: make(map[string]int)}
+	fc := clock.NewFake()
+	client, err := NewCAACache(resolver, 10, time.Hour, 5*time.Minute, fc, metrics.NoopRegisterer)
+	test.AssertNotError(t, err, "creating CAA cache")
+	return client.(*caaCache), resolver, fc
+}
+
+func TestCAACacheHonorsTTL(t *testing.T) {
+	cache, resolver, fc := setupCAACache(t, 60)
+
+	caas, response, err := cache.LookupCAA(context.Background(), "records.com")
+	test.AssertNotError(t, err, "looking up CAA")
+	test.AssertEquals(t, len(caas), 1)
+	test.AssertEquals(t, response, "response")
+	test.AssertEquals(t, resolver.requests["records.com"], 1)
+
+	caas, response, err = cache.LookupCAA(context.Background(), "Records.COM.")
+	test.AssertNotError(t, err, "looking up CAA")
+	test.AssertEquals(t, len(caas), 1)
+	test.AssertEquals(t, response, "response")
+	test.AssertEquals(t, resolver.requests["records.com"], 1)
+	test.AssertMetricWithLabelsEquals(t, cache.requests, prometheus.Labels{"status": "hit"}, 1)
+
+	// Modifying a returned record must not modify the cache.
+	caas[0].Value = "example.com"
+	caas, _, _ = cache.LookupCAA(context.Background(), "records.com")
+	test.AssertEquals(t, caas[0].Value, "letsencrypt.org")
+
+	// Once the record's TTL passes it should be looked up again.
+	fc.Add(61 * time.Second)
+	_, _, err = cache.LookupCAA(context.Background(), "records.com")
+	test.AssertNotError(t, err, "looking up CAA")
+	test.AssertEquals(t, resolver.requests["records.com"], 2)
+	test.AssertMetricWithLabelsEquals(t, cache.requests, prometheus.Labels{"status": "expired"}, 1)
+}
+
+func TestCAACacheMaxAge(t *testing.T) {
+	// A TTL of one day is capped to the cache's one hour max age.
+	cache, resolver, fc := setupCAACache(t, 86400)
+
+	_, _, err := cache.LookupCAA(context.Background(), "records.com")
+	test.AssertNotError(t, err, "looking up CAA")
+	fc.Add(59 * time.Minute)
+	_, _, err = cache.LookupCAA(context.Background(), "records.com")
+	test.AssertNotError(t, err, "looking up CAA")
+	test.AssertEquals(t, resolver.requests["records.com"], 1)
+
+	fc.Add(time.Minute)
+	_, _, err = cache.LookupCAA(context.Background(), "records.com")
+	test.AssertNotError(t, err, "looking up CAA")
+	test.AssertEquals(t, resolver.requests["records.com"], 2)
+}
+
+func TestCAACacheEmptyAndErrors(t *testing.T) {
+	cache, resolver, fc := setupCAACache(t, 60)
+
+	for i := 0; i < 2; i++ {
+		caas, _, err := cache.LookupCAA(context.Background(), "empty.com")
+		test.AssertNotError(t, err, "looking up CAA")
+		test.AssertEquals(t, len(caas), 0)
+	}
+	test.AssertEquals(t, resolver.requests["empty.com"], 1)
+	fc.Add(5 * time.Minute)
+	_, _, err := cache.LookupCAA(context.Background(), "empty.com")
+	test.AssertNotError(t, err, "looking up CAA")
+	test.AssertEquals(t, resolver.requests["empty.com"], 2)
+
+	for i := 0; i < 2; i++ {
+		_, _, err := cache.LookupCAA(context.Background(), "servfail.com")
+		test.AssertError(t, err, "expected lookup error")
+	}
+	test.AssertEquals(t, resolver.requests["servfail.com"], 2)
+}
+
+func TestNewCAACacheLimits(t *testing.T) {
+	under := &bdns.MockClient{}
+	_, err := NewCAACache(under, 10, MaxCAACacheAge+time.Second, 0, clock.NewFake(), metrics.NoopRegisterer)
+	test.AssertError(t, err, "accepted max age beyond the BR limit")
+	_, err = NewCAACache(under, 0, time.Hour, 0, clock.NewFake(), metrics.NoopRegisterer)
+	test.AssertError(t, err, "accepted zero size")
+	_, err = NewCAACache(under, 10, time.Hour, 2*time.Hour, clock.NewFake(), metrics.NoopRegisterer)
+	test.AssertError(t, err, "accepted empty TTL beyond max age")
+}
+
+func TestCAACacheSharedAcrossChecks(t *testing.T) {
+	resolver := &recordingCAAResolver{ttl: 300, requests: make(map[string]int)}
+	cache, err := NewCAACache(resolver, 100, time.Hour, time.Minute, clock.NewFake(), metrics.NoopRegisterer)
+	test.AssertNotError(t, err, "creating CAA cache")
+	va, _ := setup(nil, 0, "", nil)
+	va.dnsClient = cache
+
+	params := &caaParams{accountURIID: 1, validationMethod: "http-01"}
+	for _, name := range []string{"a.empty.com", "b.empty.com"} {
+		prob := va.checkCAA(ctx, dnsi(name), params)
+		test.Assert(t, prob == nil, "unexpected CAA problem")
+	}
+	// Both names share the "empty.com" and "com" parents, which should only
+	// have been queried once each.
+	test.AssertEquals(t, resolver.requests["empty.com"], 1)
+	test.AssertEquals(t, resolver.requests["com"], 1)
+	test.AssertEquals(t, resolver.requests["a.empty.com"], 1)
+}
@@ -2,7 +2,9 @@ package va
 
 import (
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -14,6 +16,8 @@ import (
 	"time"
 	"unicode"
 
+	"github.com/weppos/publicsuffix-go/publicsuffix"
+
 	"github.com/letsencrypt/boulder/core"
 	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/letsencrypt/boulder/iana"
@@ -335,6 +339,71 @@ func (va *ValidationAuthorityImpl) extractRequestTarget(req *http.Request) (stri
 	return reqHost, reqPort, nil
 }
 
+// checkRedirectRegisteredDomain returns an error if the registered domain
+// (eTLD+1, per the full Public Suffix List) of redirHost differs from that of
+// the name being validated. It is only enforced when the VA is configured to
+// restrict HTTP-01 redirects.
+func (va *ValidationAuthorityImpl) checkRedirectRegisteredDomain(validatedHost, redirHost string) error {
+	if !va.restrictHTTP01Redirects {
+		return nil
+	}
+	validatedDomain, err := publicsuffix.Domain(validatedHost)
+	if err != nil {
+		// The validated name is itself a public suffix. Only redirects to the
+		// exact same name are acceptable.
+		validatedDomain = validatedHost
+	}
+	redirDomain, err := publicsuffix.Domain(redirHost)
+	if err != nil || redirDomain != validatedDomain {
+		return berrors.ConnectionFailureError(
+			"Invalid host in redirect target %q. Redirects must stay within the registered domain %q",
+			redirHost, validatedDomain)
+	}
+	return nil
+}
+
+// tlsSessionInfo describes a TLS session established while following HTTP-01
+// redirects to an HTTPS URL. Certificate errors are ignored during HTTP-01
+// validation, so these details are audit logged instead to give abuse
+// investigations a full picture of the redirect chain.
+type tlsSessionInfo struct {
+	URL         string
+	ServerName  string
+	Version     string
+	CipherSuite string
+	// PeerCertificates contains the hex encoded SHA-256 fingerprints of the
+	// certificates presented by the server, leaf first.
+	PeerCertificates []string
+}
+
+var tlsVersionNames = map[uint16]string{
+	tls.VersionTLS10: "TLSv1.0",
+	tls.VersionTLS11: "TLSv1.1",
+	tls.VersionTLS12: "TLSv1.2",
+	tls.VersionTLS13: "TLSv1.3",
+}
+
+// newTLSSessionInfo summarizes the TLS connection state of a response received
+// from the provided URL.
+func newTLSSessionInfo(url string, cs *tls.ConnectionState) tlsSessionInfo {
+	version, ok := tlsVersionNames[cs.Version]
+	if !ok {
+		version = fmt.Sprintf("0x%04x", cs.Version)
+	}
+	fingerprints := make([]string, 0, len(cs.PeerCertificates))
+	for _, cert := range cs.PeerCertificates {
+		digest := sha256.Sum256(cert.Raw)
+		fingerprints = append(fingerprints, hex.EncodeToString(digest[:]))
+	}
+	return tlsSessionInfo{
+		URL:              url,
+		ServerName:       cs.ServerName,
+		Version:          version,
+		CipherSuite:      tls.CipherSuiteName(cs.CipherSuite),
+		PeerCertificates: fingerprints,
+	}
+}
+
 // setupHTTPValidation sets up a preresolvedDialer and a validation record for
 // the given request URL and httpValidationTarget. If the req URL is empty, or
 // the validation target is nil or has no available IP addresses, an error will
@@ -496,8 +565,32 @@ func (va *ValidationAuthorityImpl) processHTTPValidation(
 	// addresses explicitly, not following redirects to ports != [80,443], etc)
 	records := []core.ValidationRecord{baseRecord}
 	numRedirects := 0
+
+	// Record every TLS session established along the way, and audit log them
+	// once the validation attempt is over, regardless of its outcome.
+	var tlsSessions []tlsSessionInfo
+	recordTLS := func(resp *http.Response) {
+		if resp == nil || resp.TLS == nil || resp.Request == nil {
+			return
+		}
+		tlsSessions = append(tlsSessions, newTLSSessionInfo(resp.Request.URL.String(), resp.TLS))
+	}
+	defer func() {
+		if len(tlsSessions) == 0 {
+			return
+		}
+		va.log.AuditObject("HTTP-01 redirect TLS sessions", struct {
+			Hostname string
+			Sessions []tlsSessionInfo
+		}{
+			Hostname: host,
+			Sessions: tlsSessions,
+		})
+	}()
+
 	processRedirect := func(req *http.Request, via []*http.Request) error {
 		va.log.Debugf("processing a HTTP redirect from the server to %q", req.URL.String())
+		recordTLS(req.Response)
 		// Only process up to maxRedirect redirects
 		if numRedirects > maxRedirect {
 			return berrors.ConnectionFailureError("Too many redirects")
@@ -536,6 +629,11 @@ func (va *ValidationAuthorityImpl) processHTTPValidation(
 			return err
 		}
 
+		err = va.checkRedirectRegisteredDomain(host, redirHost)
+		if err != nil {
+			return err
+		}
+
 		redirPath := req.URL.Path
 		if len(redirPath) > maxPathSize {
 			return berrors.ConnectionFailureError("Redirect target too long")
@@ -623,6 +721,8 @@ func (va *ValidationAuthorityImpl) processHTTPValidation(
 		return nil, records, newIPError(target, err)
 	}
 
+	recordTLS(httpResponse)
+
 	if httpResponse.StatusCode != 200 {
 		return nil, records, newIPError(target, berrors.UnauthorizedError("Invalid response from %s: %d",
 			records[len(records)-1].URL, httpResponse.StatusCode))
@@ -3,7 +3,11 @@ package va
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	mrand "math/rand"
@@ -1445,6 +1449,111 @@ func TestHTTPRedirectLookup(t *testing.T) {
 				va.httpPort)))
 }
 
+func TestHTTPRedirectRegisteredDomain(t *testing.T) {
+	hs := httpSrv(t, expectedToken)
+	defer hs.Close()
+	va, _ := setup(hs, 0, "", nil)
+	va.restrictHTTP01Redirects = true
+
+	// Redirects within the validated name's registered domain are followed.
+	chall := httpChallenge()
+	setChallengeToken(&chall, pathMoved)
+	_, prob := va.validateHTTP01(ctx, dnsi("www.localhost.com"), chall)
+	test.Assert(t, prob == nil, fmt.Sprintf("unexpected problem: %s", prob))
+
+	// Redirects to another registered domain are not.
+	setChallengeToken(&chall, pathReLookup)
+	_, prob = va.validateHTTP01(ctx, dnsi("localhost.com"), chall)
+	test.AssertNotNil(t, prob, "redirect to other.valid.com should have been rejected")
+	test.AssertEquals(t, prob.Type, probs.ConnectionProblem)
+	test.AssertContains(t, prob.Detail, `Redirects must stay within the registered domain "localhost.com"`)
+}
+
+func TestCheckRedirectRegisteredDomain(t *testing.T) {
+	va, _ := setup(nil, 0, "", nil)
+	test.AssertNotError(t, va.checkRedirectRegisteredDomain("example.com", "elsewhere.org"), "restriction disabled")
+
+	va.restrictHTTP01Redirects = true
+
+	testCases := []struct {
+		validated string
+		redirect  string
+		ok        bool
+	}{
+		{"example.com", "example.com", true},
+		{"example.com", "www.example.com", true},
+		{"a.b.example.co.uk", "example.co.uk", true},
+		{"example.com", "example.org", false},
+		{"example.co.uk", "other.co.uk", false},
+		// Names under a private suffix are separate registered domains.
+		{"alice.github.io", "bob.github.io", false},
+		{"example.com", "co.uk", false},
+	}
+	for _, tc := range testCases {
+		err := va.checkRedirectRegisteredDomain(tc.validated, tc.redirect)
+		if tc.ok {
+			test.AssertNotError(t, err, fmt.Sprintf("%s -> %s", tc.validated, tc.redirect))
+		} else {
+			test.AssertError(t, err, fmt.Sprintf("%s -> %s", tc.validated, tc.redirect))
+		}
+	}
+}
+
+func TestHTTPSRedirectTLSSessionLogged(t *testing.T) {
+	chall := httpChallenge()
+
+	tlsMux := http.NewServeMux()
+	tlsMux.HandleFunc("/.well-known/acme-challenge/"+chall.Token, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, chall.ProvidedKeyAuthorization)
+	})
+	tlsSrv := httptest.NewUnstartedServer(tlsMux)
+	tlsSrv.StartTLS()
+	defer tlsSrv.Close()
+
+	httpMux := http.NewServeMux()
+	httpMux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://localhost.com"+r.URL.Path, http.StatusMovedPermanently)
+	})
+	httpSrv := httptest.NewUnstartedServer(httpMux)
+	httpSrv.Start()
+	defer httpSrv.Close()
+
+	va, log := setup(httpSrv, 0, "", nil)
+	va.httpsPort = getPort(tlsSrv)
+
+	_, prob := va.validateHTTP01(ctx, dnsi("localhost.com"), chall)
+	test.Assert(t, prob == nil, fmt.Sprintf("unexpected problem: %s", prob))
+
+	lines := log.GetAllMatching(`HTTP-01 redirect TLS sessions`)
+	test.AssertEquals(t, len(lines), 1)
+	digest := sha256.Sum256(tlsSrv.Certificate().Raw)
+	test.AssertContains(t, lines[0], `"PeerCertificates":["`+hex.EncodeToString(digest[:])+`"]`)
+	test.AssertContains(t, lines[0], `"Version":"TLSv1.3"`)
+	test.AssertContains(t, lines[0], `"ServerName":"localhost.com"`)
+}
+
+func TestNewTLSSessionInfo(t *testing.T) {
+	_, cert := test.ThrowAwayCert(t, 1)
+	cs := &tls.ConnectionState{
+		Version:          tls.VersionTLS13,
+		CipherSuite:      tls.TLS_AES_128_GCM_SHA256,
+		ServerName:       "example.com",
+		PeerCertificates: []*x509.Certificate{cert},
+	}
+	info := newTLSSessionInfo("https://example.com/path", cs)
+	digest := sha256.Sum256(cert.Raw)
+	test.AssertDeepEquals(t, info, tlsSessionInfo{
+		URL:              "https://example.com/path",
+		ServerName:       "example.com",
+		Version:          "TLSv1.3",
+		CipherSuite:      "TLS_AES_128_GCM_SHA256",
+		PeerCertificates: []string{hex.EncodeToString(digest[:])},
+	})
+
+	cs.Version = 0x0305
+	test.AssertEquals(t, newTLSSessionInfo("https://example.com", cs).Version, "0x0305")
+}
+
 func TestHTTPRedirectLoop(t *testing.T) {
 	hs := httpSrv(t, expectedToken)
 	defer hs.Close()
@@ -0,0 +1,317 @@
+package va
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"gopkg.in/go-jose/go-jose.v2"
+
+	blog "github.com/letsencrypt/boulder/log"
+	vapb "github.com/letsencrypt/boulder/va/proto"
+)
+
+const (
+	// maxPerspectiveBodySize bounds both the request body accepted by a
+	// perspective handler and the response body read by an httpRemoteVA.
+	maxPerspectiveBodySize = 64 * 1024
+
+	// perspectiveClockSkew is how far in the future a signed perspective
+	// result may be dated before it is rejected.
+	perspectiveClockSkew = 10 * time.Second
+
+	perspectiveJWSContentType = "application/jose"
+)
+
+// perspectiveRequest is the JSON body POSTed by a primary VA to a remote
+// perspective's HTTPS endpoint.
+type perspectiveRequest struct {
+	// Nonce is a random value chosen by the primary VA which the remote
+	// perspective must echo inside its signed result. It binds the result to a
+	// single request and prevents replay.
+	Nonce string
+	// Request is the protojson encoding of a vapb.PerformValidationRequest.
+	Request json.RawMessage
+}
+
+// signedPerspectiveResult is the JWS payload returned by a remote perspective.
+type signedPerspectiveResult struct {
+	Perspective string
+	Nonce       string
+	Domain      string
+	AuthzID     string
+	ValidatedAt time.Time
+	// Result is the protojson encoding of a vapb.ValidationResult.
+	Result json.RawMessage
+}
+
+// perspectiveValidator is the subset of the VA server interface needed to
+// serve validation requests over the HTTP perspective transport.
+type perspectiveValidator interface {
+	PerformValidation(context.Context, *vapb.PerformValidationRequest) (*vapb.ValidationResult, error)
+}
+
+// sigAlgForKey returns the JWS algorithm used to sign with, or verify
+// signatures from, the given perspective key. Only ECDSA P-256 and P-384 keys
+// are supported.
+func sigAlgForKey(key crypto.PublicKey) (jose.SignatureAlgorithm, error) {
+	k, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return "", fmt.Errorf("unsupported perspective key type %T, expected ECDSA", key)
+	}
+	switch k.Params().Name {
+	case "P-256":
+		return jose.ES256, nil
+	case "P-384":
+		return jose.ES384, nil
+	}
+	return "", fmt.Errorf("unsupported perspective key curve %q, expected P-256 or P-384", k.Params().Name)
+}
+
+// perspectiveHandler serves PerformValidation requests over HTTPS/JSON and
+// returns each result as a JWS signed with the perspective's key.
+type perspectiveHandler struct {
+	va          perspectiveValidator
+	perspective string
+	signer      jose.Signer
+	clk         clock.Clock
+	log         blog.Logger
+}
+
+// NewPerspectiveHandler returns an http.Handler which performs validations
+// using the provided VA and signs each result with the provided key, naming
+// the given perspective. It is the server half of the HTTP perspective
+// transport; the client half is NewHTTPRemoteVA.
+func NewPerspectiveHandler(va perspectiveValidator, perspective string, key crypto.Signer, clk clock.Clock, logger blog.Logger) (http.Handler, error) {
+	if perspective == "" {
+		return nil, errors.New("perspective name must not be empty")
+	}
+	alg, err := sigAlgForKey(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating perspective signer: %w", err)
+	}
+	return &perspectiveHandler{
+		va:          va,
+		perspective: perspective,
+		signer:      signer,
+		clk:         clk,
+		log:         logger,
+	}, nil
+}
+
+func (ph *perspectiveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var preq perspectiveRequest
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPerspectiveBodySize)).Decode(&preq)
+	if err != nil {
+		http.Error(w, "malformed request body", http.StatusBadRequest)
+		return
+	}
+	if preq.Nonce == "" {
+		http.Error(w, "missing nonce", http.StatusBadRequest)
+		return
+	}
+	var req vapb.PerformValidationRequest
+	err = protojson.Unmarshal(preq.Request, &req)
+	if err != nil || req.Authz == nil {
+		http.Error(w, "malformed validation request", http.StatusBadRequest)
+		return
+	}
+
+	res, err := ph.va.PerformValidation(r.Context(), &req)
+	if err != nil {
+		ph.log.Errf("Perspective %q PerformValidation failed: %s", ph.perspective, err)
+		http.Error(w, "validation failed", http.StatusInternalServerError)
+		return
+	}
+
+	resultJSON, err := protojson.Marshal(res)
+	if err != nil {
+		ph.log.Errf("Perspective %q marshaling validation result: %s", ph.perspective, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	payload, err := json.Marshal(signedPerspectiveResult{
+		Perspective: ph.perspective,
+		Nonce:       preq.Nonce,
+		Domain:      req.Domain,
+		AuthzID:     req.Authz.Id,
+		ValidatedAt: ph.clk.Now(),
+		Result:      resultJSON,
+	})
+	if err != nil {
+		ph.log.Errf("Perspective %q marshaling signed result: %s", ph.perspective, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	jws, err := ph.signer.Sign(payload)
+	if err != nil {
+		ph.log.Errf("Perspective %q signing validation result: %s", ph.perspective, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	compact, err := jws.CompactSerialize()
+	if err != nil {
+		ph.log.Errf("Perspective %q serializing validation result: %s", ph.perspective, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", perspectiveJWSContentType)
+	_, _ = w.Write([]byte(compact))
+}
+
+// httpRemoteVA is a vapb.VAClient which sends validation requests to a remote
+// perspective over HTTPS/JSON and verifies the signed result before returning
+// it. Any verification failure is returned as an error, so the result is never
+// counted toward quorum.
+type httpRemoteVA struct {
+	url          string
+	perspective  string
+	key          crypto.PublicKey
+	alg          jose.SignatureAlgorithm
+	client       *http.Client
+	maxResultAge time.Duration
+	clk          clock.Clock
+}
+
+// NewHTTPRemoteVA returns a RemoteVA which reaches the named perspective at
+// the given HTTPS URL. Results must be signed by the provided public key and
+// must be no older than maxResultAge when they are received.
+func NewHTTPRemoteVA(url, perspective string, key crypto.PublicKey, client *http.Client, maxResultAge time.Duration, clk clock.Clock) (RemoteVA, error) {
+	if perspective == "" {
+		return RemoteVA{}, errors.New("perspective name must not be empty")
+	}
+	if maxResultAge <= 0 {
+		return RemoteVA{}, errors.New("maxResultAge must be positive")
+	}
+	alg, err := sigAlgForKey(key)
+	if err != nil {
+		return RemoteVA{}, err
+	}
+	return RemoteVA{
+		VAClient: &httpRemoteVA{
+			url:          url,
+			perspective:  perspective,
+			key:          key,
+			alg:          alg,
+			client:       client,
+			maxResultAge: maxResultAge,
+			clk:          clk,
+		},
+		Address:     url,
+		Perspective: perspective,
+	}, nil
+}
+
+// PerformValidation implements vapb.VAClient. The grpc.CallOptions are ignored.
+func (h *httpRemoteVA) PerformValidation(ctx context.Context, req *vapb.PerformValidationRequest, _ ...grpc.CallOption) (*vapb.ValidationResult, error) {
+	if req.Authz == nil {
+		return nil, errors.New("incomplete validation request")
+	}
+	reqJSON, err := protojson.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling validation request: %w", err)
+	}
+	var nonceBytes [16]byte
+	_, err = rand.Read(nonceBytes[:])
+	if err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+	nonce := base64.RawURLEncoding.EncodeToString(nonceBytes[:])
+	body, err := json.Marshal(perspectiveRequest{Nonce: nonce, Request: reqJSON})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling perspective request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxPerspectiveBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("reading perspective response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("perspective %q returned HTTP status %d", h.perspective, resp.StatusCode)
+	}
+
+	return h.verify(respBody, nonce, req)
+}
+
+// verify checks the signature, perspective name, nonce, subject and freshness
+// of a signed perspective result, returning the enclosed ValidationResult only
+// if every check passes.
+func (h *httpRemoteVA) verify(body []byte, nonce string, req *vapb.PerformValidationRequest) (*vapb.ValidationResult, error) {
+	jws, err := jose.ParseSigned(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("parsing signed result from perspective %q: %w", h.perspective, err)
+	}
+	if len(jws.Signatures) != 1 {
+		return nil, fmt.Errorf("signed result from perspective %q has %d signatures, expected 1", h.perspective, len(jws.Signatures))
+	}
+	if alg := jws.Signatures[0].Header.Algorithm; alg != string(h.alg) {
+		return nil, fmt.Errorf("signed result from perspective %q uses algorithm %q, expected %q", h.perspective, alg, h.alg)
+	}
+	payload, err := jws.Verify(h.key)
+	if err != nil {
+		return nil, fmt.Errorf("verifying signed result from perspective %q: %w", h.perspective, err)
+	}
+
+	var signed signedPerspectiveResult
+	err = json.Unmarshal(payload, &signed)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshaling signed result from perspective %q: %w", h.perspective, err)
+	}
+	if signed.Perspective != h.perspective {
+		return nil, fmt.Errorf("signed result names perspective %q, expected %q", signed.Perspective, h.perspective)
+	}
+	if signed.Nonce != nonce {
+		return nil, fmt.Errorf("signed result from perspective %q has mismatched nonce", h.perspective)
+	}
+	if signed.Domain != req.Domain || signed.AuthzID != req.Authz.Id {
+		return nil, fmt.Errorf("signed result from perspective %q is for %q (authz %q), expected %q (authz %q)",
+			h.perspective, signed.Domain, signed.AuthzID, req.Domain, req.Authz.Id)
+	}
+	now := h.clk.Now()
+	if signed.ValidatedAt.Before(now.Add(-h.maxResultAge)) {
+		return nil, fmt.Errorf("signed result from perspective %q is stale: validated at %s", h.perspective, signed.ValidatedAt)
+	}
+	if signed.ValidatedAt.After(now.Add(perspectiveClockSkew)) {
+		return nil, fmt.Errorf("signed result from perspective %q is from the future: validated at %s", h.perspective, signed.ValidatedAt)
+	}
+
+	var res vapb.ValidationResult
+	err = protojson.Unmarshal(signed.Result, &res)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshaling validation result from perspective %q: %w", h.perspective, err)
+	}
+	return &res, nil
+}
@@ -0,0 +1,151 @@
+package va
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/letsencrypt/boulder/core"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func setupPerspective(t *testing.T, inner perspectiveValidator, name string, clk clock.Clock) (*httptest.Server, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating perspective key")
+	handler, err := NewPerspectiveHandler(inner, name, key, clk, blog.NewMock())
+	test.AssertNotError(t, err, "creating perspective handler")
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return srv, key
+}
+
+func TestHTTPRemoteVA(t *testing.T) {
+	inner, _ := setup(nil, 0, "", nil)
+	fc := inner.clk.(clock.FakeClock)
+	req := createValidationRequest("good-dns01.com", core.ChallengeTypeDNS01)
+	req.Authz.Id = "1234"
+
+	srv, key := setupPerspective(t, inner, "eu-west", fc)
+	rva, err := NewHTTPRemoteVA(srv.URL, "eu-west", key.Public(), srv.Client(), time.Minute, fc)
+	test.AssertNotError(t, err, "creating HTTP remote VA")
+	test.AssertEquals(t, rva.Address, srv.URL)
+
+	res, err := rva.PerformValidation(context.Background(), req)
+	test.AssertNotError(t, err, "performing validation over HTTP")
+	test.Assert(t, res.Problems == nil, "expected validation to succeed")
+	test.AssertEquals(t, len(res.Records), 1)
+	test.AssertEquals(t, res.Records[0].Hostname, "good-dns01.com")
+
+	// A failing validation is still a verified, countable result.
+	res, err = rva.PerformValidation(context.Background(), createValidationRequest("bad-dns01.com", core.ChallengeTypeDNS01))
+	test.AssertNotError(t, err, "performing failing validation over HTTP")
+	test.Assert(t, res.Problems != nil, "expected validation to fail")
+}
+
+func TestHTTPRemoteVAWrongKey(t *testing.T) {
+	inner, _ := setup(nil, 0, "", nil)
+	fc := inner.clk.(clock.FakeClock)
+	srv, _ := setupPerspective(t, inner, "eu-west", fc)
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating key")
+	rva, err := NewHTTPRemoteVA(srv.URL, "eu-west", otherKey.Public(), srv.Client(), time.Minute, fc)
+	test.AssertNotError(t, err, "creating HTTP remote VA")
+
+	_, err = rva.PerformValidation(context.Background(), createValidationRequest("good-dns01.com", core.ChallengeTypeDNS01))
+	test.AssertError(t, err, "accepted result signed by the wrong key")
+	test.AssertContains(t, err.Error(), "verifying signed result")
+}
+
+func TestHTTPRemoteVAWrongPerspective(t *testing.T) {
+	inner, _ := setup(nil, 0, "", nil)
+	fc := inner.clk.(clock.FakeClock)
+	srv, key := setupPerspective(t, inner, "eu-west", fc)
+
+	rva, err := NewHTTPRemoteVA(srv.URL, "us-east", key.Public(), srv.Client(), time.Minute, fc)
+	test.AssertNotError(t, err, "creating HTTP remote VA")
+
+	_, err = rva.PerformValidation(context.Background(), createValidationRequest("good-dns01.com", core.ChallengeTypeDNS01))
+	test.AssertError(t, err, "accepted result from the wrong perspective")
+	test.AssertContains(t, err.Error(), `names perspective "eu-west"`)
+}
+
+func TestHTTPRemoteVAStaleResult(t *testing.T) {
+	inner, _ := setup(nil, 0, "", nil)
+	fc := inner.clk.(clock.FakeClock)
+	srv, key := setupPerspective(t, inner, "eu-west", fc)
+
+	// The primary VA's clock is independent of the perspective's, so results
+	// can be made to look stale or from the future.
+	primaryClk := clock.NewFake()
+	primaryClk.Set(fc.Now())
+	rva, err := NewHTTPRemoteVA(srv.URL, "eu-west", key.Public(), srv.Client(), time.Minute, primaryClk)
+	test.AssertNotError(t, err, "creating HTTP remote VA")
+
+	req := createValidationRequest("good-dns01.com", core.ChallengeTypeDNS01)
+	res, err := rva.PerformValidation(context.Background(), req)
+	test.AssertNotError(t, err, "fresh result rejected")
+	test.Assert(t, res.Problems == nil, "expected validation to succeed")
+
+	primaryClk.Set(fc.Now().Add(2 * time.Minute))
+	_, err = rva.PerformValidation(context.Background(), req)
+	test.AssertError(t, err, "accepted stale result")
+	test.AssertContains(t, err.Error(), "is stale")
+
+	primaryClk.Set(fc.Now().Add(-time.Minute))
+	_, err = rva.PerformValidation(context.Background(), req)
+	test.AssertError(t, err, "accepted result from the future")
+	test.AssertContains(t, err.Error(), "from the future")
+}
+
+func TestHTTPRemoteVAReplay(t *testing.T) {
+	inner, _ := setup(nil, 0, "", nil)
+	fc := inner.clk.(clock.FakeClock)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating perspective key")
+	handler, err := NewPerspectiveHandler(inner, "eu-west", key, fc, blog.NewMock())
+	test.AssertNotError(t, err, "creating perspective handler")
+	// Serve the first signed result in response to every request.
+	var captured []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if captured != nil {
+			_, _ = w.Write(captured)
+			return
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		captured = rec.Body.Bytes()
+		_, _ = w.Write(captured)
+	}))
+	defer srv.Close()
+
+	rva, err := NewHTTPRemoteVA(srv.URL, "eu-west", key.Public(), srv.Client(), time.Minute, fc)
+	test.AssertNotError(t, err, "creating HTTP remote VA")
+
+	req := createValidationRequest("good-dns01.com", core.ChallengeTypeDNS01)
+	_, err = rva.PerformValidation(context.Background(), req)
+	test.AssertNotError(t, err, "first result rejected")
+	_, err = rva.PerformValidation(context.Background(), req)
+	test.AssertError(t, err, "accepted replayed result")
+	test.AssertContains(t, err.Error(), "mismatched nonce")
+}
+
+func TestNewHTTPRemoteVAUnsupportedKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "generating RSA key")
+	_, err = NewHTTPRemoteVA("https://example.com", "eu-west", rsaKey.Public(), http.DefaultClient, time.Minute, clock.NewFake())
+	test.AssertError(t, err, "accepted RSA perspective key")
+
+	_, err = NewPerspectiveHandler(nil, "eu-west", rsaKey, clock.NewFake(), blog.NewMock())
+	test.AssertError(t, err, "accepted RSA perspective key")
+}
@@ -12,6 +12,7 @@ import (
 	"net/url"
 	"os"
 	"regexp"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
@@ -68,10 +69,31 @@ var (
 // RemoteVA wraps the vapb.VAClient interface and adds a field containing the
 // address of the remote gRPC server since the underlying gRPC client doesn't
 // provide a way to extract this metadata which is useful for debugging gRPC
-// connection issues.
+// connection issues. The remaining fields describe the perspective the remote
+// VA validates from and are used to evaluate the geographic diversity of the
+// remote VAs which agree with the primary VA.
 type RemoteVA struct {
 	vapb.VAClient
 	Address string
+	// Perspective is a short, unique name for the remote VA's vantage point,
+	// e.g. "aws-eu-west-2". If empty, Address is used instead.
+	Perspective string
+	// Region is the geographic region the remote VA validates from. Remote VAs
+	// in the same region are not considered independent of one another.
+	Region string
+	// RIR and ASN identify the Regional Internet Registry and Autonomous System
+	// of the network the remote VA validates from.
+	RIR string
+	ASN uint32
+}
+
+// perspectiveName returns the name under which this remote VA's results are
+// recorded.
+func (rva RemoteVA) perspectiveName() string {
+	if rva.Perspective != "" {
+		return rva.Perspective
+	}
+	return rva.Address
 }
 
 type vaMetrics struct {
@@ -210,29 +232,54 @@ type ValidationAuthorityImpl struct {
 	clk                clock.Clock
 	remoteVAs          []RemoteVA
 	maxRemoteFailures  int
+	minRemoteRegions   int
 	accountURIPrefixes []string
 	singleDialTimeout  time.Duration
+	// restrictHTTP01Redirects causes HTTP-01 redirects to hosts outside the
+	// registered domain of the name being validated to be refused.
+	restrictHTTP01Redirects bool
 
 	metrics *vaMetrics
 }
 
-// NewValidationAuthorityImpl constructs a new VA
+// NewValidationAuthorityImpl constructs a new VA. If minRemoteRegions is
+// non-zero, every remote VA must have a Region and a remote validation only
+// succeeds once remote VAs in at least that many distinct regions agree with
+// the primary VA.
 func NewValidationAuthorityImpl(
 	resolver bdns.Client,
 	remoteVAs []RemoteVA,
 	maxRemoteFailures int,
+	minRemoteRegions int,
 	userAgent string,
 	issuerDomain string,
 	stats prometheus.Registerer,
 	clk clock.Clock,
 	logger blog.Logger,
 	accountURIPrefixes []string,
+	restrictHTTP01Redirects bool,
 ) (*ValidationAuthorityImpl, error) {
 
 	if features.Enabled(features.CAAAccountURI) && len(accountURIPrefixes) == 0 {
 		return nil, errors.New("no account URI prefixes configured")
 	}
 
+	if minRemoteRegions < 0 {
+		return nil, fmt.Errorf("minRemoteRegions must not be negative, got %d", minRemoteRegions)
+	}
+	if minRemoteRegions > 0 {
+		regions := make(map[string]bool)
+		for _, rva := range remoteVAs {
+			if rva.Region == "" {
+				return nil, fmt.Errorf("remote VA %q has no region, which is required when minRemoteRegions is set", rva.Address)
+			}
+			regions[rva.Region] = true
+		}
+		if len(regions) < minRemoteRegions {
+			return nil, fmt.Errorf("minRemoteRegions is %d but remote VAs only span %d regions", minRemoteRegions, len(regions))
+		}
+	}
+
 	pc := newDefaultPortConfig()
 
 	va := &ValidationAuthorityImpl{
@@ -247,12 +294,14 @@ func NewValidationAuthorityImpl(
 		metrics:            initMetrics(stats),
 		remoteVAs:          remoteVAs,
 		maxRemoteFailures:  maxRemoteFailures,
+		minRemoteRegions:   minRemoteRegions,
 		accountURIPrefixes: accountURIPrefixes,
 		// singleDialTimeout specifies how long an individual `DialContext` operation may take
 		// before timing out. This timeout ignores the base RPC timeout and is strictly
 		// used for the DialContext operations that take place during an
 		// HTTP-01 challenge validation.
-		singleDialTimeout: 10 * time.Second,
+		singleDialTimeout:       10 * time.Second,
+		restrictHTTP01Redirects: restrictHTTP01Redirects,
 	}
 
 	return va, nil
@@ -441,7 +490,9 @@ func (va *ValidationAuthorityImpl) performRemoteValidation(
 		remoteVA := va.remoteVAs[i]
 		go func(rva RemoteVA) {
 			result := &remoteValidationResult{
-				VAHostname: rva.Address,
+				VAHostname:  rva.Address,
+				Perspective: rva.perspectiveName(),
+				Region:      rva.Region,
 			}
 			res, err := rva.PerformValidation(ctx, req)
 			if err != nil && canceled.Is(err) {
@@ -473,7 +524,11 @@ func (va *ValidationAuthorityImpl) performRemoteValidation(
 // processRemoteResults evaluates a primary VA result, and a channel of remote
 // VA problems to produce a single overall validation result based on configured
 // feature flags. The overall result is calculated based on the VA's configured
-// `maxRemoteFailures` value.
+// `maxRemoteFailures` and `minRemoteRegions` values: at least
+// `numRemoteVAs - maxRemoteFailures` remote VAs must agree with the primary VA,
+// and those that agree must span at least `minRemoteRegions` distinct regions.
+// On success the perspective names of the remote VAs which agreed are returned,
+// sorted.
 //
 // If the `MultiVAFullResults` feature is enabled then `processRemoteResults`
 // will expect to read a result from the `remoteErrors` channel for each VA and
@@ -492,7 +547,7 @@ func (va *ValidationAuthorityImpl) processRemoteResults(
 	challengeType string,
 	primaryResult *probs.ProblemDetails,
 	remoteResultsChan chan *remoteValidationResult,
-	numRemoteVAs int) *probs.ProblemDetails {
+	numRemoteVAs int) ([]string, *probs.ProblemDetails) {
 
 	state := "failure"
 	start := va.clk.Now()
@@ -508,6 +563,40 @@ func (va *ValidationAuthorityImpl) processRemoteResults(
 	good := 0
 	bad := 0
 
+	// pendingByRegion counts the remote VAs in each region which have yet to
+	// respond, so we can tell when the region quorum can no longer be met.
+	pendingByRegion := make(map[string]int)
+	for _, rva := range va.remoteVAs {
+		pendingByRegion[rva.Region]++
+	}
+	goodRegions := make(map[string]bool)
+	var agreed []string
+
+	// quorumMet returns true once enough remote VAs, in enough distinct
+	// regions, have agreed with the primary VA.
+	quorumMet := func() bool {
+		return good >= required && len(goodRegions) >= va.minRemoteRegions
+	}
+	// quorumFailed returns true once the quorum can no longer be met, no
+	// matter what the remaining remote VAs return.
+	quorumFailed := func() bool {
+		if bad > va.maxRemoteFailures {
+			return true
+		}
+		possibleRegions := len(goodRegions)
+		for region, pending := range pendingByRegion {
+			if pending > 0 && !goodRegions[region] {
+				possibleRegions++
+			}
+		}
+		return possibleRegions < va.minRemoteRegions
+	}
+	succeeded := func() []string {
+		state = "success"
+		sort.Strings(agreed)
+		return agreed
+	}
+
 	var remoteResults []*remoteValidationResult
 	var firstProb *probs.ProblemDetails
 	// Due to channel behavior this could block indefinitely and we rely on gRPC
@@ -516,8 +605,11 @@ func (va *ValidationAuthorityImpl) processRemoteResults(
 	for result := range remoteResultsChan {
 		// Add the result to the slice
 		remoteResults = append(remoteResults, result)
+		pendingByRegion[result.Region]--
 		if result.Problem == nil {
 			good++
+			goodRegions[result.Region] = true
+			agreed = append(agreed, result.Perspective)
 		} else {
 			bad++
 		}
@@ -531,13 +623,10 @@ func (va *ValidationAuthorityImpl) processRemoteResults(
 		// If MultiVAFullResults isn't enabled then return early whenever the
 		// success or failure threshold is met.
 		if !features.Enabled(features.MultiVAFullResults) {
-			if good >= required {
-				state = "success"
-				return nil
-			} else if bad > va.maxRemoteFailures {
-				modifiedProblem := *result.Problem
-				modifiedProblem.Detail = "During secondary validation: " + firstProb.Detail
-				return &modifiedProblem
+			if quorumMet() {
+				return succeeded(), nil
+			} else if quorumFailed() {
+				return nil, secondaryValidationProblem(firstProb)
 			}
 		}
 
@@ -559,18 +648,28 @@ func (va *ValidationAuthorityImpl) processRemoteResults(
 		remoteResults)
 
 	// Based on the threshold of good/bad return nil or a problem.
-	if good >= required {
-		state = "success"
-		return nil
-	} else if bad > va.maxRemoteFailures {
-		modifiedProblem := *firstProb
-		modifiedProblem.Detail = "During secondary validation: " + firstProb.Detail
-		return &modifiedProblem
+	if quorumMet() {
+		return succeeded(), nil
+	} else if quorumFailed() {
+		return nil, secondaryValidationProblem(firstProb)
 	}
 
 	// This condition should not occur - it indicates the good/bad counts didn't
 	// meet either the required threshold or the maxRemoteFailures threshold.
-	return probs.ServerInternal("Too few remote PerformValidation RPC results")
+	return nil, probs.ServerInternal("Too few remote PerformValidation RPC results")
+}
+
+// secondaryValidationProblem returns a copy of the given remote VA problem
+// with its detail marked as coming from secondary validation.
+func secondaryValidationProblem(prob *probs.ProblemDetails) *probs.ProblemDetails {
+	if prob == nil {
+		// Only possible if the region quorum fails without any remote VA
+		// failing, which NewValidationAuthorityImpl's checks rule out.
+		return probs.ServerInternal("Remote VAs did not span enough regions")
+	}
+	modifiedProblem := *prob
+	modifiedProblem.Detail = "During secondary validation: " + prob.Detail
+	return &modifiedProblem
 }
 
 // logRemoteValidationDifferentials is called by `processRemoteResults` when the
@@ -640,10 +739,13 @@ func (va *ValidationAuthorityImpl) logRemoteValidationDifferentials(
 }
 
 // remoteValidationResult is a struct that combines a problem details instance
-// (that may be nil) with the remote VA hostname that produced it.
+// (that may be nil) with the remote VA hostname, perspective and region that
+// produced it.
 type remoteValidationResult struct {
-	VAHostname string
-	Problem    *probs.ProblemDetails
+	VAHostname  string
+	Perspective string `json:",omitempty"`
+	Region      string `json:",omitempty"`
+	Problem     *probs.ProblemDetails
 }
 
 // PerformValidation validates the challenge for the domain in the request.
@@ -653,6 +755,9 @@ func (va *ValidationAuthorityImpl) PerformValidation(ctx context.Context, req *v
 	if core.IsAnyNilOrZero(req, req.Domain, req.Challenge, req.Authz) {
 		return nil, berrors.InternalServerError("Incomplete validation request")
 	}
+	// Tie any DNS queries made during this validation to its authorization in
+	// the DNS query log.
+	ctx = bdns.WithAuthzID(ctx, req.Authz.Id)
 	logEvent := verificationRequestEvent{
 		ID:        req.Authz.Id,
 		Requester: req.Authz.RegID,
@@ -692,7 +797,7 @@ func (va *ValidationAuthorityImpl) PerformValidation(ctx context.Context, req *v
 			// differentials then collect and log the remote results in a separate go
 			// routine to avoid blocking the primary VA.
 			go func() {
-				_ = va.processRemoteResults(
+				_, _ = va.processRemoteResults(
 					req.Domain,
 					req.Authz.RegID,
 					string(challenge.Type),
@@ -705,7 +810,7 @@ func (va *ValidationAuthorityImpl) PerformValidation(ctx context.Context, req *v
 			// validationTime metrics increment has the correct result label.
 			challenge.Status = core.StatusValid
 		} else if features.Enabled(features.EnforceMultiVA) {
-			remoteProb := va.processRemoteResults(
+			agreed, remoteProb := va.processRemoteResults(
 				req.Domain,
 				req.Authz.RegID,
 				string(challenge.Type),
@@ -724,6 +829,9 @@ func (va *ValidationAuthorityImpl) PerformValidation(ctx context.Context, req *v
 				va.metrics.remoteValidationFailures.Inc()
 			} else {
 				challenge.Status = core.StatusValid
+				// Record which perspectives corroborated the primary VA on
+				// the final validation record.
+				records[len(records)-1].Perspectives = agreed
 			}
 		}
 	} else {
@@ -126,12 +126,14 @@ func setup(srv *httptest.Server, maxRemoteFailures int, userAgent string, remote
 		&bdns.MockClient{Log: logger},
 		nil,
 		maxRemoteFailures,
+		0,
 		userAgent,
 		"letsencrypt.org",
 		metrics.NoopRegisterer,
 		fc,
 		logger,
 		accountURIPrefixes,
+		false,
 	)
 
 	// Adjusting industry regulated ACME challenge port settings is fine during
@@ -328,8 +330,8 @@ func TestMultiVA(t *testing.T) {
 	remoteVA2 := setupRemote(ms.Server, remoteUA2)
 
 	remoteVAs := []RemoteVA{
-		{remoteVA1, remoteUA1},
-		{remoteVA2, remoteUA2},
+		{VAClient: remoteVA1, Address: remoteUA1},
+		{VAClient: remoteVA2, Address: remoteUA2},
 	}
 
 	enforceMultiVA := map[string]bool{
@@ -397,8 +399,8 @@ func TestMultiVA(t *testing.T) {
 			// If a remote VA fails with an internal err it should fail when enforcing multi VA
 			Name: "Local VA ok, remote VA internal err, enforce multi VA",
 			RemoteVAs: []RemoteVA{
-				{remoteVA1, remoteUA1},
-				{&brokenRemoteVA{}, "broken"},
+				{VAClient: remoteVA1, Address: remoteUA1},
+				{VAClient: &brokenRemoteVA{}, Address: "broken"},
 			},
 			AllowedUAs:   allowedUAs,
 			Features:     enforceMultiVA,
@@ -411,8 +413,8 @@ func TestMultiVA(t *testing.T) {
 			// enforcing multi VA
 			Name: "Local VA ok, remote VA internal err, no enforce multi VA",
 			RemoteVAs: []RemoteVA{
-				{remoteVA1, remoteUA1},
-				{&brokenRemoteVA{}, "broken"},
+				{VAClient: remoteVA1, Address: remoteUA1},
+				{VAClient: &brokenRemoteVA{}, Address: "broken"},
 			},
 			AllowedUAs: allowedUAs,
 			Features:   noEnforceMultiVA,
@@ -443,8 +445,8 @@ func TestMultiVA(t *testing.T) {
 			// When enforcing multi-VA, any cancellations are a problem.
 			Name: "Local VA and one remote VA OK, one cancelled VA, enforce multi VA",
 			RemoteVAs: []RemoteVA{
-				{remoteVA1, remoteUA1},
-				{cancelledVA{}, remoteUA2},
+				{VAClient: remoteVA1, Address: remoteUA1},
+				{VAClient: cancelledVA{}, Address: remoteUA2},
 			},
 			AllowedUAs:   allowedUAs,
 			Features:     enforceMultiVA,
@@ -454,8 +456,8 @@ func TestMultiVA(t *testing.T) {
 			// When enforcing multi-VA, any cancellations are a problem.
 			Name: "Local VA OK, two cancelled remote VAs, enforce multi VA",
 			RemoteVAs: []RemoteVA{
-				{cancelledVA{}, remoteUA1},
-				{cancelledVA{}, remoteUA2},
+				{VAClient: cancelledVA{}, Address: remoteUA1},
+				{VAClient: cancelledVA{}, Address: remoteUA2},
 			},
 			AllowedUAs:   allowedUAs,
 			Features:     enforceMultiVA,
@@ -538,8 +540,8 @@ func TestMultiVAEarlyReturn(t *testing.T) {
 	remoteVA2 := setupRemote(ms.Server, remoteUA2)
 
 	remoteVAs := []RemoteVA{
-		{remoteVA1, remoteUA1},
-		{remoteVA2, remoteUA2},
+		{VAClient: remoteVA1, Address: remoteUA1},
+		{VAClient: remoteVA2, Address: remoteUA2},
 	}
 
 	// Create a local test VA with the two remote VAs
@@ -626,8 +628,8 @@ func TestMultiVAPolicy(t *testing.T) {
 	remoteVA2 := setupRemote(ms.Server, remoteUA2)
 
 	remoteVAs := []RemoteVA{
-		{remoteVA1, remoteUA1},
-		{remoteVA2, remoteUA2},
+		{VAClient: remoteVA1, Address: remoteUA1},
+		{VAClient: remoteVA2, Address: remoteUA2},
 	}
 
 	// Create a local test VA with the two remote VAs
@@ -651,6 +653,107 @@ func TestMultiVAPolicy(t *testing.T) {
 	}
 }
 
+func TestMultiVARegionQuorum(t *testing.T) {
+	const (
+		remoteUA1 = "remote 1"
+		remoteUA2 = "remote 2"
+		remoteUA3 = "remote 3"
+		localUA   = "local 1"
+	)
+
+	testCases := []struct {
+		Name                 string
+		Forbidden            string
+		FullResults          bool
+		ExpectedPerspectives []string
+	}{
+		{
+			Name:                 "Agreement from both regions",
+			Forbidden:            remoteUA2,
+			ExpectedPerspectives: []string{"eu-1", "us-1"},
+		},
+		{
+			Name:                 "Agreement from both regions, full results",
+			Forbidden:            remoteUA2,
+			FullResults:          true,
+			ExpectedPerspectives: []string{"eu-1", "us-1"},
+		},
+		{
+			// Only one remote VA fails, which maxRemoteFailures allows, but the
+			// two that agree share a region.
+			Name:      "Agreement from one region",
+			Forbidden: remoteUA3,
+		},
+		{
+			Name:        "Agreement from one region, full results",
+			Forbidden:   remoteUA3,
+			FullResults: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			allowedUAs := map[string]bool{
+				localUA:   true,
+				remoteUA1: true,
+				remoteUA2: true,
+				remoteUA3: true,
+			}
+			allowedUAs[tc.Forbidden] = false
+			ms := httpMultiSrv(t, expectedToken, allowedUAs)
+			defer ms.Close()
+
+			remoteVAs := []RemoteVA{
+				{VAClient: setupRemote(ms.Server, remoteUA1), Address: remoteUA1, Perspective: "eu-1", Region: "eu"},
+				{VAClient: setupRemote(ms.Server, remoteUA2), Address: remoteUA2, Perspective: "eu-2", Region: "eu"},
+				{VAClient: setupRemote(ms.Server, remoteUA3), Address: remoteUA3, Perspective: "us-1", Region: "us"},
+			}
+			localVA, _ := setup(ms.Server, 1, localUA, remoteVAs)
+			localVA.minRemoteRegions = 2
+
+			err := features.Set(map[string]bool{
+				"EnforceMultiVA":     true,
+				"MultiVAFullResults": tc.FullResults,
+			})
+			test.AssertNotError(t, err, "setting feature flags")
+			defer features.Reset()
+
+			req := createValidationRequest("localhost", core.ChallengeTypeHTTP01)
+			res, err := localVA.PerformValidation(ctx, req)
+			test.AssertNotError(t, err, "performing validation")
+			if tc.ExpectedPerspectives == nil {
+				test.Assert(t, res.Problems != nil, "expected validation to fail")
+				return
+			}
+			test.Assert(t, res.Problems == nil, "expected validation to succeed")
+			test.AssertDeepEquals(t, res.Records[len(res.Records)-1].Perspectives, tc.ExpectedPerspectives)
+		})
+	}
+}
+
+func TestNewValidationAuthorityImplRegions(t *testing.T) {
+	remoteVAs := []RemoteVA{
+		{Address: "remote 1", Region: "eu"},
+		{Address: "remote 2", Region: "eu"},
+	}
+	newVA := func(remotes []RemoteVA, minRemoteRegions int) error {
+		_, err := NewValidationAuthorityImpl(
+			&bdns.MockClient{}, remotes, 0, minRemoteRegions, "", "letsencrypt.org",
+			metrics.NoopRegisterer, clock.NewFake(), blog.NewMock(), accountURIPrefixes, false)
+		return err
+	}
+
+	test.AssertNotError(t, newVA(remoteVAs, 1), "one region required")
+	err := newVA(remoteVAs, 2)
+	test.AssertError(t, err, "accepted more required regions than configured")
+	test.AssertContains(t, err.Error(), "only span 1 regions")
+
+	remoteVAs = append(remoteVAs, RemoteVA{Address: "remote 3"})
+	err = newVA(remoteVAs, 1)
+	test.AssertError(t, err, "accepted remote VA without a region")
+	test.AssertContains(t, err.Error(), `remote VA "remote 3" has no region`)
+}
+
 func TestDetailedError(t *testing.T) {
 	cases := []struct {
 		err      error
@@ -709,9 +812,9 @@ func TestLogRemoteValidationDifferentials(t *testing.T) {
 	remoteVA2 := setupRemote(nil, "remote 2")
 	remoteVA3 := setupRemote(nil, "remote 3")
 	remoteVAs := []RemoteVA{
-		{remoteVA1, "remote 1"},
-		{remoteVA2, "remote 2"},
-		{remoteVA3, "remote 3"},
+		{VAClient: remoteVA1, Address: "remote 1"},
+		{VAClient: remoteVA2, Address: "remote 2"},
+		{VAClient: remoteVA3, Address: "remote 3"},
 	}
 
 	// Set up a local VA that allows a max of 2 remote failures.